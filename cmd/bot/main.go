@@ -11,6 +11,7 @@ import (
 	"github.com/GintGld/fizteh-radio-bot/internal/app"
 	"github.com/GintGld/fizteh-radio-bot/internal/config"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/slogpretty"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/provider"
 )
 
 func main() {
@@ -22,6 +23,8 @@ func main() {
 	logSrv.Info("start bot", slog.String("env", cfg.Env))
 	logSrv.Debug("debug messages are enabled")
 
+	spotifyId, spotifySecret := getSpotifyCredentials()
+
 	// Create bot instance
 	app := app.New(
 		logSrv,
@@ -30,9 +33,34 @@ func main() {
 		cfg.RadioAdminAddr,
 		cfg.RadioClientAddr,
 		getYandexToken(),
+		spotifyId,
+		spotifySecret,
+		cfg.Spotify.AudioCommand,
+		cfg.GenreMap,
+		provider.DownloadPolicy{
+			Codecs:       cfg.Yandex.Codecs,
+			MaxBitrate:   cfg.Yandex.MaxBitrate,
+			AllowPreview: cfg.Yandex.AllowPreview,
+		},
 		cfg.WebhookAddr,
 		cfg.TmpDir,
+		cfg.TmpMaxSizeMB,
 		cfg.UserCacheFile,
+		cfg.FingerprintFile,
+		cfg.CoverDir,
+		cfg.MediaInfoFile,
+		cfg.JobsFile,
+		cfg.JobWorkers,
+		cfg.WatchFile,
+		cfg.WatchInterval,
+		cfg.PodcastFile,
+		cfg.PodcastInterval,
+		cfg.SavedFile,
+		cfg.SmartInterval,
+		cfg.CacheDir,
+		cfg.CacheSizeMB,
+		cfg.ExportMediaURL,
+		cfg.Admins,
 		cfg.UseFiller,
 	)
 
@@ -108,3 +136,9 @@ func getYandexToken() string {
 
 	return token
 }
+
+// getSpotifyCredentials returns spotify client credentials.
+// Spotify is optional, so credentials may be empty.
+func getSpotifyCredentials() (id, secret string) {
+	return os.Getenv("SPOTIFY_CLIENT_ID"), os.Getenv("SPOTIFY_CLIENT_SECRET")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -12,28 +13,53 @@ import (
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/autodj"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/datetime"
+	exportCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/export"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/help"
+	jobsCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/jobs"
+	libcheckCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/libcheck"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/live"
+	podcastCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/podcast"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/schedule"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/search"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/start"
 	statCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/stat"
+	tagsCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/tags"
 	"github.com/GintGld/fizteh-radio-bot/internal/controller/upload"
+	watchCtr "github.com/GintGld/fizteh-radio-bot/internal/controller/watch"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 
 	authSrv "github.com/GintGld/fizteh-radio-bot/internal/service/auth"
+	bulkSrv "github.com/GintGld/fizteh-radio-bot/internal/service/bulk"
+	exportSrv "github.com/GintGld/fizteh-radio-bot/internal/service/export"
 	"github.com/GintGld/fizteh-radio-bot/internal/service/filler"
+	jobsSrv "github.com/GintGld/fizteh-radio-bot/internal/service/jobs"
+	libcheckSrv "github.com/GintGld/fizteh-radio-bot/internal/service/libcheck"
 	libSrv "github.com/GintGld/fizteh-radio-bot/internal/service/library"
+	podcastSrv "github.com/GintGld/fizteh-radio-bot/internal/service/podcast"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/provider"
+	savedSrv "github.com/GintGld/fizteh-radio-bot/internal/service/saved"
 	schSrv "github.com/GintGld/fizteh-radio-bot/internal/service/schedule"
 	"github.com/GintGld/fizteh-radio-bot/internal/service/session"
 	statSrv "github.com/GintGld/fizteh-radio-bot/internal/service/stat"
+	watchSrv "github.com/GintGld/fizteh-radio-bot/internal/service/watch"
 
+	feedCl "github.com/GintGld/fizteh-radio-bot/internal/client/feed"
 	radioCl "github.com/GintGld/fizteh-radio-bot/internal/client/radio"
+	spotifyCl "github.com/GintGld/fizteh-radio-bot/internal/client/spotify"
 	yandexCl "github.com/GintGld/fizteh-radio-bot/internal/client/yandex"
 )
 
 type App struct {
-	log *slog.Logger
-	bot *bot.Bot
+	log     *slog.Logger
+	bot     *bot.Bot
+	jobs    interface{ Stop() }
+	library interface{ Stop() }
+	tmp     interface{ Stop() }
+	watch   interface{ Stop() }
+	podcast interface{ Stop() }
+	saved   interface{ Stop() }
 
 	server *http.Server
 	cancel context.CancelFunc
@@ -47,28 +73,68 @@ func New(
 	radioAdminAddr string,
 	radioClientAddr string,
 	yaToken string,
+	spotifyId string,
+	spotifySecret string,
+	spotifyAudioCmd string,
+	genreMap map[string]string,
+	downloadPolicy provider.DownloadPolicy,
 	webhookAddr string,
 	tmpDir string,
+	tmpMaxSizeMB int64,
 	userCacheFile string,
+	fingerprintFile string,
+	coverDir string,
+	mediaInfoFile string,
+	jobsFile string,
+	jobWorkers int,
+	watchFile string,
+	watchInterval time.Duration,
+	podcastFile string,
+	podcastInterval time.Duration,
+	savedFile string,
+	smartInterval time.Duration,
+	cacheDir string,
+	cacheSizeMB int64,
+	exportMediaURL string,
+	admins []int64,
 	srvFiller bool,
 ) *App {
 	// default handlers
 	errorHandler := getErrorHandler(logTg)
 	defaultHandler := getDefaultHandler(logTg, errorHandler)
+	inlineQueries := &ctr.InlineQueries{}
 
 	bot, err := bot.New(tgToken,
 		bot.WithDefaultHandler(defaultHandler),
+		bot.WithMiddlewares(inlineQueries.Middleware),
 	)
 	if err != nil {
 		panic("failed to create bot: " + err.Error())
 	}
 
+	tmp, err := tmpdir.New(logSrv, tmpDir, tmpMaxSizeMB<<20)
+	if err != nil {
+		panic("failed to create temporary directory: " + err.Error())
+	}
+	tmp.Start()
+
+	// Downloaded files shared
+	// by clients and services.
+	cache, err := dlcache.New(cacheDir, cacheSizeMB<<20)
+	if err != nil {
+		panic("failed to create download cache: " + err.Error())
+	}
+	// Downloads share quota of temporary files.
+	tmp.Include("downloads", cache.Size)
+	cache.SetQuota(tmp)
+
 	// Clients
 	var (
 		authClient        authSrv.AuthClient
 		libClient         libSrv.LibraryClient
 		libGetMediaClient schSrv.LibraryClient
-		yaClient          libSrv.YaClient
+		yaClient          provider.YaClient
+		feedClient        provider.FeedClient
 		schClient         schSrv.ScheduleClient
 		djClient          schSrv.AutoDJClient
 		liveClient        schSrv.LiveClient
@@ -81,13 +147,14 @@ func New(
 	)
 	yandexClient := yandexCl.New(
 		yaToken,
-		tmpDir,
+		cache,
 	)
 
 	authClient = radioClient
 	libClient = radioClient
 	libGetMediaClient = radioClient
 	yaClient = yandexClient
+	feedClient = feedCl.New()
 	schClient = radioClient
 	djClient = radioClient
 	liveClient = radioClient
@@ -102,8 +169,28 @@ func New(
 		mediaUploadSrv upload.MediaUpload
 		getScheduleSrv schedule.Schedule
 		dj             autodj.AutoDJ
+		taxonomy       upload.Taxonomy
+		tagsSrv        tagsCtr.Tags
 		liveSrv        live.LiveSrv
 		stat           statCtr.Stat
+		watchLib       watchSrv.Library
+		podcastLib     podcastSrv.Library
+		podcastSch     podcastSrv.Schedule
+		savedLib       savedSrv.Library
+		checkLib       libcheckSrv.Library
+		library        interface{ Stop() }
+	)
+
+	jobs := jobsSrv.New(
+		logSrv,
+		jobsFile,
+		jobWorkers,
+		cache,
+	)
+	feeds := provider.NewFeed(
+		logSrv,
+		feedClient,
+		cache,
 	)
 
 	// TODO: remove filler
@@ -117,17 +204,57 @@ func New(
 		mediaUploadSrv = filler
 		getScheduleSrv = filler
 		dj = filler
+		taxonomy = filler
+		tagsSrv = filler
+		watchLib = filler
+		podcastLib = filler
+		podcastSch = filler
+		savedLib = filler
+		checkLib = filler
+		library = filler
+
+		jobs.RegisterUpload(localModels.JobUpload, filler.UploadItem)
 	} else {
 		a := authSrv.New(
 			logSrv,
 			authClient,
 			userCacheFile,
 		)
+		yandex := provider.NewYandex(
+			logSrv,
+			yaClient,
+			provider.DefaultGenreMap.With(genreMap),
+			downloadPolicy,
+			cache,
+		)
+
+		providers := []libSrv.LinkProvider{
+			yandex,
+		}
+		if spotifyId != "" && spotifySecret != "" {
+			providers = append(providers, provider.NewSpotify(
+				logSrv,
+				spotifyCl.New(spotifyId, spotifySecret),
+				spotifyAudioCmd,
+				tmp,
+				cache,
+			))
+		} else {
+			logSrv.Warn("spotify credentials are not set, spotify links are disabled")
+		}
+		providers = append(providers, feeds)
+		providers = append(providers, provider.NewDirect(logSrv, tmp, cache))
+
 		l := libSrv.New(
 			logSrv,
 			a,
 			libClient,
-			yaClient,
+			providers,
+			yandex,
+			tmp,
+			fingerprintFile,
+			coverDir,
+			mediaInfoFile,
 		)
 		s := schSrv.New(
 			logSrv,
@@ -143,6 +270,11 @@ func New(
 			statClient,
 		)
 
+		jobs.RegisterUpload(localModels.JobUpload, l.UploadItem)
+
+		go l.LoadTaxonomy(context.Background())
+		go l.LoadIndex(context.Background())
+
 		auth = a
 		libSearchSrv = l
 		schSearchSrv = s
@@ -150,9 +282,61 @@ func New(
 		mediaUploadSrv = l
 		getScheduleSrv = s
 		dj = s
+		taxonomy = l
+		tagsSrv = l
 		liveSrv = s
+		watchLib = l
+		podcastLib = l
+		podcastSch = s
+		savedLib = l
+		checkLib = l
+		library = l
 	}
 
+	watch := watchSrv.New(
+		logSrv,
+		watchLib,
+		jobs,
+		watchFile,
+		watchInterval,
+	)
+	podcast := podcastSrv.New(
+		logSrv,
+		feeds,
+		podcastLib,
+		jobs,
+		podcastSch,
+		podcastFile,
+		podcastInterval,
+	)
+	saved := savedSrv.New(
+		logSrv,
+		savedLib,
+		savedFile,
+		smartInterval,
+	)
+
+	bulk := bulkSrv.New(
+		logSrv,
+		libSearchSrv,
+		schSearchSrv,
+	)
+	jobs.Register(localModels.JobBulk, bulk.Item)
+	export := exportSrv.New(
+		logSrv,
+		libSearchSrv,
+		saved,
+		jobs,
+		exportMediaURL,
+	)
+	check := libcheckSrv.New(
+		logSrv,
+		checkLib,
+		jobs,
+	)
+	jobs.Register(localModels.JobLibCheck, check.Item)
+	jobs.Register(localModels.JobReprobe, check.ReprobeItem)
+
 	// routing
 	session := session.New[string]()
 
@@ -179,14 +363,21 @@ func New(
 		scheduleAddSrv,
 		session,
 		errorHandler,
+		cache,
+		inlineQueries,
+		saved,
+		jobs,
 	)
 	upload.Register(
 		router.With("upload"),
 		auth,
 		mediaUploadSrv,
+		jobs,
 		session,
 		errorHandler,
-		tmpDir,
+		tmp,
+		cache,
+		taxonomy,
 	)
 	schedule.Register(
 		router.With("sch"),
@@ -201,6 +392,8 @@ func New(
 		dj,
 		session,
 		errorHandler,
+		taxonomy,
+		saved,
 	)
 	live.Register(
 		router.With("live"),
@@ -209,16 +402,72 @@ func New(
 		session,
 		errorHandler,
 	)
+	jobsCtr.Register(
+		router.With("jobs"),
+		auth,
+		jobs,
+		errorHandler,
+	)
+	watchCtr.Register(
+		router.With("watch"),
+		bot,
+		auth,
+		watch,
+		session,
+		errorHandler,
+	)
+	podcastCtr.Register(
+		router.With("podcasts"),
+		bot,
+		auth,
+		podcast,
+		session,
+		errorHandler,
+	)
+	tagsCtr.Register(
+		router.With("tags"),
+		auth,
+		tagsSrv,
+		session,
+		errorHandler,
+	)
 	statCtr.Register(
 		router.With("stat"),
 		auth,
 		stat,
+		tmp,
+		admins,
 		errorHandler,
 	)
+	exportCtr.Register(
+		router.With("export"),
+		auth,
+		export,
+		saved,
+		session,
+		errorHandler,
+		cache,
+	)
+	libcheckCtr.Register(
+		router.With("libcheck"),
+		auth,
+		check,
+		errorHandler,
+	)
+
+	watch.Start()
+	podcast.Start()
+	saved.Start()
 
 	return &App{
-		log: logSrv,
-		bot: bot,
+		log:     logSrv,
+		bot:     bot,
+		jobs:    jobs,
+		library: library,
+		tmp:     tmp,
+		watch:   watch,
+		podcast: podcast,
+		saved:   saved,
 		server: &http.Server{
 			Addr:    webhookAddr,
 			Handler: bot.WebhookHandler(),
@@ -295,6 +544,12 @@ func (a *App) Run(ctx context.Context) error {
 
 // Stop stops bot and its wekhook server.
 func (a *App) Stop() error {
+	a.watch.Stop()
+	a.podcast.Stop()
+	a.saved.Stop()
+	a.jobs.Stop()
+	a.library.Stop()
+	a.tmp.Stop()
 	a.cancel()
 	return nil
 	// return a.server.Close()
@@ -4,6 +4,11 @@ import "errors"
 
 var (
 	ErrTrackNotFound = errors.New("track not found")
+	ErrMediaNotFound = errors.New("media not found")
+	ErrTagNotFound   = errors.New("tag not found")
+
+	ErrFeedNotFound = errors.New("feed not found")
+	ErrInvalidFeed  = errors.New("invalid feed")
 
 	ErrInvalidCredentials = errors.New("invalid credentials")
 
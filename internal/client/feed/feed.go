@@ -0,0 +1,274 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Max size of feed document.
+	maxFeedSize = 10 << 20
+)
+
+var (
+	htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+	// Date formats met in RSS feeds.
+	rssDateFormats = []string{
+		time.RFC1123Z,
+		time.RFC1123,
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"2 Jan 2006 15:04:05 -0700",
+		time.RFC3339,
+	}
+)
+
+type Client struct {
+	c *http.Client
+}
+
+func New() *Client {
+	return &Client{
+		c: http.DefaultClient,
+	}
+}
+
+// Feed returns podcast feed.
+// Both RSS 2.0 and Atom are supported,
+// items without audio enclosure are skipped.
+func (c *Client) Feed(ctx context.Context, url string) (models.Feed, error) {
+	const op = "Client.Feed"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		feed, err := parse(bodyResp)
+		if err != nil {
+			return models.Feed{}, fmt.Errorf("%s: %w", op, err)
+		}
+		return feed, nil
+	case 404:
+		return models.Feed{}, client.ErrFeedNotFound
+	case 500:
+		return models.Feed{}, client.ErrInternalServerError
+	default:
+		return models.Feed{}, fmt.Errorf("%s: unknown return status %d", op, resp.StatusCode)
+	}
+}
+
+type rss struct {
+	Channel struct {
+		Title  string    `xml:"title"`
+		Author string    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+		Items  []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Summary     string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	PubDate     string `xml:"pubDate"`
+	Duration    string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Enclosure   struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+type atom struct {
+	Title  string `xml:"title"`
+	Author struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Duration  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Links     []struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+}
+
+// parse parses RSS or Atom document
+// depending on its root element.
+func parse(data []byte) (models.Feed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return models.Feed{}, fmt.Errorf("%w: %w", client.ErrInvalidFeed, err)
+	}
+
+	switch root.XMLName.Local {
+	case "rss":
+		var doc rss
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return models.Feed{}, fmt.Errorf("%w: %w", client.ErrInvalidFeed, err)
+		}
+		return doc.feed(), nil
+	case "feed":
+		var doc atom
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return models.Feed{}, fmt.Errorf("%w: %w", client.ErrInvalidFeed, err)
+		}
+		return doc.feed(), nil
+	default:
+		return models.Feed{}, client.ErrInvalidFeed
+	}
+}
+
+func (doc rss) feed() models.Feed {
+	feed := models.Feed{
+		Title:    strings.TrimSpace(doc.Channel.Title),
+		Author:   strings.TrimSpace(doc.Channel.Author),
+		Episodes: make([]models.FeedEpisode, 0, len(doc.Channel.Items)),
+	}
+
+	for _, item := range doc.Channel.Items {
+		if item.Enclosure.URL == "" || !isAudio(item.Enclosure.Type) {
+			continue
+		}
+
+		description := item.Description
+		if description == "" {
+			description = item.Summary
+		}
+
+		ep := models.FeedEpisode{
+			GUID:        strings.TrimSpace(item.GUID),
+			Title:       strings.TrimSpace(item.Title),
+			Description: plainText(description),
+			URL:         strings.TrimSpace(item.Enclosure.URL),
+			Published:   parseDate(item.PubDate),
+			Duration:    parseDuration(item.Duration),
+		}
+		if ep.GUID == "" {
+			ep.GUID = ep.URL
+		}
+
+		feed.Episodes = append(feed.Episodes, ep)
+	}
+
+	return feed
+}
+
+func (doc atom) feed() models.Feed {
+	feed := models.Feed{
+		Title:    strings.TrimSpace(doc.Title),
+		Author:   strings.TrimSpace(doc.Author.Name),
+		Episodes: make([]models.FeedEpisode, 0, len(doc.Entries)),
+	}
+
+	for _, entry := range doc.Entries {
+		var url string
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" && isAudio(link.Type) {
+				url = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+		if url == "" {
+			continue
+		}
+
+		description := entry.Summary
+		if description == "" {
+			description = entry.Content
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+
+		ep := models.FeedEpisode{
+			GUID:        strings.TrimSpace(entry.ID),
+			Title:       strings.TrimSpace(entry.Title),
+			Description: plainText(description),
+			URL:         url,
+			Published:   parseDate(published),
+			Duration:    parseDuration(entry.Duration),
+		}
+		if ep.GUID == "" {
+			ep.GUID = ep.URL
+		}
+
+		feed.Episodes = append(feed.Episodes, ep)
+	}
+
+	return feed
+}
+
+// isAudio reports if enclosure type is audio.
+// Missing type is considered audio.
+func isAudio(mimeType string) bool {
+	return mimeType == "" || strings.HasPrefix(mimeType, "audio/")
+}
+
+// plainText strips HTML markup.
+func plainText(s string) string {
+	s = htmlTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, format := range rssDateFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration parses itunes:duration
+// given as "H:MM:SS", "MM:SS" or seconds.
+func parseDuration(s string) time.Duration {
+	var total int
+	for _, part := range strings.Split(strings.TrimSpace(s), ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total) * time.Second
+}
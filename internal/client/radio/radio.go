@@ -105,6 +105,9 @@ func (c *Client) Search(ctx context.Context, token jwt.Token, filter models.Medi
 
 	url := fmt.Sprintf("%s/library/media", c.adminAddr)
 
+	// Other conditions of filter are not
+	// supported by radio, they are applied
+	// by local search index of library.
 	query := make([]string, 0, 4)
 	if filter.Name != "" {
 		query = append(query, urlPkg.PathEscape(fmt.Sprintf("name=%s", filter.Name)))
@@ -297,6 +300,119 @@ func (c *Client) UpdateMedia(ctx context.Context, token jwt.Token, media models.
 	}
 }
 
+// ReplaceSource swaps audio file of media.
+// ID, tags and segments referencing
+// media are kept by the server.
+func (c *Client) ReplaceSource(ctx context.Context, token jwt.Token, mediaId int64, sourcePath string) error {
+	const op = "Client.ReplaceSource"
+
+	url := fmt.Sprintf("%s/library/media/%d/source", c.adminAddr, mediaId)
+
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("source", sourcePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = io.Copy(part, file); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Raw)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 400:
+		var e HTTPError
+		if err := json.Unmarshal(bodyResp, &e); err != nil {
+			return fmt.Errorf("%s: %s", op, string(bodyResp))
+		}
+		return fmt.Errorf("%s: returned error %s", op, e.Err)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrMediaNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("%s: unknown return status %d", op, resp.StatusCode)
+	}
+}
+
+// Source downloads audio file of media to w.
+func (c *Client) Source(ctx context.Context, token jwt.Token, mediaId int64, w io.Writer) error {
+	const op = "Client.Source"
+
+	url := fmt.Sprintf("%s/library/media/%d/source", c.adminAddr, mediaId)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Raw)
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 200 {
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 400:
+		var e HTTPError
+		if err := json.Unmarshal(bodyResp, &e); err != nil {
+			return fmt.Errorf("%s: %s", op, string(bodyResp))
+		}
+		return fmt.Errorf("%s: returned error %s", op, e.Err)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrMediaNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("%s: unknown return status %d", op, resp.StatusCode)
+	}
+}
+
 func (c *Client) DeleteMedia(ctx context.Context, token jwt.Token, mediaId int64) error {
 	const op = "Client.DeleteMedia"
 
@@ -378,6 +494,8 @@ func (c *Client) Media(ctx context.Context, token jwt.Token, id int64) (models.M
 		return models.Media{}, fmt.Errorf("%s: returned error %s", op, e.Err)
 	case 401:
 		return models.Media{}, client.ErrNotAuthorized
+	case 404:
+		return models.Media{}, client.ErrMediaNotFound
 	case 500:
 		return models.Media{}, client.ErrInternalServerError
 	default:
@@ -485,6 +603,102 @@ func (c *Client) NewTag(ctx context.Context, token jwt.Token, tag models.Tag) (i
 	}
 }
 
+// UpdateTag changes name and meta of tag.
+// Media tagged with it keep the tag.
+func (c *Client) UpdateTag(ctx context.Context, token jwt.Token, tag models.Tag) error {
+	const op = "Client.UpdateTag"
+
+	url := fmt.Sprintf("%s/library/tag/%d", c.adminAddr, tag.ID)
+
+	bodyReq, err := json.Marshal(map[string]any{
+		"tag": tag,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(bodyReq))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Raw)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 400:
+		var e HTTPError
+		if err := json.Unmarshal(bodyResp, &e); err != nil {
+			return fmt.Errorf("%s: %s", op, string(bodyResp))
+		}
+		return fmt.Errorf("%s: returned error %s", op, e.Err)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrTagNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("%s: unknown return status %d", op, resp.StatusCode)
+	}
+}
+
+// DeleteTag deletes tag. Server removes
+// it from all media tagged with it.
+func (c *Client) DeleteTag(ctx context.Context, token jwt.Token, tagId int64) error {
+	const op = "Client.DeleteTag"
+
+	url := fmt.Sprintf("%s/library/tag/%d", c.adminAddr, tagId)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Raw)
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 400:
+		var e HTTPError
+		if err := json.Unmarshal(bodyResp, &e); err != nil {
+			return fmt.Errorf("%s: %s", op, string(bodyResp))
+		}
+		return fmt.Errorf("%s: returned error %s", op, e.Err)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrTagNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("%s: unknown return status %d", op, resp.StatusCode)
+	}
+}
+
 func (c *Client) NewSegment(ctx context.Context, token jwt.Token, segm models.Segment) error {
 	const op = "Client.NewSegment"
 
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	spmodels "github.com/GintGld/fizteh-radio-bot/internal/models/spotify"
+)
+
+const (
+	apiAddr   = "https://api.spotify.com/v1"
+	tokenAddr = "https://accounts.spotify.com/api/token"
+
+	// Token is updated a bit earlier
+	// than it actually expires.
+	tokenGap = time.Minute
+)
+
+type Client struct {
+	c      *http.Client
+	id     string
+	secret string
+
+	mutex   sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func New(
+	id string,
+	secret string,
+) *Client {
+	return &Client{
+		c:      http.DefaultClient,
+		id:     id,
+		secret: secret,
+	}
+}
+
+// Track returns track info.
+func (c *Client) Track(ctx context.Context, id string) (spmodels.Track, error) {
+	const op = "Client.Track"
+
+	var track spmodels.Track
+	if err := c.get(ctx, fmt.Sprintf("%s/tracks/%s", apiAddr, id), &track); err != nil {
+		return spmodels.Track{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return track, nil
+}
+
+// Album returns album info with all its tracks.
+func (c *Client) Album(ctx context.Context, id string) (spmodels.Album, error) {
+	const op = "Client.Album"
+
+	var res struct {
+		spmodels.Album
+		Tracks spmodels.Page[spmodels.Track] `json:"tracks"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("%s/albums/%s", apiAddr, id), &res); err != nil {
+		return spmodels.Album{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	album := res.Album
+	album.Tracks = res.Tracks.Items
+
+	for next := res.Tracks.Next; next != ""; {
+		var page spmodels.Page[spmodels.Track]
+		if err := c.get(ctx, next, &page); err != nil {
+			return spmodels.Album{}, fmt.Errorf("%s: %w", op, err)
+		}
+		album.Tracks = append(album.Tracks, page.Items...)
+		next = page.Next
+	}
+
+	// Album tracks are returned without album info.
+	for i := range album.Tracks {
+		album.Tracks[i].Album.Name = album.Name
+		album.Tracks[i].Album.Artists = album.Artists
+	}
+
+	return album, nil
+}
+
+// Playlist returns playlist info with all its tracks.
+func (c *Client) Playlist(ctx context.Context, id string) (spmodels.Playlist, error) {
+	const op = "Client.Playlist"
+
+	var res struct {
+		spmodels.Playlist
+		Tracks spmodels.Page[spmodels.PlaylistItem] `json:"tracks"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("%s/playlists/%s", apiAddr, id), &res); err != nil {
+		return spmodels.Playlist{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	playlist := res.Playlist
+	items := res.Tracks.Items
+
+	for next := res.Tracks.Next; next != ""; {
+		var page spmodels.Page[spmodels.PlaylistItem]
+		if err := c.get(ctx, next, &page); err != nil {
+			return spmodels.Playlist{}, fmt.Errorf("%s: %w", op, err)
+		}
+		items = append(items, page.Items...)
+		next = page.Next
+	}
+
+	playlist.Tracks = make([]spmodels.Track, 0, len(items))
+	for _, item := range items {
+		// Removed and local tracks have no track info.
+		if item.Track != nil && item.Track.Id != "" {
+			playlist.Tracks = append(playlist.Tracks, *item.Track)
+		}
+	}
+
+	return playlist, nil
+}
+
+// get requests API and decodes response to v.
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return json.Unmarshal(bodyResp, v)
+	case 400:
+		var res spmodels.SpotifyError
+		if err := json.Unmarshal(bodyResp, &res); err != nil {
+			return fmt.Errorf("status 400. Resp body: %s", string(bodyResp))
+		}
+		return fmt.Errorf("status 400. Error result %+v", res)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrTrackNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("unknown return status %d. Resp body: %s", resp.StatusCode, string(bodyResp))
+	}
+}
+
+// accessToken returns token obtained
+// with client credentials flow.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	const op = "Client.accessToken"
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenAddr, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	req.SetBasicAuth(c.id, c.secret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		var res spmodels.TokenResponse
+		if err := json.Unmarshal(bodyResp, &res); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		c.token = res.AccessToken
+		c.expires = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - tokenGap)
+		return c.token, nil
+	case 400, 401:
+		return "", client.ErrInvalidCredentials
+	case 500:
+		return "", client.ErrInternalServerError
+	default:
+		return "", fmt.Errorf("%s: unknown return status %d. Resp body: %s", op, resp.StatusCode, string(bodyResp))
+	}
+}
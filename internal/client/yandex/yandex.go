@@ -7,7 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 
 	"github.com/GintGld/fizteh-radio-bot/internal/client"
 	yamodels "github.com/GintGld/fizteh-radio-bot/internal/models/yandex"
@@ -18,19 +19,24 @@ const (
 )
 
 type Client struct {
-	c      *http.Client
-	token  string
-	tmpDir string
+	c     *http.Client
+	token string
+	cache Cache
+}
+
+// Cache stores downloaded tracks.
+type Cache interface {
+	Download(ctx context.Context, key string, req *http.Request) (string, error)
 }
 
 func New(
 	token string,
-	tmpDir string,
+	cache Cache,
 ) *Client {
 	return &Client{
-		c:      http.DefaultClient,
-		token:  token,
-		tmpDir: tmpDir,
+		c:     http.DefaultClient,
+		token: token,
+		cache: cache,
 	}
 }
 
@@ -132,6 +138,144 @@ func (c *Client) Playlist(ctx context.Context, user string, id string) (yamodels
 	}
 }
 
+// Search searches tracks and albums in catalog.
+func (c *Client) Search(ctx context.Context, text string, page int) (yamodels.SearchResult, error) {
+	const op = "Client.Search"
+
+	query := url.Values{}
+	query.Set("text", text)
+	query.Set("type", "all")
+	query.Set("page", strconv.Itoa(page))
+
+	reqURL := fmt.Sprintf("https://%s/search?%s", proxyAddr, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return yamodels.SearchResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Authorization", "OAuth "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return yamodels.SearchResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return yamodels.SearchResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		var res struct {
+			Res yamodels.SearchResult `json:"result"`
+		}
+		if err := json.Unmarshal(bodyResp, &res); err != nil {
+			return yamodels.SearchResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		return res.Res, nil
+	case 400:
+		var res yamodels.YaError
+		if err := json.Unmarshal(bodyResp, &res); err != nil {
+			return yamodels.SearchResult{}, fmt.Errorf("%s: status 400. Resp body: %s", op, string(bodyResp))
+		}
+		return yamodels.SearchResult{}, fmt.Errorf("%s: status 400. Error result %+v", op, res)
+	case 401:
+		return yamodels.SearchResult{}, client.ErrNotAuthorized
+	case 500:
+		return yamodels.SearchResult{}, client.ErrInternalServerError
+	default:
+		return yamodels.SearchResult{}, fmt.Errorf("%s: unknown return status %d. Resp body: %s", op, resp.StatusCode, string(bodyResp))
+	}
+}
+
+// ArtistInfo returns brief artist info.
+func (c *Client) ArtistInfo(ctx context.Context, id string) (yamodels.ArtistInfo, error) {
+	const op = "Client.ArtistInfo"
+
+	var res yamodels.ArtistInfo
+	if err := c.get(ctx, fmt.Sprintf("https://%s/artists/%s/brief-info", proxyAddr, id), &res); err != nil {
+		return yamodels.ArtistInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// ArtistTracks returns most popular artist tracks.
+func (c *Client) ArtistTracks(ctx context.Context, id string, count int) ([]yamodels.Track, error) {
+	const op = "Client.ArtistTracks"
+
+	var res struct {
+		Tracks []yamodels.Track `json:"tracks"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("https://%s/artists/%s/tracks?page=0&page-size=%d", proxyAddr, id, count), &res); err != nil {
+		return []yamodels.Track{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.Tracks, nil
+}
+
+// ArtistAlbums returns albums of artist
+// (without tracks) sorted by year.
+func (c *Client) ArtistAlbums(ctx context.Context, id string, count int) ([]yamodels.Album, error) {
+	const op = "Client.ArtistAlbums"
+
+	var res struct {
+		Albums []yamodels.Album `json:"albums"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("https://%s/artists/%s/direct-albums?page=0&page-size=%d&sort-by=year", proxyAddr, id, count), &res); err != nil {
+		return []yamodels.Album{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res.Albums, nil
+}
+
+// get requests API and decodes
+// "result" field of response to v.
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "OAuth "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyResp, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		res := struct {
+			Res any `json:"result"`
+		}{Res: v}
+		return json.Unmarshal(bodyResp, &res)
+	case 400:
+		var res yamodels.YaError
+		if err := json.Unmarshal(bodyResp, &res); err != nil {
+			return fmt.Errorf("status 400. Resp body: %s", string(bodyResp))
+		}
+		return fmt.Errorf("status 400. Error result %+v", res)
+	case 401:
+		return client.ErrNotAuthorized
+	case 404:
+		return client.ErrTrackNotFound
+	case 500:
+		return client.ErrInternalServerError
+	default:
+		return fmt.Errorf("unknown return status %d. Resp body: %s", resp.StatusCode, string(bodyResp))
+	}
+}
+
 func (c *Client) DownloadInfo(ctx context.Context, id string) ([]yamodels.DownloadInfo, error) {
 	const op = "Client.DownloadTrack"
 
@@ -180,7 +324,9 @@ func (c *Client) DownloadInfo(ctx context.Context, id string) ([]yamodels.Downlo
 	}
 }
 
-func (c *Client) DirectLink(ctx context.Context, url string) (string, error) {
+// DirectLink returns link to download
+// track encoded with codec.
+func (c *Client) DirectLink(ctx context.Context, url string, codec yamodels.CodecType) (string, error) {
 	const op = "Client.getDirectLink"
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -207,7 +353,7 @@ func (c *Client) DirectLink(ctx context.Context, url string) (string, error) {
 		if err := xml.Unmarshal(bodyResp, &resp); err != nil {
 			return "", fmt.Errorf("%s: %w", op, err)
 		}
-		return resp.BuildLink(), nil
+		return resp.BuildLink(codec), nil
 	case 400:
 		var res yamodels.YaError
 		if err := json.Unmarshal(bodyResp, &res); err != nil {
@@ -224,7 +370,9 @@ func (c *Client) DirectLink(ctx context.Context, url string) (string, error) {
 	}
 }
 
-func (c *Client) DownloadTrack(ctx context.Context, url string) (string, error) {
+// DownloadTrack downloads track to cache
+// by key or returns already cached file.
+func (c *Client) DownloadTrack(ctx context.Context, key string, url string) (string, error) {
 	const op = "Client.downloadTrack"
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -232,24 +380,11 @@ func (c *Client) DownloadTrack(ctx context.Context, url string) (string, error)
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 	req.Header.Set("Authorization", "OAuth "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.c.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-	defer resp.Body.Close()
-
-	out, err := os.CreateTemp(c.tmpDir, "ya-track-*.mp3")
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	filePath, err := c.cache.Download(ctx, key, req)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return out.Name(), nil
+	return filePath, nil
 }
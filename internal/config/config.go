@@ -4,19 +4,70 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env             string `yaml:"env" env-required:"true"`
-	Log             Log    `yaml:"log"`
-	RadioAdminAddr  string `yaml:"radio-admin-addr" env-required:"true"`
-	RadioClientAddr string `yaml:"radio-client-addr" env-required:"true"`
-	WebhookAddr     string `yaml:"webhook-addr" env-default:"8443"`
-	TmpDir          string `yaml:"tmp-dir" env-default:"tmp"`
-	UserCacheFile   string `yaml:"user-cache" env-default:".cache/users.json"`
-	UseFiller       bool   `yaml:"use-filler" env-default:"false"`
+	Env             string  `yaml:"env" env-required:"true"`
+	Log             Log     `yaml:"log"`
+	RadioAdminAddr  string  `yaml:"radio-admin-addr" env-required:"true"`
+	RadioClientAddr string  `yaml:"radio-client-addr" env-required:"true"`
+	WebhookAddr     string  `yaml:"webhook-addr" env-default:"8443"`
+	TmpDir          string  `yaml:"tmp-dir" env-default:"tmp"`
+	TmpMaxSizeMB    int64   `yaml:"tmp-max-size" env-default:"4096"` // includes download cache
+	UserCacheFile   string  `yaml:"user-cache" env-default:".cache/users.json"`
+	FingerprintFile string  `yaml:"fingerprint-cache" env-default:".cache/fingerprints.json"`
+	CoverDir        string  `yaml:"cover-dir" env-default:".cache/covers"`
+	MediaInfoFile   string  `yaml:"media-info-cache" env-default:".cache/media.json"`
+	JobsFile        string  `yaml:"jobs-cache" env-default:".cache/jobs.json"`
+	JobWorkers      int     `yaml:"job-workers" env-default:"2"`
+	WatchFile       string  `yaml:"watch-cache" env-default:".cache/watched.json"`
+	PodcastFile     string  `yaml:"podcast-cache" env-default:".cache/podcasts.json"`
+	SavedFile       string  `yaml:"saved-cache" env-default:".cache/saved.json"`
+	CacheDir        string  `yaml:"download-cache-dir" env-default:".cache/downloads"`
+	CacheSizeMB     int64   `yaml:"download-cache-size" env-default:"2048"`
+	Yandex          Yandex  `yaml:"yandex"`
+	Spotify         Spotify `yaml:"spotify"`
+	// Yandex genre to station genre,
+	// added to default map.
+	GenreMap  map[string]string `yaml:"genre-map"`
+	UseFiller bool              `yaml:"use-filler" env-default:"false"`
+	// Interval between synchronizations
+	// of watched playlists.
+	WatchInterval time.Duration `yaml:"watch-interval" env-default:"6h"`
+	// Interval between checks
+	// of podcast feeds.
+	PodcastInterval time.Duration `yaml:"podcast-interval" env-default:"1h"`
+	// Interval between updates
+	// of smart playlists.
+	SmartInterval time.Duration `yaml:"smart-interval" env-default:"1h"`
+	// Link to media audio in exported
+	// M3U playlists, {id} is replaced
+	// with media id. If empty, file
+	// names "<id>.mp3" are used.
+	ExportMediaURL string `yaml:"export-media-url" env-default:""`
+	// Telegram ids of bot administrators,
+	// they see usage of temporary files.
+	Admins []int64 `yaml:"admins"`
+}
+
+type Yandex struct {
+	// Accepted codecs, most preferred first.
+	Codecs []string `yaml:"codecs" env-default:"mp3,aac"`
+	// Max bitrate in kbps, 0 means no limit.
+	MaxBitrate float64 `yaml:"max-bitrate" env-default:"320"`
+	// Allow downloading 30-second previews
+	// if full track is not available.
+	AllowPreview bool `yaml:"allow-preview" env-default:"false"`
+}
+
+type Spotify struct {
+	// Command downloading audio of spotify track.
+	// {output} is replaced with path to mp3 file,
+	// {query} with "author - name".
+	AudioCommand string `yaml:"audio-command" env-default:"yt-dlp -x --audio-format mp3 -o {output} ytsearch1:{query}"`
 }
 
 type Log struct {
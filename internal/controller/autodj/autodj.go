@@ -3,6 +3,7 @@ package autodj
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -10,7 +11,6 @@ import (
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
-	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/slice"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/split"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
 	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
@@ -27,6 +27,8 @@ const (
 	cmdOpenCheckBox ctr.Command = "open-check"
 	cmdCheckBtn     ctr.Command = "check"
 	cmdCloseSubtask ctr.Command = "close-subtask"
+	cmdOpenSmart    ctr.Command = "open-smart"
+	cmdCheckSmart   ctr.Command = "check-smart"
 
 	cmdNoOp ctr.Command = "no-op"
 )
@@ -40,6 +42,9 @@ type autodj struct {
 	session ctr.Session
 	onError bot.ErrorsHandler
 
+	taxonomy Taxonomy
+	smart    SmartPlaylists
+
 	confStorage         storage.Storage[localModels.AutoDJInfo]
 	targetUpdateStorage storage.Storage[string]
 	msgIdStorage        storage.Storage[int]
@@ -56,19 +61,35 @@ type AutoDJ interface {
 	StopAutoDJ(ctx context.Context, id int64) error
 }
 
+// Taxonomy provides tags
+// available for selection.
+type Taxonomy interface {
+	Taxonomy(ctx context.Context, id int64) (localModels.Taxonomy, error)
+}
+
+// SmartPlaylists lists saved searches,
+// smart ones are mirrored into playlists.
+type SmartPlaylists interface {
+	List(ctx context.Context, id int64) ([]localModels.SavedSearch, error)
+}
+
 func Register(
 	router *ctr.Router,
 	auth Auth,
 	dj AutoDJ,
 	session ctr.Session,
 	onError bot.ErrorsHandler,
+	taxonomy Taxonomy,
+	smart SmartPlaylists,
 ) {
 	a := &autodj{
-		router:  router,
-		auth:    auth,
-		dj:      dj,
-		session: session,
-		onError: onError,
+		router:   router,
+		auth:     auth,
+		dj:       dj,
+		session:  session,
+		onError:  onError,
+		taxonomy: taxonomy,
+		smart:    smart,
 
 		confStorage:         storage.New[localModels.AutoDJInfo](),
 		targetUpdateStorage: storage.New[string](),
@@ -84,6 +105,8 @@ func Register(
 	router.RegisterCallbackPrefix(cmdOpenCheckBox, a.openCheckBox)
 	router.RegisterCallbackPrefix(cmdCheckBtn, a.getCheckedBtn)
 	router.RegisterCallback(cmdCloseSubtask, a.closeSubtask)
+	router.RegisterCallback(cmdOpenSmart, a.openSmart)
+	router.RegisterCallbackPrefix(cmdCheckSmart, a.checkSmart)
 
 	// start, stop
 	router.RegisterCallback(cmdStartStop, a.startStop)
@@ -175,6 +198,18 @@ func (a *autodj) openCheckBox(ctx context.Context, b *bot.Bot, update *models.Up
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 	callback := a.router.GetState(update.CallbackQuery.Data)
 
+	tax, err := a.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	var (
 		msg    string
 		markup models.InlineKeyboardMarkup
@@ -185,13 +220,13 @@ func (a *autodj) openCheckBox(ctx context.Context, b *bot.Bot, update *models.Up
 	switch callback {
 	case "genre":
 		msg = ctr.SchAutoDJAskGenre
-		markup = a.genreChooseMarkup(conf)
+		markup = a.genreChooseMarkup(conf, tax)
 	case "mood":
 		msg = ctr.SchAutoDJAskMood
-		markup = a.moodChooseMarkup(conf)
+		markup = a.moodChooseMarkup(conf, tax)
 	case "lang":
 		msg = ctr.SchAutoDJAskLanguage
-		markup = a.langChooseMarkup(conf)
+		markup = a.langChooseMarkup(conf, tax)
 	}
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
@@ -214,7 +249,7 @@ func (a *autodj) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 	callback := a.router.GetState(update.CallbackQuery.Data)
 
 	// tagType in ('genre', 'mood', 'lang')
-	// data is tag id (from 1 to GenreNumber, MoodNumber, LangNumber)
+	// data is id of tag on server
 	tagType, data, found := strings.Cut(callback, "-")
 	if !found {
 		a.onError(fmt.Errorf("%s [%d]: invalid callback data \"%s\"", op, chatId, callback))
@@ -238,6 +273,30 @@ func (a *autodj) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 		return
 	}
 
+	tax, err := a.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	tag, found := tax.Find(int64(id))
+	if !found {
+		a.onError(fmt.Errorf("%s [%d]: unknown tag id %d", op, chatId, id))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	conf := a.confStorage.Get(chatId)
 
 	var (
@@ -247,17 +306,17 @@ func (a *autodj) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 
 	switch tagType {
 	case "genre":
-		conf.Genres[id-1] = !conf.Genres[id-1]
+		conf.Genres.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskGenre
-		markup = a.genreChooseMarkup(conf)
+		markup = a.genreChooseMarkup(conf, tax)
 	case "mood":
-		conf.Moods[id-1] = !conf.Moods[id-1]
+		conf.Moods.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskMood
-		markup = a.moodChooseMarkup(conf)
+		markup = a.moodChooseMarkup(conf, tax)
 	case "lang":
-		conf.Languages[id-1] = !conf.Languages[id-1]
+		conf.Languages.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskLang
-		markup = a.langChooseMarkup(conf)
+		markup = a.langChooseMarkup(conf, tax)
 	}
 
 	a.confStorage.Set(chatId, conf)
@@ -273,6 +332,99 @@ func (a *autodj) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 	}
 }
 
+// openSmart shows smart playlists to choose.
+func (a *autodj) openSmart(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "autodj.openSmart"
+
+	a.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	a.showSmart(ctx, b, chatId, op)
+}
+
+// checkSmart toggles smart playlist,
+// callback data is id of saved search.
+func (a *autodj) checkSmart(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "autodj.checkSmart"
+
+	a.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, err := strconv.ParseInt(a.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		a.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	list, err := a.smart.List(ctx, chatId)
+	if err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		a.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	i := slices.IndexFunc(list, func(s localModels.SavedSearch) bool {
+		return s.ID == searchId && s.Smart
+	})
+	if i == -1 {
+		a.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrNotFound)
+		return
+	}
+
+	conf := a.confStorage.Get(chatId)
+	if slices.Contains(conf.Playlists, list[i].Name) {
+		conf.Playlists = slices.DeleteFunc(slices.Clone(conf.Playlists), func(p string) bool {
+			return p == list[i].Name
+		})
+	} else {
+		conf.Playlists = append(slices.Clone(conf.Playlists), list[i].Name)
+	}
+	a.confStorage.Set(chatId, conf)
+
+	a.showSmart(ctx, b, chatId, op)
+}
+
+func (a *autodj) showSmart(ctx context.Context, b *bot.Bot, chatId int64, op string) {
+	list, err := a.smart.List(ctx, chatId)
+	if err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		a.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	list = slices.DeleteFunc(list, func(s localModels.SavedSearch) bool {
+		return !s.Smart
+	})
+
+	msg := ctr.SchAutoDJAskSmart
+	if len(list) == 0 {
+		msg = ctr.SchAutoDJNoSmart
+	}
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   a.msgIdStorage.Get(chatId),
+		Text:        msg,
+		ReplyMarkup: a.smartChooseMarkup(a.confStorage.Get(chatId), list),
+	}); err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (a *autodj) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "autodj.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		a.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
 func (a *autodj) closeSubtask(ctx context.Context, b *bot.Bot, update *models.Update) {
 	const op = "autodj.closeSubtask"
 
@@ -448,10 +600,10 @@ func (a *autodj) configRepr(info localModels.AutoDJInfo) string {
 	var b strings.Builder
 
 	b.WriteString("<b>Настройки автодиджея:</b>\n")
-	b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", slice.Join(slice.Filter(localModels.GenresAvail[:], info.Genres[:]), ", ")))
+	b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", info.Genres))
 	b.WriteString(fmt.Sprintf("<b>Плейлисты:</b> %s\n", strings.Join(info.Playlists, ", ")))
-	b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", slice.Join(slice.Filter(localModels.LangsAvail[:], info.Languages[:]), ", ")))
-	b.WriteString(fmt.Sprintf("<b>Настроения:</b> %s\n", slice.Join(slice.Filter(localModels.MoodsAvail[:], info.Moods[:]), ", ")))
+	b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", info.Languages))
+	b.WriteString(fmt.Sprintf("<b>Настроения:</b> %s\n", info.Moods))
 
 	if info.IsPlaying {
 		b.WriteString("<b>Сейчас играет</b>")
@@ -2,6 +2,8 @@ package autodj
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 
 	"github.com/go-telegram/bot/models"
 
@@ -15,6 +17,7 @@ const (
 	butMsgPlaylist   = "Плейлисты"
 	butMsgLanguage   = "Языки"
 	butMsgMood       = "Настроения"
+	butMsgSmart      = "Умные плейлисты"
 	butMsgReset      = "Сбросить"
 	butMsgUpdate     = "Обновить"
 	butMsgCancel     = "Назад"
@@ -41,6 +44,9 @@ func (a *autodj) mainMenuMarkup(conf localModels.AutoDJInfo) models.InlineKeyboa
 				{Text: butMsgLanguage, CallbackData: a.router.PathPrefixState(cmdOpenCheckBox, "lang")},
 				{Text: butMsgMood, CallbackData: a.router.PathPrefixState(cmdOpenCheckBox, "mood")},
 			},
+			{
+				{Text: butMsgSmart, CallbackData: a.router.Path(cmdOpenSmart)},
+			},
 			{
 				{Text: butMsgAlbum, CallbackData: a.router.PathPrefixState(cmdUpdate, "album")},
 				{Text: butMsgReset, CallbackData: a.router.Path(cmdReset)},
@@ -53,22 +59,22 @@ func (a *autodj) mainMenuMarkup(conf localModels.AutoDJInfo) models.InlineKeyboa
 	}
 }
 
-func (a *autodj) genreChooseMarkup(conf localModels.AutoDJInfo) models.InlineKeyboardMarkup {
+func (a *autodj) genreChooseMarkup(conf localModels.AutoDJInfo, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.GenreNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Genres)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, g := range localModels.GenresAvail {
-		msg = g.String()
-		if conf.Genres[g.Id-1] {
+	for _, g := range tax.Genres {
+		msg = g.Name
+		if conf.Genres.Has(g.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("genre-%d", g.Id)
+		callback := fmt.Sprintf("genre-%d", g.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -101,22 +107,22 @@ func (a *autodj) genreChooseMarkup(conf localModels.AutoDJInfo) models.InlineKey
 	}
 }
 
-func (a *autodj) moodChooseMarkup(conf localModels.AutoDJInfo) models.InlineKeyboardMarkup {
+func (a *autodj) moodChooseMarkup(conf localModels.AutoDJInfo, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Moods)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, m := range localModels.MoodsAvail {
-		msg = m.String()
-		if conf.Moods[m.Id-1] {
+	for _, m := range tax.Moods {
+		msg = m.Name
+		if conf.Moods.Has(m.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("mood-%d", m.Id)
+		callback := fmt.Sprintf("mood-%d", m.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -148,22 +154,22 @@ func (a *autodj) moodChooseMarkup(conf localModels.AutoDJInfo) models.InlineKeyb
 	}
 }
 
-func (a *autodj) langChooseMarkup(conf localModels.AutoDJInfo) models.InlineKeyboardMarkup {
+func (a *autodj) langChooseMarkup(conf localModels.AutoDJInfo, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 3
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Languages)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, l := range localModels.LangsAvail {
-		msg = l.String()
-		if conf.Languages[l.Id-1] {
+	for _, l := range tax.Languages {
+		msg = l.Name
+		if conf.Languages.Has(l.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("lang-%d", l.Id)
+		callback := fmt.Sprintf("lang-%d", l.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -194,3 +200,29 @@ func (a *autodj) langChooseMarkup(conf localModels.AutoDJInfo) models.InlineKeyb
 		InlineKeyboard: rows,
 	}
 }
+
+func (a *autodj) smartChooseMarkup(conf localModels.AutoDJInfo, list []localModels.SavedSearch) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(list)+1)
+
+	for _, s := range list {
+		msg := s.Name
+		if slices.Contains(conf.Playlists, s.Name) {
+			msg += butMsgChecked
+		} else {
+			msg += butMsgNotChecked
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         msg,
+			CallbackData: a.router.PathPrefixState(cmdCheckSmart, strconv.FormatInt(s.ID, 10)),
+		}})
+	}
+
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgCancel,
+		CallbackData: a.router.Path(cmdCloseSubtask),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
@@ -52,10 +52,27 @@ const (
 	LibSearchAskGenre           = "Отлично, введи жанры через запятую."
 	LibSearchAskLang            = "Отлично, введи языки через запятую."
 	LibSearchAskMood            = "Отлично, введи настроения через запятую."
+	LibSearchAskExclude         = "Отлично, введи через запятую теги, которых не должно быть."
+	LibSearchAskDuration        = "Отлично, введи длительность в минутах в виде 'от-до', например '2:30-4', '-4' или '3-'."
+	LibSearchErrDuration        = "Не понял длительность, попробуй ещё раз, например '2:30-4'."
 	LibSearchErrNameAuthorEmpty = "А почему название пустое?"
 	LibSearchErrNilOption       = "Ты так получишь фиг знает что, настрой поиск получше."
 	LibSearchErrEmptyRes        = "По твоему запросу ничего не нашлось."
 
+	// "/lib/search" saved searches
+	LibSearchAskSaveName      = "Отлично, введи название для поиска."
+	LibSearchSavedSuccess     = "Поиск сохранен."
+	LibSearchSavedList        = "Сохраненные поиски:"
+	LibSearchSavedEmpty       = "Сохраненных поисков нет. Настрой поиск и нажми 'сохранить поиск'."
+	LibSearchSavedErrExists   = "Поиск с таким названием уже есть, введи другое."
+	LibSearchSavedErrNotFound = "Такого поиска больше нет."
+	LibSearchSavedErrNotOwner = "Менять поиск может только его автор."
+	LibSearchSmartOn          = "Композиции, найденные поиском, будут по расписанию попадать в плейлист \"%s\". Его можно выбрать в настройках автодиджея."
+	LibSearchSmartOff         = "Умный плейлист отключен, тег снят с композиций."
+	LibSearchSmartSynced      = "Плейлист обновлен: добавлено %d, убрано %d."
+	LibSearchSmartErrPlaylist = "Плейлист с таким названием уже есть, переименуй поиск, сохранив его заново."
+	LibSearchSmartErrSyncing  = "Плейлист уже обновляется, подожди немного."
+
 	// "/lib/search" update
 	LibSearchUpdatedSuccess    = "Успешно обновлено."
 	LibSearchUpdateAskName     = "Название."
@@ -71,6 +88,32 @@ const (
 	LibSearchDeleteSubmit  = "Точно ли хочешь удалить?"
 	LibSearchDeleteSuccess = "Успешно удалено."
 
+	// "/lib/search" queue from inline result
+	LibSearchQueueSubmit = "Добавить в очередь?"
+
+	// "/lib/search" replace audio
+	LibSearchReplaceAsk         = "Отправь новый .mp3 файл или ссылку на трек. ID, теги и сегменты в расписании сохранятся."
+	LibSearchReplaceSuccess     = "Аудио заменено."
+	LibSearchReplaceErrNotSong  = "По ссылке должен быть один трек."
+	LibSearchReplaceErrAudio    = "Не получилось прочитать аудио из файла."
+	LibSearchReplaceErrNotFound = "Этой композиции уже нет в библиотеке."
+
+	// "/lib/search" listen
+	LibSearchListenPreview = "Файл слишком большой для телеграма, вот 30-секундный фрагмент."
+
+	// "/lib/search" inline mode
+	LibSearchInlineLogin = "Войти в бота"
+
+	// "/lib/search" multi-select
+	LibSearchBulkSelect      = "Выбери композиции (выбрано %d из %d)."
+	LibSearchBulkActions     = "Что сделать с выбранными композициями (%d)?"
+	LibSearchBulkErrEmpty    = "Сначала выбери хотя бы одну композицию."
+	LibSearchBulkAskTagType  = "Какие теги поменять?"
+	LibSearchBulkAskTags     = "Отлично, введи теги через запятую."
+	LibSearchBulkAskFormat   = "Выбери новый формат."
+	LibSearchBulkDeleteAsk   = "Точно удалить композиции (%d)? Отменить это будет нельзя."
+	LibSearchBulkErrTagEmpty = "Не надо вводить пустые теги..."
+
 	// "/lib/search/pick"
 	LibSearchPickSelecting = "Выбор даты и времени."
 
@@ -87,11 +130,102 @@ const (
 	LibUploadAskPodcast            = "Введи через запятую сезоны, куда добавить подкаст."
 	LibUploadAskLang               = "Выбирай языки."
 	LibUploadAskMood               = "Выбирай настроения."
-	LibUploadAskLink               = "Отправь мне ссылку на скачивание. Поддерживаемые сервисы на данный момент: Яндекс."
+	LibUploadAskLink               = "Отправь мне ссылку на скачивание. Поддерживаемые сервисы на данный момент: %s."
 	LibUploadSuccess               = "Загружено."
 	LibUploadErrEmptyMsg           = "Не надо делать пустое поле..."
 	LibUploadErrInvalidLink        = "Не могу распознать твою ссылку"
+	LibUploadErrPreviewOnly        = "Для этого трека доступно только 30-секундное превью, загружать его я не буду."
+	LibUploadErrNoDownloadOption   = "Не нашлось подходящего формата для скачивания трека."
+	LibUploadErrTmpQuota           = "Сейчас на сервере слишком много временных файлов. Попробуй загрузить позже."
 	LibUploadErrMediaAlreadyExists = "Композиция с таким названием и автором уже существует. Если хочешь ее отредактировать, используй поиск в библиотеке."
+	LibUploadPossibleDuplicates    = "Похоже, это уже есть в библиотеке:"
+	LibUploadAskArtistMode         = "Что загрузить?"
+	LibUploadAskCatalogQuery       = "Что найти в Яндекс Музыке?"
+	LibUploadCatalogEmpty          = "Ничего не нашлось :("
+	LibUploadAskBulk               = "Отправь мне .mp3 файлы (можно группой) или архив .zip/.tar с ними. Когда закончишь, нажми \"Готово\"."
+	LibUploadBulkReceived          = "Получено файлов: %d. Отправь еще или нажми \"Готово\"."
+	LibUploadBulkEmpty             = "Ты еще не отправил(а) ни одного файла."
+	LibUploadBulkNotFound          = "Я жду .mp3 файлы или архив с ними."
+	LibUploadBulkInvalidArchive    = "Не получилось распаковать архив."
+	LibUploadBulkArchiveTooLarge   = "Архив слишком большой: файл больше 50 МБ или всего больше 500 МБ."
+
+	// "/jobs" command
+	JobsEmpty     = "Фоновых задач пока нет."
+	JobsList      = "<b>Фоновые задачи</b>"
+	JobsSeeList   = "Все задачи: /jobs"
+	JobsNotActive = "Задача уже завершена."
+	JobsActive    = "Задача еще выполняется."
+	JobsRetried   = "Задача перезапущена."
+
+	// "/watch" command
+	WatchEmpty          = "Отслеживаемых плейлистов пока нет. Новые треки отслеживаемого плейлиста Яндекс Музыки загружаются в библиотеку автоматически."
+	WatchList           = "<b>Отслеживаемые плейлисты</b>"
+	WatchAskLink        = "Отправь ссылку на плейлист Яндекс Музыки."
+	WatchAdded          = "Плейлист \"%s\" отслеживается. Пришлю итог первой синхронизации, когда она закончится."
+	WatchErrNotPlaylist = "Это не ссылка на плейлист."
+	WatchErrExists      = "Этот плейлист уже отслеживается."
+	WatchErrNotFound    = "Плейлист больше не отслеживается."
+	WatchSyncStarted    = "Синхронизация запущена."
+	WatchSyncInProgress = "Синхронизация уже идет."
+
+	// "/podcasts" command
+	PodcastEmpty           = "Подписок на подкасты пока нет. Новые выпуски из RSS-ленты подкаста загружаются в библиотеку автоматически."
+	PodcastList            = "<b>Подкасты</b>"
+	PodcastAskLink         = "Отправь ссылку на RSS-ленту подкаста. Через пробел можно указать название подкаста, иначе будет взято из ленты."
+	PodcastAdded           = "Подписка на \"%s\" оформлена. Пришлю итог загрузки последнего выпуска, когда она закончится."
+	PodcastAskSlot         = "Когда ставить новый выпуск в эфир? Например: \"пн 19:00\". Отправь \"-\", чтобы не ставить."
+	PodcastErrInvalidFeed  = "По этой ссылке не нашлось RSS-ленты."
+	PodcastErrExists       = "Эта лента уже в подписках."
+	PodcastErrNotFound     = "Подписки больше нет."
+	PodcastErrInvalidSlot  = "Не понял время. Пример: \"пн 19:00\"."
+	PodcastCheckStarted    = "Проверка ленты запущена."
+	PodcastCheckInProgress = "Лента уже проверяется."
+
+	// "/tags" command
+	TagsInit             = "Какие теги показать?"
+	TagsEmpty            = "Тегов этого типа пока нет."
+	TagsList             = "<b>%s</b>, всего %d. В скобках число композиций."
+	TagsAskName          = "Введи новое название тега."
+	TagsAskTarget        = "Введи название тега, в который перенести композиции. Можно часть названия."
+	TagsAskMeta          = "Введи значение поля \"%s\". Отправь \"-\", чтобы очистить."
+	TagsSelectTarget     = "Выбери тег, в который перенести композиции."
+	TagsMergeSubmit      = "Перенести композиции (%d) из \"%s\" в \"%s\"? Тег \"%s\" будет удален."
+	TagsMerged           = "Перенесено композиций: %d."
+	TagsDeleted          = "Тег удален."
+	TagsErrEmptyMsg      = "Не надо делать пустое поле..."
+	TagsErrNotFound      = "Такого тега уже нет."
+	TagsErrExists        = "Тег с таким названием уже есть. Если это одно и то же, объедини их."
+	TagsErrInUse         = "Тег еще используется, удалить можно только пустой тег. Объедини его с другим."
+	TagsErrInvalidYear   = "Год должен быть числом."
+	TagsErrTargetMissing = "Не нашлось подходящих тегов этого типа."
+
+	// "/export" command
+	ExportInit              = "Что выгрузить? CSV можно отредактировать в таблице и прислать обратно, чтобы поменять теги."
+	ExportAskTag            = "Введи название тега, плейлист из его композиций будет в M3U."
+	ExportSavedList         = "Выбери сохраненный поиск для плейлиста M3U."
+	ExportSavedEmpty        = "Сохраненных поисков нет. Их можно сделать в /lib."
+	ExportAskImport         = "Отправь CSV файл в формате выгрузки. Нужны колонки id и колонки тегов, которые меняются, остальные теги не тронутся."
+	ExportImportNoChanges   = "Изменений нет."
+	ExportImportCanceled    = "Импорт отменен."
+	ExportErrEmpty          = "Выгружать нечего, ничего не нашлось."
+	ExportErrEmptyMsg       = "Не надо делать пустое поле..."
+	ExportErrNotDocument    = "Я жду CSV файл."
+	ExportErrInvalidCSV     = "Не получилось прочитать CSV. Нужна строка заголовков с колонкой id и хотя бы одной колонкой тегов, id не должны повторяться."
+	ExportErrImportNotFound = "Импорт устарел, отправь файл еще раз."
+
+	// "/libcheck" command
+	LibCheckEmpty            = "Библиотека пуста, проверять нечего."
+	LibCheckInProgress       = "Проверка уже идет."
+	LibCheckNotFound         = "Проверки еще не было, запусти /libcheck."
+	LibCheckFailed           = "Не удалось проверить файлов: %d."
+	LibCheckNothingToFix     = "Исправлять уже нечего."
+	LibCheckZeroHint         = "Длительность можно определить заново по аудиофайлу."
+	LibCheckUntaggedHint     = "Нажми на композицию, чтобы открыть редактор тегов."
+	LibCheckMissingHint      = "Нажми на композицию, чтобы заменить аудио или удалить ее."
+	LibCheckMissingRunning   = "Файлы еще проверяются, список неполный."
+	LibCheckUnusedHint       = "У этих тегов нет композиций."
+	LibCheckDeleteTagsSubmit = "Удалить неиспользуемые теги (%d)? Теги, которые успели понадобиться, останутся."
+	LibCheckTagsDeleted      = "Удалено тегов: %d."
 
 	// "/sch" command
 	SchEmptySchedule = "Расписание пока пусто"
@@ -101,6 +235,8 @@ const (
 	SchAutoDJAskPlaylist = "Введи через запятую плейлисты."
 	SchAutoDJAskLanguage = "Выбирай языки."
 	SchAutoDJAskMood     = "Выбирай настроения."
+	SchAutoDJAskSmart    = "Выбирай умные плейлисты, они обновляются по сохраненным поискам."
+	SchAutoDJNoSmart     = "Умных плейлистов нет. Их можно сделать из сохраненного поиска в /lib."
 	SchAutoDJSuccess     = "Успешно обновлено."
 
 	LiveAskName    = "Введи название эфира."
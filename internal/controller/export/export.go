@@ -0,0 +1,488 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase         ctr.Command = ""
+	cmdMenu         ctr.Command = "menu"
+	cmdLibrary      ctr.Command = "library"
+	cmdTag          ctr.Command = "tag"
+	cmdGetTag       ctr.Command = "get-tag"
+	cmdSavedList    ctr.Command = "saved"
+	cmdSaved        ctr.Command = "saved-m3u"
+	cmdImport       ctr.Command = "import"
+	cmdGetFile      ctr.Command = "get-file"
+	cmdImportDo     ctr.Command = "import-do"
+	cmdImportCancel ctr.Command = "import-cancel"
+)
+
+const (
+	// Smaller exports are sent
+	// as text, larger as documents.
+	maxInlineSize = 3000
+	// Maximum number of changes
+	// listed in import preview.
+	maxPlanLines = 30
+	// Maximum number of items
+	// listed in job report.
+	maxJobLines = 30
+
+	// Source name of files sent to bot
+	// in cache keys, same as in upload.
+	telegramSource = "telegram"
+)
+
+type export struct {
+	ctr.CallbackAnswerer
+
+	router    *ctr.Router
+	auth      Auth
+	export    Export
+	saved     Saved
+	session   ctr.Session
+	onError   bot.ErrorsHandler
+	fileCache FileCache
+
+	msgIdStorage storage.Storage[int]
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type Export interface {
+	Library(ctx context.Context, id int64, format localModels.ExportFormat) ([]byte, error)
+	TagM3U(ctx context.Context, id int64, tag string) ([]byte, error)
+	SearchM3U(ctx context.Context, id int64, searchId int64) ([]byte, error)
+	PreviewImport(ctx context.Context, id int64, r io.Reader) (localModels.ImportPlan, error)
+	CommitImport(ctx context.Context, id int64, notify localModels.JobNotify) (localModels.Job, error)
+	CancelImport(ctx context.Context, id int64)
+}
+
+// Saved lists saved searches
+// available for M3U export.
+type Saved interface {
+	List(ctx context.Context, id int64) ([]localModels.SavedSearch, error)
+	Get(ctx context.Context, id int64, searchId int64) (localModels.SavedSearch, error)
+}
+
+type FileCache interface {
+	Download(ctx context.Context, key string, req *http.Request) (string, error)
+	Remove(key string) error
+}
+
+// Register registers "/export" command.
+func Register(
+	router *ctr.Router,
+	auth Auth,
+	exportSrv Export,
+	saved Saved,
+	session ctr.Session,
+	onError bot.ErrorsHandler,
+	fileCache FileCache,
+) {
+	e := &export{
+		router:    router,
+		auth:      auth,
+		export:    exportSrv,
+		saved:     saved,
+		session:   session,
+		onError:   onError,
+		fileCache: fileCache,
+
+		msgIdStorage: storage.New[int](),
+	}
+
+	router.RegisterCommand(e.init)
+	router.RegisterCallback(cmdMenu, e.menu)
+	router.RegisterCallbackPrefix(cmdLibrary, e.library)
+	router.RegisterCallback(cmdTag, e.tag)
+	router.RegisterHandler(cmdGetTag, e.getTag)
+	router.RegisterCallback(cmdSavedList, e.savedList)
+	router.RegisterCallbackPrefix(cmdSaved, e.savedM3U)
+	router.RegisterCallback(cmdImport, e.importAsk)
+	router.RegisterHandler(cmdGetFile, e.getFile)
+	router.RegisterCallback(cmdImportDo, e.importDo)
+	router.RegisterCallback(cmdImportCancel, e.importCancel)
+}
+
+func (e *export) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !e.auth.IsKnown(ctx, chatId) {
+		e.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return
+	}
+
+	e.session.Redirect(chatId, ctr.NullStatus)
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        ctr.ExportInit,
+		ReplyMarkup: e.menuMarkup(),
+	})
+	if err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	e.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (e *export) menu(ctx context.Context, b *bot.Bot, update *models.Update) {
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	e.session.Redirect(chatId, ctr.NullStatus)
+	e.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	e.editMessage(ctx, b, chatId, ctr.ExportInit, e.menuMarkup())
+}
+
+// library sends whole library in
+// format given by callback state.
+func (e *export) library(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.library"
+
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	format := localModels.ExportFormat(e.router.GetState(update.CallbackQuery.Data))
+
+	data, err := e.export.Library(ctx, chatId, format)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	e.sendExport(ctx, b, chatId, "library."+string(format), data)
+}
+
+func (e *export) tag(ctx context.Context, b *bot.Bot, update *models.Update) {
+	e.askInput(ctx, b, update, cmdGetTag, ctr.ExportAskTag)
+}
+
+// getTag sends M3U of media
+// with tag from message.
+func (e *export) getTag(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.getTag"
+
+	chatId := update.Message.Chat.ID
+
+	tag := strings.TrimSpace(update.Message.Text)
+	if tag == "" {
+		e.sendMessage(ctx, b, chatId, ctr.ExportErrEmptyMsg)
+		return
+	}
+
+	data, err := e.export.TagM3U(ctx, chatId, tag)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	e.session.Redirect(chatId, ctr.NullStatus)
+
+	e.sendExport(ctx, b, chatId, tag+".m3u", data)
+}
+
+func (e *export) savedList(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.savedList"
+
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	e.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	list, err := e.saved.List(ctx, chatId)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	msg := ctr.ExportSavedList
+	if len(list) == 0 {
+		msg = ctr.ExportSavedEmpty
+	}
+
+	e.editMessage(ctx, b, chatId, msg, e.savedListMarkup(list))
+}
+
+// savedM3U sends M3U of media
+// found by saved search.
+func (e *export) savedM3U(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.savedM3U"
+
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, err := strconv.ParseInt(e.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		e.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	search, err := e.saved.Get(ctx, chatId, searchId)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	data, err := e.export.SearchM3U(ctx, chatId, searchId)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	e.sendExport(ctx, b, chatId, search.Name+".m3u", data)
+}
+
+func (e *export) importAsk(ctx context.Context, b *bot.Bot, update *models.Update) {
+	e.askInput(ctx, b, update, cmdGetFile, ctr.ExportAskImport)
+}
+
+// getFile receives edited CSV
+// and shows changes it makes.
+func (e *export) getFile(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.getFile"
+
+	chatId := update.Message.Chat.ID
+
+	if update.Message.Document == nil {
+		e.sendMessage(ctx, b, chatId, ctr.ExportErrNotDocument)
+		return
+	}
+
+	fileId := update.Message.Document.FileID
+
+	path, err := e.downloadTelegramFile(ctx, b, fileId)
+	if err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		e.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+	defer func() {
+		if err := e.fileCache.Remove(dlcache.Key(telegramSource, update.Message.Document.FileUniqueID)); err != nil {
+			e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	file, err := os.Open(path)
+	if err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		e.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+	defer file.Close()
+
+	plan, err := e.export.PreviewImport(ctx, chatId, file)
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	e.session.Redirect(chatId, ctr.NullStatus)
+
+	text := plan.Report(maxPlanLines)
+	var markup models.ReplyMarkup
+	if len(plan.Changes) == 0 {
+		text += "\n" + ctr.ExportImportNoChanges
+	} else {
+		markup = e.importMarkup()
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	e.msgIdStorage.Set(chatId, msg.ID)
+}
+
+// importDo applies previewed
+// import in background.
+func (e *export) importDo(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "export.importDo"
+
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	e.msgIdStorage.Set(chatId, msgId)
+
+	job, err := e.export.CommitImport(ctx, chatId, e.jobProgress(ctx, b, chatId, msgId))
+	if err != nil {
+		e.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	e.editMessage(ctx, b, chatId, job.String(), nil)
+}
+
+func (e *export) importCancel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	e.export.CancelImport(ctx, chatId)
+
+	e.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+	e.editMessage(ctx, b, chatId, ctr.ExportImportCanceled, nil)
+}
+
+// jobProgress returns notification
+// updating progress message.
+func (e *export) jobProgress(ctx context.Context, b *bot.Bot, chatId int64, msgId int) localModels.JobNotify {
+	const op = "export.jobProgress"
+
+	return func(job localModels.Job) {
+		text := job.String()
+		if !job.Status.Active() {
+			text = job.Report(maxJobLines) + "\n" + ctr.JobsSeeList
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatId,
+			MessageID: msgId,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+// sendExport sends small export as
+// message text and large as document.
+func (e *export) sendExport(ctx context.Context, b *bot.Bot, chatId int64, name string, data []byte) {
+	const op = "export.sendExport"
+
+	if len(data) <= maxInlineSize {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatId,
+			Text:      "<pre>" + html.EscapeString(string(data)) + "</pre>",
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	if _, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID: chatId,
+		Document: &models.InputFileUpload{
+			Filename: name,
+			Data:     bytes.NewReader(data),
+		},
+	}); err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		e.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+// askInput redirects session to handler
+// of text input and shows prompt.
+func (e *export) askInput(ctx context.Context, b *bot.Bot, update *models.Update, cmd ctr.Command, text string) {
+	e.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	e.session.Redirect(chatId, e.router.Path(cmd))
+	e.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	e.editMessage(ctx, b, chatId, text, e.backMarkup())
+}
+
+func (e *export) handleErr(ctx context.Context, b *bot.Bot, chatId int64, op string, err error) {
+	switch {
+	case errors.Is(err, service.ErrNothingToExport):
+		e.sendMessage(ctx, b, chatId, ctr.ExportErrEmpty)
+	case errors.Is(err, service.ErrSearchNotFound):
+		e.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrNotFound)
+	case errors.Is(err, service.ErrInvalidImport):
+		e.sendMessage(ctx, b, chatId, ctr.ExportErrInvalidCSV)
+	case errors.Is(err, service.ErrImportNotFound):
+		e.sendMessage(ctx, b, chatId, ctr.ExportErrImportNotFound)
+	default:
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		e.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+// downloadTelegramFile downloads file sent to bot.
+func (e *export) downloadTelegramFile(ctx context.Context, b *bot.Bot, fileId string) (string, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{
+		FileID: fileId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.FileDownloadLink(file), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// File id may change, unique id does not.
+	return e.fileCache.Download(ctx, dlcache.Key(telegramSource, file.FileUniqueID), req)
+}
+
+// editMessage updates stored message.
+func (e *export) editMessage(ctx context.Context, b *bot.Bot, chatId int64, text string, markup models.ReplyMarkup) {
+	const op = "export.editMessage"
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   e.msgIdStorage.Get(chatId),
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	}); err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (e *export) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "export.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		e.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"strconv"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	butMsgJSON         = "Библиотека JSON"
+	butMsgCSV          = "Библиотека CSV"
+	butMsgTag          = "Тег в M3U"
+	butMsgSaved        = "Поиск в M3U"
+	butMsgImport       = "Импорт тегов из CSV"
+	butMsgImportApply  = "Применить"
+	butMsgImportCancel = "Отменить"
+	butMsgBack         = "Назад"
+)
+
+func (e *export) menuMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgJSON, CallbackData: e.router.PathPrefixState(cmdLibrary, string(localModels.ExportJSON))},
+				{Text: butMsgCSV, CallbackData: e.router.PathPrefixState(cmdLibrary, string(localModels.ExportCSV))},
+			},
+			{
+				{Text: butMsgTag, CallbackData: e.router.Path(cmdTag)},
+				{Text: butMsgSaved, CallbackData: e.router.Path(cmdSavedList)},
+			},
+			{
+				{Text: butMsgImport, CallbackData: e.router.Path(cmdImport)},
+			},
+		},
+	}
+}
+
+func (e *export) savedListMarkup(list []localModels.SavedSearch) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(list)+1)
+
+	for _, s := range list {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         s.Name,
+			CallbackData: e.router.PathPrefixState(cmdSaved, strconv.FormatInt(s.ID, 10)),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgBack,
+		CallbackData: e.router.Path(cmdMenu),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (e *export) importMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgImportApply, CallbackData: e.router.Path(cmdImportDo)},
+				{Text: butMsgImportCancel, CallbackData: e.router.Path(cmdImportCancel)},
+			},
+		},
+	}
+}
+
+func (e *export) backMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: e.router.Path(cmdMenu)},
+			},
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// InlineQueries dispatches inline queries ("@bot query").
+// Bot routes only messages and callbacks to handlers,
+// so inline queries are caught by middleware.
+type InlineQueries struct {
+	mutex   sync.RWMutex
+	handler bot.HandlerFunc
+}
+
+// Register sets handler of inline queries.
+func (q *InlineQueries) Register(handler bot.HandlerFunc) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.handler = handler
+}
+
+// Middleware passes inline queries to
+// registered handler, other updates to next.
+func (q *InlineQueries) Middleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		q.mutex.RLock()
+		handler := q.handler
+		q.mutex.RUnlock()
+
+		if update.InlineQuery != nil && handler != nil {
+			handler(ctx, b, update)
+			return
+		}
+
+		next(ctx, b, update)
+	}
+}
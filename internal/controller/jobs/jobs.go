@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase   ctr.Command = ""
+	cmdJob    ctr.Command = "job"
+	cmdCancel ctr.Command = "cancel"
+	cmdRetry  ctr.Command = "retry"
+	cmdList   ctr.Command = "list"
+)
+
+const (
+	// Maximum number of items
+	// listed in job card.
+	maxJobLines = 30
+)
+
+type jobs struct {
+	ctr.CallbackAnswerer
+
+	router  *ctr.Router
+	auth    Auth
+	jobs    Jobs
+	onError bot.ErrorsHandler
+
+	msgIdStorage storage.Storage[int]
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type Jobs interface {
+	List(ctx context.Context, id int64) ([]localModels.Job, error)
+	Job(ctx context.Context, id int64, jobId int64) (localModels.Job, error)
+	Cancel(ctx context.Context, id int64, jobId int64) error
+	Retry(ctx context.Context, id int64, jobId int64, notify localModels.JobNotify) (localModels.Job, error)
+}
+
+func Register(
+	router *ctr.Router,
+	auth Auth,
+	jobsSrv Jobs,
+	onError bot.ErrorsHandler,
+) {
+	j := &jobs{
+		router:  router,
+		auth:    auth,
+		jobs:    jobsSrv,
+		onError: onError,
+
+		msgIdStorage: storage.New[int](),
+	}
+
+	router.RegisterCommand(j.init)
+	router.RegisterCallback(cmdList, j.list)
+	router.RegisterCallbackPrefix(cmdJob, j.job)
+	router.RegisterCallbackPrefix(cmdCancel, j.cancel)
+	router.RegisterCallbackPrefix(cmdRetry, j.retry)
+}
+
+func (j *jobs) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "jobs.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !j.auth.IsKnown(ctx, chatId) {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrUnknown,
+		}); err != nil {
+			j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	jobList, err := j.jobs.List(ctx, chatId)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        j.listRepr(jobList),
+		ReplyMarkup: j.listMarkup(jobList),
+		ParseMode:   models.ParseModeHTML,
+	})
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	j.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (j *jobs) list(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "jobs.list"
+
+	j.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	jobList, err := j.jobs.List(ctx, chatId)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   update.CallbackQuery.Message.Message.ID,
+		Text:        j.listRepr(jobList),
+		ReplyMarkup: j.listMarkup(jobList),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (j *jobs) job(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "jobs.job"
+
+	j.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	jobId, err := strconv.ParseInt(j.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	job, err := j.jobs.Job(ctx, chatId, jobId)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	j.showJob(ctx, b, chatId, update.CallbackQuery.Message.Message.ID, job)
+}
+
+func (j *jobs) cancel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "jobs.cancel"
+
+	j.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	jobId, err := strconv.ParseInt(j.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := j.jobs.Cancel(ctx, chatId, jobId); err != nil {
+		if errors.Is(err, service.ErrJobNotActive) {
+			j.sendMessage(ctx, b, chatId, ctr.JobsNotActive)
+			return
+		}
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	job, err := j.jobs.Job(ctx, chatId, jobId)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	j.showJob(ctx, b, chatId, update.CallbackQuery.Message.Message.ID, job)
+}
+
+func (j *jobs) retry(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "jobs.retry"
+
+	j.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	jobId, err := strconv.ParseInt(j.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	job, err := j.jobs.Retry(ctx, chatId, jobId, func(job localModels.Job) {
+		j.showJob(ctx, b, chatId, msgId, job)
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrJobActive) {
+			j.sendMessage(ctx, b, chatId, ctr.JobsActive)
+			return
+		}
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		j.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	j.showJob(ctx, b, chatId, msgId, job)
+}
+
+// showJob updates message with job card.
+func (j *jobs) showJob(ctx context.Context, b *bot.Bot, chatId int64, msgId int, job localModels.Job) {
+	const op = "jobs.showJob"
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        job.Report(maxJobLines),
+		ReplyMarkup: j.jobMarkup(job),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (j *jobs) listRepr(jobList []localModels.Job) string {
+	if len(jobList) == 0 {
+		return ctr.JobsEmpty
+	}
+
+	var b strings.Builder
+
+	b.WriteString(ctr.JobsList + "\n\n")
+	for _, job := range jobList {
+		b.WriteString(fmt.Sprintf("<b>#%d</b> %s — %s\n%s\n\n", job.ID, job.Title, job.Status, job.Progress()))
+	}
+
+	return b.String()
+}
+
+func (j *jobs) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "jobs.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		j.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	butMsgCancel  = "Отменить"
+	butMsgRetry   = "Повторить неудачные"
+	butMsgRefresh = "Обновить"
+	butMsgBack    = "Назад"
+)
+
+func (j *jobs) listMarkup(jobList []localModels.Job) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(jobList)+1)
+
+	for _, job := range jobList {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("#%d %s", job.ID, job.Title),
+			CallbackData: j.router.PathPrefixState(cmdJob, strconv.FormatInt(job.ID, 10)),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgRefresh,
+		CallbackData: j.router.Path(cmdList),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (j *jobs) jobMarkup(job localModels.Job) models.InlineKeyboardMarkup {
+	id := strconv.FormatInt(job.ID, 10)
+
+	row := make([]models.InlineKeyboardButton, 0, 2)
+	if job.Status.Active() {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         butMsgCancel,
+			CallbackData: j.router.PathPrefixState(cmdCancel, id),
+		})
+	}
+	if job.Retryable() {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         butMsgRetry,
+			CallbackData: j.router.PathPrefixState(cmdRetry, id),
+		})
+	}
+
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: butMsgRefresh, CallbackData: j.router.PathPrefixState(cmdJob, id)},
+			{Text: butMsgBack, CallbackData: j.router.Path(cmdList)},
+		},
+	}
+	if len(row) > 0 {
+		rows = append([][]models.InlineKeyboardButton{row}, rows...)
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
@@ -0,0 +1,104 @@
+package libcheck
+
+import (
+	"fmt"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	butMsgRestart    = "Проверить заново"
+	butMsgRefresh    = "Обновить"
+	butMsgReprobe    = "Пересчитать длительность"
+	butMsgDeleteTags = "Удалить теги"
+	butMsgBack       = "Назад"
+)
+
+func (c *libcheck) reportMarkup(report localModels.LibCheckReport) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(localModels.LibCheckCategories)+1)
+
+	for _, cat := range localModels.LibCheckCategories {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s (%d)", cat, report.Count(cat)),
+			CallbackData: c.router.PathPrefixState(cmdCategory, string(cat)),
+		}})
+	}
+
+	if report.Running {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         butMsgRefresh,
+			CallbackData: c.router.Path(cmdReport),
+		}})
+	} else {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         butMsgRestart,
+			CallbackData: c.router.Path(cmdRestart),
+		}})
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+// categoryMarkup offers fix of category
+// if it can be done automatically.
+func (c *libcheck) categoryMarkup(report localModels.LibCheckReport, cat localModels.LibCheckCategory) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, 2)
+
+	if report.Count(cat) > 0 {
+		switch cat {
+		case localModels.CheckZeroDuration:
+			rows = append(rows, []models.InlineKeyboardButton{{
+				Text:         butMsgReprobe,
+				CallbackData: c.router.Path(cmdReprobe),
+			}})
+		case localModels.CheckUnusedTags:
+			rows = append(rows, []models.InlineKeyboardButton{{
+				Text:         butMsgDeleteTags,
+				CallbackData: c.router.Path(cmdDeleteTags),
+			}})
+		}
+	}
+
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgBack,
+		CallbackData: c.router.Path(cmdReport),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (c *libcheck) deleteTagsMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "Да", CallbackData: c.router.Path(cmdDeleteTagsSubmit)},
+				{Text: "Нет", CallbackData: c.router.PathPrefixState(cmdCategory, string(localModels.CheckUnusedTags))},
+			},
+		},
+	}
+}
+
+func (c *libcheck) refreshMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgRefresh, CallbackData: c.router.Path(cmdReport)},
+			},
+		},
+	}
+}
+
+func (c *libcheck) backMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: c.router.Path(cmdReport)},
+			},
+		},
+	}
+}
@@ -0,0 +1,439 @@
+package libcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase             ctr.Command = ""
+	cmdReport           ctr.Command = "report"
+	cmdRestart          ctr.Command = "restart"
+	cmdCategory         ctr.Command = "category"
+	cmdReprobe          ctr.Command = "reprobe"
+	cmdDeleteTags       ctr.Command = "delete-tags"
+	cmdDeleteTagsSubmit ctr.Command = "delete-tags-submit"
+)
+
+const (
+	// Deep link payloads of search,
+	// followed by media id.
+	linkCard = "card-"
+	linkEdit = "edit-"
+
+	// Maximum number of entries
+	// listed in category.
+	maxListLines = 30
+	// Maximum number of items
+	// listed in job report.
+	maxJobLines = 30
+)
+
+type libcheck struct {
+	ctr.CallbackAnswerer
+
+	router   *ctr.Router
+	auth     Auth
+	libcheck LibCheck
+	onError  bot.ErrorsHandler
+
+	msgIdStorage storage.Storage[int]
+
+	botNameMutex sync.Mutex
+	botUsername  string
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type LibCheck interface {
+	Start(ctx context.Context, id int64, notify localModels.JobNotify) (localModels.Job, error)
+	Report(ctx context.Context, id int64) (localModels.LibCheckReport, error)
+	Reprobe(ctx context.Context, id int64, notify localModels.JobNotify) (localModels.Job, error)
+	DeleteUnusedTags(ctx context.Context, id int64) (int, error)
+}
+
+// Register registers "/libcheck" command.
+func Register(
+	router *ctr.Router,
+	auth Auth,
+	libcheckSrv LibCheck,
+	onError bot.ErrorsHandler,
+) {
+	c := &libcheck{
+		router:   router,
+		auth:     auth,
+		libcheck: libcheckSrv,
+		onError:  onError,
+
+		msgIdStorage: storage.New[int](),
+	}
+
+	router.RegisterCommand(c.init)
+	router.RegisterCallback(cmdReport, c.report)
+	router.RegisterCallback(cmdRestart, c.restart)
+	router.RegisterCallbackPrefix(cmdCategory, c.category)
+	router.RegisterCallback(cmdReprobe, c.reprobe)
+	router.RegisterCallback(cmdDeleteTags, c.deleteTags)
+	router.RegisterCallback(cmdDeleteTagsSubmit, c.deleteTagsSubmit)
+}
+
+func (c *libcheck) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !c.auth.IsKnown(ctx, chatId) {
+		c.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	c.msgIdStorage.Set(chatId, msg.ID)
+
+	c.start(ctx, b, chatId)
+}
+
+func (c *libcheck) restart(ctx context.Context, b *bot.Bot, update *models.Update) {
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	c.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+	c.editMessage(ctx, b, chatId, ctr.InProgress, nil)
+
+	c.start(ctx, b, chatId)
+}
+
+// start runs check, stored message
+// shows its progress and then report.
+func (c *libcheck) start(ctx context.Context, b *bot.Bot, chatId int64) {
+	const op = "libcheck.start"
+
+	job, err := c.libcheck.Start(ctx, chatId, c.checkProgress(ctx, b, chatId, c.msgIdStorage.Get(chatId)))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLibraryEmpty):
+			c.editMessage(ctx, b, chatId, ctr.LibCheckEmpty, nil)
+		case errors.Is(err, service.ErrLibCheckInProgress):
+			c.editMessage(ctx, b, chatId, ctr.LibCheckInProgress, c.refreshMarkup())
+		default:
+			c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			c.editMessage(ctx, b, chatId, ctr.ErrorMessage, nil)
+		}
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, job.String(), nil)
+}
+
+// report shows numbers of problems by category.
+func (c *libcheck) report(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.report"
+
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	c.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	report, err := c.libcheck.Report(ctx, chatId)
+	if err != nil {
+		c.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, report.String(), c.reportMarkup(report))
+}
+
+// category lists problems of
+// category given by callback state.
+func (c *libcheck) category(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.category"
+
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	c.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	cat := localModels.LibCheckCategory(c.router.GetState(update.CallbackQuery.Data))
+
+	report, err := c.libcheck.Report(ctx, chatId)
+	if err != nil {
+		c.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	text, err := c.categoryText(ctx, b, report, cat)
+	if err != nil {
+		c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		c.editMessage(ctx, b, chatId, ctr.ErrorMessage, nil)
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, text, c.categoryMarkup(report, cat))
+}
+
+// reprobe detects duration of media
+// with zero duration in background.
+func (c *libcheck) reprobe(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.reprobe"
+
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	c.msgIdStorage.Set(chatId, msgId)
+
+	job, err := c.libcheck.Reprobe(ctx, chatId, c.jobProgress(ctx, b, chatId, msgId))
+	if err != nil {
+		c.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, job.String(), nil)
+}
+
+func (c *libcheck) deleteTags(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.deleteTags"
+
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	c.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	report, err := c.libcheck.Report(ctx, chatId)
+	if err != nil {
+		c.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, fmt.Sprintf(ctr.LibCheckDeleteTagsSubmit, len(report.UnusedTags)), c.deleteTagsMarkup())
+}
+
+func (c *libcheck) deleteTagsSubmit(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "libcheck.deleteTagsSubmit"
+
+	c.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	c.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+	c.editMessage(ctx, b, chatId, ctr.InProgress, nil)
+
+	n, err := c.libcheck.DeleteUnusedTags(ctx, chatId)
+	if err != nil {
+		c.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	c.editMessage(ctx, b, chatId, fmt.Sprintf(ctr.LibCheckTagsDeleted, n), c.backMarkup())
+}
+
+// checkProgress returns notification updating
+// progress message, finished check is
+// replaced with report.
+func (c *libcheck) checkProgress(ctx context.Context, b *bot.Bot, chatId int64, msgId int) localModels.JobNotify {
+	const op = "libcheck.checkProgress"
+
+	return func(job localModels.Job) {
+		text := job.String()
+		var markup models.ReplyMarkup
+
+		if !job.Status.Active() {
+			report, err := c.libcheck.Report(ctx, chatId)
+			if err != nil {
+				c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+				return
+			}
+			text = report.String()
+			if failed := job.Count(localModels.ItemFailed); failed > 0 {
+				text += "\n" + fmt.Sprintf(ctr.LibCheckFailed, failed) + "\n" + ctr.JobsSeeList
+			}
+			markup = c.reportMarkup(report)
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   msgId,
+			Text:        text,
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: markup,
+		}); err != nil {
+			c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+// jobProgress returns notification
+// updating progress message.
+func (c *libcheck) jobProgress(ctx context.Context, b *bot.Bot, chatId int64, msgId int) localModels.JobNotify {
+	const op = "libcheck.jobProgress"
+
+	return func(job localModels.Job) {
+		text := job.String()
+		var markup models.ReplyMarkup
+		if !job.Status.Active() {
+			text = job.Report(maxJobLines) + "\n" + ctr.JobsSeeList
+			markup = c.backMarkup()
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   msgId,
+			Text:        text,
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: markup,
+		}); err != nil {
+			c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+// categoryText lists first entries of category.
+// Media are links opening card in search,
+// or editor for media without tags.
+func (c *libcheck) categoryText(ctx context.Context, b *bot.Bot, report localModels.LibCheckReport, cat localModels.LibCheckCategory) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<b>%s:</b> %d\n", cat, report.Count(cat)))
+	switch cat {
+	case localModels.CheckZeroDuration:
+		sb.WriteString(ctr.LibCheckZeroHint)
+	case localModels.CheckUntagged:
+		sb.WriteString(ctr.LibCheckUntaggedHint)
+	case localModels.CheckMissing:
+		sb.WriteString(ctr.LibCheckMissingHint)
+		if report.Running {
+			sb.WriteString("\n" + ctr.LibCheckMissingRunning)
+		}
+	case localModels.CheckUnusedTags:
+		sb.WriteString(ctr.LibCheckUnusedHint)
+	}
+	sb.WriteString("\n\n")
+
+	if cat == localModels.CheckUnusedTags {
+		for i, tag := range report.UnusedTags {
+			if i == maxListLines {
+				sb.WriteString(fmt.Sprintf("... и еще %d\n", len(report.UnusedTags)-maxListLines))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s\n", tag.Type.Name, html.EscapeString(tag.Name)))
+		}
+		return sb.String(), nil
+	}
+
+	media := report.Media(cat)
+	if len(media) == 0 {
+		return sb.String(), nil
+	}
+
+	botName, err := c.botName(ctx, b)
+	if err != nil {
+		return "", err
+	}
+
+	payload := linkCard
+	if cat == localModels.CheckUntagged {
+		payload = linkEdit
+	}
+
+	for i, conf := range media {
+		if i == maxListLines {
+			sb.WriteString(fmt.Sprintf("... и еще %d\n", len(media)-maxListLines))
+			break
+		}
+		sb.WriteString(fmt.Sprintf(
+			"<a href=\"https://t.me/%s?start=%s%d\">%s — %s</a>\n",
+			botName, payload, conf.ID,
+			html.EscapeString(conf.Author),
+			html.EscapeString(conf.Name),
+		))
+	}
+
+	return sb.String(), nil
+}
+
+// botName returns bot username
+// used in deep links.
+func (c *libcheck) botName(ctx context.Context, b *bot.Bot) (string, error) {
+	c.botNameMutex.Lock()
+	defer c.botNameMutex.Unlock()
+
+	if c.botUsername != "" {
+		return c.botUsername, nil
+	}
+
+	me, err := b.GetMe(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.botUsername = me.Username
+
+	return c.botUsername, nil
+}
+
+func (c *libcheck) handleErr(ctx context.Context, b *bot.Bot, chatId int64, op string, err error) {
+	switch {
+	case errors.Is(err, service.ErrLibCheckNotFound):
+		c.editMessage(ctx, b, chatId, ctr.LibCheckNotFound, nil)
+	case errors.Is(err, service.ErrNothingToFix):
+		c.editMessage(ctx, b, chatId, ctr.LibCheckNothingToFix, c.backMarkup())
+	default:
+		c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		c.editMessage(ctx, b, chatId, ctr.ErrorMessage, nil)
+	}
+}
+
+// editMessage updates stored message.
+func (c *libcheck) editMessage(ctx context.Context, b *bot.Bot, chatId int64, text string, markup models.ReplyMarkup) {
+	const op = "libcheck.editMessage"
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   c.msgIdStorage.Get(chatId),
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+		LinkPreviewOptions: &models.LinkPreviewOptions{
+			IsDisabled: bot.True(),
+		},
+	}); err != nil {
+		c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (c *libcheck) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "libcheck.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		c.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -0,0 +1,84 @@
+package podcast
+
+import (
+	"strconv"
+
+	"github.com/go-telegram/bot/models"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	butMsgAdd         = "Подписаться"
+	butMsgCheck       = "Проверить ленту"
+	butMsgSlot        = "Время эфира"
+	butMsgUnsubscribe = "Отписаться"
+	butMsgOpen        = "Открыть"
+	butMsgBack        = "Назад"
+)
+
+func (p *podcast) listMarkup(subs []localModels.PodcastSub) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(subs)+1)
+
+	for _, sub := range subs {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         sub.Podcast,
+			CallbackData: p.router.PathPrefixState(cmdSub, strconv.FormatInt(sub.ID, 10)),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgAdd,
+		CallbackData: p.router.Path(cmdAdd),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (p *podcast) subMarkup(sub localModels.PodcastSub) models.InlineKeyboardMarkup {
+	id := strconv.FormatInt(sub.ID, 10)
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgCheck, CallbackData: p.router.PathPrefixState(cmdCheck, id)},
+				{Text: butMsgSlot, CallbackData: p.router.PathPrefixState(cmdSlot, id)},
+			},
+			{
+				{Text: butMsgUnsubscribe, CallbackData: p.router.PathPrefixState(cmdRemove, id)},
+				{Text: butMsgBack, CallbackData: p.router.Path(cmdList)},
+			},
+		},
+	}
+}
+
+func (p *podcast) summaryMarkup(sub localModels.PodcastSub) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgOpen, CallbackData: p.router.PathPrefixState(cmdSub, strconv.FormatInt(sub.ID, 10))},
+			},
+		},
+	}
+}
+
+func (p *podcast) backMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: p.router.Path(cmdList)},
+			},
+		},
+	}
+}
+
+func (p *podcast) backSubMarkup(subId int64) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: p.router.PathPrefixState(cmdSub, strconv.FormatInt(subId, 10))},
+			},
+		},
+	}
+}
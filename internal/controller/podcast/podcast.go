@@ -0,0 +1,446 @@
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase    ctr.Command = ""
+	cmdList    ctr.Command = "list"
+	cmdAdd     ctr.Command = "add"
+	cmdGetLink ctr.Command = "get-link"
+	cmdSub     ctr.Command = "sub"
+	cmdCheck   ctr.Command = "check"
+	cmdSlot    ctr.Command = "slot"
+	cmdGetSlot ctr.Command = "get-slot"
+	cmdRemove  ctr.Command = "remove"
+)
+
+type podcast struct {
+	ctr.CallbackAnswerer
+
+	router  *ctr.Router
+	auth    Auth
+	podcast Podcast
+	session ctr.Session
+	onError bot.ErrorsHandler
+
+	msgIdStorage storage.Storage[int]
+	subIdStorage storage.Storage[int64]
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type Podcast interface {
+	List(ctx context.Context, id int64) ([]localModels.PodcastSub, error)
+	Subscribe(ctx context.Context, id int64, url string, podcast string) (localModels.PodcastSub, error)
+	Unsubscribe(ctx context.Context, id int64, subId int64) error
+	SetSlot(ctx context.Context, id int64, subId int64, slot *localModels.WeeklySlot) (localModels.PodcastSub, error)
+	Check(ctx context.Context, id int64, subId int64) error
+	SetNotify(notify localModels.PodcastNotify)
+}
+
+// Register registers "/podcasts" command.
+// Summaries of background checks are
+// sent to subscription owners by b.
+func Register(
+	router *ctr.Router,
+	b *bot.Bot,
+	auth Auth,
+	podcastSrv Podcast,
+	session ctr.Session,
+	onError bot.ErrorsHandler,
+) {
+	p := &podcast{
+		router:  router,
+		auth:    auth,
+		podcast: podcastSrv,
+		session: session,
+		onError: onError,
+
+		msgIdStorage: storage.New[int](),
+		subIdStorage: storage.New[int64](),
+	}
+
+	podcastSrv.SetNotify(p.summary(b))
+
+	router.RegisterCommand(p.init)
+	router.RegisterCallback(cmdList, p.list)
+	router.RegisterCallback(cmdAdd, p.add)
+	router.RegisterHandler(cmdGetLink, p.getLink)
+	router.RegisterCallbackPrefix(cmdSub, p.sub)
+	router.RegisterCallbackPrefix(cmdCheck, p.check)
+	router.RegisterCallbackPrefix(cmdSlot, p.slot)
+	router.RegisterHandler(cmdGetSlot, p.getSlot)
+	router.RegisterCallbackPrefix(cmdRemove, p.remove)
+}
+
+func (p *podcast) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !p.auth.IsKnown(ctx, chatId) {
+		p.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return
+	}
+
+	subs, err := p.podcast.List(ctx, chatId)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        p.listRepr(subs),
+		ReplyMarkup: p.listMarkup(subs),
+		ParseMode:   models.ParseModeHTML,
+	})
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	p.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (p *podcast) list(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.list"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	p.session.Redirect(chatId, ctr.NullStatus)
+	p.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	if err := p.showList(ctx, b, chatId); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+func (p *podcast) add(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.add"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	p.session.Redirect(chatId, p.router.Path(cmdGetLink))
+	p.msgIdStorage.Set(chatId, msgId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        ctr.PodcastAskLink,
+		ReplyMarkup: p.backMarkup(),
+	}); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (p *podcast) getLink(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.getLink"
+
+	chatId := update.Message.Chat.ID
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessages(ctx, &bot.DeleteMessagesParams{
+			ChatID:     chatId,
+			MessageIDs: []int{update.Message.ID, inProgressMsg.ID},
+		}); err != nil {
+			p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	url, name, _ := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+
+	sub, err := p.podcast.Subscribe(ctx, chatId, url, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLink):
+			p.sendMessage(ctx, b, chatId, ctr.PodcastErrInvalidFeed)
+		case errors.Is(err, service.ErrAlreadySubscribed):
+			p.sendMessage(ctx, b, chatId, ctr.PodcastErrExists)
+		default:
+			p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	p.session.Redirect(chatId, ctr.NullStatus)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   p.msgIdStorage.Get(chatId),
+		Text:        fmt.Sprintf(ctr.PodcastAdded, sub.Podcast),
+		ReplyMarkup: p.subMarkup(sub),
+	}); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (p *podcast) sub(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.sub"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	subId, err := strconv.ParseInt(p.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	subs, err := p.podcast.List(ctx, chatId)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	i := slices.IndexFunc(subs, func(s localModels.PodcastSub) bool {
+		return s.ID == subId
+	})
+	if i == -1 {
+		p.sendMessage(ctx, b, chatId, ctr.PodcastErrNotFound)
+		return
+	}
+
+	p.showSub(ctx, b, chatId, msgId, subs[i])
+}
+
+func (p *podcast) check(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.check"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	subId, err := strconv.ParseInt(p.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := p.podcast.Check(ctx, chatId, subId); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCheckInProgress):
+			p.sendMessage(ctx, b, chatId, ctr.PodcastCheckInProgress)
+		case errors.Is(err, service.ErrPodcastNotFound):
+			p.sendMessage(ctx, b, chatId, ctr.PodcastErrNotFound)
+		default:
+			p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	p.sendMessage(ctx, b, chatId, ctr.PodcastCheckStarted)
+}
+
+func (p *podcast) slot(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.slot"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	subId, err := strconv.ParseInt(p.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	p.session.Redirect(chatId, p.router.Path(cmdGetSlot))
+	p.msgIdStorage.Set(chatId, msgId)
+	p.subIdStorage.Set(chatId, subId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        ctr.PodcastAskSlot,
+		ReplyMarkup: p.backSubMarkup(subId),
+	}); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (p *podcast) getSlot(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.getSlot"
+
+	chatId := update.Message.Chat.ID
+
+	defer func() {
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatId,
+			MessageID: update.Message.ID,
+		}); err != nil {
+			p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	var slot *localModels.WeeklySlot
+	if text := strings.TrimSpace(update.Message.Text); text != "-" {
+		s, err := localModels.ParseWeeklySlot(text)
+		if err != nil {
+			p.sendMessage(ctx, b, chatId, ctr.PodcastErrInvalidSlot)
+			return
+		}
+		slot = &s
+	}
+
+	sub, err := p.podcast.SetSlot(ctx, chatId, p.subIdStorage.Get(chatId), slot)
+	if err != nil {
+		if errors.Is(err, service.ErrPodcastNotFound) {
+			p.sendMessage(ctx, b, chatId, ctr.PodcastErrNotFound)
+			return
+		}
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	p.session.Redirect(chatId, ctr.NullStatus)
+	p.subIdStorage.Del(chatId)
+
+	p.showSub(ctx, b, chatId, p.msgIdStorage.Get(chatId), sub)
+}
+
+func (p *podcast) remove(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "podcast.remove"
+
+	p.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	subId, err := strconv.ParseInt(p.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := p.podcast.Unsubscribe(ctx, chatId, subId); err != nil && !errors.Is(err, service.ErrPodcastNotFound) {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	p.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	if err := p.showList(ctx, b, chatId); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		p.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+// summary returns notification
+// sending ingestion summary.
+func (p *podcast) summary(b *bot.Bot) localModels.PodcastNotify {
+	const op = "podcast.summary"
+
+	return func(summary localModels.PodcastSummary) {
+		chatId := summary.Sub.UserId
+
+		if _, err := b.SendMessage(context.Background(), &bot.SendMessageParams{
+			ChatID:      chatId,
+			Text:        summary.String(),
+			ReplyMarkup: p.summaryMarkup(summary.Sub),
+			ParseMode:   models.ParseModeHTML,
+		}); err != nil {
+			p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+// showList updates stored message
+// with list of subscriptions.
+func (p *podcast) showList(ctx context.Context, b *bot.Bot, chatId int64) error {
+	subs, err := p.podcast.List(ctx, chatId)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   p.msgIdStorage.Get(chatId),
+		Text:        p.listRepr(subs),
+		ReplyMarkup: p.listMarkup(subs),
+		ParseMode:   models.ParseModeHTML,
+	})
+	return err
+}
+
+func (p *podcast) showSub(ctx context.Context, b *bot.Bot, chatId int64, msgId int, sub localModels.PodcastSub) {
+	const op = "podcast.showSub"
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        sub.String(),
+		ReplyMarkup: p.subMarkup(sub),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (p *podcast) listRepr(subs []localModels.PodcastSub) string {
+	if len(subs) == 0 {
+		return ctr.PodcastEmpty
+	}
+
+	var b strings.Builder
+
+	b.WriteString(ctr.PodcastList + "\n\n")
+	for _, sub := range subs {
+		b.WriteString(sub.String() + "\n\n")
+	}
+
+	return b.String()
+}
+
+func (p *podcast) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "podcast.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		p.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -13,6 +13,7 @@ var (
 	pathMatch       = regexp.MustCompile(`[a-zA-Z]+`)
 	delimiter       = "/"
 	prefixDelimiter = "_"
+	startCmd        = "/start"
 )
 
 // Router struct to implement
@@ -100,6 +101,21 @@ func (r *Router) RegisterCallbackPrefix(cmd Command, handler bot.HandlerFunc) {
 	r.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, r.callbackPrefix(cmd), bot.MatchTypePrefix, handler)
 }
 
+// RegisterDeepLink registers handler of
+// "/start <payload>" messages sent when user
+// opens link "t.me/<bot>?start=<payload>".
+// Payload is matched by prefix.
+func (r *Router) RegisterDeepLink(payload string, handler bot.HandlerFunc) {
+	r.bot.RegisterHandler(bot.HandlerTypeMessageText, startCmd+" "+payload, bot.MatchTypePrefix, handler)
+}
+
+// DeepLinkPayload returns payload
+// of "/start <payload>" message.
+func (r *Router) DeepLinkPayload(text string) string {
+	_, payload, _ := strings.Cut(text, " ")
+	return payload
+}
+
 // Path returns absolute path
 // to register redirect
 func (r *Router) Path(cmd Command) string {
@@ -0,0 +1,335 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/split"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Maximum number of items
+	// listed in bulk job report.
+	maxJobLines = 30
+)
+
+// bulkOpen shows results as list
+// where several media can be selected.
+func (s *search) bulkOpen(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	res := s.mediaResultsStorage.Get(chatId)
+	if len(s.bulkSelectedStorage.Get(chatId)) != len(res) {
+		s.bulkSelectedStorage.Set(chatId, make([]bool, len(res)))
+	}
+
+	s.showBulkSelect(ctx, b, chatId)
+}
+
+// bulkToggle selects or unselects one media.
+func (s *search) bulkToggle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.bulkToggle"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	selected := s.bulkSelectedStorage.Get(chatId)
+
+	i, err := strconv.Atoi(s.router.GetState(update.CallbackQuery.Data))
+	if err != nil || i < 0 || i >= len(selected) {
+		s.onError(fmt.Errorf("%s [%d]: invalid index \"%s\"", op, chatId, s.router.GetState(update.CallbackQuery.Data)))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	selected[i] = !selected[i]
+
+	s.showBulkSelect(ctx, b, chatId)
+}
+
+// bulkAll selects all media, or
+// unselects them if all are selected.
+func (s *search) bulkAll(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	selected := s.bulkSelectedStorage.Get(chatId)
+	all := countSelected(selected) < len(selected)
+	for i := range selected {
+		selected[i] = all
+	}
+
+	s.showBulkSelect(ctx, b, chatId)
+}
+
+// bulkClose returns to media slider.
+func (s *search) bulkClose(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	id := s.mediaPageStorage.Get(chatId)
+	res := s.mediaResultsStorage.Get(chatId)
+
+	s.showCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
+}
+
+// bulkActions shows actions
+// available for selected media.
+func (s *search) bulkActions(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	n := countSelected(s.bulkSelectedStorage.Get(chatId))
+	if n == 0 {
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchBulkErrEmpty)
+		return
+	}
+
+	s.showText(ctx, b, chatId, fmt.Sprintf(ctr.LibSearchBulkActions, n), s.bulkActionsMarkup())
+}
+
+// bulkTags asks which tags to add or remove.
+func (s *search) bulkTags(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	action := localModels.BulkAddTags
+	if s.router.GetState(update.CallbackQuery.Data) == "remove" {
+		action = localModels.BulkRemoveTags
+	}
+	s.bulkArgsStorage.Set(chatId, map[string]string{
+		localModels.BulkArgAction: string(action),
+	})
+
+	s.showText(ctx, b, chatId, ctr.LibSearchBulkAskTagType, s.bulkTagTypeMarkup())
+}
+
+// bulkTagType asks for tag names.
+func (s *search) bulkTagType(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	args := s.bulkArgsStorage.Get(chatId)
+	if args == nil {
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+	args[localModels.BulkArgTagType] = s.router.GetState(update.CallbackQuery.Data)
+
+	s.session.Redirect(chatId, s.router.Path(cmdBulkGetTags))
+
+	s.showText(ctx, b, chatId, ctr.LibSearchBulkAskTags, s.bulkBackMarkup())
+}
+
+// bulkGetTags receives tag names
+// and starts tag changes.
+func (s *search) bulkGetTags(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.bulkGetTags"
+
+	chatId := update.Message.Chat.ID
+
+	tags := split.SplitMsg(update.Message.Text)
+	for _, tag := range tags {
+		if tag == "" {
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchBulkErrTagEmpty)
+			return
+		}
+	}
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatId,
+		MessageID: update.Message.ID,
+	}); err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+
+	args := s.bulkArgsStorage.Get(chatId)
+	if args == nil {
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+	args[localModels.BulkArgTags] = strings.Join(tags, ",")
+
+	title := "Добавление тегов"
+	if localModels.BulkAction(args[localModels.BulkArgAction]) == localModels.BulkRemoveTags {
+		title = "Удаление тегов"
+	}
+
+	s.submitBulk(ctx, b, chatId, title, args)
+}
+
+// bulkFormat asks for new format.
+func (s *search) bulkFormat(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.showText(ctx, b, chatId, ctr.LibSearchBulkAskFormat, s.bulkFormatMarkup())
+}
+
+// bulkSetFormat changes format to chosen one.
+func (s *search) bulkSetFormat(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.submitBulk(ctx, b, chatId, "Смена формата", map[string]string{
+		localModels.BulkArgAction: string(localModels.BulkFormat),
+		localModels.BulkArgFormat: s.router.GetState(update.CallbackQuery.Data),
+	})
+}
+
+// bulkQueue adds selected media
+// to queue in order of results.
+func (s *search) bulkQueue(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.submitBulk(ctx, b, chatId, "Добавление в очередь", map[string]string{
+		localModels.BulkArgAction: string(localModels.BulkQueue),
+	})
+}
+
+// bulkDelete asks to confirm deletion.
+func (s *search) bulkDelete(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	n := countSelected(s.bulkSelectedStorage.Get(chatId))
+
+	s.showText(ctx, b, chatId, fmt.Sprintf(ctr.LibSearchBulkDeleteAsk, n), s.bulkDeleteMarkup())
+}
+
+func (s *search) bulkDeleteSubmit(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	if !s.submitBulk(ctx, b, chatId, "Удаление", map[string]string{
+		localModels.BulkArgAction: string(localModels.BulkDelete),
+	}) {
+		return
+	}
+
+	s.mediaResultsStorage.Set(chatId, []localModels.MediaConfig{})
+}
+
+// submitBulk runs action for selected
+// media in background and shows progress.
+// Reports if job is submitted.
+func (s *search) submitBulk(ctx context.Context, b *bot.Bot, chatId int64, title string, args map[string]string) bool {
+	const op = "search.submitBulk"
+
+	res := s.mediaResultsStorage.Get(chatId)
+	selected := s.bulkSelectedStorage.Get(chatId)
+
+	items := make([]localModels.JobItem, 0, len(res))
+	for i, conf := range res {
+		if i < len(selected) && selected[i] {
+			items = append(items, localModels.JobItem{Conf: conf, Args: args})
+		}
+	}
+	if len(items) == 0 {
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchBulkErrEmpty)
+		return false
+	}
+
+	// Progress is shown in text message,
+	// so card is replaced before submit.
+	s.showText(ctx, b, chatId, ctr.InProgress, nil)
+	msgId := s.msgIdStorage.Get(chatId)
+
+	job, err := s.jobs.Submit(
+		ctx,
+		chatId,
+		localModels.JobBulk,
+		fmt.Sprintf("%s (%d)", title, len(items)),
+		items,
+		s.bulkProgress(ctx, b, chatId, msgId),
+	)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return false
+	}
+
+	s.bulkSelectedStorage.Del(chatId)
+	s.bulkArgsStorage.Del(chatId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatId,
+		MessageID: msgId,
+		Text:      job.String(),
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+
+	return true
+}
+
+// bulkProgress returns notification
+// updating progress message.
+func (s *search) bulkProgress(ctx context.Context, b *bot.Bot, chatId int64, msgId int) localModels.JobNotify {
+	const op = "search.bulkProgress"
+
+	return func(job localModels.Job) {
+		text := job.String()
+		if !job.Status.Active() {
+			text = job.Report(maxJobLines) + "\n" + ctr.JobsSeeList
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatId,
+			MessageID: msgId,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+func (s *search) showBulkSelect(ctx context.Context, b *bot.Bot, chatId int64) {
+	res := s.mediaResultsStorage.Get(chatId)
+	selected := s.bulkSelectedStorage.Get(chatId)
+
+	s.showText(
+		ctx, b, chatId,
+		fmt.Sprintf(ctr.LibSearchBulkSelect, countSelected(selected), len(res)),
+		s.bulkSelectMarkup(res, selected),
+	)
+}
+
+func countSelected(selected []bool) int {
+	n := 0
+	for _, ok := range selected {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
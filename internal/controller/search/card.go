@@ -0,0 +1,253 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+// Max length of photo caption allowed by telegram.
+const captionLimit = 1024
+
+// fileIds maps files to telegram file_id
+// of already sent ones, so the same file
+// (e.g. cover of album) is not uploaded again.
+type fileIds[K comparable] struct {
+	mutex sync.Mutex
+	vals  map[K]string
+}
+
+func newFileIds[K comparable]() *fileIds[K] {
+	return &fileIds[K]{vals: make(map[K]string)}
+}
+
+func (f *fileIds[K]) Get(key K) (string, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	id, ok := f.vals[key]
+	return id, ok
+}
+
+func (f *fileIds[K]) Set(key K, id string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.vals[key] = id
+}
+
+func (f *fileIds[K]) Del(key K) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.vals, key)
+}
+
+// showCard shows media card in current message.
+// Card with cover art is a photo with caption,
+// otherwise it is a text message.
+func (s *search) showCard(ctx context.Context, b *bot.Bot, chatId int64, conf localModels.MediaConfig, text string, markup models.ReplyMarkup) {
+	const op = "search.showCard"
+
+	if conf.Cover == "" || utf8.RuneCountInString(text) > captionLimit {
+		s.showText(ctx, b, chatId, text, markup)
+		return
+	}
+
+	if !s.photoStorage.Get(chatId) {
+		s.deleteCurrent(ctx, b, chatId)
+		s.sendCard(ctx, b, chatId, conf, text, markup)
+		return
+	}
+
+	media, closeFn, err := s.coverMedia(conf.Cover)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.showText(ctx, b, chatId, text, markup)
+		return
+	}
+	defer closeFn()
+
+	media.Caption = text
+	media.ParseMode = models.ParseModeHTML
+
+	msg, err := b.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+		ChatID:      chatId,
+		MessageID:   s.msgIdStorage.Get(chatId),
+		Media:       media,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	s.rememberPhoto(conf.Cover, msg)
+}
+
+// sendCard sends media card as new message.
+func (s *search) sendCard(ctx context.Context, b *bot.Bot, chatId int64, conf localModels.MediaConfig, text string, markup models.ReplyMarkup) {
+	const op = "search.sendCard"
+
+	if conf.Cover == "" || utf8.RuneCountInString(text) > captionLimit {
+		s.sendText(ctx, b, chatId, text, markup)
+		return
+	}
+
+	media, closeFn, err := s.coverMedia(conf.Cover)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendText(ctx, b, chatId, text, markup)
+		return
+	}
+	defer closeFn()
+
+	var photo models.InputFile = &models.InputFileString{Data: media.Media}
+	if media.MediaAttachment != nil {
+		photo = &models.InputFileUpload{
+			Filename: strings.TrimPrefix(media.Media, "attach://"),
+			Data:     media.MediaAttachment,
+		}
+	}
+
+	msg, err := b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:      chatId,
+		Photo:       photo,
+		Caption:     text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		// Broken cover should not hide media.
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendText(ctx, b, chatId, text, markup)
+		return
+	}
+
+	s.rememberPhoto(conf.Cover, msg)
+	s.photoStorage.Set(chatId, true)
+	s.msgIdStorage.Set(chatId, msg.ID)
+}
+
+// showText shows text in current message.
+// Photo can not be edited to text,
+// so it is replaced with new message.
+func (s *search) showText(ctx context.Context, b *bot.Bot, chatId int64, text string, markup models.ReplyMarkup) {
+	const op = "search.showText"
+
+	if s.photoStorage.Get(chatId) {
+		s.deleteCurrent(ctx, b, chatId)
+		s.sendText(ctx, b, chatId, text, markup)
+		return
+	}
+
+	msg, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   s.msgIdStorage.Get(chatId),
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	s.msgIdStorage.Set(chatId, msg.ID)
+}
+
+// leaveCard turns card into text message,
+// since selectors edit message text.
+func (s *search) leaveCard(ctx context.Context, b *bot.Bot, chatId int64) {
+	if !s.photoStorage.Get(chatId) {
+		return
+	}
+	s.showText(ctx, b, chatId, s.mediaSelectedStorage.Get(chatId).String(), nil)
+}
+
+func (s *search) sendText(ctx context.Context, b *bot.Bot, chatId int64, text string, markup models.ReplyMarkup) {
+	const op = "search.sendText"
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	s.photoStorage.Set(chatId, false)
+	s.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (s *search) deleteCurrent(ctx context.Context, b *bot.Bot, chatId int64) {
+	const op = "search.deleteCurrent"
+
+	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatId,
+		MessageID: s.msgIdStorage.Get(chatId),
+	}); err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// coverMedia returns photo for cover art:
+// file_id of sent photo, link or local file.
+func (s *search) coverMedia(cover string) (*models.InputMediaPhoto, func(), error) {
+	if id, ok := s.coverIds.Get(cover); ok {
+		return &models.InputMediaPhoto{Media: id}, func() {}, nil
+	}
+
+	if strings.HasPrefix(cover, "https://") || strings.HasPrefix(cover, "http://") {
+		return &models.InputMediaPhoto{Media: cover}, func() {}, nil
+	}
+
+	f, err := os.Open(cover)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.InputMediaPhoto{
+		Media:           "attach://" + filepath.Base(cover),
+		MediaAttachment: f,
+	}, func() { f.Close() }, nil
+}
+
+// rememberPhoto saves file_id of largest photo size.
+func (s *search) rememberPhoto(cover string, msg *models.Message) {
+	if msg == nil || len(msg.Photo) == 0 {
+		return
+	}
+	s.coverIds.Set(cover, msg.Photo[len(msg.Photo)-1].FileID)
+}
+
+// forward leaves card and passes
+// callback to nested selector.
+func (s *search) forward(ctx context.Context, b *bot.Bot, update *models.Update, path string) {
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.leaveCard(ctx, b, chatId)
+
+	update.CallbackQuery.Data = path
+	b.ProcessUpdate(ctx, update)
+}
+
+func (s *search) selectMedia(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.forward(ctx, b, update, s.router.Path(cmdSelectMedia))
+}
+
+func (s *search) editMedia(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.forward(ctx, b, update, s.router.Path(cmdUpdateMediaInfo))
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// Deep link payloads, followed by media id.
+	linkCard  = "card-"
+	linkQueue = "queue-"
+	// Opens media editor, used by library check.
+	linkEdit = "edit-"
+	// Payload of link for unknown users, see start.
+	linkLogin = "login"
+
+	inlineMaxResults = 20
+	// Results depend on library,
+	// so they are cached for short time.
+	inlineCacheTime = 10
+)
+
+// inlineQuery answers "@bot query" from any chat
+// with media found in library.
+func (s *search) inlineQuery(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.inlineQuery"
+
+	query := update.InlineQuery
+	userId := query.From.ID
+
+	params := &bot.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       []models.InlineQueryResult{},
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	}
+
+	switch {
+	case !s.auth.IsKnown(ctx, userId):
+		params.Button = &models.InlineQueryResultsButton{
+			Text:           ctr.LibSearchInlineLogin,
+			StartParameter: linkLogin,
+		}
+	case strings.TrimSpace(query.Query) != "":
+		res, err := s.lib.Search(ctx, userId, localModels.MediaFilter{
+			Query:      query.Query,
+			MaxRespLen: inlineMaxResults,
+		})
+		if err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, userId, err))
+			return
+		}
+
+		botName, err := s.botName(ctx, b)
+		if err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, userId, err))
+			return
+		}
+
+		for _, conf := range res {
+			params.Results = append(params.Results, s.inlineResult(botName, conf))
+		}
+	}
+
+	if _, err := b.AnswerInlineQuery(ctx, params); err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, userId, err))
+	}
+}
+
+func (s *search) inlineResult(botName string, conf localModels.MediaConfig) models.InlineQueryResult {
+	res := &models.InlineQueryResultArticle{
+		ID:          strconv.FormatInt(conf.ID, 10),
+		Title:       conf.Name,
+		Description: conf.Author,
+		InputMessageContent: &models.InputTextMessageContent{
+			MessageText: conf.String(),
+			ParseMode:   models.ParseModeHTML,
+		},
+		ReplyMarkup: s.inlineResultMarkup(botName, conf.ID),
+	}
+
+	// Local covers can not be shown as thumbnail.
+	if strings.HasPrefix(conf.Cover, "https://") || strings.HasPrefix(conf.Cover, "http://") {
+		res.ThumbnailURL = conf.Cover
+	}
+
+	return res
+}
+
+// botName returns bot username
+// used in deep links.
+func (s *search) botName(ctx context.Context, b *bot.Bot) (string, error) {
+	s.botNameMutex.Lock()
+	defer s.botNameMutex.Unlock()
+
+	if s.botUsername != "" {
+		return s.botUsername, nil
+	}
+
+	me, err := b.GetMe(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.botUsername = me.Username
+
+	return s.botUsername, nil
+}
+
+// openCard opens media from deep link
+// in search slider.
+func (s *search) openCard(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatId := update.Message.Chat.ID
+
+	conf, ok := s.linkMedia(ctx, b, update, linkCard)
+	if !ok {
+		return
+	}
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	s.mediaPageStorage.Set(chatId, 1)
+	s.mediaResultsStorage.Set(chatId, []localModels.MediaConfig{conf})
+	s.mediaSelectedStorage.Set(chatId, conf)
+
+	s.sendCard(ctx, b, chatId, conf, conf.String(), s.mediaSliderMarkup(1, 1))
+}
+
+// editFromLink opens editor of media
+// from deep link.
+func (s *search) editFromLink(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatId := update.Message.Chat.ID
+
+	conf, ok := s.linkMedia(ctx, b, update, linkEdit)
+	if !ok {
+		return
+	}
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	s.mediaPageStorage.Set(chatId, 1)
+	s.mediaResultsStorage.Set(chatId, []localModels.MediaConfig{conf})
+	s.mediaSelectedStorage.Set(chatId, conf)
+
+	// Editor edits text of current message.
+	s.sendText(ctx, b, chatId, conf.String(), nil)
+	s.openEditor(ctx, b, chatId)
+}
+
+// queueFromLink opens card of media from
+// deep link asking to add it to queue.
+// Link may be opened by accident,
+// so media is not queued at once.
+func (s *search) queueFromLink(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatId := update.Message.Chat.ID
+
+	conf, ok := s.linkMedia(ctx, b, update, linkQueue)
+	if !ok {
+		return
+	}
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	s.mediaPageStorage.Set(chatId, 1)
+	s.mediaResultsStorage.Set(chatId, []localModels.MediaConfig{conf})
+	s.mediaSelectedStorage.Set(chatId, conf)
+
+	s.sendCard(ctx, b, chatId, conf, conf.String()+"\n"+ctr.LibSearchQueueSubmit, s.submitQueueMarkup())
+}
+
+// linkMedia returns media from deep link payload.
+// Errors are reported to user.
+func (s *search) linkMedia(ctx context.Context, b *bot.Bot, update *models.Update, prefix string) (localModels.MediaConfig, bool) {
+	const op = "search.linkMedia"
+
+	chatId := update.Message.Chat.ID
+
+	if !s.auth.IsKnown(ctx, chatId) {
+		s.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return localModels.MediaConfig{}, false
+	}
+
+	payload := strings.TrimPrefix(s.router.DeepLinkPayload(update.Message.Text), prefix)
+	mediaId, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		s.sendMessage(ctx, b, chatId, ctr.UnexpectedMsg)
+		return localModels.MediaConfig{}, false
+	}
+
+	conf, err := s.lib.Media(ctx, chatId, mediaId)
+	if err != nil {
+		if errors.Is(err, service.ErrMediaNotFound) {
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchReplaceErrNotFound)
+			return localModels.MediaConfig{}, false
+		}
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return localModels.MediaConfig{}, false
+	}
+
+	return conf, true
+}
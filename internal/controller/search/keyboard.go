@@ -2,8 +2,11 @@ package search
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/go-telegram/bot/models"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 )
 
 const (
@@ -17,11 +20,40 @@ const (
 	butMsgLanguage     = "Язык"
 	butMsgMood         = "Настроение"
 	butMsgReset        = "Сбросить"
+	butMsgDuration     = "Длительность"
+	butMsgExclude      = "Исключить теги"
+	butMsgMatchAll     = "Все теги"
+	butMsgMatchAny     = "Любой тег"
+	butMsgSort         = "Сортировка: %s"
+	butMsgPeriod       = "Загружены: %s"
+	butMsgSave         = "Сохранить поиск"
+	butMsgSavedList    = "Сохраненные"
+
+	butMsgShare       = "Сделать общим"
+	butMsgUnshare     = "Сделать личным"
+	butMsgSmartOn     = "Сделать умным плейлистом"
+	butMsgSmartOff    = "Отключить умный плейлист"
+	butMsgSmartSync   = "Обновить плейлист"
+	butMsgSavedShared = "👥"
+	butMsgSavedSmart  = "⚡"
 
 	butMsgAddToSch = "Запланировать"
 	butMsgEdit     = "Редактировать"
 	butMsgPlayNext = "Добавить в очередь"
 	butMsgDelete   = "Удалить"
+	butMsgReplace  = "Заменить аудио"
+	butMsgListen   = "Послушать"
+	butMsgOpenCard = "Открыть карточку"
+	butMsgBulk     = "Выбрать несколько"
+
+	butMsgBulkOn      = "✅"
+	butMsgBulkOff     = "▫️"
+	butMsgBulkAll     = "Выбрать все"
+	butMsgBulkNone    = "Снять выбор"
+	butMsgBulkActions = "Действия (%d)"
+	butMsgAddTags     = "Добавить теги"
+	butMsgRemoveTags  = "Убрать теги"
+	butMsgFormat      = "Сменить формат"
 
 	butMsgSubmit = "Искать"
 	butMsgCancel = "Назад"
@@ -44,6 +76,11 @@ func (s *search) mainMenuMarkup(opt searchOption) models.InlineKeyboardMarkup {
 		msgCallback = ""
 	}
 
+	msgMatch := butMsgMatchAll
+	if opt.matchAny {
+		msgMatch = butMsgMatchAny
+	}
+
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
@@ -58,6 +95,21 @@ func (s *search) mainMenuMarkup(opt searchOption) models.InlineKeyboardMarkup {
 				{Text: butMsgLanguage, CallbackData: s.router.PathPrefixState(cmdUpdate, "lang")},
 				{Text: butMsgMood, CallbackData: s.router.PathPrefixState(cmdUpdate, "mood")},
 			},
+			{
+				{Text: butMsgExclude, CallbackData: s.router.PathPrefixState(cmdUpdate, "exclude")},
+				{Text: msgMatch, CallbackData: s.router.PathPrefixState(cmdUpdate, "match")},
+			},
+			{
+				{Text: butMsgDuration, CallbackData: s.router.PathPrefixState(cmdUpdate, "duration")},
+				{Text: fmt.Sprintf(butMsgPeriod, periodRepr[opt.period]), CallbackData: s.router.PathPrefixState(cmdUpdate, "period")},
+			},
+			{
+				{Text: fmt.Sprintf(butMsgSort, sortRepr[opt.sort]), CallbackData: s.router.PathPrefixState(cmdUpdate, "sort")},
+			},
+			{
+				{Text: butMsgSave, CallbackData: s.router.PathPrefixState(cmdUpdate, "save")},
+				{Text: butMsgSavedList, CallbackData: s.router.Path(cmdSavedList)},
+			},
 			{
 				{Text: butMsgSubmit, CallbackData: s.router.Path(cmdSubmit)},
 				{Text: butMsgReset, CallbackData: s.router.Path(cmdReset)},
@@ -66,6 +118,74 @@ func (s *search) mainMenuMarkup(opt searchOption) models.InlineKeyboardMarkup {
 	}
 }
 
+func (s *search) savedListMarkup(list []localModels.SavedSearch) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(list)+1)
+	for _, saved := range list {
+		text := saved.Name
+		if saved.Shared {
+			text += " " + butMsgSavedShared
+		}
+		if saved.Smart {
+			text += " " + butMsgSavedSmart
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: text, CallbackData: s.router.PathPrefixState(cmdSavedOpen, strconv.FormatInt(saved.ID, 10))},
+		})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: butMsgCancel, CallbackData: s.router.Path(cmdCloseSlider)},
+	})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+// savedMarkup returns actions with saved search.
+// Only owner can change it.
+func (s *search) savedMarkup(chatId int64, saved localModels.SavedSearch) models.InlineKeyboardMarkup {
+	id := strconv.FormatInt(saved.ID, 10)
+
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: butMsgSubmit, CallbackData: s.router.PathPrefixState(cmdSavedRun, id)},
+		},
+	}
+
+	if saved.Smart {
+		rows = append(rows, []models.InlineKeyboardButton{
+			{Text: butMsgSmartSync, CallbackData: s.router.PathPrefixState(cmdSavedSync, id)},
+		})
+	}
+
+	if saved.UserId == chatId {
+		share, smart := butMsgShare, butMsgSmartOn
+		if saved.Shared {
+			share = butMsgUnshare
+		}
+		if saved.Smart {
+			smart = butMsgSmartOff
+		}
+		rows = append(rows,
+			[]models.InlineKeyboardButton{
+				{Text: share, CallbackData: s.router.PathPrefixState(cmdSavedShare, id)},
+				{Text: butMsgDelete, CallbackData: s.router.PathPrefixState(cmdSavedDelete, id)},
+			},
+			[]models.InlineKeyboardButton{
+				{Text: smart, CallbackData: s.router.PathPrefixState(cmdSavedSmart, id)},
+			},
+		)
+	}
+
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: butMsgCancel, CallbackData: s.router.Path(cmdSavedList)},
+	})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
 func (s *search) getSettingDataMarkup() models.InlineKeyboardMarkup {
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
@@ -104,13 +224,20 @@ func (s *search) mediaSliderMarkup(id int, maxId int) models.InlineKeyboardMarku
 				butRight,
 			},
 			{
-				{Text: butMsgAddToSch, CallbackData: s.router.Path(cmdSelectMedia)},
+				{Text: butMsgAddToSch, CallbackData: s.router.Path(cmdSchedule)},
 				{Text: butMsgPlayNext, CallbackData: s.router.Path(cmdAddToQueue)},
 			},
 			{
-				{Text: butMsgEdit, CallbackData: s.router.Path(cmdUpdateMediaInfo)},
+				{Text: butMsgEdit, CallbackData: s.router.Path(cmdEdit)},
 				{Text: butMsgDelete, CallbackData: s.router.Path(cmdDeleteMedia)},
 			},
+			{
+				{Text: butMsgListen, CallbackData: s.router.Path(cmdListen)},
+				{Text: butMsgReplace, CallbackData: s.router.Path(cmdReplaceAudio)},
+			},
+			{
+				{Text: butMsgBulk, CallbackData: s.router.Path(cmdBulk)},
+			},
 			{
 				{Text: butMsgCancel, CallbackData: s.router.Path(cmdCloseSlider)},
 			},
@@ -118,6 +245,137 @@ func (s *search) mediaSliderMarkup(id int, maxId int) models.InlineKeyboardMarku
 	}
 }
 
+// bulkSelectMarkup returns button
+// for every found media in order.
+func (s *search) bulkSelectMarkup(res []localModels.MediaConfig, selected []bool) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(res)+3)
+	for i, conf := range res {
+		mark := butMsgBulkOff
+		if i < len(selected) && selected[i] {
+			mark = butMsgBulkOn
+		}
+		rows = append(rows, []models.InlineKeyboardButton{
+			{
+				Text:         fmt.Sprintf("%s %s — %s", mark, conf.Author, conf.Name),
+				CallbackData: s.router.PathPrefixState(cmdBulkToggle, strconv.Itoa(i)),
+			},
+		})
+	}
+
+	all := butMsgBulkAll
+	if n := countSelected(selected); n > 0 && n == len(selected) {
+		all = butMsgBulkNone
+	}
+
+	rows = append(rows,
+		[]models.InlineKeyboardButton{
+			{Text: all, CallbackData: s.router.Path(cmdBulkAll)},
+		},
+		[]models.InlineKeyboardButton{
+			{Text: fmt.Sprintf(butMsgBulkActions, countSelected(selected)), CallbackData: s.router.Path(cmdBulkActions)},
+		},
+		[]models.InlineKeyboardButton{
+			{Text: butMsgCancel, CallbackData: s.router.Path(cmdBulkClose)},
+		},
+	)
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (s *search) bulkActionsMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgAddTags, CallbackData: s.router.PathPrefixState(cmdBulkTags, "add")},
+				{Text: butMsgRemoveTags, CallbackData: s.router.PathPrefixState(cmdBulkTags, "remove")},
+			},
+			{
+				{Text: butMsgFormat, CallbackData: s.router.Path(cmdBulkFormat)},
+				{Text: butMsgPlayNext, CallbackData: s.router.Path(cmdBulkQueue)},
+			},
+			{
+				{Text: butMsgDelete, CallbackData: s.router.Path(cmdBulkDelete)},
+			},
+			{
+				{Text: butMsgCancel, CallbackData: s.router.Path(cmdBulk)},
+			},
+		},
+	}
+}
+
+func (s *search) bulkTagTypeMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgGenre, CallbackData: s.router.PathPrefixState(cmdBulkTagType, "genre")},
+				{Text: butMsgPlaylist, CallbackData: s.router.PathPrefixState(cmdBulkTagType, "playlist")},
+			},
+			{
+				{Text: butMsgLanguage, CallbackData: s.router.PathPrefixState(cmdBulkTagType, "language")},
+				{Text: butMsgMood, CallbackData: s.router.PathPrefixState(cmdBulkTagType, "mood")},
+			},
+			{
+				{Text: butMsgPodcasts, CallbackData: s.router.PathPrefixState(cmdBulkTagType, "podcast")},
+			},
+			{
+				{Text: butMsgCancel, CallbackData: s.router.Path(cmdBulkActions)},
+			},
+		},
+	}
+}
+
+func (s *search) bulkFormatMarkup() models.InlineKeyboardMarkup {
+	format := func(f localModels.MediaFormat) string {
+		return s.router.PathPrefixState(cmdBulkSetFormat, strconv.Itoa(int(f)))
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgSong, CallbackData: format(localModels.Song)},
+				{Text: butMsgPodcast, CallbackData: format(localModels.Podcast)},
+				{Text: butMsgJingle, CallbackData: format(localModels.Jingle)},
+			},
+			{
+				{Text: butMsgCancel, CallbackData: s.router.Path(cmdBulkActions)},
+			},
+		},
+	}
+}
+
+func (s *search) bulkBackMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgCancel, CallbackData: s.router.Path(cmdBulkActions)},
+			},
+		},
+	}
+}
+
+func (s *search) bulkDeleteMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "Да", CallbackData: s.router.Path(cmdBulkDeleteSubmit)},
+				{Text: "Нет", CallbackData: s.router.Path(cmdBulkActions)},
+			},
+		},
+	}
+}
+
+func (s *search) replaceAudioMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgCancel, CallbackData: s.router.Path(cmdReplaceCancel)},
+			},
+		},
+	}
+}
+
 func (s *search) submitDeleteMarkup() models.InlineKeyboardMarkup {
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
@@ -128,3 +386,34 @@ func (s *search) submitDeleteMarkup() models.InlineKeyboardMarkup {
 		},
 	}
 }
+
+// submitQueueMarkup confirms adding media
+// to queue, rejection opens media card.
+func (s *search) submitQueueMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "Да", CallbackData: s.router.Path(cmdAddToQueue)},
+				{Text: "Нет", CallbackData: s.router.Path(cmdDeleteReject)},
+			},
+		},
+	}
+}
+
+// inlineResultMarkup returns deep links to bot,
+// since callbacks of inline messages are
+// sent without chat to reply in.
+func (s *search) inlineResultMarkup(botName string, mediaId int64) models.InlineKeyboardMarkup {
+	link := func(payload string) string {
+		return fmt.Sprintf("https://t.me/%s?start=%s%d", botName, payload, mediaId)
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgPlayNext, URL: link(linkQueue)},
+				{Text: butMsgOpenCard, URL: link(linkCard)},
+			},
+		},
+	}
+}
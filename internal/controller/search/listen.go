@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// Title suffix of preview clip.
+const previewSuffix = " (фрагмент)"
+
+// listen sends audio of selected media.
+// Sent file_id is reused for the same media.
+func (s *search) listen(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.listen"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	conf := s.mediaSelectedStorage.Get(chatId)
+
+	if fileId, ok := s.audioIds.Get(conf.ID); ok {
+		_, err := b.SendAudio(ctx, &bot.SendAudioParams{
+			ChatID: chatId,
+			Audio:  &models.InputFileString{Data: fileId},
+		})
+		if err == nil {
+			return
+		}
+		// Upload file again if file_id is no longer valid.
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.audioIds.Del(conf.ID)
+	}
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatId,
+			MessageID: inProgressMsg.ID,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	path, preview, err := s.lib.Source(ctx, chatId, conf)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMediaNotFound):
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchReplaceErrNotFound)
+		case errors.Is(err, tmpdir.ErrQuotaExceeded):
+			s.sendMessage(ctx, b, chatId, ctr.LibUploadErrTmpQuota)
+		default:
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+	defer f.Close()
+
+	msg, err := b.SendAudio(ctx, s.audioParams(chatId, conf, preview, &models.InputFileUpload{
+		Filename: filepath.Base(path),
+		Data:     f,
+	}))
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if msg.Audio != nil {
+		s.audioIds.Set(conf.ID, msg.Audio.FileID)
+	}
+}
+
+// audioParams returns audio message for media.
+// Preview is marked in title, since title
+// is kept with file_id and caption is not.
+func (s *search) audioParams(chatId int64, conf localModels.MediaConfig, preview bool, audio models.InputFile) *bot.SendAudioParams {
+	params := &bot.SendAudioParams{
+		ChatID:    chatId,
+		Audio:     audio,
+		Performer: conf.Author,
+		Title:     conf.Name,
+		Duration:  int(conf.Duration.Seconds()),
+	}
+
+	if preview {
+		params.Title += previewSuffix
+		params.Caption = ctr.LibSearchListenPreview
+		params.Duration = 0
+	}
+
+	return params
+}
@@ -2,13 +2,19 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/split"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 )
 
 func (s *search) update(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -40,18 +46,35 @@ func (s *search) update(ctx context.Context, b *bot.Bot, update *models.Update)
 			opt.format = formatSong
 		}
 		s.searchStorage.Set(chatId, opt)
-		id := s.msgIdStorage.Get(chatId)
-
-		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:      chatId,
-			MessageID:   id,
-			Text:        s.filterRepr(opt),
-			ReplyMarkup: s.mainMenuMarkup(opt),
-			ParseMode:   models.ParseModeHTML,
-		}); err != nil {
-			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-		}
+		s.updateMainMenu(ctx, b, chatId, opt)
+		return
+	case "match":
+		opt := s.searchStorage.Get(chatId)
+		opt.matchAny = !opt.matchAny
+		s.searchStorage.Set(chatId, opt)
+		s.updateMainMenu(ctx, b, chatId, opt)
+		return
+	case "sort":
+		opt := s.searchStorage.Get(chatId)
+		opt.sort = cycle(sortOrders, opt.sort)
+		s.searchStorage.Set(chatId, opt)
+		s.updateMainMenu(ctx, b, chatId, opt)
+		return
+	case "period":
+		opt := s.searchStorage.Get(chatId)
+		opt.period = cycle(periods, opt.period)
+		s.searchStorage.Set(chatId, opt)
+		s.updateMainMenu(ctx, b, chatId, opt)
 		return
+	case "duration":
+		s.targetUpdateStorage.Set(chatId, "duration")
+		msg = ctr.LibSearchAskDuration
+	case "exclude":
+		s.targetUpdateStorage.Set(chatId, "exclude")
+		msg = ctr.LibSearchAskExclude
+	case "save":
+		s.targetUpdateStorage.Set(chatId, "save")
+		msg = ctr.LibSearchAskSaveName
 	case "podcast-playlist":
 		opt := s.searchStorage.Get(chatId)
 		switch opt.format {
@@ -75,6 +98,11 @@ func (s *search) update(ctx context.Context, b *bot.Bot, update *models.Update)
 		opt.podcasts = nil
 		opt.languages = nil
 		opt.moods = nil
+		opt.excluded = nil
+		opt.matchAny = false
+		opt.minDur, opt.maxDur = 0, 0
+		opt.sort = localModels.SortRelevance
+		opt.period = 0
 		s.searchStorage.Set(chatId, opt)
 		msg = s.filterRepr(opt)
 
@@ -121,6 +149,21 @@ func (s *search) getData(ctx context.Context, b *bot.Bot, update *models.Update)
 	}
 
 	switch s.targetUpdateStorage.Get(chatId) {
+	case "save":
+		if !s.saveSearch(ctx, b, chatId, msg, opt) {
+			return
+		}
+
+		s.session.Redirect(chatId, ctr.NullStatus)
+		s.targetUpdateStorage.Del(chatId)
+
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatId,
+			MessageID: update.Message.ID,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
 	case "name-author":
 		opt.nameAuthor = msg
 	case "genre":
@@ -136,6 +179,20 @@ func (s *search) getData(ctx context.Context, b *bot.Bot, update *models.Update)
 		opt.languages = split.SplitMsg(msg)
 	case "mood":
 		opt.moods = split.SplitMsg(msg)
+	case "exclude":
+		opt.excluded = split.SplitMsg(msg)
+	case "duration":
+		minDur, maxDur, err := parseDurRange(msg)
+		if err != nil {
+			if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatId,
+				Text:   ctr.LibSearchErrDuration,
+			}); err != nil {
+				s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			}
+			return
+		}
+		opt.minDur, opt.maxDur = minDur, maxDur
 	default:
 		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatId,
@@ -168,14 +225,9 @@ func (s *search) getData(ctx context.Context, b *bot.Bot, update *models.Update)
 	}
 }
 
-func (s *search) cancelSlider(ctx context.Context, b *bot.Bot, update *models.Update) {
-	const op = "search.cancelSlider"
-
-	s.CallbackAnswer(ctx, b, update.CallbackQuery)
-
-	chatId := update.CallbackQuery.Message.Message.Chat.ID
-
-	opt := s.searchStorage.Get(chatId)
+// updateMainMenu shows changed search options.
+func (s *search) updateMainMenu(ctx context.Context, b *bot.Bot, chatId int64, opt searchOption) {
+	const op = "search.updateMainMenu"
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatId,
@@ -187,3 +239,82 @@ func (s *search) cancelSlider(ctx context.Context, b *bot.Bot, update *models.Up
 		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
 }
+
+func (s *search) cancelSlider(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	opt := s.searchStorage.Get(chatId)
+
+	s.showText(ctx, b, chatId, s.filterRepr(opt), s.mainMenuMarkup(opt))
+}
+
+// cycle returns value following cur in vals.
+func cycle[T comparable](vals []T, cur T) T {
+	return vals[(slices.Index(vals, cur)+1)%len(vals)]
+}
+
+// parseDurRange parses duration range "from-to",
+// e.g. "2:30-4", "-4:00" or "3-".
+// Bounds are minutes or "minutes:seconds",
+// missing bound is zero.
+func parseDurRange(text string) (time.Duration, time.Duration, error) {
+	from, to, found := strings.Cut(strings.TrimSpace(text), "-")
+	if !found {
+		return 0, 0, errors.New("no range separator")
+	}
+
+	minDur, err := parseDur(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxDur, err := parseDur(to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if minDur == 0 && maxDur == 0 {
+		return 0, 0, errors.New("empty range")
+	}
+	if maxDur > 0 && minDur > maxDur {
+		return 0, 0, errors.New("invalid range")
+	}
+
+	return minDur, maxDur, nil
+}
+
+func parseDur(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+
+	minStr, secStr, found := strings.Cut(text, ":")
+
+	minutes, err := strconv.Atoi(minStr)
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("invalid minutes %q", minStr)
+	}
+
+	seconds := 0
+	if found {
+		seconds, err = strconv.Atoi(secStr)
+		if err != nil || seconds < 0 || seconds >= 60 {
+			return 0, fmt.Errorf("invalid seconds %q", secStr)
+		}
+	}
+
+	return time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+}
+
+func durRangeRepr(minDur, maxDur time.Duration) string {
+	switch {
+	case minDur > 0 && maxDur > 0:
+		return fmt.Sprintf("от %s до %s", minDur, maxDur)
+	case minDur > 0:
+		return fmt.Sprintf("от %s", minDur)
+	default:
+		return fmt.Sprintf("до %s", maxDur)
+	}
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// savedList shows saved searches
+// visible to user.
+func (s *search) savedList(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedList"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.showSavedList(ctx, b, chatId, op)
+}
+
+func (s *search) showSavedList(ctx context.Context, b *bot.Bot, chatId int64, op string) {
+	list, err := s.saved.List(ctx, chatId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	msg := ctr.LibSearchSavedList
+	if len(list) == 0 {
+		msg = ctr.LibSearchSavedEmpty
+	}
+
+	s.showText(ctx, b, chatId, msg, s.savedListMarkup(list))
+}
+
+// savedOpen shows saved search.
+func (s *search) savedOpen(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedOpen"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	saved, err := s.saved.Get(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	s.showSaved(ctx, b, chatId, saved, saved.String())
+}
+
+// savedRun shows results of saved search in slider.
+func (s *search) savedRun(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedRun"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	res, err := s.saved.Run(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	s.showResults(ctx, b, chatId, res)
+}
+
+// savedShare makes search shared or personal.
+func (s *search) savedShare(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedShare"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	saved, err := s.saved.Get(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	saved, err = s.saved.SetShared(ctx, chatId, searchId, !saved.Shared)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	s.showSaved(ctx, b, chatId, saved, saved.String())
+}
+
+// savedSmart promotes search to smart playlist
+// or turns smart playlist back into search.
+func (s *search) savedSmart(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedSmart"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	saved, err := s.saved.Get(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	saved, err = s.saved.SetSmart(ctx, chatId, searchId, !saved.Smart)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	msg := ctr.LibSearchSmartOff
+	if saved.Smart {
+		msg = fmt.Sprintf(ctr.LibSearchSmartOn, html.EscapeString(saved.Name))
+	}
+
+	s.showSaved(ctx, b, chatId, saved, saved.String()+"\n\n"+msg)
+}
+
+// savedSync updates smart playlist.
+func (s *search) savedSync(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedSync"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	res, err := s.saved.Sync(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	saved, err := s.saved.Get(ctx, chatId, searchId)
+	if err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	s.showSaved(ctx, b, chatId, saved, saved.String()+"\n\n"+fmt.Sprintf(ctr.LibSearchSmartSynced, res.Added, res.Removed))
+}
+
+// savedDelete deletes saved search.
+func (s *search) savedDelete(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.savedDelete"
+
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	searchId, ok := s.savedId(ctx, b, chatId, update, op)
+	if !ok {
+		return
+	}
+
+	if err := s.saved.Delete(ctx, chatId, searchId); err != nil {
+		s.handleSavedErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	s.showSavedList(ctx, b, chatId, op)
+}
+
+// saveSearch saves current search
+// options under name from message.
+// Reports if name is accepted.
+func (s *search) saveSearch(ctx context.Context, b *bot.Bot, chatId int64, name string, opt searchOption) bool {
+	const op = "search.saveSearch"
+
+	saved, err := s.saved.Save(ctx, chatId, name, opt.ToFilter(), opt.period)
+	if err != nil {
+		if errors.Is(err, service.ErrSearchExists) {
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrExists)
+			return false
+		}
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return true
+	}
+
+	s.showSaved(ctx, b, chatId, saved, saved.String()+"\n\n"+ctr.LibSearchSavedSuccess)
+
+	return true
+}
+
+func (s *search) showSaved(ctx context.Context, b *bot.Bot, chatId int64, saved localModels.SavedSearch, text string) {
+	s.showText(ctx, b, chatId, text, s.savedMarkup(chatId, saved))
+}
+
+// savedId parses id of saved search
+// from callback data.
+func (s *search) savedId(ctx context.Context, b *bot.Bot, chatId int64, update *models.Update, op string) (int64, bool) {
+	searchId, err := strconv.ParseInt(s.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return 0, false
+	}
+	return searchId, true
+}
+
+func (s *search) handleSavedErr(ctx context.Context, b *bot.Bot, chatId int64, op string, err error) {
+	switch {
+	case errors.Is(err, service.ErrSearchNotFound), errors.Is(err, service.ErrNotSmart):
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrNotFound)
+	case errors.Is(err, service.ErrSearchExists):
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrExists)
+	case errors.Is(err, service.ErrNotSearchOwner):
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchSavedErrNotOwner)
+	case errors.Is(err, service.ErrPlaylistExists):
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchSmartErrPlaylist)
+	case errors.Is(err, service.ErrSyncInProgress):
+		s.sendMessage(ctx, b, chatId, ctr.LibSearchSmartErrSyncing)
+	default:
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
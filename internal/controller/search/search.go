@@ -3,7 +3,10 @@ package search
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -31,10 +34,40 @@ const (
 	cmdCloseSlider     ctr.Command = "cancel"
 	cmdSelectMedia     ctr.Command = "select"
 	cmdUpdateMediaInfo ctr.Command = "update-media"
+	cmdSchedule        ctr.Command = "schedule"
+	cmdEdit            ctr.Command = "edit"
 	cmdAddToQueue      ctr.Command = "add-queue"
 	cmdDeleteMedia     ctr.Command = "delete"
 	cmdDeleteSubmit    ctr.Command = "delete-submit"
 	cmdDeleteReject    ctr.Command = "delete-reject"
+	cmdReplaceAudio    ctr.Command = "replace-audio"
+	cmdGetAudio        ctr.Command = "get-audio"
+	cmdReplaceCancel   ctr.Command = "replace-cancel"
+	cmdListen          ctr.Command = "listen"
+
+	// saved searches
+	cmdSavedList   ctr.Command = "saved"
+	cmdSavedOpen   ctr.Command = "saved-open"
+	cmdSavedRun    ctr.Command = "saved-run"
+	cmdSavedShare  ctr.Command = "saved-share"
+	cmdSavedSmart  ctr.Command = "saved-smart"
+	cmdSavedSync   ctr.Command = "saved-sync"
+	cmdSavedDelete ctr.Command = "saved-delete"
+
+	// multi-select
+	cmdBulk             ctr.Command = "bulk"
+	cmdBulkToggle       ctr.Command = "bulk-toggle"
+	cmdBulkAll          ctr.Command = "bulk-all"
+	cmdBulkClose        ctr.Command = "bulk-close"
+	cmdBulkActions      ctr.Command = "bulk-actions"
+	cmdBulkTags         ctr.Command = "bulk-tags"
+	cmdBulkTagType      ctr.Command = "bulk-tag-type"
+	cmdBulkGetTags      ctr.Command = "bulk-get-tags"
+	cmdBulkFormat       ctr.Command = "bulk-format"
+	cmdBulkSetFormat    ctr.Command = "bulk-set-format"
+	cmdBulkQueue        ctr.Command = "bulk-queue"
+	cmdBulkDelete       ctr.Command = "bulk-delete"
+	cmdBulkDeleteSubmit ctr.Command = "bulk-delete-submit"
 
 	// filler
 	cmdNoOp ctr.Command = "no-op"
@@ -50,12 +83,31 @@ type search struct {
 	session ctr.Session
 	onError bot.ErrorsHandler
 
+	fileCache FileCache
+	saved     Saved
+	jobs      Jobs
+
 	searchStorage        storage.Storage[searchOption]
 	targetUpdateStorage  storage.Storage[string]
 	mediaPageStorage     storage.Storage[int]
 	mediaResultsStorage  storage.Storage[[]localModels.MediaConfig]
 	mediaSelectedStorage storage.Storage[localModels.MediaConfig]
 	msgIdStorage         storage.Storage[int]
+	// Whether current message is a photo.
+	photoStorage storage.Storage[bool]
+	coverIds     *fileIds[string]
+	audioIds     *fileIds[int64]
+
+	// Multi-select mode, flags are
+	// aligned with found media.
+	bulkSelectedStorage storage.Storage[[]bool]
+	bulkArgsStorage     storage.Storage[map[string]string]
+
+	// Opens media editor from deep link.
+	openEditor setting.Open
+
+	botNameMutex sync.Mutex
+	botUsername  string
 }
 
 type Auth interface {
@@ -64,14 +116,42 @@ type Auth interface {
 
 type Library interface {
 	Search(ctx context.Context, id int64, filter localModels.MediaFilter) ([]localModels.MediaConfig, error)
+	Media(ctx context.Context, id int64, mediaId int64) (localModels.MediaConfig, error)
 	UpdateMedia(ctx context.Context, id int64, mediaConf localModels.MediaConfig) error
 	DeleteMedia(ctx context.Context, id int64, mediaConf localModels.MediaConfig) error
+	ReplaceSource(ctx context.Context, id int64, mediaConf localModels.MediaConfig, path string) (localModels.MediaConfig, error)
+	LinkDownload(ctx context.Context, id int64, link string) (localModels.LinkDownloadResult, error)
+	Source(ctx context.Context, id int64, mediaConf localModels.MediaConfig) (string, bool, error)
+	Taxonomy(ctx context.Context, id int64) (localModels.Taxonomy, error)
 }
 
 type Schedule interface {
 	AddToQueue(ctx context.Context, id int64, media localModels.MediaConfig) (localModels.Segment, error)
 }
 
+// Saved stores named searches
+// and smart playlists built from them.
+type Saved interface {
+	List(ctx context.Context, id int64) ([]localModels.SavedSearch, error)
+	Get(ctx context.Context, id int64, searchId int64) (localModels.SavedSearch, error)
+	Save(ctx context.Context, id int64, name string, filter localModels.MediaFilter, period time.Duration) (localModels.SavedSearch, error)
+	Run(ctx context.Context, id int64, searchId int64) ([]localModels.MediaConfig, error)
+	SetShared(ctx context.Context, id int64, searchId int64, shared bool) (localModels.SavedSearch, error)
+	SetSmart(ctx context.Context, id int64, searchId int64, smart bool) (localModels.SavedSearch, error)
+	Sync(ctx context.Context, id int64, searchId int64) (localModels.SmartSyncResult, error)
+	Delete(ctx context.Context, id int64, searchId int64) error
+}
+
+// Jobs runs actions with
+// selected media in background.
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind localModels.JobKind, title string, items []localModels.JobItem, notify localModels.JobNotify) (localModels.Job, error)
+}
+
+type FileCache interface {
+	Download(ctx context.Context, key string, req *http.Request) (string, error)
+}
+
 type searchOption struct {
 	nameAuthor string
 	format     searchFormat
@@ -80,6 +160,48 @@ type searchOption struct {
 	genres     []string
 	languages  []string
 	moods      []string
+	excluded   []string
+	// Match any of selected tags
+	// instead of all of them.
+	matchAny bool
+	minDur   time.Duration
+	maxDur   time.Duration
+	sort     localModels.MediaSort
+	// Only media uploaded during
+	// this period, zero for all.
+	period time.Duration
+}
+
+// Values switched by menu buttons in turn.
+var (
+	sortOrders = []localModels.MediaSort{
+		localModels.SortRelevance,
+		localModels.SortName,
+		localModels.SortAuthor,
+		localModels.SortDuration,
+		localModels.SortRecent,
+	}
+	periods = []time.Duration{
+		0,
+		24 * time.Hour,
+		7 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+	}
+)
+
+var sortRepr = map[localModels.MediaSort]string{
+	localModels.SortRelevance: "по умолчанию",
+	localModels.SortName:      "по названию",
+	localModels.SortAuthor:    "по автору",
+	localModels.SortDuration:  "по длительности",
+	localModels.SortRecent:    "сначала новые",
+}
+
+var periodRepr = map[time.Duration]string{
+	0:                   "когда угодно",
+	24 * time.Hour:      "за сутки",
+	7 * 24 * time.Hour:  "за неделю",
+	30 * 24 * time.Hour: "за месяц",
 }
 
 type searchFormat int
@@ -91,19 +213,34 @@ const (
 )
 
 func (opt searchOption) ToFilter() localModels.MediaFilter {
-	tags := make([]string, 0)
-	tags = append(tags, opt.format.String())
-	tags = append(tags, opt.playlists...)
-	tags = append(tags, opt.genres...)
-	tags = append(tags, opt.languages...)
-	tags = append(tags, opt.moods...)
-
-	return localModels.MediaFilter{
-		Name:       opt.nameAuthor,
-		Author:     opt.nameAuthor,
-		Tags:       tags,
-		MaxRespLen: 20,
+	selected := make([]string, 0)
+	selected = append(selected, opt.playlists...)
+	selected = append(selected, opt.genres...)
+	selected = append(selected, opt.languages...)
+	selected = append(selected, opt.moods...)
+
+	filter := localModels.MediaFilter{
+		Query:       opt.nameAuthor,
+		Tags:        []string{opt.format.String()},
+		ExcludeTags: opt.excluded,
+		MinDuration: opt.minDur,
+		MaxDuration: opt.maxDur,
+		Sort:        opt.sort,
+		MaxRespLen:  20,
+	}
+
+	// Format is always required.
+	if opt.matchAny {
+		filter.AnyTags = selected
+	} else {
+		filter.Tags = append(filter.Tags, selected...)
+	}
+
+	if opt.period > 0 {
+		filter.AddedAfter = time.Now().Add(-opt.period)
 	}
+
+	return filter
 }
 
 func (sOpt searchFormat) String() string {
@@ -140,14 +277,21 @@ func Register(
 	scheduleAdd datetime.ScheduleAdd,
 	session ctr.Session,
 	onError bot.ErrorsHandler,
+	fileCache FileCache,
+	inlineQueries *ctr.InlineQueries,
+	saved Saved,
+	jobs Jobs,
 ) {
 	s := &search{
-		router:  router,
-		auth:    auth,
-		lib:     lib,
-		sch:     sch,
-		session: session,
-		onError: onError,
+		router:    router,
+		auth:      auth,
+		lib:       lib,
+		sch:       sch,
+		session:   session,
+		onError:   onError,
+		fileCache: fileCache,
+		saved:     saved,
+		jobs:      jobs,
 
 		searchStorage:        storage.New[searchOption](),
 		targetUpdateStorage:  storage.New[string](),
@@ -155,6 +299,11 @@ func Register(
 		mediaResultsStorage:  storage.New[[]localModels.MediaConfig](),
 		mediaSelectedStorage: storage.New[localModels.MediaConfig](),
 		msgIdStorage:         storage.New[int](),
+		photoStorage:         storage.New[bool](),
+		bulkSelectedStorage:  storage.New[[]bool](),
+		bulkArgsStorage:      storage.New[map[string]string](),
+		coverIds:             newFileIds[string](),
+		audioIds:             newFileIds[int64](),
 	}
 
 	// main menu
@@ -173,6 +322,8 @@ func Register(
 	router.RegisterCallbackPrefix(cmdUpdateSlide, s.updateSlide)
 	router.RegisterCallback(cmdCloseSlider, s.cancelSlider)
 	router.RegisterCallback(cmdAddToQueue, s.addToQueue)
+	router.RegisterCallback(cmdSchedule, s.selectMedia)
+	router.RegisterCallback(cmdEdit, s.editMedia)
 
 	// selector for schedule modify
 	datetime.Register(
@@ -186,7 +337,7 @@ func Register(
 	)
 
 	// selector for updating media info
-	setting.Register(
+	s.openEditor = setting.Register(
 		router.With(cmdUpdateMediaInfo),
 		session,
 		s.updateMedia,
@@ -194,6 +345,7 @@ func Register(
 		onError,
 		s.mediaSelectedStorage,
 		s.msgIdStorage,
+		lib,
 	)
 
 	// delete media
@@ -201,6 +353,44 @@ func Register(
 	router.RegisterCallback(cmdDeleteSubmit, s.deleteSubmit)
 	router.RegisterCallback(cmdDeleteReject, s.deleteReject)
 
+	// replace audio
+	router.RegisterCallback(cmdReplaceAudio, s.replaceAudio)
+	router.RegisterHandler(cmdGetAudio, s.getAudio)
+	router.RegisterCallback(cmdReplaceCancel, s.replaceCancel)
+
+	// listen
+	router.RegisterCallback(cmdListen, s.listen)
+
+	// saved searches
+	router.RegisterCallback(cmdSavedList, s.savedList)
+	router.RegisterCallbackPrefix(cmdSavedOpen, s.savedOpen)
+	router.RegisterCallbackPrefix(cmdSavedRun, s.savedRun)
+	router.RegisterCallbackPrefix(cmdSavedShare, s.savedShare)
+	router.RegisterCallbackPrefix(cmdSavedSmart, s.savedSmart)
+	router.RegisterCallbackPrefix(cmdSavedSync, s.savedSync)
+	router.RegisterCallbackPrefix(cmdSavedDelete, s.savedDelete)
+
+	// multi-select
+	router.RegisterCallback(cmdBulk, s.bulkOpen)
+	router.RegisterCallbackPrefix(cmdBulkToggle, s.bulkToggle)
+	router.RegisterCallback(cmdBulkAll, s.bulkAll)
+	router.RegisterCallback(cmdBulkClose, s.bulkClose)
+	router.RegisterCallback(cmdBulkActions, s.bulkActions)
+	router.RegisterCallbackPrefix(cmdBulkTags, s.bulkTags)
+	router.RegisterCallbackPrefix(cmdBulkTagType, s.bulkTagType)
+	router.RegisterHandler(cmdBulkGetTags, s.bulkGetTags)
+	router.RegisterCallback(cmdBulkFormat, s.bulkFormat)
+	router.RegisterCallbackPrefix(cmdBulkSetFormat, s.bulkSetFormat)
+	router.RegisterCallback(cmdBulkQueue, s.bulkQueue)
+	router.RegisterCallback(cmdBulkDelete, s.bulkDelete)
+	router.RegisterCallback(cmdBulkDeleteSubmit, s.bulkDeleteSubmit)
+
+	// inline mode and its deep links
+	inlineQueries.Register(s.inlineQuery)
+	router.RegisterDeepLink(linkCard, s.openCard)
+	router.RegisterDeepLink(linkQueue, s.queueFromLink)
+	router.RegisterDeepLink(linkEdit, s.editFromLink)
+
 	// null handler to answer callbacks for empty buttons
 	router.RegisterCallback(cmdNoOp, s.nullHandler)
 }
@@ -232,6 +422,7 @@ func (s *search) init(ctx context.Context, b *bot.Bot, update *models.Update) {
 		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
 
+	s.photoStorage.Set(chatId, false)
 	s.msgIdStorage.Set(chatId, msg.ID)
 }
 
@@ -279,35 +470,22 @@ func (s *search) submit(ctx context.Context, b *bot.Bot, update *models.Update)
 		return
 	}
 
+	s.showResults(ctx, b, chatId, res)
+}
+
+// showResults shows slider for found media.
+func (s *search) showResults(ctx context.Context, b *bot.Bot, chatId int64, res []localModels.MediaConfig) {
 	if len(res) == 0 {
-		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:      chatId,
-			MessageID:   s.msgIdStorage.Get(chatId),
-			Text:        ctr.LibSearchErrEmptyRes,
-			ReplyMarkup: s.getSettingDataMarkup(),
-		}); err != nil {
-			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-		}
+		s.showText(ctx, b, chatId, ctr.LibSearchErrEmptyRes, s.getSettingDataMarkup())
 		return
 	}
 
 	s.mediaPageStorage.Set(chatId, 1)
 	s.mediaResultsStorage.Set(chatId, res)
+	s.bulkSelectedStorage.Del(chatId)
 	s.mediaSelectedStorage.Set(chatId, res[0])
 
-	msg, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:      chatId,
-		MessageID:   s.msgIdStorage.Get(chatId),
-		Text:        res[0].String(),
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: s.mediaSliderMarkup(1, len(res)),
-	})
-
-	if err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
-
-	s.msgIdStorage.Set(chatId, msg.ID)
+	s.showCard(ctx, b, chatId, res[0], res[0].String(), s.mediaSliderMarkup(1, len(res)))
 }
 
 func (s *search) nullHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -336,7 +514,22 @@ func (s *search) filterRepr(opt searchOption) string {
 		b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", strings.Join(opt.languages, ", ")))
 	}
 	if len(opt.moods) > 0 {
-		b.WriteString(fmt.Sprintf("<b>Настроения:</b> %s", strings.Join(opt.moods, ", ")))
+		b.WriteString(fmt.Sprintf("<b>Настроения:</b> %s\n", strings.Join(opt.moods, ", ")))
+	}
+	if opt.matchAny {
+		b.WriteString("<b>Теги:</b> любой из выбранных\n")
+	}
+	if len(opt.excluded) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Исключить:</b> %s\n", strings.Join(opt.excluded, ", ")))
+	}
+	if opt.minDur > 0 || opt.maxDur > 0 {
+		b.WriteString(fmt.Sprintf("<b>Длительность:</b> %s\n", durRangeRepr(opt.minDur, opt.maxDur)))
+	}
+	if opt.period > 0 {
+		b.WriteString(fmt.Sprintf("<b>Загружены:</b> %s\n", periodRepr[opt.period]))
+	}
+	if opt.sort != localModels.SortRelevance {
+		b.WriteString(fmt.Sprintf("<b>Сортировка:</b> %s", sortRepr[opt.sort]))
 	}
 
 	return b.String()
@@ -2,14 +2,27 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
 	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	mp3MimeType = "audio/mpeg"
+
+	// Source name of files sent to bot
+	// in cache keys, same as in upload.
+	telegramSource = "telegram"
 )
 
 func (s *search) updateSlide(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -39,40 +52,16 @@ func (s *search) updateSlide(ctx context.Context, b *bot.Bot, update *models.Upd
 	res := s.mediaResultsStorage.Get(chatId)
 	s.mediaSelectedStorage.Set(chatId, res[id-1])
 
-	msgId := s.msgIdStorage.Get(chatId)
-
-	msg, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:      chatId,
-		MessageID:   msgId,
-		Text:        res[id-1].String(),
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: s.mediaSliderMarkup(id, len(res)),
-	})
-	if err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
-
-	s.msgIdStorage.Set(chatId, msg.ID)
+	s.showCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
 }
 
 func (s *search) canceledDateTimeSelector(ctx context.Context, b *bot.Bot, mes models.MaybeInaccessibleMessage) {
-	const op = "search.canceledDateTimeSelector"
-
 	chatId := mes.Message.Chat.ID
 
 	id := s.mediaPageStorage.Get(chatId)
 	res := s.mediaResultsStorage.Get(chatId)
 
-	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      chatId,
-		Text:        res[id-1].String(),
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: s.mediaSliderMarkup(id, len(res)),
-	})
-	if err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
-	s.msgIdStorage.Set(chatId, msg.ID)
+	s.sendCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
 }
 
 func (s *search) addToQueue(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -95,13 +84,7 @@ func (s *search) addToQueue(ctx context.Context, b *bot.Bot, update *models.Upda
 		return
 	}
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:    chatId,
-		MessageID: s.msgIdStorage.Get(chatId),
-		Text:      s.successMsg(segm.Start, segm.Start.Add(segm.StopCut-segm.BeginCut)),
-	}); err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
+	s.showText(ctx, b, chatId, s.successMsg(segm.Start, segm.Start.Add(segm.StopCut-segm.BeginCut)), nil)
 }
 
 func (s *search) updateMedia(ctx context.Context, b *bot.Bot, msg models.MaybeInaccessibleMessage) {
@@ -122,49 +105,24 @@ func (s *search) updateMedia(ctx context.Context, b *bot.Bot, msg models.MaybeIn
 		return
 	}
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:    chatId,
-		MessageID: s.msgIdStorage.Get(chatId),
-		Text:      ctr.LibSearchUpdatedSuccess,
-	}); err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
+	s.showText(ctx, b, chatId, ctr.LibSearchUpdatedSuccess, nil)
 }
 
 func (s *search) closedUpdateMedia(ctx context.Context, b *bot.Bot, msg models.MaybeInaccessibleMessage) {
-	const op = "search.closedUpdateMedia"
-
 	chatId := msg.Message.Chat.ID
 
 	id := s.mediaPageStorage.Get(chatId)
 	res := s.mediaResultsStorage.Get(chatId)
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:      chatId,
-		MessageID:   s.msgIdStorage.Get(chatId),
-		Text:        res[id-1].String(),
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: s.mediaSliderMarkup(id, len(res)),
-	}); err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
+	s.showCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
 }
 
 func (s *search) deleteMedia(ctx context.Context, b *bot.Bot, update *models.Update) {
-	const op = "search.deleteMedia"
-
 	s.CallbackAnswer(ctx, b, update.CallbackQuery)
 
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:      chatId,
-		MessageID:   s.msgIdStorage.Get(chatId),
-		Text:        ctr.LibSearchDeleteSubmit,
-		ReplyMarkup: s.submitDeleteMarkup(),
-	}); err != nil {
-		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
-	}
+	s.showText(ctx, b, chatId, ctr.LibSearchDeleteSubmit, s.submitDeleteMarkup())
 }
 
 func (s *search) deleteSubmit(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -186,29 +144,151 @@ func (s *search) deleteSubmit(ctx context.Context, b *bot.Bot, update *models.Up
 
 	s.mediaResultsStorage.Set(chatId, []localModels.MediaConfig{})
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:    chatId,
-		MessageID: s.msgIdStorage.Get(chatId),
-		Text:      ctr.LibSearchDeleteSuccess,
-	}); err != nil {
+	s.showText(ctx, b, chatId, ctr.LibSearchDeleteSuccess, nil)
+}
+
+func (s *search) deleteReject(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	id := s.mediaPageStorage.Get(chatId)
+	res := s.mediaResultsStorage.Get(chatId)
+
+	s.showCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
+}
+
+func (s *search) replaceAudio(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	s.session.Redirect(chatId, s.router.Path(cmdGetAudio))
+
+	s.showText(ctx, b, chatId, ctr.LibSearchReplaceAsk, s.replaceAudioMarkup())
+}
+
+// getAudio receives mp3 file or link
+// and replaces audio of selected media.
+func (s *search) getAudio(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "search.getAudio"
+
+	chatId := update.Message.Chat.ID
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
 		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
+	defer func() {
+		if _, err := b.DeleteMessages(ctx, &bot.DeleteMessagesParams{
+			ChatID:     chatId,
+			MessageIDs: []int{update.Message.ID, inProgressMsg.ID},
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	var path string
+	switch {
+	case update.Message.Audio != nil:
+		if update.Message.Audio.MimeType != mp3MimeType {
+			s.sendMessage(ctx, b, chatId, ctr.LibUploadInvalidMimeType)
+			return
+		}
+		path, err = s.downloadTelegramFile(ctx, b, update.Message.Audio.FileID)
+		if err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+			return
+		}
+	case update.Message.Text != "":
+		res, err := s.lib.LinkDownload(ctx, chatId, strings.TrimSpace(update.Message.Text))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidLink):
+				s.sendMessage(ctx, b, chatId, ctr.LibUploadErrInvalidLink)
+			case errors.Is(err, service.ErrPreviewOnly):
+				s.sendMessage(ctx, b, chatId, ctr.LibUploadErrPreviewOnly)
+			default:
+				s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+				s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+			}
+			return
+		}
+		if res.Type != localModels.ResSong {
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchReplaceErrNotSong)
+			return
+		}
+		path = res.MediaConf.SourcePath
+	default:
+		s.sendMessage(ctx, b, chatId, ctr.LibUploadFileNotFound)
+		return
+	}
+
+	conf, err := s.lib.ReplaceSource(ctx, chatId, s.mediaSelectedStorage.Get(chatId), path)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAudio):
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchReplaceErrAudio)
+		case errors.Is(err, service.ErrMediaNotFound):
+			s.sendMessage(ctx, b, chatId, ctr.LibSearchReplaceErrNotFound)
+		default:
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			s.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	s.session.Redirect(chatId, ctr.NullStatus)
+
+	id := s.mediaPageStorage.Get(chatId)
+	res := s.mediaResultsStorage.Get(chatId)
+	res[id-1] = conf
+	s.mediaSelectedStorage.Set(chatId, conf)
+	s.audioIds.Del(conf.ID)
+
+	s.showCard(ctx, b, chatId, conf, ctr.LibSearchReplaceSuccess+"\n\n"+conf.String(), s.mediaSliderMarkup(id, len(res)))
 }
 
-func (s *search) deleteReject(ctx context.Context, b *bot.Bot, update *models.Update) {
-	const op = "search.closedUpdateMedia"
+func (s *search) replaceCancel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	s.CallbackAnswer(ctx, b, update.CallbackQuery)
 
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 
+	s.session.Redirect(chatId, ctr.NullStatus)
+
 	id := s.mediaPageStorage.Get(chatId)
 	res := s.mediaResultsStorage.Get(chatId)
 
-	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:      chatId,
-		MessageID:   s.msgIdStorage.Get(chatId),
-		Text:        res[id-1].String(),
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: s.mediaSliderMarkup(id, len(res)),
+	s.showCard(ctx, b, chatId, res[id-1], res[id-1].String(), s.mediaSliderMarkup(id, len(res)))
+}
+
+// downloadTelegramFile downloads file sent
+// to bot or returns already cached file.
+func (s *search) downloadTelegramFile(ctx context.Context, b *bot.Bot, fileId string) (string, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{
+		FileID: fileId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.FileDownloadLink(file), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// File id may change, unique id does not.
+	return s.fileCache.Download(ctx, dlcache.Key(telegramSource, file.FileUniqueID), req)
+}
+
+func (s *search) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "search.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
 	}); err != nil {
 		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
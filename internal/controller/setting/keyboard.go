@@ -75,22 +75,22 @@ func (s *setting) MainSettingsMarkup(conf localModels.MediaConfig) models.Inline
 	}
 }
 
-func (s *setting) genreChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+func (s *setting) genreChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.GenreNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Genres)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, g := range localModels.GenresAvail {
-		msg = g.String()
-		if conf.Genres[g.Id-1] {
+	for _, g := range tax.Genres {
+		msg = g.Name
+		if conf.Genres.Has(g.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("genre-%d", g.Id)
+		callback := fmt.Sprintf("genre-%d", g.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -122,22 +122,22 @@ func (s *setting) genreChooseMarkup(conf localModels.MediaConfig) models.InlineK
 	}
 }
 
-func (s *setting) moodChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+func (s *setting) moodChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Moods)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, m := range localModels.MoodsAvail {
-		msg = m.String()
-		if conf.Moods[m.Id-1] {
+	for _, m := range tax.Moods {
+		msg = m.Name
+		if conf.Moods.Has(m.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("mood-%d", m.Id)
+		callback := fmt.Sprintf("mood-%d", m.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -169,22 +169,22 @@ func (s *setting) moodChooseMarkup(conf localModels.MediaConfig) models.InlineKe
 	}
 }
 
-func (s *setting) langChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+func (s *setting) langChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 3
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Languages)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, l := range localModels.LangsAvail {
-		msg = l.String()
-		if conf.Languages[l.Id-1] {
+	for _, l := range tax.Languages {
+		msg = l.Name
+		if conf.Languages.Has(l.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("lang-%d", l.Id)
+		callback := fmt.Sprintf("lang-%d", l.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -196,7 +196,7 @@ func (s *setting) langChooseMarkup(conf localModels.MediaConfig) models.InlineKe
 		})
 	}
 
-	if len(row) < localModels.LangNumber {
+	if len(row) < rowLen {
 		row = append(row, slice.Repeat(
 			models.InlineKeyboardButton{
 				Text:         "\t",
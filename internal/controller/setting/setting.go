@@ -36,6 +36,7 @@ type setting struct {
 	onSelect ctr.OnSelectHandler
 	onCancel ctr.OnSelectHandler
 	onError  bot.ErrorsHandler
+	taxonomy Taxonomy
 
 	initialConfigStorage storage.Storage[localModels.MediaConfig]
 	mediaConfigStorage   storage.Storage[localModels.MediaConfig]
@@ -45,6 +46,17 @@ type setting struct {
 
 type OnSelect func()
 
+// Open shows settings of stored media
+// in stored message, used to start
+// editing without callback.
+type Open func(ctx context.Context, b *bot.Bot, chatId int64)
+
+// Taxonomy provides tags
+// available for selection.
+type Taxonomy interface {
+	Taxonomy(ctx context.Context, id int64) (localModels.Taxonomy, error)
+}
+
 func Register(
 	router *ctr.Router,
 	session ctr.Session,
@@ -53,13 +65,15 @@ func Register(
 	onError bot.ErrorsHandler,
 	mediaConfigStorage storage.Storage[localModels.MediaConfig],
 	msgIdStorage storage.Storage[int],
-) {
+	taxonomy Taxonomy,
+) Open {
 	s := &setting{
 		router:   router,
 		session:  session,
 		onSelect: onSelect,
 		onCancel: onCancel,
 		onError:  onError,
+		taxonomy: taxonomy,
 
 		mediaConfigStorage:   mediaConfigStorage,
 		msgIdStorage:         msgIdStorage,
@@ -79,14 +93,18 @@ func Register(
 	router.RegisterCallback(cmdClose, s.close)
 
 	router.RegisterCallback(cmdNoOp, s.nullHandler)
+
+	return s.open
 }
 
 func (s *setting) init(ctx context.Context, b *bot.Bot, update *models.Update) {
-	const op = "setting.init"
-
 	s.CallbackAnswer(ctx, b, update.CallbackQuery)
 
-	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	s.open(ctx, b, update.CallbackQuery.Message.Message.Chat.ID)
+}
+
+func (s *setting) open(ctx context.Context, b *bot.Bot, chatId int64) {
+	const op = "setting.open"
 
 	conf := s.mediaConfigStorage.Get(chatId)
 	s.initialConfigStorage.Set(chatId, conf)
@@ -245,6 +263,18 @@ func (s *setting) openCheckBox(ctx context.Context, b *bot.Bot, update *models.U
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 	callback := s.router.GetState(update.CallbackQuery.Data)
 
+	tax, err := s.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	var (
 		msg    string
 		markup models.InlineKeyboardMarkup
@@ -255,13 +285,13 @@ func (s *setting) openCheckBox(ctx context.Context, b *bot.Bot, update *models.U
 	switch callback {
 	case "genre":
 		msg = ctr.LibUploadAskGenre
-		markup = s.genreChooseMarkup(conf)
+		markup = s.genreChooseMarkup(conf, tax)
 	case "mood":
 		msg = ctr.LibUploadAskMood
-		markup = s.moodChooseMarkup(conf)
+		markup = s.moodChooseMarkup(conf, tax)
 	case "lang":
 		msg = ctr.LibUploadAskLang
-		markup = s.langChooseMarkup(conf)
+		markup = s.langChooseMarkup(conf, tax)
 	}
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
@@ -284,7 +314,7 @@ func (s *setting) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.
 	callback := s.router.GetState(update.CallbackQuery.Data)
 
 	// tagType in ('genre', 'mood', 'lang')
-	// data is tag id (from 1 to GenreNumber, MoodNumber, LangNumber)
+	// data is id of tag on server
 	tagType, data, found := strings.Cut(callback, "-")
 	if !found {
 		s.onError(fmt.Errorf("%s [%d]: invalid callback data \"%s\"", op, chatId, callback))
@@ -308,6 +338,30 @@ func (s *setting) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.
 		return
 	}
 
+	tax, err := s.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	tag, found := tax.Find(int64(id))
+	if !found {
+		s.onError(fmt.Errorf("%s [%d]: unknown tag id %d", op, chatId, id))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			s.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	conf := s.mediaConfigStorage.Get(chatId)
 
 	var (
@@ -317,17 +371,17 @@ func (s *setting) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.
 
 	switch tagType {
 	case "genre":
-		conf.Genres[id-1] = !conf.Genres[id-1]
+		conf.Genres.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskGenre
-		markup = s.genreChooseMarkup(conf)
+		markup = s.genreChooseMarkup(conf, tax)
 	case "mood":
-		conf.Moods[id-1] = !conf.Moods[id-1]
+		conf.Moods.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskMood
-		markup = s.moodChooseMarkup(conf)
+		markup = s.moodChooseMarkup(conf, tax)
 	case "lang":
-		conf.Languages[id-1] = !conf.Languages[id-1]
+		conf.Languages.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskLang
-		markup = s.langChooseMarkup(conf)
+		markup = s.langChooseMarkup(conf, tax)
 	}
 
 	s.mediaConfigStorage.Set(chatId, conf)
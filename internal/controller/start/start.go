@@ -14,6 +14,10 @@ import (
 const (
 	cmdLogin = "login"
 	cmdPass  = "pass"
+
+	// Deep link sent to unknown
+	// users of inline mode.
+	linkLogin = "login"
 )
 
 // TODO delete messages with login and password after authorization.
@@ -50,6 +54,7 @@ func Register(
 	}
 
 	router.RegisterCommand(app.init)
+	router.RegisterDeepLink(linkLogin, app.init)
 	router.RegisterHandler(cmdLogin, app.login)
 	router.RegisterHandler(cmdPass, app.pass)
 }
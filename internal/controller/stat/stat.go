@@ -3,12 +3,14 @@ package stat
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 )
 
 type stat struct {
@@ -17,6 +19,8 @@ type stat struct {
 	router  *ctr.Router
 	auth    Auth
 	stat    Stat
+	tmpDir  TmpDir
+	admins  []int64
 	onError bot.ErrorsHandler
 
 	msgIdStorage storage.Storage[int]
@@ -30,16 +34,24 @@ type Stat interface {
 	ListenersNumber(ctx context.Context, id int64) (int64, error)
 }
 
+type TmpDir interface {
+	Usage() localModels.TmpUsage
+}
+
 func Register(
 	router *ctr.Router,
 	auth Auth,
 	statSrv Stat,
+	tmpDir TmpDir,
+	admins []int64,
 	onError bot.ErrorsHandler,
 ) {
 	s := &stat{
 		router:  router,
 		auth:    auth,
 		stat:    statSrv,
+		tmpDir:  tmpDir,
+		admins:  admins,
 		onError: onError,
 
 		msgIdStorage: storage.New[int](),
@@ -74,9 +86,14 @@ func (s *stat) init(ctx context.Context, b *bot.Bot, update *models.Update) {
 		return
 	}
 
+	text := s.formatListenersNumber(N)
+	if slices.Contains(s.admins, chatId) {
+		text += "\n\n" + s.tmpDir.Usage().String()
+	}
+
 	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatId,
-		Text:      s.formatListenersNumber(N),
+		Text:      text,
 		ParseMode: models.ParseModeHTML,
 	})
 
@@ -0,0 +1,208 @@
+package tags
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-telegram/bot/models"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	butMsgRename = "Переименовать"
+	butMsgMerge  = "Объединить с..."
+	butMsgDelete = "Удалить"
+	butMsgMerged = "Объединить"
+	butMsgMeta   = "Изменить: %s"
+	butMsgPrev   = "<<"
+	butMsgNext   = ">>"
+	butMsgBack   = "Назад"
+	butMsgCancel = "Отмена"
+	butMsgTag    = "%s (%d)"
+)
+
+const (
+	pageSize = 20
+	rowLen   = 2
+)
+
+// Tag types available for management.
+// Formats are fixed by server.
+var typesAvail = []string{"genre", "mood", "language", "playlist", "album", "podcast"}
+
+var typeNames = map[string]string{
+	"genre":    "Жанры",
+	"mood":     "Настроения",
+	"language": "Языки",
+	"playlist": "Плейлисты",
+	"album":    "Альбомы",
+	"podcast":  "Подкасты",
+}
+
+var metaNames = map[string]string{
+	"author": "автор",
+	"year":   "год",
+	"label":  "лейбл",
+}
+
+func typeName(tagType string) string {
+	if name, ok := typeNames[tagType]; ok {
+		return name
+	}
+	return tagType
+}
+
+func metaName(key string) string {
+	if name, ok := metaNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+func (t *tags) typesMarkup() models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(typesAvail)/rowLen+1)
+	row := make([]models.InlineKeyboardButton, 0, rowLen)
+
+	for _, tagType := range typesAvail {
+		if len(row) == rowLen {
+			rows = append(rows, row)
+			row = make([]models.InlineKeyboardButton, 0, rowLen)
+		}
+		row = append(row, models.InlineKeyboardButton{
+			Text:         typeName(tagType),
+			CallbackData: t.router.PathPrefixState(cmdType, tagType+"-0"),
+		})
+	}
+	rows = append(rows, row)
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+// typeMarkup returns page of tags
+// with navigation buttons.
+func (t *tags) typeMarkup(tagType string, usage []localModels.TagUsage, page int) models.InlineKeyboardMarkup {
+	pages := (len(usage) + pageSize - 1) / pageSize
+	page = max(0, min(page, pages-1))
+
+	start := page * pageSize
+	end := min(start+pageSize, len(usage))
+
+	rows := make([][]models.InlineKeyboardButton, 0, pageSize/rowLen+2)
+	row := make([]models.InlineKeyboardButton, 0, rowLen)
+
+	for _, u := range usage[start:end] {
+		if len(row) == rowLen {
+			rows = append(rows, row)
+			row = make([]models.InlineKeyboardButton, 0, rowLen)
+		}
+		row = append(row, models.InlineKeyboardButton{
+			Text:         fmt.Sprintf(butMsgTag, u.Tag.Name, u.Count),
+			CallbackData: t.router.PathPrefixState(cmdTag, strconv.FormatInt(u.Tag.ID, 10)),
+		})
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	nav := make([]models.InlineKeyboardButton, 0, 3)
+	if page > 0 {
+		nav = append(nav, models.InlineKeyboardButton{
+			Text:         butMsgPrev,
+			CallbackData: t.router.PathPrefixState(cmdType, fmt.Sprintf("%s-%d", tagType, page-1)),
+		})
+	}
+	nav = append(nav, models.InlineKeyboardButton{
+		Text:         butMsgBack,
+		CallbackData: t.router.Path(cmdTypes),
+	})
+	if page < pages-1 {
+		nav = append(nav, models.InlineKeyboardButton{
+			Text:         butMsgNext,
+			CallbackData: t.router.PathPrefixState(cmdType, fmt.Sprintf("%s-%d", tagType, page+1)),
+		})
+	}
+	rows = append(rows, nav)
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (t *tags) tagMarkup(u localModels.TagUsage) models.InlineKeyboardMarkup {
+	id := strconv.FormatInt(u.Tag.ID, 10)
+
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: butMsgRename, CallbackData: t.router.PathPrefixState(cmdRename, id)},
+			{Text: butMsgMerge, CallbackData: t.router.PathPrefixState(cmdMerge, id)},
+		},
+	}
+
+	for _, key := range localModels.TagMetaKeys[u.Tag.Type.Name] {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf(butMsgMeta, metaName(key)),
+			CallbackData: t.router.PathPrefixState(cmdMeta, id+"-"+key),
+		}})
+	}
+
+	last := make([]models.InlineKeyboardButton, 0, 2)
+	// Only unused tags can be deleted.
+	if u.Count == 0 {
+		last = append(last, models.InlineKeyboardButton{
+			Text:         butMsgDelete,
+			CallbackData: t.router.PathPrefixState(cmdDelete, id+"-"+u.Tag.Type.Name),
+		})
+	}
+	last = append(last, models.InlineKeyboardButton{
+		Text:         butMsgBack,
+		CallbackData: t.router.PathPrefixState(cmdType, u.Tag.Type.Name+"-0"),
+	})
+	rows = append(rows, last)
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (t *tags) targetsMarkup(fromId int64, targets []localModels.TagUsage) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(targets)+1)
+
+	for _, u := range targets {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf(butMsgTag, u.Tag.Name, u.Count),
+			CallbackData: t.router.PathPrefixState(cmdMergeAsk, fmt.Sprintf("%d-%d", fromId, u.Tag.ID)),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgCancel,
+		CallbackData: t.router.PathPrefixState(cmdTag, strconv.FormatInt(fromId, 10)),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (t *tags) mergeSubmitMarkup(fromId, toId int64) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgMerged, CallbackData: t.router.PathPrefixState(cmdMergeDo, fmt.Sprintf("%d-%d", fromId, toId))},
+				{Text: butMsgCancel, CallbackData: t.router.PathPrefixState(cmdTag, strconv.FormatInt(fromId, 10))},
+			},
+		},
+	}
+}
+
+func (t *tags) backTagMarkup(tagId int64) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: t.router.PathPrefixState(cmdTag, strconv.FormatInt(tagId, 10))},
+			},
+		},
+	}
+}
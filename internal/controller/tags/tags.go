@@ -0,0 +1,598 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase      ctr.Command = ""
+	cmdTypes     ctr.Command = "types"
+	cmdType      ctr.Command = "type"
+	cmdTag       ctr.Command = "tag"
+	cmdRename    ctr.Command = "rename"
+	cmdGetName   ctr.Command = "get-name"
+	cmdMerge     ctr.Command = "merge"
+	cmdGetTarget ctr.Command = "get-target"
+	cmdMergeAsk  ctr.Command = "merge-ask"
+	cmdMergeDo   ctr.Command = "merge-do"
+	cmdMeta      ctr.Command = "meta"
+	cmdGetMeta   ctr.Command = "get-meta"
+	cmdDelete    ctr.Command = "delete"
+)
+
+// Max number of merge targets shown.
+const maxTargets = 10
+
+type tags struct {
+	ctr.CallbackAnswerer
+
+	router  *ctr.Router
+	auth    Auth
+	tags    Tags
+	session ctr.Session
+	onError bot.ErrorsHandler
+
+	msgIdStorage   storage.Storage[int]
+	tagIdStorage   storage.Storage[int64]
+	metaKeyStorage storage.Storage[string]
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type Tags interface {
+	TagUsage(ctx context.Context, id int64, tagType string) ([]localModels.TagUsage, error)
+	Tag(ctx context.Context, id int64, tagId int64) (localModels.TagUsage, error)
+	RenameTag(ctx context.Context, id int64, tagId int64, name string) (localModels.Tag, error)
+	SetTagMeta(ctx context.Context, id int64, tagId int64, key, val string) (localModels.Tag, error)
+	MergeTags(ctx context.Context, id int64, fromId, toId int64) (int, error)
+	DeleteTag(ctx context.Context, id int64, tagId int64) error
+}
+
+// Register registers "/tags" command.
+func Register(
+	router *ctr.Router,
+	auth Auth,
+	tagsSrv Tags,
+	session ctr.Session,
+	onError bot.ErrorsHandler,
+) {
+	t := &tags{
+		router:  router,
+		auth:    auth,
+		tags:    tagsSrv,
+		session: session,
+		onError: onError,
+
+		msgIdStorage:   storage.New[int](),
+		tagIdStorage:   storage.New[int64](),
+		metaKeyStorage: storage.New[string](),
+	}
+
+	router.RegisterCommand(t.init)
+	router.RegisterCallback(cmdTypes, t.types)
+	router.RegisterCallbackPrefix(cmdType, t.tagType)
+	router.RegisterCallbackPrefix(cmdTag, t.tag)
+	router.RegisterCallbackPrefix(cmdRename, t.rename)
+	router.RegisterHandler(cmdGetName, t.getName)
+	router.RegisterCallbackPrefix(cmdMerge, t.merge)
+	router.RegisterHandler(cmdGetTarget, t.getTarget)
+	router.RegisterCallbackPrefix(cmdMergeAsk, t.mergeAsk)
+	router.RegisterCallbackPrefix(cmdMergeDo, t.mergeDo)
+	router.RegisterCallbackPrefix(cmdMeta, t.meta)
+	router.RegisterHandler(cmdGetMeta, t.getMeta)
+	router.RegisterCallbackPrefix(cmdDelete, t.delete)
+}
+
+func (t *tags) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !t.auth.IsKnown(ctx, chatId) {
+		t.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        ctr.TagsInit,
+		ReplyMarkup: t.typesMarkup(),
+	})
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	t.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (t *tags) types(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.types"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+	t.msgIdStorage.Set(chatId, msgId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        ctr.TagsInit,
+		ReplyMarkup: t.typesMarkup(),
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// tagType shows page of tags of one type.
+// State is "<type>-<page>".
+func (t *tags) tagType(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.tagType"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	tagType, pageStr, _ := strings.Cut(t.router.GetState(update.CallbackQuery.Data), "-")
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+	t.msgIdStorage.Set(chatId, msgId)
+
+	t.showType(ctx, b, chatId, tagType, page)
+}
+
+func (t *tags) tag(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.tag"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	tagId, err := strconv.ParseInt(t.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+	t.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	t.showTag(ctx, b, chatId, tagId)
+}
+
+func (t *tags) rename(ctx context.Context, b *bot.Bot, update *models.Update) {
+	t.askInput(ctx, b, update, cmdGetName, ctr.TagsAskName)
+}
+
+func (t *tags) getName(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.getName"
+
+	chatId := update.Message.Chat.ID
+	t.deleteMessage(ctx, b, chatId, update.Message.ID)
+
+	name := strings.TrimSpace(update.Message.Text)
+	if name == "" {
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrEmptyMsg)
+		return
+	}
+
+	tagId := t.tagIdStorage.Get(chatId)
+
+	if _, err := t.tags.RenameTag(ctx, chatId, tagId, name); err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+	t.tagIdStorage.Del(chatId)
+
+	t.showTag(ctx, b, chatId, tagId)
+}
+
+func (t *tags) merge(ctx context.Context, b *bot.Bot, update *models.Update) {
+	t.askInput(ctx, b, update, cmdGetTarget, ctr.TagsAskTarget)
+}
+
+// getTarget shows tags of the same type
+// matching entered name as merge targets.
+func (t *tags) getTarget(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.getTarget"
+
+	chatId := update.Message.Chat.ID
+	t.deleteMessage(ctx, b, chatId, update.Message.ID)
+
+	query := strings.ToLower(strings.TrimSpace(update.Message.Text))
+	if query == "" {
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrEmptyMsg)
+		return
+	}
+
+	from, err := t.tags.Tag(ctx, chatId, t.tagIdStorage.Get(chatId))
+	if err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	all, err := t.tags.TagUsage(ctx, chatId, from.Tag.Type.Name)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	targets := make([]localModels.TagUsage, 0, maxTargets)
+	for _, u := range all {
+		if u.Tag.ID == from.Tag.ID || !strings.Contains(strings.ToLower(u.Tag.Name), query) {
+			continue
+		}
+		targets = append(targets, u)
+	}
+	// Exact match goes first.
+	slices.SortStableFunc(targets, func(a, b localModels.TagUsage) int {
+		aExact, bExact := strings.ToLower(a.Tag.Name) == query, strings.ToLower(b.Tag.Name) == query
+		switch {
+		case aExact && !bExact:
+			return -1
+		case !aExact && bExact:
+			return 1
+		}
+		return 0
+	})
+	if len(targets) > maxTargets {
+		targets = targets[:maxTargets]
+	}
+
+	if len(targets) == 0 {
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrTargetMissing)
+		return
+	}
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   t.msgIdStorage.Get(chatId),
+		Text:        ctr.TagsSelectTarget,
+		ReplyMarkup: t.targetsMarkup(from.Tag.ID, targets),
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// mergeAsk asks to submit merge.
+// State is "<from>-<to>".
+func (t *tags) mergeAsk(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.mergeAsk"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	fromId, toId, err := parsePair(t.router.GetState(update.CallbackQuery.Data))
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	from, err := t.tags.Tag(ctx, chatId, fromId)
+	if err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+	to, err := t.tags.Tag(ctx, chatId, toId)
+	if err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        fmt.Sprintf(ctr.TagsMergeSubmit, from.Count, from.Tag.Name, to.Tag.Name, from.Tag.Name),
+		ReplyMarkup: t.mergeSubmitMarkup(fromId, toId),
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (t *tags) mergeDo(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.mergeDo"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	fromId, toId, err := parsePair(t.router.GetState(update.CallbackQuery.Data))
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	t.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatId,
+		MessageID: t.msgIdStorage.Get(chatId),
+		Text:      ctr.InProgress,
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+
+	retagged, err := t.tags.MergeTags(ctx, chatId, fromId, toId)
+	if err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	t.sendMessage(ctx, b, chatId, fmt.Sprintf(ctr.TagsMerged, retagged))
+	t.showTag(ctx, b, chatId, toId)
+}
+
+// meta asks value of tag meta.
+// State is "<id>-<key>".
+func (t *tags) meta(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.meta"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	idStr, key, _ := strings.Cut(t.router.GetState(update.CallbackQuery.Data), "-")
+	tagId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	t.session.Redirect(chatId, t.router.Path(cmdGetMeta))
+	t.msgIdStorage.Set(chatId, msgId)
+	t.tagIdStorage.Set(chatId, tagId)
+	t.metaKeyStorage.Set(chatId, key)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        fmt.Sprintf(ctr.TagsAskMeta, metaName(key)),
+		ReplyMarkup: t.backTagMarkup(tagId),
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (t *tags) getMeta(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.getMeta"
+
+	chatId := update.Message.Chat.ID
+	t.deleteMessage(ctx, b, chatId, update.Message.ID)
+
+	val := strings.TrimSpace(update.Message.Text)
+	if val == "" {
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrEmptyMsg)
+		return
+	}
+	if val == "-" {
+		val = ""
+	}
+
+	tagId := t.tagIdStorage.Get(chatId)
+
+	if _, err := t.tags.SetTagMeta(ctx, chatId, tagId, t.metaKeyStorage.Get(chatId), val); err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	t.session.Redirect(chatId, ctr.NullStatus)
+	t.tagIdStorage.Del(chatId)
+	t.metaKeyStorage.Del(chatId)
+
+	t.showTag(ctx, b, chatId, tagId)
+}
+
+// delete deletes unused tag.
+// State is "<id>-<type>".
+func (t *tags) delete(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "tags.delete"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	idStr, tagType, _ := strings.Cut(t.router.GetState(update.CallbackQuery.Data), "-")
+	tagId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := t.tags.DeleteTag(ctx, chatId, tagId); err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	t.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	t.sendMessage(ctx, b, chatId, ctr.TagsDeleted)
+	t.showType(ctx, b, chatId, tagType, 0)
+}
+
+// askInput redirects to text handler
+// asking input for tag from callback state.
+func (t *tags) askInput(ctx context.Context, b *bot.Bot, update *models.Update, cmd ctr.Command, text string) {
+	const op = "tags.askInput"
+
+	t.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	tagId, err := strconv.ParseInt(t.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	t.session.Redirect(chatId, t.router.Path(cmd))
+	t.msgIdStorage.Set(chatId, msgId)
+	t.tagIdStorage.Set(chatId, tagId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        text,
+		ReplyMarkup: t.backTagMarkup(tagId),
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// showType updates stored message
+// with page of tags of given type.
+func (t *tags) showType(ctx context.Context, b *bot.Bot, chatId int64, tagType string, page int) {
+	const op = "tags.showType"
+
+	usage, err := t.tags.TagUsage(ctx, chatId, tagType)
+	if err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	text := ctr.TagsEmpty
+	if len(usage) > 0 {
+		text = fmt.Sprintf(ctr.TagsList, typeName(tagType), len(usage))
+	}
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   t.msgIdStorage.Get(chatId),
+		Text:        text,
+		ReplyMarkup: t.typeMarkup(tagType, usage, page),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// showTag updates stored message with tag card.
+func (t *tags) showTag(ctx context.Context, b *bot.Bot, chatId int64, tagId int64) {
+	const op = "tags.showTag"
+
+	u, err := t.tags.Tag(ctx, chatId, tagId)
+	if err != nil {
+		t.handleErr(ctx, b, chatId, op, err)
+		return
+	}
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   t.msgIdStorage.Get(chatId),
+		Text:        tagRepr(u),
+		ReplyMarkup: t.tagMarkup(u),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (t *tags) handleErr(ctx context.Context, b *bot.Bot, chatId int64, op string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTagNotFound):
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrNotFound)
+	case errors.Is(err, service.ErrTagExists):
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrExists)
+	case errors.Is(err, service.ErrTagInUse):
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrInUse)
+	case errors.Is(err, service.ErrInvalidYear):
+		t.sendMessage(ctx, b, chatId, ctr.TagsErrInvalidYear)
+	default:
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		t.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+func tagRepr(u localModels.TagUsage) string {
+	var b strings.Builder
+
+	b.WriteString("<b>" + html.EscapeString(u.Tag.Name) + "</b>\n")
+	b.WriteString(fmt.Sprintf("Тип: %s\n", typeName(u.Tag.Type.Name)))
+	b.WriteString(fmt.Sprintf("Композиций: %d\n", u.Count))
+
+	for _, key := range localModels.TagMetaKeys[u.Tag.Type.Name] {
+		if v := u.Tag.Meta[key]; v != "" {
+			b.WriteString(fmt.Sprintf("%s: %s\n", metaName(key), html.EscapeString(v)))
+		}
+	}
+
+	return b.String()
+}
+
+func parsePair(state string) (int64, int64, error) {
+	aStr, bStr, _ := strings.Cut(state, "-")
+
+	a, err := strconv.ParseInt(aStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := strconv.ParseInt(bStr, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return a, b, nil
+}
+
+func (t *tags) deleteMessage(ctx context.Context, b *bot.Bot, chatId int64, msgId int) {
+	const op = "tags.deleteMessage"
+
+	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatId,
+		MessageID: msgId,
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (t *tags) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "tags.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		t.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -0,0 +1,442 @@
+package upload
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/slice"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Maximum number of files in one archive.
+	maxArchiveFiles = 100
+	// Maximum size of one extracted file.
+	maxArchiveEntrySize = 50 << 20
+	// Maximum size of all files extracted from one archive.
+	maxArchiveSize = 500 << 20
+	// Maximum number of tracks listed in review.
+	maxBulkLines = 30
+	// Owner of extracted files in temporary directory.
+	archiveOwner = "archive"
+	// Extracted files are kept while upload
+	// is reviewed and then while it is processed.
+	archiveTTL = 24 * time.Hour
+)
+
+var (
+	errUnknownArchive  = errors.New("unknown archive format")
+	errArchiveTooLarge = errors.New("archive is too large")
+)
+
+// bulkFile is an audio file
+// received for bulk upload.
+type bulkFile struct {
+	path string
+	name string
+}
+
+func (u *upload) bulkUpload(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.bulkUpload"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	u.session.Redirect(chatId, u.router.Path(cmdBulkFile))
+	u.linkTypeStorage.Set(chatId, localModels.ResBulk)
+	u.mediaConfigStorage.Set(chatId, localModels.MediaConfig{})
+
+	u.bulkMutex.Lock()
+	u.dropBulkLocked(chatId)
+	u.bulkMutex.Unlock()
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        ctr.LibUploadAskBulk,
+		ReplyMarkup: u.bulkReceiveMarkup(),
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// bulkFile receives audio or archive with audio.
+// Media group is delivered as several
+// messages, each one is handled here.
+func (u *upload) bulkFile(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.bulkFile"
+
+	chatId := update.Message.Chat.ID
+
+	var (
+		files     []bulkFile
+		extracted bool
+	)
+
+	switch {
+	case update.Message.Audio != nil && update.Message.Audio.MimeType == mp3MimeType:
+		path, err := u.downloadTelegramFile(ctx, b, update.Message.Audio.FileID)
+		if errors.Is(err, tmpdir.ErrQuotaExceeded) {
+			u.sendError(ctx, b, chatId, ctr.LibUploadErrTmpQuota)
+			return
+		}
+		if err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+			return
+		}
+		files = append(files, bulkFile{path: path, name: update.Message.Audio.FileName})
+	case update.Message.Document != nil && isArchive(update.Message.Document.FileName):
+		path, err := u.downloadTelegramFile(ctx, b, update.Message.Document.FileID)
+		if errors.Is(err, tmpdir.ErrQuotaExceeded) {
+			u.sendError(ctx, b, chatId, ctr.LibUploadErrTmpQuota)
+			return
+		}
+		if err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+			return
+		}
+		files, err = u.extractArchive(path, update.Message.Document.FileName)
+		// Only extracted files are needed.
+		if err := u.fileCache.Remove(telegramKey(update.Message.Document.FileUniqueID)); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		if errors.Is(err, tmpdir.ErrQuotaExceeded) {
+			u.sendError(ctx, b, chatId, ctr.LibUploadErrTmpQuota)
+			return
+		}
+		if errors.Is(err, errArchiveTooLarge) {
+			u.sendError(ctx, b, chatId, ctr.LibUploadBulkArchiveTooLarge)
+			return
+		}
+		if err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			u.sendError(ctx, b, chatId, ctr.LibUploadBulkInvalidArchive)
+			return
+		}
+		extracted = true
+	default:
+		u.sendError(ctx, b, chatId, ctr.LibUploadBulkNotFound)
+		return
+	}
+
+	confs := make([]localModels.MediaConfig, 0, len(files))
+	for _, f := range files {
+		// Cached files are kept
+		// until upload is submitted.
+		if !extracted {
+			u.fileCache.Pin(f.path)
+		}
+		confs = append(confs, u.probeFile(ctx, f.path, f.name))
+	}
+
+	u.bulkMutex.Lock()
+	values := append(u.bulkStorage.Get(chatId), confs...)
+	u.bulkStorage.Set(chatId, values)
+	if extracted {
+		tmp := u.bulkTmpStorage.Get(chatId)
+		for _, f := range files {
+			tmp = append(tmp, f.path)
+		}
+		u.bulkTmpStorage.Set(chatId, tmp)
+	}
+	u.bulkMutex.Unlock()
+
+	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+		ChatID:    chatId,
+		MessageID: update.Message.ID,
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        fmt.Sprintf(ctr.LibUploadBulkReceived, len(values)),
+		ReplyMarkup: u.bulkReceiveMarkup(),
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// bulkDone finishes receiving files
+// and shows review screen.
+func (u *upload) bulkDone(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.bulkDone"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	u.bulkMutex.Lock()
+	n := len(u.bulkStorage.Get(chatId))
+	u.bulkMutex.Unlock()
+
+	if n == 0 {
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   u.msgIdStorage.Get(chatId),
+			Text:        ctr.LibUploadBulkEmpty,
+			ReplyMarkup: u.bulkReceiveMarkup(),
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	u.session.Redirect(chatId, ctr.NullStatus)
+
+	text, markup := u.confView(chatId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        text,
+		ReplyMarkup: markup,
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// bulkRepr returns review of bulk upload:
+// list of tracks and shared tags.
+func (u *upload) bulkRepr(shared localModels.MediaConfig, values []localModels.MediaConfig) string {
+	var b strings.Builder
+
+	totalDur := time.Duration(0)
+	for _, v := range values {
+		totalDur += v.Duration
+	}
+
+	b.WriteString("<b>Пакетная загрузка</b>\n")
+	b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", len(values)))
+	b.WriteString(fmt.Sprintf("<b>Общая длительность:</b> %s\n\n", totalDur.Round(time.Second).String()))
+
+	for i, v := range values {
+		if i == maxBulkLines {
+			b.WriteString(fmt.Sprintf("... и еще %d\n", len(values)-maxBulkLines))
+			break
+		}
+		b.WriteString(fmt.Sprintf("%d. %s — %s (%s)\n", i+1, v.Author, v.Name, v.Duration.Round(time.Second).String()))
+	}
+
+	b.WriteString("\n<b>Общие теги</b>\n")
+	b.WriteString(fmt.Sprintf("<b>Формат:</b> %s\n", shared.Format))
+	if len(shared.Albums) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Альбомы:</b> %s\n", slice.Join(shared.Albums, ", ")))
+	}
+	if len(shared.Podcasts) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Подкасты:</b> %s\n", strings.Join(shared.Podcasts, ", ")))
+	}
+	if len(shared.Playlists) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Плейлисты:</b> %s\n", strings.Join(shared.Playlists, ", ")))
+	}
+	b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", shared.Genres))
+	b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", shared.Languages))
+	b.WriteString(fmt.Sprintf("<b>Настроение:</b> %s\n", shared.Moods))
+
+	return b.String()
+}
+
+// extractArchive extracts mp3 files
+// from zip or tar (possibly gzipped) archive.
+func (u *upload) extractArchive(path, name string) ([]bulkFile, error) {
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return u.extractZip(path)
+	case strings.HasSuffix(lower, ".tar"):
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return u.extractTar(f)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return u.extractTar(gz)
+	default:
+		return nil, errUnknownArchive
+	}
+}
+
+func (u *upload) extractZip(path string) ([]bulkFile, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	files := make([]bulkFile, 0)
+	left := int64(maxArchiveSize)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isMp3(f.Name) {
+			continue
+		}
+		if len(files) == maxArchiveFiles {
+			break
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			u.releaseFiles(files)
+			return nil, err
+		}
+		out, n, err := u.saveArchiveEntry(rc, left)
+		rc.Close()
+		if err != nil {
+			u.releaseFiles(files)
+			return nil, err
+		}
+		left -= n
+
+		files = append(files, bulkFile{path: out, name: filepath.Base(f.Name)})
+	}
+
+	return files, nil
+}
+
+func (u *upload) extractTar(r io.Reader) ([]bulkFile, error) {
+	tr := tar.NewReader(r)
+
+	files := make([]bulkFile, 0)
+	left := int64(maxArchiveSize)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			u.releaseFiles(files)
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !isMp3(header.Name) {
+			continue
+		}
+		if len(files) == maxArchiveFiles {
+			break
+		}
+
+		out, n, err := u.saveArchiveEntry(tr, left)
+		if err != nil {
+			u.releaseFiles(files)
+			return nil, err
+		}
+		left -= n
+
+		files = append(files, bulkFile{path: out, name: filepath.Base(header.Name)})
+	}
+
+	return files, nil
+}
+
+// saveArchiveEntry extracts file to temporary directory.
+// File is limited by maxArchiveEntrySize and by left
+// bytes of archive, errArchiveTooLarge is returned
+// if limit is reached. Returns number of written bytes.
+func (u *upload) saveArchiveEntry(r io.Reader, left int64) (string, int64, error) {
+	limit := min(int64(maxArchiveEntrySize), left)
+
+	// Extracted files are not cached, they are
+	// released if upload is dropped or expire.
+	out, err := u.tmpDir.Create(archiveOwner, "media-upload-*.mp3", archiveTTL)
+	if err != nil {
+		return "", 0, err
+	}
+	defer out.Close()
+
+	// One extra byte shows that limit is exceeded.
+	n, err := io.Copy(u.tmpDir.Limit(out), io.LimitReader(r, limit+1))
+	if err == nil && n > limit {
+		err = errArchiveTooLarge
+	}
+	if err != nil {
+		u.tmpDir.Release(out.Name())
+		return "", 0, err
+	}
+
+	return out.Name(), n, nil
+}
+
+// dropBulkLocked deletes received files of bulk upload,
+// so they may be evicted from cache. Extracted files
+// are removed. Must be called with locked bulkMutex.
+func (u *upload) dropBulkLocked(chatId int64) {
+	for _, conf := range u.bulkStorage.Get(chatId) {
+		u.fileCache.Unpin(conf.SourcePath)
+	}
+	for _, path := range u.bulkTmpStorage.Get(chatId) {
+		u.tmpDir.Release(path)
+	}
+	u.bulkStorage.Del(chatId)
+	u.bulkTmpStorage.Del(chatId)
+}
+
+// submitBulkLocked passes extracted files of
+// bulk upload to job, so they are kept until
+// it is processed. Must be called with locked bulkMutex.
+func (u *upload) submitBulkLocked(chatId int64) {
+	for _, path := range u.bulkTmpStorage.Get(chatId) {
+		u.tmpDir.Extend(path, archiveTTL)
+	}
+	u.bulkTmpStorage.Del(chatId)
+}
+
+// releaseFiles removes already extracted
+// files of broken archive.
+func (u *upload) releaseFiles(files []bulkFile) {
+	for _, f := range files {
+		u.tmpDir.Release(f.path)
+	}
+}
+
+func (u *upload) sendError(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "upload.sendError"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMp3(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".mp3" && !strings.HasPrefix(filepath.Base(name), ".")
+}
@@ -0,0 +1,156 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+)
+
+func (u *upload) catalogSearch(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.catalogSearch"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	u.session.Redirect(chatId, u.router.Path(cmdCatalogQuery))
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        ctr.LibUploadAskCatalogQuery,
+		ReplyMarkup: u.cancelMarkup(),
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (u *upload) catalogQuery(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.catalogQuery"
+
+	chatId := update.Message.Chat.ID
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessages(ctx, &bot.DeleteMessagesParams{
+			ChatID:     chatId,
+			MessageIDs: []int{update.Message.ID, inProgressMsg.ID},
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	items, err := u.mediaUpload.CatalogSearch(ctx, chatId, update.Message.Text)
+	if err != nil {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	if len(items) == 0 {
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   u.msgIdStorage.Get(chatId),
+			Text:        ctr.LibUploadCatalogEmpty,
+			ReplyMarkup: u.cancelMarkup(),
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	u.session.Redirect(chatId, ctr.NullStatus)
+	u.catalogStorage.Set(chatId, items)
+	u.catalogPageStorage.Set(chatId, 1)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        items[0].String(),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: u.catalogSliderMarkup(1, len(items), items[0]),
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (u *upload) catalogSlide(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.catalogSlide"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	direction := u.router.GetState(update.CallbackQuery.Data)
+
+	items := u.catalogStorage.Get(chatId)
+	id := u.catalogPageStorage.Get(chatId)
+	switch direction {
+	case "prev":
+		id--
+	case "next":
+		id++
+	}
+	if id < 1 || id > len(items) {
+		return
+	}
+	u.catalogPageStorage.Set(chatId, id)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   u.msgIdStorage.Get(chatId),
+		Text:        items[id-1].String(),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: u.catalogSliderMarkup(id, len(items), items[id-1]),
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// catalogImport imports selected item
+// the same way as link sent by user.
+func (u *upload) catalogImport(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.catalogImport"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	items := u.catalogStorage.Get(chatId)
+	id := u.catalogPageStorage.Get(chatId)
+	if id < 1 || id > len(items) {
+		return
+	}
+	item := items[id-1]
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatId,
+			MessageID: inProgressMsg.ID,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	u.handleLink(ctx, b, chatId, item.Link)
+}
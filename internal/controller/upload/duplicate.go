@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Maximum number of cards shown for single media.
+	maxDupCards = 3
+	// Maximum number of lines shown for album/playlist.
+	maxDupLines = 20
+)
+
+// possibleDuplicates returns message with possible
+// duplicates for media prepared to upload.
+func (u *upload) possibleDuplicates(ctx context.Context, chatId int64) (string, bool) {
+	const op = "upload.possibleDuplicates"
+
+	var values []localModels.MediaConfig
+
+	res := u.linkDownloadResStorage.Get(chatId)
+
+	switch u.linkTypeStorage.Get(chatId) {
+	case localModels.ResSong:
+		conf := u.mediaConfigStorage.Get(chatId)
+
+		dups, err := u.mediaUpload.Duplicates(ctx, chatId, conf)
+		if err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			return "", false
+		}
+		if len(dups) == 0 {
+			return "", false
+		}
+
+		var b strings.Builder
+
+		b.WriteString(ctr.LibUploadPossibleDuplicates + "\n\n")
+		for i, d := range dups {
+			if i == maxDupCards {
+				b.WriteString(fmt.Sprintf("... и еще %d\n", len(dups)-maxDupCards))
+				break
+			}
+			b.WriteString(d.String() + "\n")
+		}
+
+		return b.String(), true
+	case localModels.ResAlbum:
+		values = res.Album.Values
+	case localModels.ResPlaylist:
+		values = res.Playlist.Values
+	case localModels.ResArtist:
+		values = res.Artist.Values
+	case localModels.ResBulk:
+		u.bulkMutex.Lock()
+		values = u.bulkStorage.Get(chatId)
+		u.bulkMutex.Unlock()
+	}
+
+	lines := make([]string, 0)
+	for _, v := range values {
+		dups, err := u.mediaUpload.Duplicates(ctx, chatId, v)
+		if err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			return "", false
+		}
+		if len(dups) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf(
+			"<b>%s</b> — %s ≈ %s — %s",
+			v.Name, v.Author, dups[0].Name, dups[0].Author,
+		))
+	}
+
+	if len(lines) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+
+	b.WriteString(ctr.LibUploadPossibleDuplicates + "\n\n")
+	for i, l := range lines {
+		if i == maxDupLines {
+			b.WriteString(fmt.Sprintf("... и еще %d\n", len(lines)-maxDupLines))
+			break
+		}
+		b.WriteString(l + "\n")
+	}
+
+	return b.String(), true
+}
@@ -0,0 +1,136 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Maximum number of items
+	// listed in job progress.
+	maxJobLines = 30
+)
+
+// submitJob starts uploading album, playlist,
+// artist or bulk media in background.
+func (u *upload) submitJob(ctx context.Context, b *bot.Bot, chatId int64) {
+	const op = "upload.submitJob"
+
+	var (
+		title  string
+		values []localModels.MediaConfig
+	)
+
+	res := u.linkDownloadResStorage.Get(chatId)
+
+	switch u.linkTypeStorage.Get(chatId) {
+	case localModels.ResAlbum:
+		title = fmt.Sprintf("Альбом \"%s\"", res.Album.Name)
+		if res.Album.Format == localModels.Podcast {
+			title = fmt.Sprintf("Подкаст \"%s\"", res.Album.Name)
+		}
+		values = res.Album.Values
+	case localModels.ResArtist:
+		title = fmt.Sprintf("Исполнитель \"%s\"", res.Artist.Name)
+		values = res.Artist.Values
+	case localModels.ResPlaylist:
+		title = fmt.Sprintf("Плейлист \"%s\"", res.Playlist.Name)
+		values = res.Playlist.Values
+	case localModels.ResBulk:
+		shared := u.mediaConfigStorage.Get(chatId)
+
+		u.bulkMutex.Lock()
+		for _, v := range u.bulkStorage.Get(chatId) {
+			values = append(values, v.WithShared(shared))
+		}
+		u.bulkMutex.Unlock()
+
+		title = fmt.Sprintf("Пакетная загрузка (%d)", len(values))
+	}
+
+	if err := u.mediaUpload.PrepareUpload(ctx, chatId, values); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	items := make([]localModels.JobItem, 0, len(values))
+	for _, v := range values {
+		items = append(items, localModels.JobItem{Conf: v})
+	}
+
+	msgId := u.msgIdStorage.Get(chatId)
+
+	job, err := u.jobs.Submit(ctx, chatId, localModels.JobUpload, title, items, u.jobProgress(ctx, b, chatId, msgId))
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	u.bulkMutex.Lock()
+	u.submitBulkLocked(chatId)
+	u.bulkMutex.Unlock()
+	u.clearStorages(chatId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        job.String(),
+		ReplyMarkup: u.jobMarkup(job),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+// jobProgress returns notification
+// updating progress message.
+func (u *upload) jobProgress(ctx context.Context, b *bot.Bot, chatId int64, msgId int) localModels.JobNotify {
+	const op = "upload.jobProgress"
+
+	return func(job localModels.Job) {
+		text := job.String()
+		if !job.Status.Active() {
+			text = job.Report(maxJobLines) + "\n" + ctr.JobsSeeList
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   msgId,
+			Text:        text,
+			ReplyMarkup: u.jobMarkup(job),
+			ParseMode:   models.ParseModeHTML,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+func (u *upload) cancelJob(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.cancelJob"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	jobId, err := strconv.ParseInt(u.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	// Message will be updated
+	// by job notification.
+	if err := u.jobs.Cancel(ctx, chatId, jobId); err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
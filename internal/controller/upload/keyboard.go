@@ -2,6 +2,7 @@ package upload
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/slice"
 	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
@@ -11,9 +12,13 @@ import (
 const (
 	butMsgManual = "Файл"
 	butMsgLink   = "Ссылка"
+	butMsgBulk   = "Пакет"
+	butMsgSearch = "Поиск в Яндексе"
+	butMsgDone   = "Готово"
 
 	butMsgName       = "Название"
 	butMsgAuthor     = "Автор"
+	butMsgAlbum      = "Альбом"
 	butMsgGenre      = "Жанр"
 	butMsgPlaylist   = "Плейлисты"
 	butMsgPodcast    = "Подкаст"
@@ -26,8 +31,14 @@ const (
 	butMsgChecked    = "☑️"
 	butMsgNotChecked = "✖️"
 
-	butMsgSubmit = "Загрузить"
-	butMsgCancel = "Назад"
+	butMsgSubmit      = "Загрузить"
+	butMsgSubmitForce = "Все равно загрузить"
+	butMsgCancel      = "Назад"
+	butMsgJobCancel   = "Отменить"
+	butMsgImport      = "Импортировать"
+	butMsgArtistTop   = "Популярные треки"
+	butMsgArtistAll   = "Вся дискография"
+	butMsgImportForce = "Все равно импортировать"
 )
 
 func (u *upload) mainMenuMarkup() models.InlineKeyboardMarkup {
@@ -37,6 +48,10 @@ func (u *upload) mainMenuMarkup() models.InlineKeyboardMarkup {
 				{Text: butMsgManual, CallbackData: u.router.Path(cmdManual)},
 				{Text: butMsgLink, CallbackData: u.router.Path(cmdLink)},
 			},
+			{
+				{Text: butMsgBulk, CallbackData: u.router.Path(cmdBulk)},
+				{Text: butMsgSearch, CallbackData: u.router.Path(cmdCatalog)},
+			},
 		},
 	}
 }
@@ -84,22 +99,45 @@ func (u *upload) mediaConfMarkup(conf localModels.MediaConfig) models.InlineKeyb
 	}
 }
 
-func (u *upload) genreChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+// bulkConfMarkup is the same as mediaConfMarkup,
+// but sets album instead of name and author.
+func (u *upload) bulkConfMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+	markup := u.mediaConfMarkup(conf)
+
+	markup.InlineKeyboard[0] = []models.InlineKeyboardButton{
+		{Text: butMsgAlbum, CallbackData: u.router.PathPrefixState(cmdSettings, "album")},
+	}
+
+	return markup
+}
+
+func (u *upload) bulkReceiveMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgDone, CallbackData: u.router.Path(cmdBulkDone)},
+				{Text: butMsgCancel, CallbackData: u.router.Path(cmdCancel)},
+			},
+		},
+	}
+}
+
+func (u *upload) genreChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.GenreNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Genres)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, g := range localModels.GenresAvail {
-		msg = g.String()
-		if conf.Genres[g.Id-1] {
+	for _, g := range tax.Genres {
+		msg = g.Name
+		if conf.Genres.Has(g.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("genre-%d", g.Id)
+		callback := fmt.Sprintf("genre-%d", g.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -132,22 +170,22 @@ func (u *upload) genreChooseMarkup(conf localModels.MediaConfig) models.InlineKe
 	}
 }
 
-func (u *upload) moodChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+func (u *upload) moodChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 2
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Moods)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, m := range localModels.MoodsAvail {
-		msg = m.String()
-		if conf.Moods[m.Id-1] {
+	for _, m := range tax.Moods {
+		msg = m.Name
+		if conf.Moods.Has(m.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("mood-%d", m.Id)
+		callback := fmt.Sprintf("mood-%d", m.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -179,22 +217,22 @@ func (u *upload) moodChooseMarkup(conf localModels.MediaConfig) models.InlineKey
 	}
 }
 
-func (u *upload) langChooseMarkup(conf localModels.MediaConfig) models.InlineKeyboardMarkup {
+func (u *upload) langChooseMarkup(conf localModels.MediaConfig, tax localModels.Taxonomy) models.InlineKeyboardMarkup {
 	var msg string
 
 	const rowLen = 3
 
-	rows := make([][]models.InlineKeyboardButton, 0, localModels.MoodNumber/rowLen)
+	rows := make([][]models.InlineKeyboardButton, 0, len(tax.Languages)/rowLen+2)
 	row := make([]models.InlineKeyboardButton, 0, rowLen)
 
-	for _, l := range localModels.LangsAvail {
-		msg = l.String()
-		if conf.Languages[l.Id-1] {
+	for _, l := range tax.Languages {
+		msg = l.Name
+		if conf.Languages.Has(l.Name) {
 			msg += butMsgChecked
 		} else {
 			msg += butMsgNotChecked
 		}
-		callback := fmt.Sprintf("lang-%d", l.Id)
+		callback := fmt.Sprintf("lang-%d", l.ID)
 
 		if len(row) == rowLen {
 			rows = append(rows, row)
@@ -246,6 +284,62 @@ func (u *upload) cancelMarkup() models.InlineKeyboardMarkup {
 	}
 }
 
+func (u *upload) catalogSliderMarkup(id int, maxId int, item localModels.CatalogItem) models.InlineKeyboardMarkup {
+	var (
+		butLeft = models.InlineKeyboardButton{
+			Text:         "\u00AB",
+			CallbackData: u.router.PathPrefixState(cmdCatalogSlide, "prev"),
+		}
+		butRight = models.InlineKeyboardButton{
+			Text:         "\u00BB",
+			CallbackData: u.router.PathPrefixState(cmdCatalogSlide, "next"),
+		}
+	)
+	if id == 1 {
+		butLeft.Text = "\t"
+		butLeft.CallbackData = u.router.Path(cmdNoOp)
+	}
+	if id == maxId {
+		butRight.Text = "\t"
+		butRight.CallbackData = u.router.Path(cmdNoOp)
+	}
+
+	importMsg := butMsgImport
+	if item.InLibrary {
+		importMsg = butMsgImportForce
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				butLeft,
+				{Text: fmt.Sprintf("%d/%d", id, maxId), CallbackData: u.router.Path(cmdNoOp)},
+				butRight,
+			},
+			{
+				{Text: importMsg, CallbackData: u.router.Path(cmdCatalogImport)},
+			},
+			{
+				{Text: butMsgCancel, CallbackData: u.router.Path(cmdCancel)},
+			},
+		},
+	}
+}
+
+func (u *upload) artistChoiceMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgArtistTop, CallbackData: u.router.PathPrefixState(cmdArtist, "tracks")},
+				{Text: butMsgArtistAll, CallbackData: u.router.PathPrefixState(cmdArtist, "albums")},
+			},
+			{
+				{Text: butMsgCancel, CallbackData: u.router.Path(cmdCancel)},
+			},
+		},
+	}
+}
+
 func (u *upload) askUploadMarkup() models.InlineKeyboardMarkup {
 	return models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
@@ -256,3 +350,30 @@ func (u *upload) askUploadMarkup() models.InlineKeyboardMarkup {
 		},
 	}
 }
+
+func (u *upload) duplicatesMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgSubmitForce, CallbackData: u.router.Path(cmdSubmit)},
+				{Text: butMsgCancel, CallbackData: u.router.Path(cmdCancel)},
+			},
+		},
+	}
+}
+
+func (u *upload) jobMarkup(job localModels.Job) models.InlineKeyboardMarkup {
+	if !job.Status.Active() {
+		return models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{},
+		}
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgJobCancel, CallbackData: u.router.PathPrefixState(cmdJobCancel, strconv.FormatInt(job.ID, 10))},
+			},
+		},
+	}
+}
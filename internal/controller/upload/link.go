@@ -11,6 +11,7 @@ import (
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
 	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 	"github.com/GintGld/fizteh-radio-bot/internal/service"
 )
@@ -27,7 +28,7 @@ func (u *upload) linkUpload(ctx context.Context, b *bot.Bot, update *models.Upda
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatId,
 		MessageID:   u.msgIdStorage.Get(chatId),
-		Text:        ctr.LibUploadAskLink,
+		Text:        fmt.Sprintf(ctr.LibUploadAskLink, strings.Join(u.mediaUpload.LinkProviders(), ", ")),
 		ReplyMarkup: u.cancelMarkup(),
 	}); err != nil {
 		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
@@ -57,7 +58,15 @@ func (u *upload) getLink(ctx context.Context, b *bot.Bot, update *models.Update)
 		}
 	}()
 
-	res, err := u.mediaUpload.LinkDownload(ctx, chatId, msg)
+	u.handleLink(ctx, b, chatId, msg)
+}
+
+// handleLink downloads media by link and shows
+// its config or album/playlist summary.
+func (u *upload) handleLink(ctx context.Context, b *bot.Bot, chatId int64, link string) {
+	const op = "upload.handleLink"
+
+	res, err := u.mediaUpload.LinkDownload(ctx, chatId, link)
 	if err != nil {
 		// Handle more errors.
 		if errors.Is(err, service.ErrInvalidLink) {
@@ -71,9 +80,18 @@ func (u *upload) getLink(ctx context.Context, b *bot.Bot, update *models.Update)
 			u.msgIdStorage.Set(chatId, msg.ID)
 			return
 		}
+		text := ctr.ErrorMessage
+		switch {
+		case errors.Is(err, service.ErrPreviewOnly):
+			text = ctr.LibUploadErrPreviewOnly
+		case errors.Is(err, service.ErrNoDownloadOption):
+			text = ctr.LibUploadErrNoDownloadOption
+		case errors.Is(err, tmpdir.ErrQuotaExceeded):
+			text = ctr.LibUploadErrTmpQuota
+		}
 		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatId,
-			Text:   ctr.ErrorMessage,
+			Text:   text,
 		}); err != nil {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 		}
@@ -121,6 +139,22 @@ func (u *upload) getLink(ctx context.Context, b *bot.Bot, update *models.Update)
 		}); err != nil {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 		}
+	case localModels.ResArtist:
+		text, markup := u.artistRepr(res), u.artistChoiceMarkup()
+		if len(res.Artist.Values) > 0 {
+			u.linkTypeStorage.Set(chatId, localModels.ResArtist)
+			markup = u.askUploadMarkup()
+		}
+
+		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatId,
+			MessageID:   u.msgIdStorage.Get(chatId),
+			Text:        text,
+			ReplyMarkup: markup,
+			ParseMode:   models.ParseModeHTML,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
 	}
 }
 
@@ -129,13 +163,31 @@ func (u *upload) albumRepr(res localModels.LinkDownloadResult) string {
 
 	totalDur := time.Duration(0)
 
-	b.WriteString(fmt.Sprintf("<b>Альбом:</b> %s\n", res.Album.Name))
+	if res.Album.Format == localModels.Podcast {
+		b.WriteString(fmt.Sprintf("<b>Подкаст:</b> %s\n", res.Album.Name))
+	} else {
+		b.WriteString(fmt.Sprintf("<b>Альбом:</b> %s\n", res.Album.Name))
+	}
 
 	for _, m := range res.Album.Values {
 		totalDur += m.Duration
 	}
 
-	b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", len(res.Album.Values)))
+	if res.Album.Format == localModels.Podcast {
+		b.WriteString(fmt.Sprintf("<b>Количество выпусков:</b> %d\n", len(res.Album.Values)))
+	} else {
+		b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", len(res.Album.Values)))
+	}
+	if len(res.Album.Values) > 0 && len(res.Album.Values[0].Albums) > 0 {
+		album := res.Album.Values[0].Albums[0]
+		if album.Year != 0 {
+			b.WriteString(fmt.Sprintf("<b>Год:</b> %d\n", album.Year))
+		}
+		if album.Label != "" {
+			b.WriteString(fmt.Sprintf("<b>Лейбл:</b> %s\n", album.Label))
+		}
+	}
+	b.WriteString(u.autoTagsRepr(res.Album.Values))
 	b.WriteString(fmt.Sprintf("<b>Общая длительность:</b> %s", totalDur.String()))
 
 	return b.String()
@@ -153,7 +205,91 @@ func (u *upload) playlistRepr(res localModels.LinkDownloadResult) string {
 	}
 
 	b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", len(res.Playlist.Values)))
+	b.WriteString(u.autoTagsRepr(res.Playlist.Values))
+	b.WriteString(fmt.Sprintf("<b>Общая длительность:</b> %s", totalDur.String()))
+
+	return b.String()
+}
+
+// autoTagsRepr lists genres and languages
+// filled automatically for any of media.
+func (u *upload) autoTagsRepr(values []localModels.MediaConfig) string {
+	var (
+		b      strings.Builder
+		genres localModels.TagSet
+		langs  localModels.TagSet
+	)
+
+	for _, v := range values {
+		genres = genres.Union(v.Genres)
+		langs = langs.Union(v.Languages)
+	}
+
+	if len(genres) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", genres))
+	}
+	if len(langs) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", langs))
+	}
+
+	return b.String()
+}
+
+func (u *upload) artistRepr(res localModels.LinkDownloadResult) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>Исполнитель:</b> %s\n", res.Artist.Name))
+
+	if len(res.Artist.Values) == 0 {
+		b.WriteString(fmt.Sprintf("<b>Треков:</b> %d\n", res.Artist.TrackCount))
+		b.WriteString(fmt.Sprintf("<b>Альбомов:</b> %d\n\n", res.Artist.AlbumCount))
+		b.WriteString(ctr.LibUploadAskArtistMode)
+		return b.String()
+	}
+
+	totalDur := time.Duration(0)
+	for _, m := range res.Artist.Values {
+		totalDur += m.Duration
+	}
+
+	b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", len(res.Artist.Values)))
+	b.WriteString(u.autoTagsRepr(res.Artist.Values))
 	b.WriteString(fmt.Sprintf("<b>Общая длительность:</b> %s", totalDur.String()))
 
 	return b.String()
 }
+
+// artistImport imports popular tracks
+// or discography of selected artist.
+func (u *upload) artistImport(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "upload.artistImport"
+
+	u.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	mode := u.router.GetState(update.CallbackQuery.Data)
+
+	res := u.linkDownloadResStorage.Get(chatId)
+	if res.Type != localModels.ResArtist {
+		u.sendError(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+			ChatID:    chatId,
+			MessageID: inProgressMsg.ID,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	u.handleLink(ctx, b, chatId, res.Artist.Link+"/"+mode)
+}
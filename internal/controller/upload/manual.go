@@ -2,22 +2,28 @@ package upload
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"strings"
-	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 
 	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/id3"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
 	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
 )
 
 const (
 	mp3MimeType = "audio/mpeg"
+
+	// Source name of files
+	// sent to bot in cache keys.
+	telegramSource = "telegram"
 )
 
 func (u *upload) manualUpload(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -79,48 +85,25 @@ func (u *upload) manualUploadFile(ctx context.Context, b *bot.Bot, update *model
 		return
 	}
 
-	file, err := b.GetFile(ctx, &bot.GetFileParams{
-		FileID: update.Message.Audio.FileID,
-	})
+	filepath, err := u.downloadTelegramFile(ctx, b, update.Message.Audio.FileID)
 	if err != nil {
-		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatId,
-			Text:   ctr.ErrorMessage,
-		}); err != nil {
+		text := ctr.ErrorMessage
+		if errors.Is(err, tmpdir.ErrQuotaExceeded) {
+			text = ctr.LibUploadErrTmpQuota
+		} else {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 		}
-		return
-	}
-
-	filepath, err := u.downloadFile(b.FileDownloadLink(file))
-	if err != nil {
 		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatId,
-			Text:   ctr.ErrorMessage,
+			Text:   text,
 		}); err != nil {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 		}
 		return
 	}
-	// Automatic removal after 1 hour
-	time.AfterFunc(time.Hour, func() {
-		u.deleteFile(filepath)
-	})
-
-	author, name, found := strings.Cut(update.Message.Audio.FileName, " - ")
-	if !found {
-		author = ""
-		name = ""
-	}
-	author = strings.TrimSuffix(author, ".mp3")
-
-	conf := localModels.MediaConfig{
-		Name:       name,
-		Author:     author,
-		Duration:   getMediaDuration(filepath),
-		SourcePath: filepath,
-	}
+	conf := u.probeFile(ctx, filepath, update.Message.Audio.FileName)
 
+	u.linkTypeStorage.Set(chatId, localModels.ResSong)
 	u.mediaConfigStorage.Set(chatId, conf)
 
 	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{
@@ -140,30 +123,76 @@ func (u *upload) manualUploadFile(ctx context.Context, b *bot.Bot, update *model
 	}
 }
 
-// download downloads file from telegram.
-func (u *upload) downloadFile(link string) (string, error) {
-	resp, err := http.Get(link)
+// downloadTelegramFile downloads file sent to bot
+// to cache or returns already cached file.
+func (u *upload) downloadTelegramFile(ctx context.Context, b *bot.Bot, fileId string) (string, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{
+		FileID: fileId,
+	})
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	out, err := os.CreateTemp(u.tmpDir, "media-upload-*.mp3")
+	req, err := http.NewRequestWithContext(ctx, "GET", b.FileDownloadLink(file), nil)
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
 
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
-	return out.Name(), err
+	return u.fileCache.Download(ctx, telegramKey(file.FileUniqueID), req)
 }
 
-func (u *upload) deleteFile(path string) error {
-	return os.Remove(path)
+// telegramKey returns cache key of file sent to bot.
+// File id differs between bots and may change,
+// so key is built from unique id.
+func telegramKey(fileUniqueId string) string {
+	return dlcache.Key(telegramSource, fileUniqueId)
 }
 
-// FIXME
-func getMediaDuration(_ string) time.Duration {
-	return time.Duration(0)
+// probeFile returns media config filled
+// with ID3 tags and detected duration.
+// If there are no tags, name and author are taken
+// from file name formatted as "author - name.mp3".
+func (u *upload) probeFile(ctx context.Context, path, fileName string) localModels.MediaConfig {
+	const op = "upload.probeFile"
+
+	author, name, found := strings.Cut(strings.TrimSuffix(fileName, ".mp3"), " - ")
+	if !found {
+		author = ""
+		name = ""
+	}
+
+	conf := localModels.MediaConfig{
+		Name:       strings.TrimSpace(name),
+		Author:     strings.TrimSpace(author),
+		SourcePath: path,
+	}
+
+	dur, err := audio.Duration(ctx, path)
+	if err != nil {
+		u.onError(fmt.Errorf("%s: %w", op, err))
+	}
+	conf.Duration = dur
+
+	tags, err := id3.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, id3.ErrNoTags) {
+			u.onError(fmt.Errorf("%s: %w", op, err))
+		}
+		return conf
+	}
+
+	if tags.Title != "" {
+		conf.Name = tags.Title
+	}
+	if tags.Artist != "" {
+		conf.Author = tags.Artist
+	}
+	if tags.Album != "" {
+		conf.Albums = []localModels.Album{{
+			Name:   tags.Album,
+			Author: conf.Author,
+		}}
+	}
+
+	return conf
 }
@@ -29,6 +29,9 @@ func (u *upload) updateSettings(ctx context.Context, b *bot.Bot, update *models.
 	case "author":
 		u.settingTargetStorage.Set(chatId, "author")
 		msg = ctr.LibUploadAskAuthor
+	case "album":
+		u.settingTargetStorage.Set(chatId, "album")
+		msg = ctr.LibUploadAskAlbum
 	case "genre":
 		u.settingTargetStorage.Set(chatId, "genre")
 		msg = ctr.LibUploadAskGenre
@@ -46,11 +49,13 @@ func (u *upload) updateSettings(ctx context.Context, b *bot.Bot, update *models.
 		}
 		u.mediaConfigStorage.Set(chatId, conf)
 
+		text, markup := u.confView(chatId)
+
 		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 			ChatID:      chatId,
 			MessageID:   u.msgIdStorage.Get(chatId),
-			Text:        conf.String(),
-			ReplyMarkup: u.mediaConfMarkup(conf),
+			Text:        text,
+			ReplyMarkup: markup,
 			ParseMode:   models.ParseModeHTML,
 		}); err != nil {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
@@ -76,19 +81,23 @@ func (u *upload) updateSettings(ctx context.Context, b *bot.Bot, update *models.
 		msg = ctr.LibUploadAskMood
 	case "reset":
 		conf := u.mediaConfigStorage.Get(chatId)
-		conf.Genres = [localModels.GenreNumber]bool{}
+		conf.Genres = nil
+		if u.linkTypeStorage.Get(chatId) == localModels.ResBulk {
+			conf.Albums = nil
+		}
 		conf.Playlists = nil
 		conf.Podcasts = nil
-		conf.Languages = [localModels.LangNumber]bool{}
-		conf.Moods = [localModels.MoodNumber]bool{}
+		conf.Languages = nil
+		conf.Moods = nil
 		u.mediaConfigStorage.Set(chatId, conf)
-		msg = conf.String()
+
+		text, markup := u.confView(chatId)
 
 		if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 			ChatID:      chatId,
 			MessageID:   u.msgIdStorage.Get(chatId),
-			Text:        msg,
-			ReplyMarkup: u.mediaConfMarkup(conf),
+			Text:        text,
+			ReplyMarkup: markup,
 			ParseMode:   models.ParseModeHTML,
 		}); err != nil {
 			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
@@ -135,6 +144,12 @@ func (u *upload) getSettingNewData(ctx context.Context, b *bot.Bot, update *mode
 		conf.Name = msg
 	case "author":
 		conf.Author = msg
+	case "album":
+		// Author is taken from each media.
+		conf.Albums = make([]localModels.Album, 0)
+		for _, name := range split.SplitMsg(msg) {
+			conf.Albums = append(conf.Albums, localModels.Album{Name: name})
+		}
 	case "podcasts":
 		conf.Podcasts = split.SplitMsg(msg)
 	case "playlists":
@@ -151,11 +166,13 @@ func (u *upload) getSettingNewData(ctx context.Context, b *bot.Bot, update *mode
 	}); err != nil {
 		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
+	text, markup := u.confView(chatId)
+
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatId,
 		MessageID:   u.msgIdStorage.Get(chatId),
-		Text:        conf.String(),
-		ReplyMarkup: u.mediaConfMarkup(conf),
+		Text:        text,
+		ReplyMarkup: markup,
 		ParseMode:   models.ParseModeHTML,
 	}); err != nil {
 		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
@@ -170,6 +187,18 @@ func (u *upload) openCheckBox(ctx context.Context, b *bot.Bot, update *models.Up
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 	callback := u.router.GetState(update.CallbackQuery.Data)
 
+	tax, err := u.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	var (
 		msg    string
 		markup models.InlineKeyboardMarkup
@@ -180,13 +209,13 @@ func (u *upload) openCheckBox(ctx context.Context, b *bot.Bot, update *models.Up
 	switch callback {
 	case "genre":
 		msg = ctr.LibSearchUpdateAskGenre
-		markup = u.genreChooseMarkup(conf)
+		markup = u.genreChooseMarkup(conf, tax)
 	case "mood":
 		msg = ctr.LibSearchUpdateAskMood
-		markup = u.moodChooseMarkup(conf)
+		markup = u.moodChooseMarkup(conf, tax)
 	case "lang":
 		msg = ctr.LibSearchUpdateAskLang
-		markup = u.langChooseMarkup(conf)
+		markup = u.langChooseMarkup(conf, tax)
 	}
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
@@ -209,7 +238,7 @@ func (u *upload) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 	callback := u.router.GetState(update.CallbackQuery.Data)
 
 	// tagType in ('genre', 'mood', 'lang')
-	// data is tag id (from 1 to GenreNumber, MoodNumber, LangNumber)
+	// data is id of tag on server
 	tagType, data, found := strings.Cut(callback, "-")
 	if !found {
 		u.onError(fmt.Errorf("%s [%d]: invalid callback data \"%s\"", op, chatId, callback))
@@ -233,6 +262,30 @@ func (u *upload) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 		return
 	}
 
+	tax, err := u.taxonomy.Taxonomy(ctx, chatId)
+	if err != nil {
+		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
+	tag, found := tax.Find(int64(id))
+	if !found {
+		u.onError(fmt.Errorf("%s [%d]: unknown tag id %d", op, chatId, id))
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatId,
+			Text:   ctr.ErrorMessage,
+		}); err != nil {
+			u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+		return
+	}
+
 	conf := u.mediaConfigStorage.Get(chatId)
 
 	var (
@@ -242,17 +295,17 @@ func (u *upload) getCheckedBtn(ctx context.Context, b *bot.Bot, update *models.U
 
 	switch tagType {
 	case "genre":
-		conf.Genres[id-1] = !conf.Genres[id-1]
+		conf.Genres.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskGenre
-		markup = u.genreChooseMarkup(conf)
+		markup = u.genreChooseMarkup(conf, tax)
 	case "mood":
-		conf.Moods[id-1] = !conf.Moods[id-1]
+		conf.Moods.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskMood
-		markup = u.moodChooseMarkup(conf)
+		markup = u.moodChooseMarkup(conf, tax)
 	case "lang":
-		conf.Languages[id-1] = !conf.Languages[id-1]
+		conf.Languages.Toggle(tag.Name)
 		msg = ctr.LibSearchUpdateAskLang
-		markup = u.langChooseMarkup(conf)
+		markup = u.langChooseMarkup(conf, tax)
 	}
 
 	u.mediaConfigStorage.Set(chatId, conf)
@@ -275,15 +328,31 @@ func (u *upload) cancelSubTask(ctx context.Context, b *bot.Bot, update *models.U
 
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 
-	conf := u.mediaConfigStorage.Get(chatId)
+	text, markup := u.confView(chatId)
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatId,
 		MessageID:   u.msgIdStorage.Get(chatId),
-		Text:        conf.String(),
-		ReplyMarkup: u.mediaConfMarkup(conf),
+		Text:        text,
+		ReplyMarkup: markup,
 		ParseMode:   models.ParseModeHTML,
 	}); err != nil {
 		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
 }
+
+// confView returns representation and markup
+// of media config to be uploaded.
+func (u *upload) confView(chatId int64) (string, models.InlineKeyboardMarkup) {
+	conf := u.mediaConfigStorage.Get(chatId)
+
+	if u.linkTypeStorage.Get(chatId) == localModels.ResBulk {
+		u.bulkMutex.Lock()
+		values := u.bulkStorage.Get(chatId)
+		u.bulkMutex.Unlock()
+
+		return u.bulkRepr(conf, values), u.bulkConfMarkup(conf)
+	}
+
+	return conf.String(), u.mediaConfMarkup(conf)
+}
@@ -4,6 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -17,16 +22,28 @@ import (
 // FIXME delete custom setting and use special controller
 
 const (
-	cmdBase   ctr.Command = ""
-	cmdManual ctr.Command = "manual"
-	cmdLink   ctr.Command = "link"
-	cmdBack   ctr.Command = "back"
+	cmdBase    ctr.Command = ""
+	cmdManual  ctr.Command = "manual"
+	cmdLink    ctr.Command = "link"
+	cmdBulk    ctr.Command = "bulk"
+	cmdCatalog ctr.Command = "catalog"
+	cmdBack    ctr.Command = "back"
 
 	// manual upload
 	cmdFile ctr.Command = "file"
 
 	// link upload
 	cmdGetLink ctr.Command = "get-link"
+	cmdArtist  ctr.Command = "artist"
+
+	// catalog search
+	cmdCatalogQuery  ctr.Command = "catalog-query"
+	cmdCatalogSlide  ctr.Command = "catalog-slide"
+	cmdCatalogImport ctr.Command = "catalog-import"
+
+	// bulk upload
+	cmdBulkFile ctr.Command = "bulk-file"
+	cmdBulkDone ctr.Command = "bulk-done"
 
 	// settings
 	cmdSettings      ctr.Command = "settings"
@@ -37,6 +54,9 @@ const (
 	cmdCancel        ctr.Command = "cancel"
 	cmdCancelSetting ctr.Command = "cancel-settings"
 
+	// background upload
+	cmdJobCancel ctr.Command = "job-cancel"
+
 	// filler
 	cmdNoOp ctr.Command = "no-op"
 )
@@ -47,15 +67,29 @@ type upload struct {
 	router      *ctr.Router
 	auth        Auth
 	mediaUpload MediaUpload
+	jobs        Jobs
 	session     ctr.Session
 	onError     bot.ErrorsHandler
-	tmpDir      string
+	tmpDir      TmpDir
+	fileCache   FileCache
+	taxonomy    Taxonomy
 
 	linkTypeStorage        storage.Storage[localModels.ResultType]
 	mediaConfigStorage     storage.Storage[localModels.MediaConfig]
 	settingTargetStorage   storage.Storage[string]
 	linkDownloadResStorage storage.Storage[localModels.LinkDownloadResult]
+	dupCheckedStorage      storage.Storage[bool]
 	msgIdStorage           storage.Storage[int]
+	catalogStorage         storage.Storage[[]localModels.CatalogItem]
+	catalogPageStorage     storage.Storage[int]
+
+	// Files of media group are handled
+	// concurrently, so storage is guarded.
+	bulkStorage storage.Storage[[]localModels.MediaConfig]
+	// Files extracted from archives,
+	// released if upload is dropped.
+	bulkTmpStorage storage.Storage[[]string]
+	bulkMutex      sync.Mutex
 }
 
 type Auth interface {
@@ -65,30 +99,72 @@ type Auth interface {
 type MediaUpload interface {
 	NewMedia(ctx context.Context, id int64, media localModels.MediaConfig) (int64, error)
 	LinkDownload(ctx context.Context, id int64, link string) (localModels.LinkDownloadResult, error)
-	LinkUpload(ctx context.Context, id int64, res localModels.LinkDownloadResult) error
+	LinkProviders() []string
+	CatalogSearch(ctx context.Context, id int64, query string) ([]localModels.CatalogItem, error)
+	PrepareUpload(ctx context.Context, id int64, values []localModels.MediaConfig) error
+	Duplicates(ctx context.Context, id int64, conf localModels.MediaConfig) ([]localModels.MediaConfig, error)
+}
+
+// TmpDir creates temporary files
+// removed after TTL.
+type TmpDir interface {
+	Create(owner, pattern string, ttl time.Duration) (*os.File, error)
+	Extend(path string, ttl time.Duration)
+	Release(path string)
+	Limit(w io.Writer) io.Writer
+}
+
+// FileCache stores files sent to bot.
+type FileCache interface {
+	Download(ctx context.Context, key string, req *http.Request) (string, error)
+	Remove(key string) error
+	Pin(path string)
+	Unpin(path string)
+}
+
+// Taxonomy provides tags
+// available for selection.
+type Taxonomy interface {
+	Taxonomy(ctx context.Context, id int64) (localModels.Taxonomy, error)
+}
+
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind localModels.JobKind, title string, items []localModels.JobItem, notify localModels.JobNotify) (localModels.Job, error)
+	Cancel(ctx context.Context, id int64, jobId int64) error
 }
 
 func Register(
 	router *ctr.Router,
 	auth Auth,
 	mediaUpload MediaUpload,
+	jobs Jobs,
 	session ctr.Session,
 	onError bot.ErrorsHandler,
-	tmpDir string,
+	tmpDir TmpDir,
+	fileCache FileCache,
+	taxonomy Taxonomy,
 ) {
 	u := &upload{
 		router:      router,
 		auth:        auth,
 		mediaUpload: mediaUpload,
+		jobs:        jobs,
 		session:     session,
 		onError:     onError,
 		tmpDir:      tmpDir,
+		fileCache:   fileCache,
+		taxonomy:    taxonomy,
 
 		linkTypeStorage:        storage.New[localModels.ResultType](),
 		mediaConfigStorage:     storage.New[localModels.MediaConfig](),
 		settingTargetStorage:   storage.New[string](),
 		linkDownloadResStorage: storage.New[localModels.LinkDownloadResult](),
+		dupCheckedStorage:      storage.New[bool](),
 		msgIdStorage:           storage.New[int](),
+		catalogStorage:         storage.New[[]localModels.CatalogItem](),
+		catalogPageStorage:     storage.New[int](),
+		bulkStorage:            storage.New[[]localModels.MediaConfig](),
+		bulkTmpStorage:         storage.New[[]string](),
 	}
 
 	router.RegisterCommand(u.init)
@@ -100,6 +176,18 @@ func Register(
 	// link upload
 	router.RegisterCallback(cmdLink, u.linkUpload)
 	router.RegisterHandler(cmdGetLink, u.getLink)
+	router.RegisterCallbackPrefix(cmdArtist, u.artistImport)
+
+	// catalog search
+	router.RegisterCallback(cmdCatalog, u.catalogSearch)
+	router.RegisterHandler(cmdCatalogQuery, u.catalogQuery)
+	router.RegisterCallbackPrefix(cmdCatalogSlide, u.catalogSlide)
+	router.RegisterCallback(cmdCatalogImport, u.catalogImport)
+
+	// bulk upload
+	router.RegisterCallback(cmdBulk, u.bulkUpload)
+	router.RegisterHandler(cmdBulkFile, u.bulkFile)
+	router.RegisterCallback(cmdBulkDone, u.bulkDone)
 
 	// settings
 	router.RegisterCallbackPrefix(cmdSettings, u.updateSettings)
@@ -110,6 +198,9 @@ func Register(
 	router.RegisterCallback(cmdSubmit, u.submit)
 	router.RegisterCallback(cmdCancel, u.returnToMainMenu)
 
+	// background upload
+	router.RegisterCallbackPrefix(cmdJobCancel, u.cancelJob)
+
 	// filler
 	router.RegisterCallback(cmdNoOp, u.nullHandler)
 }
@@ -137,6 +228,8 @@ func (u *upload) init(ctx context.Context, b *bot.Bot, update *models.Update) {
 		u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
 	}
 
+	u.linkTypeStorage.Del(chatId)
+	u.dupCheckedStorage.Del(chatId)
 	u.msgIdStorage.Set(chatId, msg.ID)
 }
 
@@ -165,16 +258,32 @@ func (u *upload) submit(ctx context.Context, b *bot.Bot, update *models.Update)
 		}
 	}()
 
+	// Show possible duplicates once,
+	// second submit uploads anyway.
+	if !u.dupCheckedStorage.Get(chatId) {
+		u.dupCheckedStorage.Set(chatId, true)
+
+		if text, found := u.possibleDuplicates(ctx, chatId); found {
+			if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:      chatId,
+				MessageID:   u.msgIdStorage.Get(chatId),
+				Text:        text,
+				ReplyMarkup: u.duplicatesMarkup(),
+				ParseMode:   models.ParseModeHTML,
+			}); err != nil {
+				u.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			}
+			return
+		}
+	}
+
 	switch u.linkTypeStorage.Get(chatId) {
-	case localModels.ResSong:
-		_, err = u.mediaUpload.NewMedia(ctx, chatId, u.mediaConfigStorage.Get(chatId))
-	case localModels.ResAlbum:
-		err = u.mediaUpload.LinkUpload(ctx, chatId, u.linkDownloadResStorage.Get(chatId))
-	case localModels.ResPlaylist:
-		err = u.mediaUpload.LinkUpload(ctx, chatId, u.linkDownloadResStorage.Get(chatId))
+	case localModels.ResAlbum, localModels.ResPlaylist, localModels.ResBulk, localModels.ResArtist:
+		u.submitJob(ctx, b, chatId)
+		return
 	}
 
-	if err != nil {
+	if _, err := u.mediaUpload.NewMedia(ctx, chatId, u.mediaConfigStorage.Get(chatId)); err != nil {
 		// TODO handle errors
 		// Media exists case.
 		if errors.Is(err, service.ErrMediaExists) {
@@ -196,10 +305,7 @@ func (u *upload) submit(ctx context.Context, b *bot.Bot, update *models.Update)
 		return
 	}
 
-	u.linkTypeStorage.Del(chatId)
-	u.linkDownloadResStorage.Del(chatId)
-	u.mediaConfigStorage.Del(chatId)
-	u.settingTargetStorage.Del(chatId)
+	u.clearStorages(chatId)
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:    chatId,
@@ -217,9 +323,8 @@ func (u *upload) returnToMainMenu(ctx context.Context, b *bot.Bot, update *model
 
 	chatId := update.CallbackQuery.Message.Message.Chat.ID
 
-	u.linkDownloadResStorage.Del(chatId)
-	u.mediaConfigStorage.Del(chatId)
-	u.settingTargetStorage.Del(chatId)
+	u.session.Redirect(chatId, ctr.NullStatus)
+	u.clearStorages(chatId)
 
 	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:      chatId,
@@ -231,6 +336,21 @@ func (u *upload) returnToMainMenu(ctx context.Context, b *bot.Bot, update *model
 	}
 }
 
+// clearStorages deletes media
+// prepared to upload.
+func (u *upload) clearStorages(chatId int64) {
+	u.linkTypeStorage.Del(chatId)
+	u.linkDownloadResStorage.Del(chatId)
+	u.mediaConfigStorage.Del(chatId)
+	u.settingTargetStorage.Del(chatId)
+	u.dupCheckedStorage.Del(chatId)
+	u.catalogStorage.Del(chatId)
+	u.catalogPageStorage.Del(chatId)
+	u.bulkMutex.Lock()
+	u.dropBulkLocked(chatId)
+	u.bulkMutex.Unlock()
+}
+
 func (u *upload) nullHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	u.CallbackAnswer(ctx, b, update.CallbackQuery)
 }
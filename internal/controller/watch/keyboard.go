@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-telegram/bot/models"
+
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	butMsgAdd           = "Добавить плейлист"
+	butMsgSync          = "Синхронизировать"
+	butMsgRemoveMissing = "Снимать тег с удаленных: %s"
+	butMsgUnwatch       = "Не отслеживать"
+	butMsgOpen          = "Открыть"
+	butMsgBack          = "Назад"
+
+	butOn  = "да"
+	butOff = "нет"
+)
+
+func (w *watch) listMarkup(playlists []localModels.WatchedPlaylist) models.InlineKeyboardMarkup {
+	rows := make([][]models.InlineKeyboardButton, 0, len(playlists)+1)
+
+	for _, p := range playlists {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         p.Name,
+			CallbackData: w.router.PathPrefixState(cmdPlaylist, strconv.FormatInt(p.ID, 10)),
+		}})
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         butMsgAdd,
+		CallbackData: w.router.Path(cmdAdd),
+	}})
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: rows,
+	}
+}
+
+func (w *watch) playlistMarkup(p localModels.WatchedPlaylist) models.InlineKeyboardMarkup {
+	id := strconv.FormatInt(p.ID, 10)
+
+	// Button sets opposite value.
+	toggleText, toggleState := butOff, id+"-on"
+	if p.RemoveMissing {
+		toggleText, toggleState = butOn, id+"-off"
+	}
+
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgSync, CallbackData: w.router.PathPrefixState(cmdSync, id)},
+			},
+			{
+				{Text: fmt.Sprintf(butMsgRemoveMissing, toggleText), CallbackData: w.router.PathPrefixState(cmdToggle, toggleState)},
+			},
+			{
+				{Text: butMsgUnwatch, CallbackData: w.router.PathPrefixState(cmdRemove, id)},
+				{Text: butMsgBack, CallbackData: w.router.Path(cmdList)},
+			},
+		},
+	}
+}
+
+func (w *watch) summaryMarkup(p localModels.WatchedPlaylist) models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgOpen, CallbackData: w.router.PathPrefixState(cmdPlaylist, strconv.FormatInt(p.ID, 10))},
+			},
+		},
+	}
+}
+
+func (w *watch) backMarkup() models.InlineKeyboardMarkup {
+	return models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: butMsgBack, CallbackData: w.router.Path(cmdList)},
+			},
+		},
+	}
+}
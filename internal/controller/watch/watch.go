@@ -0,0 +1,400 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	ctr "github.com/GintGld/fizteh-radio-bot/internal/controller"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/storage"
+	localModels "github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	cmdBase     ctr.Command = ""
+	cmdList     ctr.Command = "list"
+	cmdAdd      ctr.Command = "add"
+	cmdGetLink  ctr.Command = "get-link"
+	cmdPlaylist ctr.Command = "playlist"
+	cmdSync     ctr.Command = "sync"
+	cmdToggle   ctr.Command = "toggle"
+	cmdRemove   ctr.Command = "remove"
+)
+
+type watch struct {
+	ctr.CallbackAnswerer
+
+	router  *ctr.Router
+	auth    Auth
+	watch   Watch
+	session ctr.Session
+	onError bot.ErrorsHandler
+
+	msgIdStorage storage.Storage[int]
+}
+
+type Auth interface {
+	IsKnown(ctx context.Context, id int64) bool
+}
+
+type Watch interface {
+	List(ctx context.Context, id int64) ([]localModels.WatchedPlaylist, error)
+	Add(ctx context.Context, id int64, link string) (localModels.WatchedPlaylist, error)
+	Remove(ctx context.Context, id int64, playlistId int64) error
+	SetRemoveMissing(ctx context.Context, id int64, playlistId int64, remove bool) (localModels.WatchedPlaylist, error)
+	Sync(ctx context.Context, id int64, playlistId int64) error
+	SetNotify(notify localModels.WatchNotify)
+}
+
+// Register registers "/watch" command.
+// Summaries of background synchronizations
+// are sent to playlist owners by b.
+func Register(
+	router *ctr.Router,
+	b *bot.Bot,
+	auth Auth,
+	watchSrv Watch,
+	session ctr.Session,
+	onError bot.ErrorsHandler,
+) {
+	w := &watch{
+		router:  router,
+		auth:    auth,
+		watch:   watchSrv,
+		session: session,
+		onError: onError,
+
+		msgIdStorage: storage.New[int](),
+	}
+
+	watchSrv.SetNotify(w.summary(b))
+
+	router.RegisterCommand(w.init)
+	router.RegisterCallback(cmdList, w.list)
+	router.RegisterCallback(cmdAdd, w.add)
+	router.RegisterHandler(cmdGetLink, w.getLink)
+	router.RegisterCallbackPrefix(cmdPlaylist, w.playlist)
+	router.RegisterCallbackPrefix(cmdSync, w.sync)
+	router.RegisterCallbackPrefix(cmdToggle, w.toggle)
+	router.RegisterCallbackPrefix(cmdRemove, w.remove)
+}
+
+func (w *watch) init(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.init"
+
+	chatId := update.Message.Chat.ID
+
+	if !w.auth.IsKnown(ctx, chatId) {
+		w.sendMessage(ctx, b, chatId, ctr.ErrUnknown)
+		return
+	}
+
+	playlists, err := w.watch.List(ctx, chatId)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatId,
+		Text:        w.listRepr(playlists),
+		ReplyMarkup: w.listMarkup(playlists),
+		ParseMode:   models.ParseModeHTML,
+	})
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		return
+	}
+
+	w.msgIdStorage.Set(chatId, msg.ID)
+}
+
+func (w *watch) list(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.list"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	w.session.Redirect(chatId, ctr.NullStatus)
+	w.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	if err := w.showList(ctx, b, chatId); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+func (w *watch) add(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.add"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	w.session.Redirect(chatId, w.router.Path(cmdGetLink))
+	w.msgIdStorage.Set(chatId, msgId)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        ctr.WatchAskLink,
+		ReplyMarkup: w.backMarkup(),
+	}); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (w *watch) getLink(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.getLink"
+
+	chatId := update.Message.Chat.ID
+
+	inProgressMsg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   ctr.InProgress,
+	})
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+	defer func() {
+		if _, err := b.DeleteMessages(ctx, &bot.DeleteMessagesParams{
+			ChatID:     chatId,
+			MessageIDs: []int{update.Message.ID, inProgressMsg.ID},
+		}); err != nil {
+			w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}()
+
+	p, err := w.watch.Add(ctx, chatId, strings.TrimSpace(update.Message.Text))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotPlaylist), errors.Is(err, service.ErrInvalidLink):
+			w.sendMessage(ctx, b, chatId, ctr.WatchErrNotPlaylist)
+		case errors.Is(err, service.ErrAlreadyWatched):
+			w.sendMessage(ctx, b, chatId, ctr.WatchErrExists)
+		default:
+			w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	w.session.Redirect(chatId, ctr.NullStatus)
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   w.msgIdStorage.Get(chatId),
+		Text:        fmt.Sprintf(ctr.WatchAdded, p.Name),
+		ReplyMarkup: w.playlistMarkup(p),
+	}); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (w *watch) playlist(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.playlist"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	playlistId, err := strconv.ParseInt(w.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	playlists, err := w.watch.List(ctx, chatId)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	i := slices.IndexFunc(playlists, func(p localModels.WatchedPlaylist) bool {
+		return p.ID == playlistId
+	})
+	if i == -1 {
+		w.sendMessage(ctx, b, chatId, ctr.WatchErrNotFound)
+		return
+	}
+
+	w.showPlaylist(ctx, b, chatId, msgId, playlists[i])
+}
+
+func (w *watch) sync(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.sync"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	playlistId, err := strconv.ParseInt(w.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := w.watch.Sync(ctx, chatId, playlistId); err != nil {
+		switch {
+		case errors.Is(err, service.ErrSyncInProgress):
+			w.sendMessage(ctx, b, chatId, ctr.WatchSyncInProgress)
+		case errors.Is(err, service.ErrWatchNotFound):
+			w.sendMessage(ctx, b, chatId, ctr.WatchErrNotFound)
+		default:
+			w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+			w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		}
+		return
+	}
+
+	w.sendMessage(ctx, b, chatId, ctr.WatchSyncStarted)
+}
+
+func (w *watch) toggle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.toggle"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+	msgId := update.CallbackQuery.Message.Message.ID
+
+	idStr, value, _ := strings.Cut(w.router.GetState(update.CallbackQuery.Data), "-")
+	playlistId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	p, err := w.watch.SetRemoveMissing(ctx, chatId, playlistId, value == "on")
+	if err != nil {
+		if errors.Is(err, service.ErrWatchNotFound) {
+			w.sendMessage(ctx, b, chatId, ctr.WatchErrNotFound)
+			return
+		}
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	w.showPlaylist(ctx, b, chatId, msgId, p)
+}
+
+func (w *watch) remove(ctx context.Context, b *bot.Bot, update *models.Update) {
+	const op = "watch.remove"
+
+	w.CallbackAnswer(ctx, b, update.CallbackQuery)
+
+	chatId := update.CallbackQuery.Message.Message.Chat.ID
+
+	playlistId, err := strconv.ParseInt(w.router.GetState(update.CallbackQuery.Data), 10, 64)
+	if err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	if err := w.watch.Remove(ctx, chatId, playlistId); err != nil && !errors.Is(err, service.ErrWatchNotFound) {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+		return
+	}
+
+	w.msgIdStorage.Set(chatId, update.CallbackQuery.Message.Message.ID)
+
+	if err := w.showList(ctx, b, chatId); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		w.sendMessage(ctx, b, chatId, ctr.ErrorMessage)
+	}
+}
+
+// summary returns notification
+// sending synchronization summary.
+func (w *watch) summary(b *bot.Bot) localModels.WatchNotify {
+	const op = "watch.summary"
+
+	return func(summary localModels.WatchSummary) {
+		chatId := summary.Playlist.UserId
+
+		if _, err := b.SendMessage(context.Background(), &bot.SendMessageParams{
+			ChatID:      chatId,
+			Text:        summary.String(),
+			ReplyMarkup: w.summaryMarkup(summary.Playlist),
+			ParseMode:   models.ParseModeHTML,
+		}); err != nil {
+			w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+		}
+	}
+}
+
+// showList updates stored message with
+// list of watched playlists.
+func (w *watch) showList(ctx context.Context, b *bot.Bot, chatId int64) error {
+	playlists, err := w.watch.List(ctx, chatId)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   w.msgIdStorage.Get(chatId),
+		Text:        w.listRepr(playlists),
+		ReplyMarkup: w.listMarkup(playlists),
+		ParseMode:   models.ParseModeHTML,
+	})
+	return err
+}
+
+func (w *watch) showPlaylist(ctx context.Context, b *bot.Bot, chatId int64, msgId int, p localModels.WatchedPlaylist) {
+	const op = "watch.showPlaylist"
+
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatId,
+		MessageID:   msgId,
+		Text:        p.String(),
+		ReplyMarkup: w.playlistMarkup(p),
+		ParseMode:   models.ParseModeHTML,
+	}); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
+
+func (w *watch) listRepr(playlists []localModels.WatchedPlaylist) string {
+	if len(playlists) == 0 {
+		return ctr.WatchEmpty
+	}
+
+	var b strings.Builder
+
+	b.WriteString(ctr.WatchList + "\n\n")
+	for _, p := range playlists {
+		b.WriteString(p.String() + "\n\n")
+	}
+
+	return b.String()
+}
+
+func (w *watch) sendMessage(ctx context.Context, b *bot.Bot, chatId int64, text string) {
+	const op = "watch.sendMessage"
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatId,
+		Text:   text,
+	}); err != nil {
+		w.onError(fmt.Errorf("%s [%d]: %w", op, chatId, err))
+	}
+}
@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	ffprobe = "ffprobe"
+	ffmpeg  = "ffmpeg"
+)
+
+// Duration returns duration of audio file
+// detected by ffprobe.
+func Duration(ctx context.Context, path string) (time.Duration, error) {
+	const op = "audio.Duration"
+
+	out, err := exec.CommandContext(ctx, ffprobe,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sec, err := strconv.ParseFloat(res.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return time.Duration(sec * float64(time.Second)), nil
+}
+
+// Transcode converts audio file
+// to mp3 with given bitrate in kbps.
+func Transcode(ctx context.Context, src, dst string, bitrate int) error {
+	const op = "audio.Transcode"
+
+	out, err := exec.CommandContext(ctx, ffmpeg,
+		"-v", "error",
+		"-y",
+		"-i", src,
+		"-vn",
+		"-codec:a", "libmp3lame",
+		"-b:a", strconv.Itoa(bitrate)+"k",
+		dst,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", op, err, string(out))
+	}
+
+	return nil
+}
+
+// Clip cuts fragment of audio file
+// starting at start with given length.
+func Clip(ctx context.Context, src, dst string, start, length time.Duration) error {
+	const op = "audio.Clip"
+
+	out, err := exec.CommandContext(ctx, ffmpeg,
+		"-v", "error",
+		"-y",
+		"-ss", strconv.FormatFloat(start.Seconds(), 'f', 3, 64),
+		"-t", strconv.FormatFloat(length.Seconds(), 'f', 3, 64),
+		"-i", src,
+		"-vn",
+		"-codec:a", "libmp3lame",
+		dst,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", op, err, string(out))
+	}
+
+	return nil
+}
@@ -0,0 +1,562 @@
+package dlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	indexFile = "index.json"
+	partExt   = ".part"
+	// Partial downloads older than this
+	// are removed on startup.
+	partTTL = 24 * time.Hour
+	// Extension of files
+	// downloaded by link without one.
+	defaultExt = ".mp3"
+)
+
+// Cache stores downloaded files
+// by keys (e.g. provider and track id).
+// Files are named by content hash, so keys
+// with equal content share one file.
+// Least recently used files are evicted
+// when total size exceeds the limit,
+// pinned files are never evicted.
+type Cache struct {
+	dir     string
+	maxSize int64
+	client  *http.Client
+	quota   Quota
+
+	mutex   sync.Mutex
+	entries map[string]*entry
+	// Keys being downloaded.
+	inflight map[string]chan struct{}
+	// Number of pins by file path.
+	pins map[string]int
+	// Size of running downloads.
+	partSize atomic.Int64
+}
+
+// Quota limits disk usage
+// of files being downloaded.
+type Quota interface {
+	Limit(w io.Writer) io.Writer
+}
+
+type entry struct {
+	Hash string    `json:"hash"`
+	Path string    `json:"path"`
+	Size int64     `json:"size"`
+	Used time.Time `json:"used"`
+}
+
+// Key returns cache key of
+// the file from external source.
+func Key(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// New returns cache stored in dir.
+// Files missing from index, stale partial
+// downloads and index entries without files
+// are removed.
+func New(dir string, maxSize int64) (*Cache, error) {
+	const op = "dlcache.New"
+
+	c := &Cache{
+		dir:      dir,
+		maxSize:  maxSize,
+		client:   http.DefaultClient,
+		entries:  make(map[string]*entry),
+		inflight: make(map[string]chan struct{}),
+		pins:     make(map[string]int),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return c, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c, fmt.Errorf("%s: %w", op, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &c.entries); err != nil {
+			// Broken index, start from scratch.
+			c.entries = make(map[string]*entry)
+		}
+	}
+
+	if err := c.cleanup(); err != nil {
+		return c, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c, nil
+}
+
+// SetQuota limits downloads by quota,
+// download is stopped once quota is exceeded.
+func (c *Cache) SetQuota(q Quota) {
+	c.quota = q
+}
+
+// Get returns path to cached file.
+func (c *Cache) Get(key string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if _, err := os.Stat(e.Path); err != nil {
+		delete(c.entries, key)
+		return "", false
+	}
+
+	e.Used = time.Now()
+
+	return e.Path, true
+}
+
+// Download returns cached file or downloads it.
+// Interrupted download is resumed with range request.
+// Concurrent downloads of the same key are merged.
+func (c *Cache) Download(ctx context.Context, key string, req *http.Request) (string, error) {
+	const op = "dlcache.Download"
+
+	release, err := c.acquire(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer release()
+
+	if p, ok := c.Get(key); ok {
+		return p, nil
+	}
+
+	part := filepath.Join(c.dir, keyName(key)+partExt)
+	if err := c.fetch(ctx, req, part); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	ext := path.Ext(req.URL.Path)
+	if ext == "" {
+		ext = defaultExt
+	}
+
+	p, err := c.store(key, part, ext)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// Put moves file to cache.
+func (c *Cache) Put(key string, src string) (string, error) {
+	const op = "dlcache.Put"
+
+	release, err := c.acquire(context.Background(), key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer release()
+
+	ext := filepath.Ext(src)
+	if ext == "" {
+		ext = defaultExt
+	}
+
+	p, err := c.store(key, src, ext)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// Remove deletes entry. File is deleted
+// if it is not used by other entries.
+func (c *Cache) Remove(key string) error {
+	const op = "dlcache.Remove"
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	delete(c.entries, key)
+
+	if err := c.removeFile(e); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.saveIndex()
+}
+
+// Pin protects file from eviction and removal
+// while it is used (e.g. by queued upload).
+// Every Pin must be followed by Unpin.
+// Paths outside of cache are ignored.
+func (c *Cache) Pin(path string) {
+	if filepath.Dir(path) != filepath.Clean(c.dir) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pins[path]++
+}
+
+// Unpin releases file pinned by Pin.
+// File removed from cache while
+// pinned is deleted now.
+func (c *Cache) Unpin(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pins[path] > 1 {
+		c.pins[path]--
+		return
+	}
+	if _, ok := c.pins[path]; !ok {
+		return
+	}
+	delete(c.pins, path)
+
+	c.removeFile(&entry{Path: path})
+}
+
+// Size returns total size of cached
+// files and running downloads.
+func (c *Cache) Size() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size() + c.partSize.Load()
+}
+
+// acquire waits until key is not downloaded
+// by others and locks it.
+func (c *Cache) acquire(ctx context.Context, key string) (func(), error) {
+	for {
+		c.mutex.Lock()
+		ch, busy := c.inflight[key]
+		if !busy {
+			ch = make(chan struct{})
+			c.inflight[key] = ch
+			c.mutex.Unlock()
+
+			return func() {
+				c.mutex.Lock()
+				delete(c.inflight, key)
+				c.mutex.Unlock()
+				close(ch)
+			}, nil
+		}
+		c.mutex.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// fetch downloads file to part,
+// continuing previous download if any.
+func (c *Cache) fetch(ctx context.Context, req *http.Request, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req = req.Clone(ctx)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Range is not supported, start again.
+		flags |= os.O_TRUNC
+	case http.StatusPartialContent:
+		// Server may return other range,
+		// appending it would break the file.
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+			os.Remove(part)
+			return fmt.Errorf("unexpected content range \"%s\"", resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Part is already complete.
+		return nil
+	default:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// Downloaded bytes are counted in
+	// cache size until file is stored.
+	counter := &partWriter{w: out, size: &c.partSize}
+	defer func() { c.partSize.Add(-counter.written) }()
+
+	var w io.Writer = counter
+	if c.quota != nil {
+		w = c.quota.Limit(counter)
+	}
+
+	// Partial file is kept on error
+	// to resume download later.
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// store moves file to content-addressed
+// path and adds entry to index.
+func (c *Cache) store(key, src, ext string) (string, error) {
+	hash, size, err := hashFile(src)
+	if err != nil {
+		return "", err
+	}
+
+	dst := filepath.Join(c.dir, hash+ext)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, err := os.Stat(dst); err == nil {
+		// Same content is already cached.
+		if err := os.Remove(src); err != nil {
+			return "", err
+		}
+	} else if err := moveFile(src, dst); err != nil {
+		return "", err
+	}
+
+	c.entries[key] = &entry{
+		Hash: hash,
+		Path: dst,
+		Size: size,
+		Used: time.Now(),
+	}
+
+	c.evict(key)
+
+	if err := c.saveIndex(); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// evict removes least recently used entries
+// until cache fits size limit. Entry with
+// key keep and pinned files are never evicted.
+func (c *Cache) evict(keep string) {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for k, e := range c.entries {
+		if k != keep && c.pins[e.Path] == 0 {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return c.entries[a].Used.Compare(c.entries[b].Used)
+	})
+
+	for _, k := range keys {
+		if c.size() <= c.maxSize {
+			return
+		}
+		e := c.entries[k]
+		delete(c.entries, k)
+		c.removeFile(e)
+	}
+}
+
+// cleanup removes files not referenced
+// by index, stale partial downloads
+// and entries without files.
+func (c *Cache) cleanup() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for k, e := range c.entries {
+		if _, err := os.Stat(e.Path); err != nil {
+			delete(c.entries, k)
+		}
+	}
+
+	used := make(map[string]bool, len(c.entries))
+	for _, e := range c.entries {
+		used[filepath.Base(e.Path)] = true
+	}
+
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || f.Name() == indexFile || used[f.Name()] {
+			continue
+		}
+		if strings.HasSuffix(f.Name(), partExt) {
+			info, err := f.Info()
+			if err == nil && time.Since(info.ModTime()) < partTTL {
+				continue
+			}
+		}
+		os.Remove(filepath.Join(c.dir, f.Name()))
+	}
+
+	c.evict("")
+
+	return c.saveIndex()
+}
+
+// removeFile deletes file of entry
+// if no other entry refers to it
+// and it is not pinned.
+func (c *Cache) removeFile(e *entry) error {
+	if c.pins[e.Path] > 0 {
+		return nil
+	}
+	for _, other := range c.entries {
+		if other.Path == e.Path {
+			return nil
+		}
+	}
+	if err := os.Remove(e.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// size returns size of unique files.
+func (c *Cache) size() int64 {
+	var total int64
+
+	seen := make(map[string]bool, len(c.entries))
+	for _, e := range c.entries {
+		if seen[e.Path] {
+			continue
+		}
+		seen[e.Path] = true
+		total += e.Size
+	}
+
+	return total
+}
+
+func (c *Cache) saveIndex() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, indexFile), data, 0644)
+}
+
+// partWriter counts bytes
+// of running download.
+type partWriter struct {
+	w       io.Writer
+	size    *atomic.Int64
+	written int64
+}
+
+func (p *partWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.size.Add(int64(n))
+	return n, err
+}
+
+// keyName returns file name
+// safe representation of key.
+func keyName(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:8])
+}
+
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// moveFile renames file or copies it
+// if it is on the other device.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
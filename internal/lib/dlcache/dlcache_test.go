@@ -0,0 +1,222 @@
+package dlcache
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, content []byte) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.ServeContent(w, r, "track.mp3", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func request(t *testing.T, url string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestDownloadHit(t *testing.T) {
+	content := []byte("some audio content")
+	srv, requests := serve(t, content)
+
+	c, err := New(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	key := Key("yandex", "1")
+	p, err := c.Download(context.Background(), key, request(t, srv.URL+"/track.mp3"))
+	require.NoError(t, err)
+
+	again, err := c.Download(context.Background(), key, request(t, srv.URL+"/track.mp3"))
+	require.NoError(t, err)
+
+	assert.Equal(t, p, again)
+	assert.Equal(t, int32(1), requests.Load())
+
+	data, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	// Same content under other key shares the file.
+	other, err := c.Download(context.Background(), Key("direct", "2"), request(t, srv.URL+"/track.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, p, other)
+	assert.Equal(t, int64(len(content)), c.Size())
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv, _ := serve(t, content)
+
+	dir := t.TempDir()
+	c, err := New(dir, 0)
+	require.NoError(t, err)
+
+	key := Key("yandex", "1")
+	part := filepath.Join(dir, keyName(key)+partExt)
+	require.NoError(t, os.WriteFile(part, content[:8], 0644))
+
+	var ranges []string
+	c.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	p, err := c.Download(context.Background(), key, request(t, srv.URL+"/track.mp3"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bytes=8-"}, ranges)
+
+	data, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	_, err = os.Stat(part)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDownloadResumeOtherRange(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+
+	dir := t.TempDir()
+	c, err := New(dir, 0)
+	require.NoError(t, err)
+
+	key := Key("yandex", "1")
+	part := filepath.Join(dir, keyName(key)+partExt)
+	require.NoError(t, os.WriteFile(part, content[:8], 0644))
+
+	// Server ignores requested offset.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-19/20")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err = c.Download(context.Background(), key, request(t, srv.URL+"/track.mp3"))
+	assert.Error(t, err)
+
+	// Broken part is not resumed.
+	_, err = os.Stat(part)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEviction(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 10)
+	require.NoError(t, err)
+
+	put := func(key, content string) string {
+		src := filepath.Join(t.TempDir(), "file.mp3")
+		require.NoError(t, os.WriteFile(src, []byte(content), 0644))
+		p, err := c.Put(key, src)
+		require.NoError(t, err)
+		return p
+	}
+
+	first := put("a", "aaaa")
+	put("b", "bbbb")
+
+	// Touch first, so second is least recently used.
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	put("c", "cccc")
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	assert.FileExists(t, first)
+	assert.LessOrEqual(t, c.Size(), int64(10))
+}
+
+func TestPin(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 10)
+	require.NoError(t, err)
+
+	put := func(key, content string) string {
+		src := filepath.Join(t.TempDir(), "file.mp3")
+		require.NoError(t, os.WriteFile(src, []byte(content), 0644))
+		p, err := c.Put(key, src)
+		require.NoError(t, err)
+		return p
+	}
+
+	first := put("a", "aaaa")
+	c.Pin(first)
+
+	put("b", "bbbb")
+	put("c", "cccc")
+
+	// Pinned file is kept, though it
+	// is least recently used.
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	// Removed while pinned, deleted on unpin.
+	require.NoError(t, c.Remove("a"))
+	assert.FileExists(t, first)
+	c.Unpin(first)
+	assert.NoFileExists(t, first)
+}
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 0)
+	require.NoError(t, err)
+
+	src := filepath.Join(t.TempDir(), "file.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("content"), 0644))
+	kept, err := c.Put("kept", src)
+	require.NoError(t, err)
+
+	orphan := filepath.Join(dir, "orphan.mp3")
+	require.NoError(t, os.WriteFile(orphan, []byte("orphan"), 0644))
+
+	stale := filepath.Join(dir, "stale"+partExt)
+	require.NoError(t, os.WriteFile(stale, []byte("stale"), 0644))
+	old := time.Now().Add(-2 * partTTL)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	fresh := filepath.Join(dir, "fresh"+partExt)
+	require.NoError(t, os.WriteFile(fresh, []byte("fresh"), 0644))
+
+	c, err = New(dir, 0)
+	require.NoError(t, err)
+
+	p, ok := c.Get("kept")
+	assert.True(t, ok)
+	assert.Equal(t, kept, p)
+	assert.NoFileExists(t, orphan)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, fresh)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
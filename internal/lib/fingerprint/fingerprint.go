@@ -0,0 +1,91 @@
+package fingerprint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os/exec"
+)
+
+// Fingerprint is a raw chromaprint
+// fingerprint of an audio file.
+type Fingerprint []uint32
+
+var (
+	ErrEmptyFingerprint = errors.New("empty fingerprint")
+)
+
+const (
+	// fpcalc is a chromaprint command line tool.
+	fpcalc = "fpcalc"
+
+	// maxOffset is a maximum shift (in fingerprint items)
+	// between two fingerprints to compare.
+	// One item covers ~0.124 sec of audio.
+	maxOffset = 80
+	// minOverlap is a minimum number of items
+	// to compare two fingerprints.
+	minOverlap = 40
+)
+
+// Compute calculates fingerprint for audio file
+// using chromaprint's fpcalc utility.
+func Compute(ctx context.Context, path string) (Fingerprint, error) {
+	const op = "fingerprint.Compute"
+
+	out, err := exec.CommandContext(ctx, fpcalc, "-raw", "-json", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var res struct {
+		Duration    float64  `json:"duration"`
+		Fingerprint []uint32 `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(res.Fingerprint) == 0 {
+		return nil, ErrEmptyFingerprint
+	}
+
+	return res.Fingerprint, nil
+}
+
+// Similarity returns similarity of two fingerprints
+// from 0 to 1. Fingerprints are aligned with the
+// best offset, so different silence at the beginning
+// of records does not matter.
+func Similarity(a, b Fingerprint) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	best := 0.0
+
+	for offset := -maxOffset; offset <= maxOffset; offset++ {
+		var (
+			diff  int
+			count int
+		)
+		for i := range a {
+			j := i + offset
+			if j < 0 || j >= len(b) {
+				continue
+			}
+			diff += bits.OnesCount32(a[i] ^ b[j])
+			count++
+		}
+		if count < minOverlap {
+			continue
+		}
+		if sim := 1 - float64(diff)/float64(32*count); sim > best {
+			best = sim
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,78 @@
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sample(n int, seed uint32) Fingerprint {
+	fp := make(Fingerprint, n)
+	x := seed
+	for i := range fp {
+		// xorshift, deterministic pseudo-random items.
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		fp[i] = x
+	}
+	return fp
+}
+
+func TestSimilarity(t *testing.T) {
+	a := sample(200, 1)
+
+	inverted := make(Fingerprint, len(a))
+	for i, v := range a {
+		inverted[i] = ^v
+	}
+
+	tests := []struct {
+		desc     string
+		a, b     Fingerprint
+		expected float64
+	}{
+		{
+			desc:     "identical",
+			a:        a,
+			b:        a,
+			expected: 1,
+		},
+		{
+			desc:     "empty",
+			a:        a,
+			b:        Fingerprint{},
+			expected: 0,
+		},
+		{
+			desc:     "leading silence",
+			a:        a,
+			b:        append(make(Fingerprint, 30), a...),
+			expected: 1,
+		},
+		{
+			desc:     "short overlap",
+			a:        a[:minOverlap-1],
+			b:        a[:minOverlap-1],
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, Similarity(tt.a, tt.b), 1e-9)
+		})
+	}
+
+	// Unrelated items match by half of bits.
+	t.Run("different records", func(t *testing.T) {
+		assert.Less(t, Similarity(a, sample(200, 7)), 0.6)
+	})
+	t.Run("inverted", func(t *testing.T) {
+		assert.Less(t, Similarity(a, inverted), 0.6)
+	})
+	t.Run("shift over max offset", func(t *testing.T) {
+		b := append(sample(maxOffset+1, 7), a[:minOverlap]...)
+		assert.Less(t, Similarity(a[:minOverlap], b), 0.6)
+	})
+}
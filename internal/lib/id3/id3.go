@@ -0,0 +1,309 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Tags is a subset of ID3 metadata
+// used for media upload.
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+	Genre  string
+	Year   int
+	Track  int
+	Disc   int
+	// Attached picture, front
+	// cover is preferred.
+	Picture Picture
+}
+
+type Picture struct {
+	MIME string
+	Data []byte
+}
+
+var (
+	ErrNoTags = errors.New("id3 tags not found")
+)
+
+const (
+	headerSize = 10
+	v1Size     = 128
+
+	// APIC picture type of front cover.
+	frontCover = 3
+)
+
+// ReadFile reads ID3v2 tags from file,
+// falling back to ID3v1 if there are no v2 tags.
+func ReadFile(path string) (Tags, error) {
+	const op = "id3.ReadFile"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Tags{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	tags, err := readV2(f)
+	if err == nil {
+		return tags, nil
+	}
+	if !errors.Is(err, ErrNoTags) {
+		return Tags{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, err = readV1(f)
+	if err != nil {
+		return Tags{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+func readV2(r io.ReadSeeker) (Tags, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Tags{}, ErrNoTags
+	}
+	if string(header[:3]) != "ID3" {
+		return Tags{}, ErrNoTags
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Tags{}, err
+	}
+
+	// Skip extended header.
+	if flags&0x40 != 0 && len(body) >= 4 {
+		var extSize int
+		if version == 4 {
+			extSize = syncsafe(body[:4])
+		} else {
+			extSize = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+		if extSize > len(body) {
+			return Tags{}, ErrNoTags
+		}
+		body = body[extSize:]
+	}
+
+	var tags Tags
+
+	for len(body) >= headerSize {
+		id := string(body[:4])
+		if id[0] == 0 {
+			// padding
+			break
+		}
+
+		var frameSize int
+		if version == 4 {
+			frameSize = syncsafe(body[4:8])
+		} else {
+			frameSize = int(binary.BigEndian.Uint32(body[4:8]))
+		}
+		if frameSize <= 0 || headerSize+frameSize > len(body) {
+			break
+		}
+
+		data := body[headerSize : headerSize+frameSize]
+		body = body[headerSize+frameSize:]
+
+		switch id {
+		case "TIT2":
+			tags.Title = decodeText(data)
+		case "TPE1":
+			tags.Artist = decodeText(data)
+		case "TALB":
+			tags.Album = decodeText(data)
+		case "TCON":
+			tags.Genre = decodeText(data)
+		case "TYER", "TDRC":
+			tags.Year = leadingInt(decodeText(data))
+		case "TRCK":
+			tags.Track = leadingInt(decodeText(data))
+		case "TPOS":
+			tags.Disc = leadingInt(decodeText(data))
+		case "APIC":
+			pic, picType, ok := decodePicture(data)
+			if ok && (len(tags.Picture.Data) == 0 || picType == frontCover) {
+				tags.Picture = pic
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func readV1(r io.ReadSeeker) (Tags, error) {
+	if _, err := r.Seek(-v1Size, io.SeekEnd); err != nil {
+		return Tags{}, ErrNoTags
+	}
+
+	data := make([]byte, v1Size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Tags{}, ErrNoTags
+	}
+	if string(data[:3]) != "TAG" {
+		return Tags{}, ErrNoTags
+	}
+
+	tags := Tags{
+		Title:  trimV1(data[3:33]),
+		Artist: trimV1(data[33:63]),
+		Album:  trimV1(data[63:93]),
+		Year:   leadingInt(trimV1(data[93:97])),
+	}
+	// ID3v1.1 track number.
+	if data[125] == 0 && data[126] != 0 {
+		tags.Track = int(data[126])
+	}
+
+	return tags, nil
+}
+
+// decodeText decodes text frame
+// according to its encoding byte.
+func decodeText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	enc, data := data[0], data[1:]
+
+	var s string
+	switch enc {
+	case 1, 2:
+		s = decodeUTF16(data, enc == 2)
+	default:
+		// ISO-8859-1 is a subset of unicode,
+		// UTF-8 is used as is.
+		if enc == 0 {
+			runes := make([]rune, 0, len(data))
+			for _, b := range data {
+				runes = append(runes, rune(b))
+			}
+			s = string(runes)
+		} else {
+			s = string(data)
+		}
+	}
+
+	// Multiple values are separated by null character.
+	s, _, _ = strings.Cut(s, "\x00")
+
+	return strings.TrimSpace(s)
+}
+
+// decodePicture decodes APIC frame.
+func decodePicture(data []byte) (Picture, byte, bool) {
+	if len(data) < 2 {
+		return Picture{}, 0, false
+	}
+
+	enc, data := data[0], data[1:]
+
+	mime, data, found := bytes.Cut(data, []byte{0})
+	// "-->" means link instead of image.
+	if !found || len(data) < 1 || string(mime) == "-->" {
+		return Picture{}, 0, false
+	}
+
+	picType, data := data[0], data[1:]
+
+	// Skip description terminated
+	// according to text encoding.
+	if enc == 1 || enc == 2 {
+		i := 0
+		for ; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				break
+			}
+		}
+		if i+1 >= len(data) {
+			return Picture{}, 0, false
+		}
+		data = data[i+2:]
+	} else {
+		_, data, found = bytes.Cut(data, []byte{0})
+		if !found {
+			return Picture{}, 0, false
+		}
+	}
+
+	if len(data) == 0 {
+		return Picture{}, 0, false
+	}
+
+	pic := Picture{
+		MIME: strings.ToLower(string(mime)),
+		Data: data,
+	}
+	switch pic.MIME {
+	case "image/jpg", "jpg", "":
+		pic.MIME = "image/jpeg"
+	case "png":
+		pic.MIME = "image/png"
+	}
+
+	return pic, picType, true
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	if len(data) >= 2 {
+		switch {
+		case data[0] == 0xFF && data[1] == 0xFE:
+			bigEndian = false
+			data = data[2:]
+		case data[0] == 0xFE && data[1] == 0xFF:
+			bigEndian = true
+			data = data[2:]
+		}
+	}
+
+	u := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			u = append(u, binary.BigEndian.Uint16(data[i:]))
+		} else {
+			u = append(u, binary.LittleEndian.Uint16(data[i:]))
+		}
+	}
+
+	return string(utf16.Decode(u))
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0])<<21 | int(b[1])<<14 | int(b[2])<<7 | int(b[3])
+}
+
+func trimV1(b []byte) string {
+	return strings.TrimSpace(string(bytes.TrimRight(b, "\x00")))
+}
+
+// leadingInt parses integer prefix
+// of strings like "3/12" or "2011-05-01".
+func leadingInt(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
@@ -0,0 +1,159 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeSyncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// frame encodes frame of
+// given ID3v2 minor version.
+func frame(version byte, id string, data []byte) []byte {
+	size := make([]byte, 4)
+	if version == 4 {
+		size = encodeSyncsafe(len(data))
+	} else {
+		binary.BigEndian.PutUint32(size, uint32(len(data)))
+	}
+
+	b := []byte(id)
+	b = append(b, size...)
+	b = append(b, 0, 0)
+	return append(b, data...)
+}
+
+// tag encodes ID3v2 tag
+// with frames and padding.
+func tag(version byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 16)...)
+
+	b := []byte{'I', 'D', '3', version, 0, 0}
+	b = append(b, encodeSyncsafe(len(body))...)
+	return append(b, body...)
+}
+
+func latin1(s string) []byte {
+	b := []byte{0}
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+func utf16LE(s string) []byte {
+	b := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func utf8(s string) []byte {
+	return append([]byte{3}, s...)
+}
+
+// picture encodes APIC frame data
+// with latin-1 description.
+func picture(mime string, picType byte, data []byte) []byte {
+	b := []byte{0}
+	b = append(b, mime...)
+	b = append(b, 0, picType)
+	b = append(b, "cover"...)
+	b = append(b, 0)
+	return append(b, data...)
+}
+
+func writeFile(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestReadV23(t *testing.T) {
+	back := []byte("back")
+	front := []byte("front")
+
+	path := writeFile(t, tag(3,
+		frame(3, "TIT2", utf16LE("Группа крови")),
+		frame(3, "TPE1", latin1("Kino")),
+		frame(3, "TALB", utf16LE("Группа крови\x00Remastered")),
+		frame(3, "TYER", latin1("1988")),
+		frame(3, "TRCK", latin1("1/10")),
+		frame(3, "TPOS", latin1("2/2")),
+		frame(3, "APIC", picture("image/png", 0, back)),
+		frame(3, "APIC", picture("jpg", frontCover, front)),
+		frame(3, "APIC", picture("-->", frontCover, []byte("https://example.com/cover.jpg"))),
+	))
+
+	tags, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Группа крови", tags.Title)
+	assert.Equal(t, "Kino", tags.Artist)
+	// Only first value is used.
+	assert.Equal(t, "Группа крови", tags.Album)
+	assert.Equal(t, 1988, tags.Year)
+	assert.Equal(t, 1, tags.Track)
+	assert.Equal(t, 2, tags.Disc)
+	// Front cover is preferred, links are skipped.
+	assert.Equal(t, Picture{MIME: "image/jpeg", Data: front}, tags.Picture)
+}
+
+func TestReadV24(t *testing.T) {
+	// Longer than 127 bytes,
+	// so frame size is syncsafe.
+	cover := bytes.Repeat([]byte{0xFF}, 200)
+
+	path := writeFile(t, tag(4,
+		frame(4, "TIT2", utf8("Восьмиклассница")),
+		frame(4, "TPE1", utf8("Кино")),
+		frame(4, "TCON", utf8("Rock")),
+		frame(4, "TDRC", utf8("1982-05-01")),
+		frame(4, "APIC", picture("image/png", 0, cover)),
+		frame(4, "TRCK", utf8("7")),
+	))
+
+	tags, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Восьмиклассница", tags.Title)
+	assert.Equal(t, "Кино", tags.Artist)
+	assert.Equal(t, "Rock", tags.Genre)
+	assert.Equal(t, 1982, tags.Year)
+	assert.Equal(t, 7, tags.Track)
+	// Picture of any type is used
+	// if there is no front cover.
+	assert.Equal(t, Picture{MIME: "image/png", Data: cover}, tags.Picture)
+}
+
+func TestReadV1(t *testing.T) {
+	data := make([]byte, v1Size)
+	copy(data, "TAG")
+	copy(data[3:], "Title")
+	copy(data[33:], "Artist")
+	copy(data[63:], "Album")
+	copy(data[93:], "2001")
+	data[126] = 5
+
+	path := writeFile(t, append([]byte("audio"), data...))
+
+	tags, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, Tags{Title: "Title", Artist: "Artist", Album: "Album", Year: 2001, Track: 5}, tags)
+}
+
+func TestReadNoTags(t *testing.T) {
+	path := writeFile(t, bytes.Repeat([]byte("audio"), 100))
+
+	_, err := ReadFile(path)
+	assert.ErrorIs(t, err, ErrNoTags)
+}
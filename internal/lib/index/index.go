@@ -0,0 +1,218 @@
+// Package index implements in-memory full-text
+// index ranking documents with BM25.
+//
+// Text is normalized and transliterated (see fuzzy.Key),
+// so cyrillic and latin spellings match each other.
+// Besides words, character trigrams are indexed,
+// so partial words and typos still match.
+package index
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/fuzzy"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Whole words weigh more than trigrams,
+// so exact word match is ranked higher.
+const wordWeight = 3
+
+// Share of query trigrams document must
+// contain to be returned. It is low enough
+// to tolerate a typo in short word.
+const minCoverage = 1. / 3
+
+// Prefix of word terms,
+// distinguishing them from trigrams.
+const wordPrefix = "#"
+
+type Index struct {
+	mutex    sync.RWMutex
+	docs     map[int64]map[string]int
+	lens     map[int64]int
+	postings map[string]map[int64]struct{}
+	totalLen int
+}
+
+type Result struct {
+	ID    int64
+	Score float64
+}
+
+func New() *Index {
+	return &Index{
+		docs:     make(map[int64]map[string]int),
+		lens:     make(map[int64]int),
+		postings: make(map[string]map[int64]struct{}),
+	}
+}
+
+// Add indexes document built from fields.
+// Existing document with the same id is replaced.
+func (idx *Index) Add(id int64, fields ...string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.remove(id)
+
+	terms := make(map[string]int)
+	length := 0
+	for _, f := range fields {
+		for _, t := range Terms(f) {
+			terms[t]++
+			length++
+		}
+	}
+
+	idx.docs[id] = terms
+	idx.lens[id] = length
+	idx.totalLen += length
+
+	for t := range terms {
+		if idx.postings[t] == nil {
+			idx.postings[t] = make(map[int64]struct{})
+		}
+		idx.postings[t][id] = struct{}{}
+	}
+}
+
+// Remove removes document from index.
+func (idx *Index) Remove(id int64) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.remove(id)
+}
+
+// Len returns number of documents.
+func (idx *Index) Len() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Search returns documents matching query
+// sorted by relevance. Non-positive limit
+// means no limit.
+func (idx *Index) Search(query string, limit int) []Result {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	queryTerms := unique(Terms(query))
+	if len(queryTerms) == 0 || len(idx.docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+
+	trigrams := 0
+	for _, t := range queryTerms {
+		if !strings.HasPrefix(t, wordPrefix) {
+			trigrams++
+		}
+	}
+
+	scores := make(map[int64]float64)
+	matched := make(map[int64]int)
+
+	for _, t := range queryTerms {
+		docs := idx.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		word := strings.HasPrefix(t, wordPrefix)
+		weight := 1.0
+		if word {
+			weight = wordWeight
+		}
+
+		for id := range docs {
+			tf := float64(idx.docs[id][t])
+			norm := tf * (k1 + 1) / (tf + k1*(1-b+b*float64(idx.lens[id])/avgLen))
+			scores[id] += weight * idf * norm
+			if !word {
+				matched[id]++
+			}
+		}
+	}
+
+	res := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		if float64(matched[id]) < minCoverage*float64(trigrams) {
+			continue
+		}
+		res = append(res, Result{ID: id, Score: score})
+	}
+
+	slices.SortFunc(res, func(x, y Result) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.ID, y.ID)
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res
+}
+
+// Terms splits text into indexed terms:
+// normalized words and their trigrams.
+func Terms(text string) []string {
+	words := strings.Fields(fuzzy.Key(text))
+
+	terms := make([]string, 0, len(words)*4)
+	for _, w := range words {
+		terms = append(terms, wordPrefix+w)
+
+		// Padding marks word boundaries,
+		// so word beginnings weigh more.
+		r := []rune(" " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			terms = append(terms, string(r[i:i+3]))
+		}
+	}
+
+	return terms
+}
+
+func (idx *Index) remove(id int64) {
+	terms, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for t := range terms {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+
+	idx.totalLen -= idx.lens[id]
+	delete(idx.docs, id)
+	delete(idx.lens, id)
+}
+
+func unique(terms []string) []string {
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
@@ -0,0 +1,80 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	idx := New()
+	idx.Add(1, "Хочешь?", "Земфира")
+	idx.Add(2, "Искала", "Земфира")
+	idx.Add(3, "Zombie", "The Cranberries")
+	idx.Add(4, "Кукушка", "Кино")
+	idx.Add(5, "Группа крови", "Кино")
+
+	testCases := []struct {
+		desc     string
+		query    string
+		expected []int64
+	}{
+		{
+			desc:  "transliteration",
+			query: "zemfira",
+			// Shorter document ranks higher.
+			expected: []int64{2, 1},
+		},
+		{
+			desc:     "case and punctuation",
+			query:    "ХОЧЕШЬ",
+			expected: []int64{1},
+		},
+		{
+			desc:     "partial word",
+			query:    "cranber",
+			expected: []int64{3},
+		},
+		{
+			desc:     "typo",
+			query:    "Кукушко",
+			expected: []int64{4},
+		},
+		{
+			desc:     "name and author",
+			query:    "кино группа",
+			expected: []int64{5, 4},
+		},
+		{
+			desc:     "nothing",
+			query:    "metallica",
+			expected: []int64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ids := make([]int64, 0)
+			for _, r := range idx.Search(tc.query, 0) {
+				ids = append(ids, r.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	idx := New()
+	idx.Add(1, "Кукушка", "Кино")
+	idx.Add(1, "Звезда по имени Солнце", "Кино")
+
+	assert.Equal(t, 1, idx.Len())
+	assert.Empty(t, idx.Search("кукушка", 0))
+	assert.Len(t, idx.Search("солнце", 0), 1)
+
+	idx.Remove(1)
+
+	assert.Equal(t, 0, idx.Len())
+	assert.Empty(t, idx.Search("солнце", 0))
+	assert.Empty(t, idx.postings)
+}
@@ -0,0 +1,185 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Registry keeps records by id, stores them
+// to JSON file and processes them periodically
+// in background. Records and marks are accessed
+// with locked registry, Dump locks it itself.
+type Registry[T any] struct {
+	sync.Mutex
+
+	file string
+	id   func(*T) int64
+	copy func(*T) T
+
+	items  map[int64]*T
+	lastId int64
+	// Records being processed.
+	marked map[int64]bool
+
+	// Guards file writes.
+	fileMutex sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns registry loaded from file.
+// Registry is usable even if file
+// failed to load, it is empty then.
+func New[T any](file string, id func(*T) int64, copy func(*T) T) (*Registry[T], error) {
+	const op = "registry.New"
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Registry[T]{
+		file:   file,
+		id:     id,
+		copy:   copy,
+		items:  make(map[int64]*T),
+		marked: make(map[int64]bool),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return r, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var items []*T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return r, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, item := range items {
+		r.items[id(item)] = item
+		r.lastId = max(r.lastId, id(item))
+	}
+
+	return r, nil
+}
+
+// Context returns context
+// canceled on Stop.
+func (r *Registry[T]) Context() context.Context {
+	return r.ctx
+}
+
+// Start calls check at once and then
+// every interval until Stop is called.
+func (r *Registry[T]) Start(interval time.Duration, check func()) {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			check()
+
+			select {
+			case <-ticker.C:
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops background checks
+// started by Start.
+func (r *Registry[T]) Stop() {
+	r.cancel()
+	<-r.done
+}
+
+// NextId returns id for new record.
+func (r *Registry[T]) NextId() int64 {
+	r.lastId++
+	return r.lastId
+}
+
+// Get returns record by id.
+func (r *Registry[T]) Get(id int64) (*T, bool) {
+	item, ok := r.items[id]
+	return item, ok
+}
+
+// Put adds record or replaces
+// record with the same id.
+func (r *Registry[T]) Put(item *T) {
+	r.items[r.id(item)] = item
+}
+
+// Delete removes record and its mark.
+func (r *Registry[T]) Delete(id int64) {
+	delete(r.items, id)
+	delete(r.marked, id)
+}
+
+// All returns records by ids.
+// Map must not be modified.
+func (r *Registry[T]) All() map[int64]*T {
+	return r.items
+}
+
+// Mark marks record as being processed.
+// Returns false if it is already marked.
+func (r *Registry[T]) Mark(id int64) bool {
+	if r.marked[id] {
+		return false
+	}
+	r.marked[id] = true
+	return true
+}
+
+// Marked reports if record is being processed.
+func (r *Registry[T]) Marked(id int64) bool {
+	return r.marked[id]
+}
+
+// Unmark marks record as processed.
+func (r *Registry[T]) Unmark(id int64) {
+	delete(r.marked, id)
+}
+
+// Dump writes copies of records to file.
+func (r *Registry[T]) Dump() error {
+	const op = "registry.Dump"
+
+	r.Lock()
+	items := make([]T, 0, len(r.items))
+	for _, item := range r.items {
+		items = append(items, r.copy(item))
+	}
+	r.Unlock()
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.fileMutex.Lock()
+	defer r.fileMutex.Unlock()
+
+	if err := os.WriteFile(r.file, data, 0644); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
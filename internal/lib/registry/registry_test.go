@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newRegistry(t *testing.T, file string) *Registry[record] {
+	r, err := New(file, func(rec *record) int64 {
+		return rec.ID
+	}, func(rec *record) record {
+		return *rec
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestDump(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "records.json")
+
+	r := newRegistry(t, file)
+	r.Lock()
+	r.Put(&record{ID: r.NextId(), Name: "first"})
+	r.Put(&record{ID: r.NextId(), Name: "second"})
+	r.Delete(1)
+	r.Unlock()
+	require.NoError(t, r.Dump())
+
+	// Ids are not reused after restart.
+	restored := newRegistry(t, file)
+	restored.Lock()
+	defer restored.Unlock()
+
+	assert.Equal(t, map[int64]*record{2: {ID: 2, Name: "second"}}, restored.All())
+	assert.Equal(t, int64(3), restored.NextId())
+}
+
+func TestBrokenFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "records.json")
+	require.NoError(t, os.WriteFile(file, []byte("{"), 0644))
+
+	r, err := New(file, func(rec *record) int64 {
+		return rec.ID
+	}, func(rec *record) record {
+		return *rec
+	})
+	assert.Error(t, err)
+	// Registry is usable anyway.
+	assert.Empty(t, r.All())
+}
+
+func TestMark(t *testing.T) {
+	r := newRegistry(t, filepath.Join(t.TempDir(), "records.json"))
+
+	assert.True(t, r.Mark(1))
+	assert.False(t, r.Mark(1))
+	assert.True(t, r.Marked(1))
+
+	r.Unmark(1)
+	assert.False(t, r.Marked(1))
+
+	r.Mark(1)
+	r.Delete(1)
+	assert.False(t, r.Marked(1))
+}
+
+func TestStart(t *testing.T) {
+	r := newRegistry(t, filepath.Join(t.TempDir(), "records.json"))
+
+	checks := make(chan struct{})
+	r.Start(time.Millisecond, func() {
+		select {
+		case checks <- struct{}{}:
+		default:
+		}
+	})
+
+	<-checks
+	<-checks
+	r.Stop()
+
+	assert.Error(t, r.Context().Err())
+}
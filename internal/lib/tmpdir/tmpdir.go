@@ -0,0 +1,331 @@
+package tmpdir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	indexFile     = ".index.json"
+	sweepInterval = 10 * time.Minute
+	// Quota is checked after every
+	// written chunk of this size.
+	checkEvery = 1 << 20
+)
+
+var (
+	ErrQuotaExceeded = errors.New("temporary directory quota exceeded")
+)
+
+// Manager owns temporary directory.
+// Every file is created with owner and TTL,
+// expired and unknown files are removed
+// on startup and periodically.
+// Quota covers files of directory and
+// included storages (e.g. download cache).
+type Manager struct {
+	log     *slog.Logger
+	dir     string
+	maxSize int64
+
+	mutex sync.Mutex
+	files map[string]file
+	// Sizes of included storages by owners.
+	included map[string]func() int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type file struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+// New returns manager of dir.
+// Files not created by manager
+// (e.g. left after crash) are removed.
+func New(log *slog.Logger, dir string, maxSize int64) (*Manager, error) {
+	const op = "tmpdir.New"
+
+	m := &Manager{
+		log:      log,
+		dir:      dir,
+		maxSize:  maxSize,
+		files:    make(map[string]file),
+		included: make(map[string]func() int64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return m, fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return m, fmt.Errorf("%s: %w", op, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &m.files); err != nil {
+			// Broken index, all files are orphans.
+			m.files = make(map[string]file)
+		}
+	}
+
+	m.Sweep()
+
+	return m, nil
+}
+
+// Start runs periodic sweeps in background.
+func (m *Manager) Start() {
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Sweep()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic sweeps.
+func (m *Manager) Stop() {
+	close(m.stop)
+	<-m.done
+}
+
+// Include counts storage outside of directory
+// in quota and usage. Size must not call manager.
+func (m *Manager) Include(owner string, size func() int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.included[owner] = size
+}
+
+// Exceeded reports if total size
+// of files reached the limit.
+func (m *Manager) Exceeded() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.maxSize > 0 && m.size() >= m.maxSize
+}
+
+// Limit returns writer failing with ErrQuotaExceeded
+// once quota is exceeded, so large files are
+// stopped while they are written.
+func (m *Manager) Limit(w io.Writer) io.Writer {
+	if m.maxSize <= 0 {
+		return w
+	}
+	return &quotaWriter{m: m, w: w, unchecked: checkEvery}
+}
+
+// Create creates new file removed after ttl.
+// Returns ErrQuotaExceeded if total size
+// of files exceeds the limit.
+func (m *Manager) Create(owner, pattern string, ttl time.Duration) (*os.File, error) {
+	const op = "tmpdir.Create"
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.maxSize > 0 && m.size() >= m.maxSize {
+		m.log.Warn(
+			"temporary directory quota exceeded",
+			slog.String("op", op),
+			slog.String("owner", owner),
+			slog.Int64("maxSize", m.maxSize),
+		)
+		return nil, fmt.Errorf("%s: %w", op, ErrQuotaExceeded)
+	}
+
+	f, err := os.CreateTemp(m.dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.files[filepath.Base(f.Name())] = file{
+		Owner:   owner,
+		Expires: time.Now().Add(ttl),
+	}
+	m.saveIndex()
+
+	return f, nil
+}
+
+// Release removes file before its TTL.
+func (m *Manager) Release(path string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.remove(filepath.Base(path))
+	m.saveIndex()
+}
+
+// Extend sets TTL of file counting
+// from now. Unknown files are ignored.
+func (m *Manager) Extend(path string, ttl time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name := filepath.Base(path)
+	f, ok := m.files[name]
+	if !ok {
+		return
+	}
+	f.Expires = time.Now().Add(ttl)
+	m.files[name] = f
+	m.saveIndex()
+}
+
+// Sweep removes expired files, files unknown
+// to manager and index entries without files.
+func (m *Manager) Sweep() {
+	const op = "tmpdir.Sweep"
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	removed := 0
+
+	for name, f := range m.files {
+		if _, err := os.Stat(filepath.Join(m.dir, name)); err != nil {
+			delete(m.files, name)
+			continue
+		}
+		if now.After(f.Expires) {
+			m.remove(name)
+			removed++
+		}
+	}
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		m.log.Error("failed to read directory", slog.String("op", op), sl.Err(err))
+		return
+	}
+	for _, e := range entries {
+		if e.Name() == indexFile {
+			continue
+		}
+		if _, ok := m.files[e.Name()]; ok {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(m.dir, e.Name())); err != nil {
+			m.log.Warn("failed to remove orphan", slog.String("name", e.Name()), sl.Err(err))
+			continue
+		}
+		removed++
+	}
+
+	m.saveIndex()
+
+	if removed > 0 {
+		m.log.Info("temporary files removed", slog.String("op", op), slog.Int("count", removed))
+	}
+}
+
+// Usage returns number and size
+// of temporary files by owners.
+func (m *Manager) Usage() models.TmpUsage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	usage := models.TmpUsage{
+		MaxSize: m.maxSize,
+		Owners:  make(map[string]int64),
+	}
+
+	for name, f := range m.files {
+		info, err := os.Stat(filepath.Join(m.dir, name))
+		if err != nil {
+			continue
+		}
+		usage.Files++
+		usage.Size += info.Size()
+		usage.Owners[f.Owner] += info.Size()
+	}
+	for owner, size := range m.included {
+		s := size()
+		usage.Size += s
+		usage.Owners[owner] += s
+	}
+
+	return usage
+}
+
+// size returns total size of files
+// and included storages.
+func (m *Manager) size() int64 {
+	var total int64
+	for name := range m.files {
+		if info, err := os.Stat(filepath.Join(m.dir, name)); err == nil {
+			total += info.Size()
+		}
+	}
+	for _, size := range m.included {
+		total += size()
+	}
+	return total
+}
+
+func (m *Manager) remove(name string) {
+	delete(m.files, name)
+	if err := os.Remove(filepath.Join(m.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		m.log.Warn("failed to remove file", slog.String("name", name), sl.Err(err))
+	}
+}
+
+func (m *Manager) saveIndex() {
+	data, err := json.Marshal(m.files)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(m.dir, indexFile), data, 0644)
+	}
+	if err != nil {
+		m.log.Error("failed to save index", sl.Err(err))
+	}
+}
+
+// quotaWriter checks quota
+// while file is written.
+type quotaWriter struct {
+	m *Manager
+	w io.Writer
+	// Bytes written since last check.
+	unchecked int
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if q.unchecked >= checkEvery {
+		if q.m.Exceeded() {
+			return 0, ErrQuotaExceeded
+		}
+		q.unchecked = 0
+	}
+
+	n, err := q.w.Write(p)
+	q.unchecked += n
+	return n, err
+}
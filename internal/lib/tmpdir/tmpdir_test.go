@@ -0,0 +1,110 @@
+package tmpdir
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestSweep(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := New(log, dir, 0)
+	require.NoError(t, err)
+
+	kept, err := m.Create("upload", "kept-*", time.Hour)
+	require.NoError(t, err)
+	kept.Close()
+
+	expired, err := m.Create("upload", "expired-*", -time.Second)
+	require.NoError(t, err)
+	expired.Close()
+
+	orphan := filepath.Join(dir, "orphan.mp3")
+	require.NoError(t, os.WriteFile(orphan, []byte("orphan"), 0644))
+
+	// Restarted manager keeps TTLs.
+	m, err = New(log, dir, 0)
+	require.NoError(t, err)
+
+	assert.FileExists(t, kept.Name())
+	assert.NoFileExists(t, expired.Name())
+	assert.NoFileExists(t, orphan)
+	assert.Equal(t, 1, m.Usage().Files)
+}
+
+func TestExtend(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := New(log, dir, 0)
+	require.NoError(t, err)
+
+	f, err := m.Create("upload", "extended-*", -time.Second)
+	require.NoError(t, err)
+	f.Close()
+
+	m.Extend(f.Name(), time.Hour)
+	// Unknown files are not added.
+	m.Extend(filepath.Join(dir, "unknown.mp3"), time.Hour)
+
+	m.Sweep()
+	assert.FileExists(t, f.Name())
+	assert.Equal(t, 1, m.Usage().Files)
+}
+
+func TestQuota(t *testing.T) {
+	m, err := New(log, t.TempDir(), 4)
+	require.NoError(t, err)
+
+	f, err := m.Create("upload", "file-*", time.Hour)
+	require.NoError(t, err)
+	_, err = f.WriteString("content")
+	require.NoError(t, err)
+	f.Close()
+
+	_, err = m.Create("upload", "file-*", time.Hour)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	m.Release(f.Name())
+
+	_, err = m.Create("upload", "file-*", time.Hour)
+	assert.NoError(t, err)
+
+	usage := m.Usage()
+	assert.Equal(t, 1, usage.Files)
+	assert.Equal(t, int64(0), usage.Owners["upload"])
+}
+
+func TestQuotaWhileWriting(t *testing.T) {
+	m, err := New(log, t.TempDir(), 3*checkEvery)
+	require.NoError(t, err)
+
+	// Included storage is counted.
+	m.Include("downloads", func() int64 { return checkEvery })
+
+	f, err := m.Create("upload", "file-*", time.Hour)
+	require.NoError(t, err)
+	defer f.Close()
+
+	chunk := make([]byte, checkEvery)
+	w := m.Limit(f)
+
+	_, err = w.Write(chunk)
+	require.NoError(t, err)
+	_, err = w.Write(chunk)
+	require.NoError(t, err)
+	_, err = w.Write(chunk)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	usage := m.Usage()
+	assert.Equal(t, int64(3*checkEvery), usage.Size)
+	assert.Equal(t, int64(checkEvery), usage.Owners["downloads"])
+}
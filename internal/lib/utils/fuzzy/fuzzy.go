@@ -0,0 +1,141 @@
+package fuzzy
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// "Artist feat. X", "Artist ft X", "Artist (feat. X)",
+	// "Песня (при уч. X)". Boundaries are explicit,
+	// since \b of RE2 does not match cyrillic letters.
+	// Keyword must follow separator, so names
+	// like "Ft Lauderdale" are kept.
+	featuring = regexp.MustCompile(`(?i)\s*[\s(\[](feat|ft|featuring|при\s+уч(астии)?)(\.|\s|$).*$`)
+	// "(Remastered 2011)", "[Live]", "- Radio Edit" and so on.
+	versionSuffix = regexp.MustCompile(`(?i)\s*([\(\[][^\)\]]*(remaster|remix|edit|version|live|mono|stereo|deluxe|bonus|ремастер|ремикс|версия)[^\)\]]*[\)\]]|-\s*(\d{4}\s*)?(remaster(ed)?|radio edit|live|mono|stereo)( version)?( \d{4})?)\s*$`)
+)
+
+var diacritics = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a',
+	'ç': 'c', 'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ñ': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y',
+	'ё': 'е',
+}
+
+var translitTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Normalize lowercases string, removes diacritics,
+// punctuation and repeated spaces.
+func Normalize(s string) string {
+	var b strings.Builder
+
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if d, ok := diacritics[r]; ok {
+			r = d
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		default:
+			if !space && b.Len() > 0 {
+				b.WriteRune(' ')
+				space = true
+			}
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// Translit converts cyrillic letters to latin ones,
+// so "земфира" and "zemfira" are equal after it.
+func Translit(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if t, ok := translitTable[unicode.ToLower(r)]; ok {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Key returns comparison key for string:
+// normalized and transliterated one.
+func Key(s string) string {
+	return Translit(Normalize(s))
+}
+
+// BaseName cuts off version suffixes
+// ("(Remastered 2011)", "- Live") and featuring.
+func BaseName(s string) string {
+	s = versionSuffix.ReplaceAllString(s, "")
+	s = featuring.ReplaceAllString(s, "")
+	return strings.TrimSpace(s)
+}
+
+// MainArtist returns the first artist
+// from strings like "Artist feat. X" or "A, B x C".
+// "&", "and" and "и" are kept, since they are
+// usually part of band name ("Simon & Garfunkel",
+// "Король и Шут").
+func MainArtist(s string) string {
+	s = featuring.ReplaceAllString(s, "")
+	for _, sep := range []string{",", " x "} {
+		s, _, _ = strings.Cut(s, sep)
+	}
+	return strings.TrimSpace(s)
+}
+
+// Similarity returns similarity ratio of two strings
+// from 0 (completely different) to 1 (equal).
+// Based on Levenshtein distance.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	dist := levenshtein(ra, rb)
+
+	return 1 - float64(dist)/float64(max(len(ra), len(rb)))
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,165 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{
+			desc:     "cyrillic and latin",
+			a:        "Земфира",
+			b:        "Zemfira",
+			expected: true,
+		},
+		{
+			desc:     "case and punctuation",
+			a:        "Hello, World!",
+			b:        "hello world",
+			expected: true,
+		},
+		{
+			desc:     "diacritics",
+			a:        "Beyoncé",
+			b:        "Beyonce",
+			expected: true,
+		},
+		{
+			desc:     "different",
+			a:        "Кино",
+			b:        "Сплин",
+			expected: false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, Key(tC.a) == Key(tC.b))
+		})
+	}
+}
+
+func TestBaseName(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		name     string
+		expected string
+	}{
+		{
+			desc:     "remaster",
+			name:     "Let It Be (Remastered 2009)",
+			expected: "Let It Be",
+		},
+		{
+			desc:     "dash suffix",
+			name:     "Bohemian Rhapsody - Remastered 2011",
+			expected: "Bohemian Rhapsody",
+		},
+		{
+			desc:     "featuring",
+			name:     "Song feat. Somebody",
+			expected: "Song",
+		},
+		{
+			desc:     "featuring in brackets",
+			name:     "Song (ft. Somebody)",
+			expected: "Song",
+		},
+		{
+			desc:     "cyrillic featuring",
+			name:     "Песня (при уч. Иванов)",
+			expected: "Песня",
+		},
+		{
+			desc:     "cyrillic featuring full",
+			name:     "Песня при участии Иванова",
+			expected: "Песня",
+		},
+		{
+			desc:     "name starting with feat",
+			name:     "Feat. Somebody",
+			expected: "Feat. Somebody",
+		},
+		{
+			desc:     "name starting with ft",
+			name:     "Ft Lauderdale",
+			expected: "Ft Lauderdale",
+		},
+		{
+			desc:     "ft inside word",
+			name:     "Left Behind",
+			expected: "Left Behind",
+		},
+		{
+			desc:     "plain",
+			name:     "Группа крови",
+			expected: "Группа крови",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, BaseName(tC.name))
+		})
+	}
+}
+
+func TestMainArtist(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		author   string
+		expected string
+	}{
+		{
+			desc:     "featuring",
+			author:   "Artist feat. X",
+			expected: "Artist",
+		},
+		{
+			desc:     "list",
+			author:   "A, B & C",
+			expected: "A",
+		},
+		{
+			desc:     "collaboration",
+			author:   "A x B",
+			expected: "A",
+		},
+		{
+			desc:     "band with ampersand",
+			author:   "Simon & Garfunkel",
+			expected: "Simon & Garfunkel",
+		},
+		{
+			desc:     "band with cyrillic and",
+			author:   "Король и Шут",
+			expected: "Король и Шут",
+		},
+		{
+			desc:     "band starting with ft",
+			author:   "Ft Lauderdale feat. X",
+			expected: "Ft Lauderdale",
+		},
+		{
+			desc:     "band starting with feat",
+			author:   "Feat",
+			expected: "Feat",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, MainArtist(tC.author))
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("abc", "abc"))
+	assert.Equal(t, 0.0, Similarity("abc", "xyz"))
+	assert.InDelta(t, 0.75, Similarity("kino", "kin"), 0.001)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ExportFormat is format of library export.
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportCSV  ExportFormat = "csv"
+	ExportM3U  ExportFormat = "m3u"
+)
+
+// ImportChange is tag change of one
+// media made by import of edited CSV.
+type ImportChange struct {
+	Conf    MediaConfig
+	Added   TagList
+	Removed TagList
+}
+
+// ImportPlan is preview of import
+// applied only after confirmation.
+type ImportPlan struct {
+	// Number of media rows in file.
+	Rows    int
+	Changes []ImportChange
+	// Ids of rows not found in library.
+	Unknown []int64
+}
+
+func (c ImportChange) String() string {
+	parts := make([]string, 0, len(c.Added)+len(c.Removed))
+	for _, t := range c.Added {
+		parts = append(parts, fmt.Sprintf("+%s \"%s\"", t.Type.Name, html.EscapeString(t.Name)))
+	}
+	for _, t := range c.Removed {
+		parts = append(parts, fmt.Sprintf("−%s \"%s\"", t.Type.Name, html.EscapeString(t.Name)))
+	}
+	return fmt.Sprintf(
+		"%s — %s: %s",
+		html.EscapeString(c.Conf.Author),
+		html.EscapeString(c.Conf.Name),
+		strings.Join(parts, ", "),
+	)
+}
+
+// Report returns plan description
+// with first maxLines changes.
+func (p ImportPlan) Report(maxLines int) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>Строк в файле:</b> %d\n", p.Rows))
+	b.WriteString(fmt.Sprintf("<b>Изменится композиций:</b> %d\n", len(p.Changes)))
+	if len(p.Unknown) > 0 {
+		b.WriteString(fmt.Sprintf("<b>Не найдено в библиотеке:</b> %d\n", len(p.Unknown)))
+	}
+
+	if len(p.Changes) > 0 {
+		b.WriteString("\n")
+	}
+	for i, c := range p.Changes {
+		if i == maxLines {
+			b.WriteString(fmt.Sprintf("... и еще %d\n", len(p.Changes)-maxLines))
+			break
+		}
+		b.WriteString(c.String() + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JobKind defines handler
+// used to process job items.
+type JobKind string
+
+const (
+	JobUpload JobKind = "upload"
+	// Changes of existing media,
+	// see BulkAction.
+	JobBulk JobKind = "bulk"
+	// Check of audio files of
+	// media by library check.
+	JobLibCheck JobKind = "libcheck"
+	// Detection of duration of media
+	// found by library check.
+	JobReprobe JobKind = "reprobe"
+)
+
+// BulkAction is change applied
+// to every item of bulk job.
+type BulkAction string
+
+const (
+	BulkAddTags    BulkAction = "add-tags"
+	BulkRemoveTags BulkAction = "remove-tags"
+	BulkFormat     BulkAction = "format"
+	BulkQueue      BulkAction = "queue"
+	BulkDelete     BulkAction = "delete"
+	// Adds and removes tags of any types,
+	// used by import of edited CSV.
+	BulkRetag BulkAction = "retag"
+)
+
+// Args of bulk job items.
+const (
+	BulkArgAction = "action"
+	// Tag type and comma separated
+	// tag names for tag actions.
+	BulkArgTagType = "tagType"
+	BulkArgTags    = "tags"
+	// MediaFormat number for format action.
+	BulkArgFormat = "format"
+	// Tags added and removed by retag
+	// action, see BulkTagsArg.
+	BulkArgAdd    = "add"
+	BulkArgRemove = "remove"
+)
+
+// BulkTagsArg encodes tags as
+// "type:name" lines.
+func BulkTagsArg(tags TagList) string {
+	lines := make([]string, 0, len(tags))
+	for _, t := range tags {
+		lines = append(lines, t.Type.Name+":"+t.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseBulkTagsArg decodes tags
+// encoded by BulkTagsArg.
+func ParseBulkTagsArg(arg string) TagList {
+	tags := make(TagList, 0)
+	for _, line := range strings.Split(arg, "\n") {
+		tagType, name, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		tags = append(tags, Tag{
+			Name: name,
+			Type: TagTypesAvail[tagType],
+		})
+	}
+	return tags
+}
+
+type JobStatus int
+
+const (
+	JobPending JobStatus = iota
+	JobRunning
+	JobDone
+	JobCanceled
+	// Job was stopped by bot restart.
+	JobInterrupted
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobPending:
+		return "в очереди"
+	case JobRunning:
+		return "выполняется"
+	case JobDone:
+		return "завершено"
+	case JobCanceled:
+		return "отменено"
+	case JobInterrupted:
+		return "прервано"
+	default:
+		return ""
+	}
+}
+
+// Active reports if job is
+// waiting for worker or running.
+func (s JobStatus) Active() bool {
+	return s == JobPending || s == JobRunning
+}
+
+type JobItemStatus int
+
+const (
+	ItemPending JobItemStatus = iota
+	ItemDone
+	// Media already existed,
+	// new tags were added to it.
+	ItemMerged
+	ItemFailed
+	ItemCanceled
+	// Media is uploaded, but provider
+	// gave only preview of track.
+	ItemPreview
+)
+
+func (s JobItemStatus) String() string {
+	switch s {
+	case ItemPending:
+		return "ожидает"
+	case ItemDone:
+		return "загружено"
+	case ItemMerged:
+		return "уже есть, теги добавлены"
+	case ItemFailed:
+		return "ошибка"
+	case ItemCanceled:
+		return "отменено"
+	case ItemPreview:
+		return "загружено только превью"
+	default:
+		return ""
+	}
+}
+
+// Uploaded reports if media
+// of item is in library.
+func (s JobItemStatus) Uploaded() bool {
+	return s == ItemDone || s == ItemMerged || s == ItemPreview
+}
+
+// Icon returns short representation of status.
+func (s JobItemStatus) Icon() string {
+	switch s {
+	case ItemPending:
+		return "⏳"
+	case ItemDone:
+		return "✅"
+	case ItemMerged:
+		return "♻️"
+	case ItemFailed:
+		return "❌"
+	case ItemCanceled:
+		return "⏹"
+	case ItemPreview:
+		return "⚠️"
+	default:
+		return ""
+	}
+}
+
+// Job is a long operation over
+// list of items running in background.
+type Job struct {
+	ID         int64     `json:"id"`
+	UserId     int64     `json:"userId"`
+	Kind       JobKind   `json:"kind"`
+	Title      string    `json:"title"`
+	Status     JobStatus `json:"status"`
+	Items      []JobItem `json:"items"`
+	CreatedAt  time.Time `json:"createdAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// JobNotify is called when job progress changes.
+type JobNotify func(job Job)
+
+type JobItem struct {
+	Conf   MediaConfig       `json:"conf"`
+	Args   map[string]string `json:"args,omitempty"`
+	Status JobItemStatus     `json:"status"`
+	Err    string            `json:"err,omitempty"`
+	// Id of uploaded media.
+	MediaId int64 `json:"mediaId,omitempty"`
+}
+
+// Count returns number of items with given status.
+func (j Job) Count(s JobItemStatus) int {
+	n := 0
+	for _, item := range j.Items {
+		if item.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Processed returns number of
+// items that are already handled.
+func (j Job) Processed() int {
+	return j.Count(ItemDone) + j.Count(ItemMerged) + j.Count(ItemPreview) + j.Count(ItemFailed)
+}
+
+// Progress returns one line summary of job.
+func (j Job) Progress() string {
+	if j.Kind == JobLibCheck {
+		return fmt.Sprintf(
+			"%d/%d проверено, ошибок: %d",
+			j.Count(ItemDone),
+			len(j.Items),
+			j.Count(ItemFailed),
+		)
+	}
+
+	if j.Kind == JobBulk || j.Kind == JobReprobe {
+		return fmt.Sprintf(
+			"%d/%d выполнено, ошибок: %d",
+			j.Count(ItemDone),
+			len(j.Items),
+			j.Count(ItemFailed),
+		)
+	}
+
+	progress := fmt.Sprintf(
+		"%d/%d загружено, дубликатов объединено: %d, ошибок: %d",
+		j.Count(ItemDone)+j.Count(ItemMerged)+j.Count(ItemPreview),
+		len(j.Items),
+		j.Count(ItemMerged),
+		j.Count(ItemFailed),
+	)
+	if n := j.Count(ItemPreview); n > 0 {
+		progress += fmt.Sprintf(", только превью: %d", n)
+	}
+
+	return progress
+}
+
+func (j Job) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>Задача #%d:</b> %s\n", j.ID, j.Title))
+	b.WriteString(fmt.Sprintf("<b>Статус:</b> %s\n", j.Status))
+	b.WriteString(fmt.Sprintf("<b>Создана:</b> %s\n", j.CreatedAt.Add(TimeZone).Format("01-02 15:04:05")))
+	b.WriteString(j.Progress())
+
+	return b.String()
+}
+
+// Report returns job description
+// with statuses of first maxLines items.
+func (j Job) Report(maxLines int) string {
+	var b strings.Builder
+
+	b.WriteString(j.String() + "\n\n")
+
+	for i, item := range j.Items {
+		if i == maxLines {
+			b.WriteString(fmt.Sprintf("... и еще %d\n", len(j.Items)-maxLines))
+			break
+		}
+		b.WriteString(fmt.Sprintf("%s %s — %s", item.Status.Icon(), item.Conf.Author, item.Conf.Name))
+		if item.Status == ItemFailed || item.Status == ItemMerged || item.Status == ItemPreview {
+			b.WriteString(": " + item.Status.String())
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Retryable reports if job has
+// items that can be processed again.
+func (j Job) Retryable() bool {
+	return !j.Status.Active() && (j.Count(ItemFailed)+j.Count(ItemCanceled)+j.Count(ItemPending)) > 0
+}
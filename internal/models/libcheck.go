@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LibCheckCategory is kind of
+// problem found by library check.
+type LibCheckCategory string
+
+const (
+	CheckZeroDuration LibCheckCategory = "zero"
+	CheckUntagged     LibCheckCategory = "untagged"
+	CheckMissing      LibCheckCategory = "missing"
+	CheckUnusedTags   LibCheckCategory = "unused"
+)
+
+// LibCheckCategories in report order.
+var LibCheckCategories = []LibCheckCategory{
+	CheckZeroDuration,
+	CheckUntagged,
+	CheckMissing,
+	CheckUnusedTags,
+}
+
+func (c LibCheckCategory) String() string {
+	switch c {
+	case CheckZeroDuration:
+		return "Нулевая длительность"
+	case CheckUntagged:
+		return "Без жанра или языка"
+	case CheckMissing:
+		return "Нет аудиофайла"
+	case CheckUnusedTags:
+		return "Неиспользуемые теги"
+	default:
+		return ""
+	}
+}
+
+// LibCheckReport is result of library check.
+type LibCheckReport struct {
+	// Number of checked media.
+	Total        int
+	ZeroDuration []MediaConfig
+	// Songs without genre or language.
+	Untagged []MediaConfig
+	// Media whose audio file
+	// is not found by radio.
+	Missing    []MediaConfig
+	UnusedTags TagList
+	// Audio files are still being checked,
+	// Missing is not complete.
+	Running bool
+}
+
+// Media returns media of category,
+// nil for unused tags.
+func (r LibCheckReport) Media(c LibCheckCategory) []MediaConfig {
+	switch c {
+	case CheckZeroDuration:
+		return r.ZeroDuration
+	case CheckUntagged:
+		return r.Untagged
+	case CheckMissing:
+		return r.Missing
+	default:
+		return nil
+	}
+}
+
+// Count returns number of problems in category.
+func (r LibCheckReport) Count(c LibCheckCategory) int {
+	if c == CheckUnusedTags {
+		return len(r.UnusedTags)
+	}
+	return len(r.Media(c))
+}
+
+func (r LibCheckReport) String() string {
+	var b strings.Builder
+
+	b.WriteString("<b>Проверка библиотеки</b>\n")
+	b.WriteString(fmt.Sprintf("<b>Композиций:</b> %d\n", r.Total))
+	for _, c := range LibCheckCategories {
+		b.WriteString(fmt.Sprintf("<b>%s:</b> %d", c, r.Count(c)))
+		if c == CheckMissing && r.Running {
+			b.WriteString(" (проверка идет)")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
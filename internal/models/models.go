@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,33 +29,71 @@ type Media struct {
 	Author     string        `json:"author"`
 	Duration   time.Duration `json:"duration"`
 	Tags       TagList       `json:"tags"`
+	CreatedAt  time.Time     `json:"createdAt"` // stored by bot on upload, zero if unknown
 	SourcePath string        `json:"-"`
 }
 
 type AlbumDownloadRes struct {
 	Name   string
 	Author string
+	// Podcast albums contain episodes.
+	Format MediaFormat
 	Values []MediaConfig
 }
 
+// ArtistDownloadRes is a result of artist link.
+// If Values are empty, user has to choose
+// what to import: top tracks or discography.
+type ArtistDownloadRes struct {
+	Name       string
+	Link       string
+	TrackCount int
+	AlbumCount int
+	Values     []MediaConfig
+}
+
 type Playlist struct {
 	Name   string
 	Values []MediaConfig
 }
 
 type MediaConfig struct {
-	ID         int64
-	Name       string
-	Author     string
-	Duration   time.Duration
-	Format     MediaFormat
-	Albums     []Album
-	Playlists  []string
-	Podcasts   []string
-	Genres     [GenreNumber]bool
-	Moods      [MoodNumber]bool
-	Languages  [LangNumber]bool
+	ID        int64
+	Name      string
+	Author    string
+	Duration  time.Duration
+	Format    MediaFormat
+	Albums    []Album
+	Playlists []string
+	Podcasts  []string
+	Genres    TagSet
+	Moods     TagSet
+	Languages TagSet
+	// Upload time, zero if unknown.
+	CreatedAt time.Time
+	// Info not stored by radio,
+	// it is kept by bot.
+	Meta       map[string]string
 	SourcePath string
+	// Link to cover art or path
+	// to local image file.
+	Cover string
+	// Where to fetch audio from
+	// if source is not downloaded yet.
+	External ExternalRef
+	// Source is only a preview of
+	// track given by provider.
+	Preview bool
+}
+
+// ExternalRef references media
+// in external music service.
+type ExternalRef struct {
+	Provider string
+	ID       string
+	// Link to audio file
+	// if it is not given by ID.
+	URL string
 }
 
 type MediaFormat int
@@ -67,20 +107,24 @@ const (
 type Album struct {
 	Name   string
 	Author string
+	// Optional info stored in tag meta.
+	Year  int
+	Label string
+	// Position of media in album, stored
+	// in album tag meta by media id.
+	Track int
+	Disc  int
 }
 
 type Genre struct {
-	Id   int64
 	Name string
 }
 
 type Mood struct {
-	Id   int64
 	Name string
 }
 
 type Language struct {
-	Id   int64
 	Name string
 }
 
@@ -102,6 +146,63 @@ type LinkDownloadResult struct {
 	MediaConf MediaConfig
 	Album     AlbumDownloadRes
 	Playlist  Playlist
+	Artist    ArtistDownloadRes
+}
+
+// KnownTags drops genres and languages
+// set by provider but missing in taxonomy.
+func (res *LinkDownloadResult) KnownTags(tax Taxonomy) {
+	known := func(values []MediaConfig) {
+		for i := range values {
+			values[i].knownTags(tax)
+		}
+	}
+
+	res.MediaConf.knownTags(tax)
+	known(res.Album.Values)
+	known(res.Playlist.Values)
+	known(res.Artist.Values)
+}
+
+// CatalogItem is a track or album
+// found in external music catalog.
+type CatalogItem struct {
+	Type       ResultType
+	Name       string
+	Author     string
+	Album      string
+	Duration   time.Duration
+	TrackCount int
+	// Link used to import item.
+	Link      string
+	InLibrary bool
+}
+
+func (item CatalogItem) String() string {
+	var b strings.Builder
+
+	switch item.Type {
+	case ResAlbum:
+		b.WriteString("<b>Альбом</b>\n")
+	default:
+		b.WriteString("<b>Композиция</b>\n")
+	}
+	b.WriteString(fmt.Sprintf("<b>Название:</b> %s\n", item.Name))
+	b.WriteString(fmt.Sprintf("<b>Автор:</b> %s\n", item.Author))
+	if item.Album != "" {
+		b.WriteString(fmt.Sprintf("<b>Альбом:</b> %s\n", item.Album))
+	}
+	if item.TrackCount > 0 {
+		b.WriteString(fmt.Sprintf("<b>Количество песен:</b> %d\n", item.TrackCount))
+	}
+	if item.Duration > 0 {
+		b.WriteString(fmt.Sprintf("<b>Длительность:</b> %s\n", item.Duration.Round(time.Second).String()))
+	}
+	if item.InLibrary {
+		b.WriteString("\n✅ Уже есть в библиотеке\n")
+	}
+
+	return b.String()
 }
 
 type ResultType int
@@ -110,21 +211,51 @@ const (
 	ResSong ResultType = iota
 	ResAlbum
 	ResPlaylist
+	ResBulk
+	ResArtist
 )
 
 type MediaFilter struct {
-	Name       string
-	Author     string
-	Tags       []string
-	MaxRespLen int
+	Name   string `json:"name,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Free text matched against name,
+	// author and albums by local index.
+	Query string `json:"query,omitempty"`
+	// Media must have all of Tags,
+	// at least one of AnyTags (if not empty)
+	// and none of ExcludeTags.
+	Tags        []string `json:"tags,omitempty"`
+	AnyTags     []string `json:"anyTags,omitempty"`
+	ExcludeTags []string `json:"excludeTags,omitempty"`
+	// Zero bounds are not applied.
+	MinDuration time.Duration `json:"minDuration,omitempty"`
+	MaxDuration time.Duration `json:"maxDuration,omitempty"`
+	// Zero time is not applied.
+	AddedAfter time.Time `json:"addedAfter,omitempty"`
+	Sort       MediaSort `json:"sort,omitempty"`
+	MaxRespLen int       `json:"maxRespLen,omitempty"`
 }
 
+// MediaSort is order of search results.
+type MediaSort string
+
+const (
+	// Most relevant first for text query,
+	// order of ids otherwise.
+	SortRelevance MediaSort = ""
+	SortName      MediaSort = "name"
+	SortAuthor    MediaSort = "author"
+	SortDuration  MediaSort = "duration"
+	// Recently uploaded first.
+	SortRecent MediaSort = "recent"
+)
+
 type AutoDJInfo struct {
 	IsPlaying bool
-	Genres    [GenreNumber]bool
+	Genres    TagSet
 	Playlists []string
-	Languages [LangNumber]bool
-	Moods     [MoodNumber]bool
+	Languages TagSet
+	Moods     TagSet
 }
 
 // TODO add stub
@@ -133,23 +264,11 @@ type AutoDJConfig struct {
 }
 
 func (a AutoDJInfo) ToConfig() AutoDJConfig {
-	tags := make(TagList, len(a.Genres)+len(a.Playlists)+len(a.Languages)+len(a.Moods))
+	tags := make(TagList, 0, len(a.Genres)+len(a.Playlists)+len(a.Languages)+len(a.Moods))
 
-	for i, g := range a.Genres {
-		if g {
-			tags = append(tags, GenresAvail[i].Tag())
-		}
-	}
-	for i, m := range a.Moods {
-		if m {
-			tags = append(tags, MoodsAvail[i].Tag())
-		}
-	}
-	for i, l := range a.Languages {
-		if l {
-			tags = append(tags, LangsAvail[i].Tag())
-		}
-	}
+	tags = append(tags, a.Genres.Tags("genre")...)
+	tags = append(tags, a.Moods.Tags("mood")...)
+	tags = append(tags, a.Languages.Tags("language")...)
 	for _, p := range a.Playlists {
 		tags = append(tags, Tag{
 			Name: p,
@@ -163,30 +282,27 @@ func (a AutoDJInfo) ToConfig() AutoDJConfig {
 }
 
 func (a AutoDJConfig) ToInfo() AutoDJInfo {
-	playlists := make([]string, 0)
-	genres := [GenreNumber]bool{}
-	moods := [MoodNumber]bool{}
-	langs := [LangNumber]bool{}
+	info := AutoDJInfo{
+		Playlists: make([]string, 0),
+		Genres:    NewTagSet(),
+		Moods:     NewTagSet(),
+		Languages: NewTagSet(),
+	}
 
 	for _, t := range a.Tags {
 		switch t.Type.Name {
 		case "playlist":
-			playlists = append(playlists, t.Name)
+			info.Playlists = append(info.Playlists, t.Name)
 		case "genre":
-			genres[t.AsGenre().Id-1] = true
+			info.Genres.Add(t.Name)
 		case "mood":
-			moods[t.AsMood().Id-1] = true
+			info.Moods.Add(t.Name)
 		case "language":
-			langs[t.AsLang().Id-1] = true
+			info.Languages.Add(t.Name)
 		}
 	}
 
-	return AutoDJInfo{
-		Playlists: playlists,
-		Genres:    genres,
-		Moods:     moods,
-		Languages: langs,
-	}
+	return info
 }
 
 type TagTypes []TagType
@@ -275,13 +391,7 @@ func (conf MediaConfig) ToMedia() Media {
 		})
 	}
 	for _, a := range conf.Albums {
-		tags = append(tags, Tag{
-			Name: a.Name,
-			Type: TagTypesAvail["album"],
-			Meta: map[string]string{
-				"author": a.Author,
-			},
-		})
+		tags = append(tags, a.Tag())
 	}
 	for _, t := range conf.Playlists {
 		tags = append(tags, Tag{
@@ -295,27 +405,16 @@ func (conf MediaConfig) ToMedia() Media {
 			Type: TagTypesAvail["podcast"],
 		})
 	}
-	for i, g := range conf.Genres {
-		if g {
-			tags = append(tags, GenresAvail[i].Tag())
-		}
-	}
-	for i, l := range conf.Languages {
-		if l {
-			tags = append(tags, LangsAvail[i].Tag())
-		}
-	}
-	for i, m := range conf.Moods {
-		if m {
-			tags = append(tags, MoodsAvail[i].Tag())
-		}
-	}
+	tags = append(tags, conf.Genres.Tags("genre")...)
+	tags = append(tags, conf.Languages.Tags("language")...)
+	tags = append(tags, conf.Moods.Tags("mood")...)
 	return Media{
 		ID:         conf.ID,
 		Name:       conf.Name,
 		Author:     conf.Author,
 		Duration:   conf.Duration,
 		Tags:       tags,
+		CreatedAt:  conf.CreatedAt,
 		SourcePath: conf.SourcePath,
 	}
 }
@@ -324,9 +423,9 @@ func (m Media) ToConfig() MediaConfig {
 	Albums := make([]Album, 0)
 	Playlists := make([]string, 0)
 	Podcasts := make([]string, 0)
-	Genres := [GenreNumber]bool{}
-	Languages := [LangNumber]bool{}
-	Moods := [MoodNumber]bool{}
+	Genres := NewTagSet()
+	Languages := NewTagSet()
+	Moods := NewTagSet()
 
 	var format MediaFormat
 
@@ -342,20 +441,19 @@ func (m Media) ToConfig() MediaConfig {
 				format = Jingle
 			}
 		case "album":
-			Albums = append(Albums, Album{
-				Name:   t.Name,
-				Author: t.Meta["author"],
-			})
+			a := t.AsAlbum()
+			a.Track, a.Disc = t.TrackOf(m.ID)
+			Albums = append(Albums, a)
 		case "playlist":
 			Playlists = append(Playlists, t.Name)
 		case "podcast":
 			Podcasts = append(Podcasts, t.Name)
 		case "genre":
-			Genres[t.AsGenre().Id-1] = true
+			Genres.Add(t.Name)
 		case "language":
-			Languages[t.AsLang().Id-1] = true
+			Languages.Add(t.Name)
 		case "mood":
-			Moods[t.AsMood().Id-1] = true
+			Moods.Add(t.Name)
 		}
 	}
 
@@ -371,13 +469,55 @@ func (m Media) ToConfig() MediaConfig {
 		Genres:    Genres,
 		Languages: Languages,
 		Moods:     Moods,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// WithShared returns media config
+// with shared tags added.
+// Empty album author is replaced by media author.
+func (conf MediaConfig) WithShared(shared MediaConfig) MediaConfig {
+	conf.Format = shared.Format
+
+	if len(shared.Albums) > 0 {
+		conf.Albums = make([]Album, 0, len(shared.Albums))
+		for _, a := range shared.Albums {
+			if a.Author == "" {
+				a.Author = conf.Author
+			}
+			conf.Albums = append(conf.Albums, a)
+		}
+	}
+
+	for _, p := range shared.Playlists {
+		if !slices.Contains(conf.Playlists, p) {
+			conf.Playlists = append(conf.Playlists, p)
+		}
+	}
+	for _, p := range shared.Podcasts {
+		if !slices.Contains(conf.Podcasts, p) {
+			conf.Podcasts = append(conf.Podcasts, p)
+		}
 	}
+	conf.Genres = conf.Genres.Union(shared.Genres)
+	conf.Moods = conf.Moods.Union(shared.Moods)
+	conf.Languages = conf.Languages.Union(shared.Languages)
+
+	return conf
+}
+
+func (conf *MediaConfig) knownTags(tax Taxonomy) {
+	conf.Genres = conf.Genres.Known(tax.Genres)
+	conf.Languages = conf.Languages.Known(tax.Languages)
 }
 
 func (conf MediaConfig) String() string {
 	var b strings.Builder
 
 	b.WriteString("<b>Композиция</b>\n")
+	if conf.Preview {
+		b.WriteString("⚠️ <b>Загружено только превью трека</b>\n")
+	}
 	b.WriteString(fmt.Sprintf("<b>Название:</b> %s\n", conf.Name))
 	b.WriteString(fmt.Sprintf("<b>Автор:</b> %s\n", conf.Author))
 	b.WriteString(fmt.Sprintf("<b>Формат:</b> %s\n", conf.Format))
@@ -386,6 +526,13 @@ func (conf MediaConfig) String() string {
 	if len(conf.Albums) > 0 {
 		b.WriteString(fmt.Sprintf("<b>Альбомы:</b> %s\n", slice.Join(conf.Albums, ", ")))
 	}
+	if i := slices.IndexFunc(conf.Albums, func(a Album) bool { return a.Track != 0 }); i != -1 {
+		track := strconv.Itoa(conf.Albums[i].Track)
+		if disc := conf.Albums[i].Disc; disc > 1 {
+			track += fmt.Sprintf(" (диск %d)", disc)
+		}
+		b.WriteString(fmt.Sprintf("<b>Номер трека:</b> %s\n", track))
+	}
 	if len(conf.Podcasts) > 0 {
 		b.WriteString(fmt.Sprintf("<b>Подкасты:</b> %s\n", strings.Join(conf.Podcasts, ", ")))
 	}
@@ -393,13 +540,13 @@ func (conf MediaConfig) String() string {
 		b.WriteString(fmt.Sprintf("<b>Плейлисты:</b> %s\n", strings.Join(conf.Playlists, ", ")))
 	}
 	if len(conf.Genres) > 0 {
-		b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", slice.Join(slice.Filter(GenresAvail[:], conf.Genres[:]), ", ")))
+		b.WriteString(fmt.Sprintf("<b>Жанры:</b> %s\n", conf.Genres))
 	}
 	if len(conf.Languages) > 0 {
-		b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", slice.Join(slice.Filter(LangsAvail[:], conf.Languages[:]), ", ")))
+		b.WriteString(fmt.Sprintf("<b>Языки:</b> %s\n", conf.Languages))
 	}
 	if len(conf.Moods) > 0 {
-		b.WriteString(fmt.Sprintf("<b>Настроение:</b> %s\n", slice.Join(slice.Filter(MoodsAvail[:], conf.Moods[:]), ", ")))
+		b.WriteString(fmt.Sprintf("<b>Настроение:</b> %s\n", conf.Moods))
 	}
 
 	return b.String()
@@ -422,3 +569,40 @@ func (l Live) String() string {
 
 	return b.String()
 }
+
+// TmpUsage is usage of
+// temporary directory.
+type TmpUsage struct {
+	Files   int
+	Size    int64
+	MaxSize int64
+	// Size of files by owners.
+	Owners map[string]int64
+}
+
+func (u TmpUsage) String() string {
+	var b strings.Builder
+
+	b.WriteString("<b>Временные файлы</b>\n")
+	b.WriteString(fmt.Sprintf("Файлов: %d\n", u.Files))
+	if u.MaxSize > 0 {
+		b.WriteString(fmt.Sprintf("Занято: %s из %s", megabytes(u.Size), megabytes(u.MaxSize)))
+	} else {
+		b.WriteString(fmt.Sprintf("Занято: %s", megabytes(u.Size)))
+	}
+
+	owners := make([]string, 0, len(u.Owners))
+	for owner := range u.Owners {
+		owners = append(owners, owner)
+	}
+	slices.Sort(owners)
+	for _, owner := range owners {
+		b.WriteString(fmt.Sprintf("\n  %s: %s", owner, megabytes(u.Owners[owner])))
+	}
+
+	return b.String()
+}
+
+func megabytes(size int64) string {
+	return fmt.Sprintf("%.1f МБ", float64(size)/(1<<20))
+}
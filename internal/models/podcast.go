@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Feed is podcast RSS or Atom feed.
+type Feed struct {
+	Title    string
+	Author   string
+	Episodes []FeedEpisode
+}
+
+type FeedEpisode struct {
+	GUID        string
+	Title       string
+	Description string
+	// Link to audio file (enclosure).
+	URL       string
+	Published time.Time
+	Duration  time.Duration
+}
+
+// WeeklySlot is a time of week
+// in station time zone.
+type WeeklySlot struct {
+	Weekday time.Weekday `json:"weekday"`
+	Hour    int          `json:"hour"`
+	Minute  int          `json:"minute"`
+}
+
+var weekdays = [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// ParseWeeklySlot parses slot
+// formatted as "пн 19:00".
+func ParseWeeklySlot(s string) (WeeklySlot, error) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(strings.ToLower(s)), " ")
+	if !ok {
+		return WeeklySlot{}, fmt.Errorf("invalid slot %q", s)
+	}
+
+	slot := WeeklySlot{Weekday: -1}
+	for i, d := range weekdays {
+		if d == day {
+			slot.Weekday = time.Weekday(i)
+		}
+	}
+	if slot.Weekday == -1 {
+		return WeeklySlot{}, fmt.Errorf("invalid weekday %q", day)
+	}
+
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return WeeklySlot{}, fmt.Errorf("invalid time %q", clock)
+	}
+	slot.Hour, slot.Minute = t.Hour(), t.Minute()
+
+	return slot, nil
+}
+
+// Next returns nearest start of slot after t.
+func (s WeeklySlot) Next(t time.Time) time.Time {
+	// Station time is represented
+	// as UTC shifted by TimeZone.
+	local := t.UTC().Add(TimeZone)
+	start := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, time.UTC)
+	start = start.AddDate(0, 0, (int(s.Weekday)-int(local.Weekday())+7)%7)
+	if !start.After(local) {
+		start = start.AddDate(0, 0, 7)
+	}
+	return start.Add(-TimeZone)
+}
+
+func (s WeeklySlot) String() string {
+	return fmt.Sprintf("%s %02d:%02d", weekdays[s.Weekday], s.Hour, s.Minute)
+}
+
+// PodcastSub is subscription of
+// podcast tag to RSS/Atom feed.
+type PodcastSub struct {
+	ID      int64  `json:"id"`
+	UserId  int64  `json:"userId"`
+	URL     string `json:"url"`
+	Podcast string `json:"podcast"`
+	// Newest new episode is queued
+	// at the slot if it is set.
+	Slot *WeeklySlot `json:"slot,omitempty"`
+	// Links of imported episodes.
+	Seen      []string  `json:"seen"`
+	LastCheck time.Time `json:"lastCheck"`
+	LastErr   string    `json:"lastErr,omitempty"`
+}
+
+func (s PodcastSub) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>%s</b>\n", s.Podcast))
+	b.WriteString(fmt.Sprintf("Лента: %s\n", s.URL))
+	b.WriteString(fmt.Sprintf("Загружено выпусков: %d\n", len(s.Seen)))
+	if s.Slot != nil {
+		b.WriteString(fmt.Sprintf("Новый выпуск ставится в эфир: %s\n", s.Slot))
+	}
+	if s.LastCheck.IsZero() {
+		b.WriteString("Еще не проверялась")
+	} else {
+		b.WriteString(fmt.Sprintf("Проверена: %s", s.LastCheck.Add(TimeZone).Format("01-02 15:04")))
+	}
+	if s.LastErr != "" {
+		b.WriteString("\n❌ Последняя проверка не удалась")
+	}
+
+	return b.String()
+}
+
+// PodcastSummary is result of
+// new episodes ingestion.
+type PodcastSummary struct {
+	Sub PodcastSub
+	Job *Job
+	// Start of segment with newest
+	// episode if it was queued.
+	Queued time.Time
+	Err    error
+}
+
+// PodcastNotify is called when
+// new episodes are ingested.
+type PodcastNotify func(summary PodcastSummary)
+
+func (s PodcastSummary) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>Новые выпуски подкаста \"%s\"</b>\n", s.Sub.Podcast))
+
+	if s.Err != nil {
+		b.WriteString("Не удалось загрузить выпуски.")
+		return b.String()
+	}
+	if s.Job == nil {
+		b.WriteString("Новых выпусков нет.")
+		return b.String()
+	}
+
+	b.WriteString(s.Job.Progress() + "\n")
+	for i, item := range s.Job.Items {
+		if i == maxSummaryLines {
+			b.WriteString(fmt.Sprintf("... и еще %d\n", len(s.Job.Items)-maxSummaryLines))
+			break
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", item.Status.Icon(), item.Conf.Name))
+	}
+	if !s.Queued.IsZero() {
+		b.WriteString(fmt.Sprintf("Последний выпуск поставлен в эфир на %s\n", s.Queued.Add(TimeZone).Format("01-02 15:04")))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// SavedSearch is search filter saved under name.
+// Smart search is also mirrored into playlist tag
+// with the same name, so AutoDJ can play it.
+type SavedSearch struct {
+	ID     int64  `json:"id"`
+	UserId int64  `json:"userId"`
+	Name   string `json:"name"`
+	// Visible to all users,
+	// otherwise only to owner.
+	Shared bool        `json:"shared"`
+	Filter MediaFilter `json:"filter"`
+	// Filter matches media uploaded during
+	// this period before search, zero for all.
+	Period time.Duration `json:"period,omitempty"`
+	Smart  bool          `json:"smart"`
+	// Number of media in smart playlist.
+	Size     int       `json:"size"`
+	LastSync time.Time `json:"lastSync"`
+	LastErr  string    `json:"lastErr,omitempty"`
+}
+
+// MediaFilter returns filter
+// for search made right now.
+func (s SavedSearch) MediaFilter() MediaFilter {
+	filter := s.Filter
+	if s.Period > 0 {
+		filter.AddedAfter = time.Now().Add(-s.Period)
+	}
+	return filter
+}
+
+func (s SavedSearch) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>%s</b>\n", html.EscapeString(s.Name)))
+	if s.Shared {
+		b.WriteString("Общий поиск\n")
+	} else {
+		b.WriteString("Личный поиск\n")
+	}
+
+	if !s.Smart {
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+
+	b.WriteString(fmt.Sprintf("Умный плейлист \"%s\", треков: %d\n", html.EscapeString(s.Name), s.Size))
+	if s.LastSync.IsZero() {
+		b.WriteString("Еще не обновлен")
+	} else {
+		b.WriteString(fmt.Sprintf("Обновлен: %s", s.LastSync.Add(TimeZone).Format("01-02 15:04")))
+	}
+	if s.LastErr != "" {
+		b.WriteString("\n❌ Последнее обновление не удалось")
+	}
+
+	return b.String()
+}
+
+// SmartSyncResult is result of
+// smart playlist update.
+type SmartSyncResult struct {
+	Added   int
+	Removed int
+}
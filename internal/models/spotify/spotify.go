@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+type Artist struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Track struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	DurationMs int64    `json:"duration_ms"`
+	Artists    []Artist `json:"artists"`
+	Album      struct {
+		Name    string   `json:"name"`
+		Artists []Artist `json:"artists"`
+	} `json:"album"`
+}
+
+func (t Track) Duration() time.Duration {
+	return time.Duration(t.DurationMs) * time.Millisecond
+}
+
+type Album struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"album_type"`
+	Artists []Artist `json:"artists"`
+	Tracks  []Track  `json:"-"`
+}
+
+type Playlist struct {
+	Id     string  `json:"id"`
+	Name   string  `json:"name"`
+	Tracks []Track `json:"-"`
+}
+
+// Page is a part of paginated response.
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next"`
+}
+
+type PlaylistItem struct {
+	Track *Track `json:"track"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type SpotifyError struct {
+	Err struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
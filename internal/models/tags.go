@@ -1,10 +1,15 @@
 package models
 
-import "slices"
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
 
 // In agreement with server migrations.
-// For Genre, Moog, Language type id is not equal
-// tag they coreespond to.
 var (
 	// TODO reqrite tag types avail as variables (like others)
 	TagTypesAvail = map[string]TagType{
@@ -16,58 +21,228 @@ var (
 		"podcast":  {ID: 6, Name: "podcast"},
 		"album":    {ID: 7, Name: "album"},
 	}
-
-	Pop          = Genre{Id: 1, Name: "Поп"}
-	HipHop       = Genre{Id: 2, Name: "Хип-хоп"}
-	Rock         = Genre{Id: 3, Name: "Рок"}
-	Jazz         = Genre{Id: 4, Name: "Джаз"}
-	Electro      = Genre{Id: 5, Name: "Электро"}
-	Instrumental = Genre{Id: 6, Name: "Инструментальный"}
-	Rap          = Genre{Id: 7, Name: "Рэп"}
-	LoFi         = Genre{Id: 8, Name: "Lo-fi"}
-
-	Aggresive  = Mood{Id: 1, Name: "агрессивное"}
-	Optimistic = Mood{Id: 2, Name: "оптимистичное"}
-	Calm       = Mood{Id: 3, Name: "спокойное"}
-	Anxious    = Mood{Id: 4, Name: "тревожное"}
-	Rhythmic   = Mood{Id: 5, Name: "ритмичное"}
-	Romantic   = Mood{Id: 6, Name: "романтичное"}
-	Sad        = Mood{Id: 7, Name: "печальное"}
-
-	Russian   = Language{Id: 1, Name: "русский"}
-	English   = Language{Id: 2, Name: "английский"}
-	French    = Language{Id: 3, Name: "французский"}
-	Italian   = Language{Id: 4, Name: "итальянский"}
-	German    = Language{Id: 5, Name: "немецкий"}
-	Spanish   = Language{Id: 6, Name: "испанский"}
-	Mongolian = Language{Id: 7, Name: "монгольский"}
-	Korean    = Language{Id: 8, Name: "корейский"}
-	Japanese  = Language{Id: 9, Name: "японский"}
-	Chinese   = Language{Id: 10, Name: "китайский"}
-	NoWords   = Language{Id: 11, Name: "без слов"}
-
-	GenresAvail = [GenreNumber]Genre{Pop, HipHop, Rock, Jazz, Electro, Instrumental, Rap, LoFi}
-	MoodsAvail  = [MoodNumber]Mood{Aggresive, Optimistic, Calm, Anxious, Rhythmic, Romantic, Sad}
-	LangsAvail  = [LangNumber]Language{Russian, English, French, Italian, German, Spanish, Mongolian, Korean, Japanese, Chinese, NoWords}
 )
 
-const (
-	GenreNumber = 8
-	MoodNumber  = 7
-	LangNumber  = 11
+// Tags set by automatic tagging.
+// Available genres, moods and languages
+// are loaded from server (see Taxonomy),
+// tags missing there are dropped.
+var (
+	Pop          = Genre{Name: "Поп"}
+	HipHop       = Genre{Name: "Хип-хоп"}
+	Rock         = Genre{Name: "Рок"}
+	Jazz         = Genre{Name: "Джаз"}
+	Electro      = Genre{Name: "Электро"}
+	Instrumental = Genre{Name: "Инструментальный"}
+	Rap          = Genre{Name: "Рэп"}
+	LoFi         = Genre{Name: "Lo-fi"}
+
+	Russian  = Language{Name: "русский"}
+	Korean   = Language{Name: "корейский"}
+	Japanese = Language{Name: "японский"}
+	Chinese  = Language{Name: "китайский"}
 )
 
+// TagSet is a set of names
+// of tags of the same type.
+type TagSet map[string]struct{}
+
+func NewTagSet(names ...string) TagSet {
+	s := make(TagSet, len(names))
+	for _, name := range names {
+		s[name] = struct{}{}
+	}
+	return s
+}
+
+func (s TagSet) Has(name string) bool {
+	_, ok := s[name]
+	return ok
+}
+
+func (s *TagSet) Add(names ...string) {
+	if *s == nil {
+		*s = make(TagSet, len(names))
+	}
+	for _, name := range names {
+		(*s)[name] = struct{}{}
+	}
+}
+
+// Toggle adds name if it is not in set
+// and removes it otherwise.
+func (s *TagSet) Toggle(name string) {
+	if s.Has(name) {
+		delete(*s, name)
+		return
+	}
+	s.Add(name)
+}
+
+// Known returns new set containing
+// names of tags present in list.
+func (s TagSet) Known(list TagList) TagSet {
+	res := make(TagSet, len(s))
+	for _, tag := range list {
+		if s.Has(tag.Name) {
+			res[tag.Name] = struct{}{}
+		}
+	}
+	return res
+}
+
+// Union returns new set
+// containing names of both sets.
+func (s TagSet) Union(other TagSet) TagSet {
+	res := make(TagSet, len(s)+len(other))
+	for name := range s {
+		res[name] = struct{}{}
+	}
+	for name := range other {
+		res[name] = struct{}{}
+	}
+	return res
+}
+
+// Names returns sorted names.
+func (s TagSet) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Tags returns tags of given type.
+func (s TagSet) Tags(tagType string) TagList {
+	tags := make(TagList, 0, len(s))
+	for _, name := range s.Names() {
+		tags = append(tags, Tag{
+			Name: name,
+			Type: TagTypesAvail[tagType],
+		})
+	}
+	return tags
+}
+
+func (s TagSet) String() string {
+	return strings.Join(s.Names(), ", ")
+}
+
+func (s TagSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Names())
+}
+
+// UnmarshalJSON decodes list of names.
+// Bool arrays stored by previous versions
+// can not be mapped to names and are skipped.
+func (s *TagSet) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		var legacy []bool
+		if json.Unmarshal(data, &legacy) == nil {
+			*s = nil
+			return nil
+		}
+		return err
+	}
+
+	*s = NewTagSet(names...)
+
+	return nil
+}
+
+// Taxonomy contains tags available
+// for selection, grouped by type.
+// It is loaded from server, so new
+// genre can be added without bot release.
+type Taxonomy struct {
+	Genres    TagList
+	Moods     TagList
+	Languages TagList
+}
+
+// NewTaxonomy groups tags by type.
+// Tags are sorted by id, i.e. in order of creation.
+func NewTaxonomy(tags TagList) Taxonomy {
+	var t Taxonomy
+
+	for _, tag := range tags {
+		switch tag.Type.Name {
+		case "genre":
+			t.Genres = append(t.Genres, tag)
+		case "mood":
+			t.Moods = append(t.Moods, tag)
+		case "language":
+			t.Languages = append(t.Languages, tag)
+		}
+	}
+
+	byId := func(a, b Tag) int {
+		return cmp.Compare(a.ID, b.ID)
+	}
+	slices.SortFunc(t.Genres, byId)
+	slices.SortFunc(t.Moods, byId)
+	slices.SortFunc(t.Languages, byId)
+
+	return t
+}
+
+// Find returns tag by id.
+func (t Taxonomy) Find(id int64) (Tag, bool) {
+	for _, list := range []TagList{t.Genres, t.Moods, t.Languages} {
+		if i := slices.IndexFunc(list, func(tag Tag) bool {
+			return tag.ID == id
+		}); i != -1 {
+			return list[i], true
+		}
+	}
+	return Tag{}, false
+}
+
+// TagUsage is tag with number
+// of media tagged with it.
+type TagUsage struct {
+	Tag   Tag
+	Count int
+}
+
+// TagMetaKeys are meta keys
+// editable for tags of given type.
+var TagMetaKeys = map[string][]string{
+	"album": {"author", "year", "label"},
+}
+
 func (a Album) String() string {
-	return a.Name
+	var b strings.Builder
+
+	b.WriteString(a.Name)
+	if a.Year != 0 {
+		b.WriteString(fmt.Sprintf(" (%d)", a.Year))
+	}
+	if a.Label != "" {
+		b.WriteString(", " + a.Label)
+	}
+
+	return b.String()
 }
 
 func (a Album) Tag() Tag {
+	meta := map[string]string{
+		"author": a.Author,
+	}
+	if a.Year != 0 {
+		meta["year"] = strconv.Itoa(a.Year)
+	}
+	if a.Label != "" {
+		meta["label"] = a.Label
+	}
+
 	return Tag{
 		Name: a.Name,
 		Type: TagTypesAvail["album"],
-		Meta: map[string]string{
-			"author": a.Author,
-		},
+		Meta: meta,
 	}
 }
 
@@ -104,36 +279,44 @@ func (l Language) Tag() Tag {
 	}
 }
 
-func (t Tag) AsAlbum() Album {
-	return Album{
-		Name:   t.Name,
-		Author: t.Meta["author"],
-	}
+const (
+	trackMetaPrefix = "track:"
+	discMetaPrefix  = "disc:"
+)
+
+// TrackMetaKey returns key of album tag meta
+// storing track number of media in album.
+func TrackMetaKey(mediaId int64) string {
+	return trackMetaPrefix + strconv.FormatInt(mediaId, 10)
 }
 
-func (t Tag) AsGenre() Genre {
-	return Genre{
-		Id: GenresAvail[slices.IndexFunc(GenresAvail[:], func(g Genre) bool {
-			return g.Name == t.Name
-		})].Id,
-		Name: t.Name,
-	}
+// DiscMetaKey returns key of album tag
+// meta storing disc number of media.
+func DiscMetaKey(mediaId int64) string {
+	return discMetaPrefix + strconv.FormatInt(mediaId, 10)
 }
 
-func (t Tag) AsLang() Language {
-	return Language{
-		Id: LangsAvail[slices.IndexFunc(LangsAvail[:], func(l Language) bool {
-			return l.Name == t.Name
-		})].Id,
-		Name: t.Name,
-	}
+// IsPositionMetaKey reports if key of
+// album tag meta stores position of media.
+func IsPositionMetaKey(key string) bool {
+	return strings.HasPrefix(key, trackMetaPrefix) || strings.HasPrefix(key, discMetaPrefix)
+}
+
+// TrackOf returns position of media
+// in album, zeros if it is unknown.
+func (t Tag) TrackOf(mediaId int64) (track, disc int) {
+	track, _ = strconv.Atoi(t.Meta[TrackMetaKey(mediaId)])
+	disc, _ = strconv.Atoi(t.Meta[DiscMetaKey(mediaId)])
+	return track, disc
 }
 
-func (t Tag) AsMood() Mood {
-	return Mood{
-		Id: MoodsAvail[slices.IndexFunc(MoodsAvail[:], func(m Mood) bool {
-			return m.Name == t.Name
-		})].Id,
-		Name: t.Name,
+func (t Tag) AsAlbum() Album {
+	year, _ := strconv.Atoi(t.Meta["year"])
+
+	return Album{
+		Name:   t.Name,
+		Author: t.Meta["author"],
+		Year:   year,
+		Label:  t.Meta["label"],
 	}
 }
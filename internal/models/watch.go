@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Maximum number of tracks
+// listed in sync summary.
+const maxSummaryLines = 30
+
+// WatchedPlaylist is external playlist
+// mirrored into station playlist tag.
+type WatchedPlaylist struct {
+	ID     int64  `json:"id"`
+	UserId int64  `json:"userId"`
+	Link   string `json:"link"`
+	// Station playlist tag, set on
+	// registration and kept on rename.
+	Name string `json:"name"`
+	// Remove playlist tag from tracks
+	// taken out of the source playlist.
+	RemoveMissing bool `json:"removeMissing"`
+	// Tracks already imported.
+	Tracks   []WatchedTrack `json:"tracks"`
+	LastSync time.Time      `json:"lastSync"`
+	LastErr  string         `json:"lastErr,omitempty"`
+}
+
+type WatchedTrack struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Author string `json:"author"`
+}
+
+func (t WatchedTrack) String() string {
+	return fmt.Sprintf("%s — %s", t.Author, t.Name)
+}
+
+func (p WatchedPlaylist) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>%s</b>\n", p.Name))
+	b.WriteString(fmt.Sprintf("Треков: %d\n", len(p.Tracks)))
+	if p.RemoveMissing {
+		b.WriteString("Удаленные из источника треки теряют тег плейлиста\n")
+	}
+	if p.LastSync.IsZero() {
+		b.WriteString("Еще не синхронизирован")
+	} else {
+		b.WriteString(fmt.Sprintf("Синхронизирован: %s", p.LastSync.Add(TimeZone).Format("01-02 15:04")))
+	}
+	if p.LastErr != "" {
+		b.WriteString("\n❌ Последняя синхронизация не удалась")
+	}
+
+	return b.String()
+}
+
+// WatchSummary is result of
+// watched playlist synchronization.
+type WatchSummary struct {
+	Playlist WatchedPlaylist
+	// Import job of new tracks.
+	Job     *Job
+	Removed []WatchedTrack
+	Err     error
+}
+
+// WatchNotify is called when
+// synchronization is finished.
+type WatchNotify func(summary WatchSummary)
+
+func (s WatchSummary) String() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("<b>Синхронизация плейлиста \"%s\"</b>\n", s.Playlist.Name))
+
+	if s.Err != nil {
+		b.WriteString("Не удалось получить плейлист.")
+		return b.String()
+	}
+
+	if s.Job == nil && len(s.Removed) == 0 {
+		b.WriteString("Изменений нет.")
+		return b.String()
+	}
+
+	if s.Job != nil {
+		b.WriteString(fmt.Sprintf("Новые треки: %s\n", s.Job.Progress()))
+		for i, item := range s.Job.Items {
+			if i == maxSummaryLines {
+				b.WriteString(fmt.Sprintf("... и еще %d\n", len(s.Job.Items)-maxSummaryLines))
+				break
+			}
+			b.WriteString(fmt.Sprintf("%s %s — %s\n", item.Status.Icon(), item.Conf.Author, item.Conf.Name))
+		}
+	}
+
+	if len(s.Removed) > 0 {
+		if s.Playlist.RemoveMissing {
+			b.WriteString(fmt.Sprintf("Убраны из плейлиста (%d):\n", len(s.Removed)))
+		} else {
+			b.WriteString(fmt.Sprintf("Пропали из источника, тег оставлен (%d):\n", len(s.Removed)))
+		}
+		for i, t := range s.Removed {
+			if i == maxSummaryLines {
+				b.WriteString(fmt.Sprintf("... и еще %d\n", len(s.Removed)-maxSummaryLines))
+				break
+			}
+			b.WriteString(t.String() + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
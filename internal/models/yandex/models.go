@@ -6,11 +6,15 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"strings"
 	"time"
 )
 
 const (
 	SIGN_SALT = "XGRlBW9FXlekgbPrRHuSiA"
+
+	// Size of cover art requested.
+	coverSize = "400x400"
 )
 
 type DownloadInfo struct {
@@ -26,9 +30,17 @@ type CodecType string
 
 const (
 	CodecMP3 = "mp3"
-	CodeAAC  = "aac"
+	CodecAAC = "aac"
 )
 
+func (d DownloadInfo) String() string {
+	res := fmt.Sprintf("%s %.0fkbps", d.Codec, d.Bitrate)
+	if d.Preview {
+		res += " (preview)"
+	}
+	return res
+}
+
 type DownloadInfoXMLResponse struct {
 	XMLName xml.Name `xml:"download-info"`
 	Host    string   `xml:"host"`
@@ -37,13 +49,13 @@ type DownloadInfoXMLResponse struct {
 	S       string   `xml:"s"`
 }
 
-func (d DownloadInfoXMLResponse) BuildLink() string {
+func (d DownloadInfoXMLResponse) BuildLink(codec CodecType) string {
 	h := md5.New()
 	h.Write([]byte(SIGN_SALT + d.Path[1:] + d.S))
 	sign := hex.EncodeToString(h.Sum(nil))
 
-	// 'https://{host}/get-mp3/{sign}/{ts}{path}'
-	return fmt.Sprintf("https://%s/get-mp3/%s/%s%s", d.Host, sign, d.Ts, d.Path)
+	// 'https://{host}/get-{codec}/{sign}/{ts}{path}'
+	return fmt.Sprintf("https://%s/get-%s/%s/%s%s", d.Host, codec, sign, d.Ts, d.Path)
 }
 
 type Playlist struct {
@@ -55,13 +67,17 @@ type Playlist struct {
 }
 
 type Album struct {
-	Id       int
-	Err      *string
-	Title    string
-	MetaType MetaType
-	Genre    string
-	Artists  []Artist
-	Tracks   []Track
+	Id         int
+	Err        *string
+	Title      string
+	MetaType   MetaType
+	Genre      string
+	Year       int
+	Labels     []string
+	TrackCount int
+	Artists    []Artist
+	Tracks     []Track
+	CoverUri   string
 }
 
 type MetaType string
@@ -83,6 +99,37 @@ type Track struct {
 	Duration time.Duration
 	Artists  []Artist
 	Format   string
+	// First album containing track.
+	AlbumId    int
+	AlbumTitle string
+	AlbumGenre string
+	AlbumYear  int
+	// Position in album.
+	Number   int
+	Disc     int
+	CoverUri string
+}
+
+// CoverURL returns link to cover art
+// by cover uri like "avatars.yandex.net/.../%%".
+func CoverURL(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	return "https://" + strings.Replace(uri, "%%", coverSize, 1)
+}
+
+// ArtistInfo is a brief artist info.
+type ArtistInfo struct {
+	Artist     Artist
+	TrackCount int
+	AlbumCount int
+}
+
+// SearchResult is a result of catalog search.
+type SearchResult struct {
+	Tracks []Track
+	Albums []Album
 }
 
 const (
@@ -97,8 +144,53 @@ type YaError struct {
 	} `json:"error"`
 }
 
+// Id is an identifier returned
+// either as string or as number.
 type Id string
 
+func (id *Id) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*id = Id(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*id = Id(s)
+
+	return nil
+}
+
+func (a *ArtistInfo) UnmarshalJSON(data []byte) error {
+	var tmp artistInfoResponse
+
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	a.Artist = tmp.Artist.Artist
+	a.TrackCount = tmp.Artist.Counts.Tracks
+	a.AlbumCount = tmp.Artist.Counts.DirectAlbums
+
+	return nil
+}
+
+func (r *SearchResult) UnmarshalJSON(data []byte) error {
+	var tmp searchResponse
+
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	r.Tracks = tmp.Tracks.Results
+	r.Albums = tmp.Albums.Results
+
+	return nil
+}
+
 func (p *Playlist) UnmarshalJSON(data []byte) error {
 	var tmp playlistResponse
 
@@ -127,7 +219,7 @@ func (a *Album) UnmarshalJSON(data []byte) error {
 	}
 
 	if tmp.Err != nil {
-		*(a.Err) = *tmp.Err
+		a.Err = tmp.Err
 		return nil
 	}
 
@@ -135,10 +227,25 @@ func (a *Album) UnmarshalJSON(data []byte) error {
 	a.Title = tmp.Title
 	a.MetaType = tmp.MetaType
 	a.Genre = tmp.Genre
+	a.Year = tmp.Year
+	a.TrackCount = tmp.TrackCount
 	a.Artists = tmp.Artists
+	a.CoverUri = tmp.CoverUri
+
+	a.Labels = make([]string, 0, len(tmp.Labels))
+	for _, l := range tmp.Labels {
+		a.Labels = append(a.Labels, string(l))
+	}
 
 	a.Tracks = make([]Track, 0, tmp.TrackCount)
-	for _, vol := range tmp.Volumes {
+	for i, vol := range tmp.Volumes {
+		for j := range vol {
+			vol[j].Disc = i + 1
+			vol[j].Number = j + 1
+			if vol[j].CoverUri == "" {
+				vol[j].CoverUri = tmp.CoverUri
+			}
+		}
 		a.Tracks = append(a.Tracks, vol...)
 	}
 
@@ -152,11 +259,24 @@ func (t *Track) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	t.Id = tmp.Id
+	t.Id = string(tmp.Id)
 	t.Title = tmp.Title
 	t.Duration = time.Millisecond * time.Duration(tmp.DurationMs)
 	t.Artists = tmp.Artists
 	t.Format = tmp.Type
+	t.CoverUri = tmp.CoverUri
+	if len(tmp.Albums) > 0 {
+		album := tmp.Albums[0]
+		t.AlbumId = album.Id
+		t.AlbumTitle = album.Title
+		t.AlbumGenre = album.Genre
+		t.AlbumYear = album.Year
+		t.Number = album.TrackPosition.Index
+		t.Disc = album.TrackPosition.Volume
+		if t.CoverUri == "" {
+			t.CoverUri = album.CoverUri
+		}
+	}
 
 	return nil
 }
@@ -175,17 +295,73 @@ type albumResponse struct {
 	Title      string    `json:"title"`
 	MetaType   MetaType  `json:"metaType"`
 	Genre      string    `json:"genre"`
+	Year       int       `json:"year"`
+	Labels     []label   `json:"labels"`
 	Artists    []Artist  `json:"artists"`
 	TrackCount int       `json:"trackCount"`
 	Volumes    [][]Track `json:"volumes"`
+	CoverUri   string    `json:"coverUri"`
 }
 
 type trackResponse struct {
-	Id         string   `json:"id"`
+	Id         Id       `json:"id"`
 	Title      string   `json:"title"`
 	DurationMs int      `json:"durationMs"`
 	Artists    []Artist `json:"artists"`
 	Type       string   `json:"type"`
+	CoverUri   string   `json:"coverUri"`
+	Albums     []struct {
+		Id            int    `json:"id"`
+		Title         string `json:"title"`
+		Genre         string `json:"genre"`
+		Year          int    `json:"year"`
+		CoverUri      string `json:"coverUri"`
+		TrackPosition struct {
+			Volume int `json:"volume"`
+			Index  int `json:"index"`
+		} `json:"trackPosition"`
+	} `json:"albums"`
+}
+
+// label is returned either as
+// object with name or as string.
+type label string
+
+func (l *label) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = label(s)
+		return nil
+	}
+
+	var obj struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*l = label(obj.Name)
+
+	return nil
+}
+
+type artistInfoResponse struct {
+	Artist struct {
+		Artist
+		Counts struct {
+			Tracks       int `json:"tracks"`
+			DirectAlbums int `json:"directAlbums"`
+		} `json:"counts"`
+	} `json:"artist"`
+}
+
+type searchResponse struct {
+	Tracks struct {
+		Results []Track `json:"results"`
+	} `json:"tracks"`
+	Albums struct {
+		Results []Album `json:"results"`
+	} `json:"albums"`
 }
 
 type trackItem struct {
@@ -70,6 +70,19 @@ func (a *auth) IsKnown(_ context.Context, id int64) bool {
 	return ok
 }
 
+// Users returns ids of known users.
+func (a *auth) Users(_ context.Context) []int64 {
+	a.mapMutex.Lock()
+	defer a.mapMutex.Unlock()
+
+	ids := make([]int64, 0, len(a.users))
+	for id := range a.users {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
 // Login logins user and
 // setup user token update.
 func (a *auth) Login(ctx context.Context, id int64, login, pass string) error {
@@ -0,0 +1,167 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+var (
+	ErrUnknownAction  = errors.New("unknown bulk action")
+	ErrUnknownTagType = errors.New("unknown tag type")
+	ErrInvalidFormat  = errors.New("invalid media format")
+)
+
+type bulk struct {
+	log     *slog.Logger
+	library Library
+	sch     Schedule
+}
+
+type Library interface {
+	Media(ctx context.Context, id int64, mediaId int64) (models.MediaConfig, error)
+	UpdateMedia(ctx context.Context, id int64, mediaConf models.MediaConfig) error
+	DeleteMedia(ctx context.Context, id int64, mediaConf models.MediaConfig) error
+}
+
+type Schedule interface {
+	AddToQueue(ctx context.Context, id int64, media models.MediaConfig) (models.Segment, error)
+}
+
+// New returns service applying
+// bulk actions to media.
+func New(
+	log *slog.Logger,
+	library Library,
+	sch Schedule,
+) *bulk {
+	return &bulk{
+		log:     log,
+		library: library,
+		sch:     sch,
+	}
+}
+
+// Item is job handler applying action from item
+// args to media. Media is requested again,
+// so changes made after selection are kept.
+func (b *bulk) Item(ctx context.Context, userId int64, item models.JobItem) (models.JobItemStatus, error) {
+	const op = "bulk.Item"
+
+	log := b.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+		slog.Int64("mediaId", item.Conf.ID),
+		slog.String("action", item.Args[models.BulkArgAction]),
+	)
+
+	conf, err := b.library.Media(ctx, userId, item.Conf.ID)
+	if err != nil {
+		log.Error("failed to get media", sl.Err(err))
+		return models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch models.BulkAction(item.Args[models.BulkArgAction]) {
+	case models.BulkQueue:
+		_, err = b.sch.AddToQueue(ctx, userId, conf)
+	case models.BulkDelete:
+		err = b.library.DeleteMedia(ctx, userId, conf)
+	default:
+		conf, err = apply(conf, item.Args)
+		if err == nil {
+			err = b.library.UpdateMedia(ctx, userId, conf)
+		}
+	}
+	if err != nil {
+		log.Error("failed to apply action", sl.Err(err))
+		return models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.ItemDone, nil
+}
+
+// apply returns media changed by
+// tag or format action from args.
+func apply(conf models.MediaConfig, args map[string]string) (models.MediaConfig, error) {
+	action := models.BulkAction(args[models.BulkArgAction])
+
+	switch action {
+	case models.BulkAddTags, models.BulkRemoveTags:
+		remove := action == models.BulkRemoveTags
+		for _, name := range strings.Split(args[models.BulkArgTags], ",") {
+			if err := changeTag(&conf, args[models.BulkArgTagType], name, remove); err != nil {
+				return conf, err
+			}
+		}
+	case models.BulkRetag:
+		for _, t := range models.ParseBulkTagsArg(args[models.BulkArgAdd]) {
+			if err := changeTag(&conf, t.Type.Name, t.Name, false); err != nil {
+				return conf, err
+			}
+		}
+		for _, t := range models.ParseBulkTagsArg(args[models.BulkArgRemove]) {
+			if err := changeTag(&conf, t.Type.Name, t.Name, true); err != nil {
+				return conf, err
+			}
+		}
+	case models.BulkFormat:
+		format, err := strconv.Atoi(args[models.BulkArgFormat])
+		if err != nil || models.MediaFormat(format).String() == "" {
+			return conf, ErrInvalidFormat
+		}
+		conf.Format = models.MediaFormat(format)
+	default:
+		return conf, ErrUnknownAction
+	}
+
+	return conf, nil
+}
+
+// changeTag adds tag to media or removes it.
+// Tag sets and lists of media are copied,
+// so media passed to apply is not changed.
+func changeTag(conf *models.MediaConfig, tagType string, name string, remove bool) error {
+	switch tagType {
+	case "genre":
+		conf.Genres = changeSet(conf.Genres, name, remove)
+	case "language":
+		conf.Languages = changeSet(conf.Languages, name, remove)
+	case "mood":
+		conf.Moods = changeSet(conf.Moods, name, remove)
+	case "playlist":
+		conf.Playlists = changeList(conf.Playlists, name, remove)
+	case "podcast":
+		conf.Podcasts = changeList(conf.Podcasts, name, remove)
+	default:
+		return ErrUnknownTagType
+	}
+	return nil
+}
+
+func changeSet(set models.TagSet, name string, remove bool) models.TagSet {
+	res := set.Union(nil)
+	if remove {
+		delete(res, name)
+	} else {
+		res.Add(name)
+	}
+	return res
+}
+
+func changeList(list []string, name string, remove bool) []string {
+	i := slices.Index(list, name)
+	switch {
+	case remove && i != -1:
+		return slices.Delete(slices.Clone(list), i, i+1)
+	case !remove && i == -1:
+		return append(slices.Clone(list), name)
+	}
+	return list
+}
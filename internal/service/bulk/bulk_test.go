@@ -0,0 +1,70 @@
+package bulk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestApply(t *testing.T) {
+	conf := models.MediaConfig{
+		Genres:    models.NewTagSet("Рок"),
+		Playlists: []string{"Утро", "Драйв"},
+	}
+
+	res, err := apply(conf, map[string]string{
+		models.BulkArgAction:  string(models.BulkAddTags),
+		models.BulkArgTagType: "genre",
+		models.BulkArgTags:    "Поп,Рок",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Поп", "Рок"}, res.Genres.Names())
+	// Source media is not changed.
+	assert.Equal(t, []string{"Рок"}, conf.Genres.Names())
+
+	res, err = apply(conf, map[string]string{
+		models.BulkArgAction:  string(models.BulkRemoveTags),
+		models.BulkArgTagType: "playlist",
+		models.BulkArgTags:    "Утро,Вечер",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Драйв"}, res.Playlists)
+	assert.Equal(t, []string{"Утро", "Драйв"}, conf.Playlists)
+
+	res, err = apply(conf, map[string]string{
+		models.BulkArgAction: string(models.BulkRetag),
+		models.BulkArgAdd: models.BulkTagsArg(models.TagList{
+			{Name: "Вечер", Type: models.TagTypesAvail["playlist"]},
+			{Name: "английский", Type: models.TagTypesAvail["language"]},
+		}),
+		models.BulkArgRemove: models.BulkTagsArg(models.TagList{
+			{Name: "Рок", Type: models.TagTypesAvail["genre"]},
+		}),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Genres)
+	assert.Equal(t, []string{"английский"}, res.Languages.Names())
+	assert.Equal(t, []string{"Утро", "Драйв", "Вечер"}, res.Playlists)
+
+	res, err = apply(conf, map[string]string{
+		models.BulkArgAction: string(models.BulkFormat),
+		models.BulkArgFormat: "2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.Jingle, res.Format)
+
+	_, err = apply(conf, map[string]string{
+		models.BulkArgAction: string(models.BulkFormat),
+		models.BulkArgFormat: "7",
+	})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = apply(conf, map[string]string{
+		models.BulkArgAction:  string(models.BulkAddTags),
+		models.BulkArgTagType: "album",
+	})
+	assert.ErrorIs(t, err, ErrUnknownTagType)
+}
@@ -0,0 +1,232 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+type export struct {
+	log      *slog.Logger
+	library  Library
+	saved    Saved
+	jobs     Jobs
+	mediaURL string
+
+	mutex sync.Mutex
+	// Import previews waiting
+	// for confirmation by user.
+	plans map[int64]models.ImportPlan
+}
+
+type Library interface {
+	Search(ctx context.Context, id int64, filter models.MediaFilter) ([]models.MediaConfig, error)
+}
+
+type Saved interface {
+	Get(ctx context.Context, id int64, searchId int64) (models.SavedSearch, error)
+}
+
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind models.JobKind, title string, items []models.JobItem, notify models.JobNotify) (models.Job, error)
+}
+
+// New returns service exporting library
+// and importing tags edited in export.
+// mediaURL is location of media audio
+// in M3U, see m3uLocation.
+func New(
+	log *slog.Logger,
+	library Library,
+	saved Saved,
+	jobs Jobs,
+	mediaURL string,
+) *export {
+	return &export{
+		log:      log,
+		library:  library,
+		saved:    saved,
+		jobs:     jobs,
+		mediaURL: mediaURL,
+		plans:    make(map[int64]models.ImportPlan),
+	}
+}
+
+// Library returns whole library
+// with tags and meta in JSON or CSV.
+func (e *export) Library(ctx context.Context, id int64, format models.ExportFormat) ([]byte, error) {
+	const op = "export.Library"
+
+	log := e.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("format", string(format)),
+	)
+
+	// Empty filter matches whole library.
+	media, err := e.library.Search(ctx, id, models.MediaFilter{})
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(media) == 0 {
+		return nil, service.ErrNothingToExport
+	}
+
+	var data []byte
+	switch format {
+	case models.ExportJSON:
+		data, err = encodeJSON(media)
+	case models.ExportCSV:
+		data, err = encodeCSV(media)
+	default:
+		err = fmt.Errorf("unsupported format \"%s\"", format)
+	}
+	if err != nil {
+		log.Error("failed to encode library", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("library exported", slog.Int("media", len(media)))
+
+	return data, nil
+}
+
+// TagM3U returns M3U playlist
+// of media with given tag.
+func (e *export) TagM3U(ctx context.Context, id int64, tag string) ([]byte, error) {
+	const op = "export.TagM3U"
+
+	media, err := e.library.Search(ctx, id, models.MediaFilter{Tags: []string{tag}})
+	if err != nil {
+		e.log.Error(
+			"failed to search media",
+			slog.String("op", op),
+			slog.Int64("userId", id),
+			sl.Err(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(media) == 0 {
+		return nil, service.ErrNothingToExport
+	}
+
+	return encodeM3U(media, e.mediaURL), nil
+}
+
+// SearchM3U returns M3U playlist of media
+// found by saved search, without limit
+// on number of results.
+func (e *export) SearchM3U(ctx context.Context, id int64, searchId int64) ([]byte, error) {
+	const op = "export.SearchM3U"
+
+	search, err := e.saved.Get(ctx, id, searchId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := search.MediaFilter()
+	filter.MaxRespLen = 0
+
+	media, err := e.library.Search(ctx, id, filter)
+	if err != nil {
+		e.log.Error(
+			"failed to search media",
+			slog.String("op", op),
+			slog.Int64("userId", id),
+			slog.Int64("searchId", searchId),
+			sl.Err(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(media) == 0 {
+		return nil, service.ErrNothingToExport
+	}
+
+	return encodeM3U(media, e.mediaURL), nil
+}
+
+// PreviewImport reads CSV in export format
+// and returns tag changes it makes.
+// Changes are applied by CommitImport.
+func (e *export) PreviewImport(ctx context.Context, id int64, r io.Reader) (models.ImportPlan, error) {
+	const op = "export.PreviewImport"
+
+	log := e.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	rows, err := parseCSV(r)
+	if err != nil {
+		log.Warn("invalid import file", sl.Err(err))
+		return models.ImportPlan{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	media, err := e.library.Search(ctx, id, models.MediaFilter{})
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return models.ImportPlan{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	plan := diff(rows, media)
+
+	e.mutex.Lock()
+	e.plans[id] = plan
+	e.mutex.Unlock()
+
+	return plan, nil
+}
+
+// CommitImport applies changes of last
+// previewed import as background job.
+func (e *export) CommitImport(ctx context.Context, id int64, notify models.JobNotify) (models.Job, error) {
+	const op = "export.CommitImport"
+
+	e.mutex.Lock()
+	plan, ok := e.plans[id]
+	delete(e.plans, id)
+	e.mutex.Unlock()
+
+	if !ok || len(plan.Changes) == 0 {
+		return models.Job{}, service.ErrImportNotFound
+	}
+
+	items := make([]models.JobItem, 0, len(plan.Changes))
+	for _, c := range plan.Changes {
+		items = append(items, models.JobItem{
+			Conf: c.Conf,
+			Args: map[string]string{
+				models.BulkArgAction: string(models.BulkRetag),
+				models.BulkArgAdd:    models.BulkTagsArg(c.Added),
+				models.BulkArgRemove: models.BulkTagsArg(c.Removed),
+			},
+		})
+	}
+
+	job, err := e.jobs.Submit(ctx, id, models.JobBulk, fmt.Sprintf("Импорт тегов (%d)", len(items)), items, notify)
+	if err != nil {
+		e.log.Error(
+			"failed to submit job",
+			slog.String("op", op),
+			slog.Int64("userId", id),
+			sl.Err(err),
+		)
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// CancelImport drops previewed import.
+func (e *export) CancelImport(_ context.Context, id int64) {
+	e.mutex.Lock()
+	delete(e.plans, id)
+	e.mutex.Unlock()
+}
@@ -0,0 +1,367 @@
+package export
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// Separator of values in one CSV cell.
+const listSep = ";"
+
+// CSV columns, tag columns
+// are also read by import.
+const (
+	colId        = "id"
+	colName      = "name"
+	colAuthor    = "author"
+	colFormat    = "format"
+	colDuration  = "duration"
+	colAlbums    = "albums"
+	colPlaylists = "playlists"
+	colPodcasts  = "podcasts"
+	colGenres    = "genres"
+	colLanguages = "languages"
+	colMoods     = "moods"
+	colMeta      = "meta"
+)
+
+var columns = []string{
+	colId,
+	colName,
+	colAuthor,
+	colFormat,
+	colDuration,
+	colAlbums,
+	colPlaylists,
+	colPodcasts,
+	colGenres,
+	colLanguages,
+	colMoods,
+	colMeta,
+}
+
+// Tag type of tag columns.
+var tagColumns = map[string]string{
+	colPlaylists: "playlist",
+	colPodcasts:  "podcast",
+	colGenres:    "genre",
+	colLanguages: "language",
+	colMoods:     "mood",
+}
+
+var formatNames = map[models.MediaFormat]string{
+	models.Song:    "song",
+	models.Podcast: "podcast",
+	models.Jingle:  "jingle",
+}
+
+// exportMedia is media in JSON export.
+type exportMedia struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Author string `json:"author"`
+	Format string `json:"format"`
+	// Duration in seconds.
+	Duration  int64             `json:"duration"`
+	Albums    []exportAlbum     `json:"albums"`
+	Playlists []string          `json:"playlists"`
+	Podcasts  []string          `json:"podcasts"`
+	Genres    []string          `json:"genres"`
+	Languages []string          `json:"languages"`
+	Moods     []string          `json:"moods"`
+	Meta      map[string]string `json:"meta,omitempty"`
+}
+
+type exportAlbum struct {
+	Name   string `json:"name"`
+	Author string `json:"author,omitempty"`
+	Year   int    `json:"year,omitempty"`
+	Label  string `json:"label,omitempty"`
+	Track  int    `json:"track,omitempty"`
+	Disc   int    `json:"disc,omitempty"`
+}
+
+func toExport(conf models.MediaConfig) exportMedia {
+	albums := make([]exportAlbum, 0, len(conf.Albums))
+	for _, a := range conf.Albums {
+		albums = append(albums, exportAlbum(a))
+	}
+
+	return exportMedia{
+		ID:        conf.ID,
+		Name:      conf.Name,
+		Author:    conf.Author,
+		Format:    formatNames[conf.Format],
+		Duration:  int64(conf.Duration.Seconds()),
+		Albums:    albums,
+		Playlists: nonNil(conf.Playlists),
+		Podcasts:  nonNil(conf.Podcasts),
+		Genres:    conf.Genres.Names(),
+		Languages: conf.Languages.Names(),
+		Moods:     conf.Moods.Names(),
+		Meta:      conf.Meta,
+	}
+}
+
+func encodeJSON(media []models.MediaConfig) ([]byte, error) {
+	res := make([]exportMedia, 0, len(media))
+	for _, conf := range sortById(media) {
+		res = append(res, toExport(conf))
+	}
+	return json.MarshalIndent(res, "", "  ")
+}
+
+func encodeCSV(media []models.MediaConfig) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	for _, conf := range sortById(media) {
+		m := toExport(conf)
+
+		albums := make([]string, 0, len(m.Albums))
+		for _, a := range m.Albums {
+			albums = append(albums, a.Name)
+		}
+
+		metaKeys := make([]string, 0, len(m.Meta))
+		for key := range m.Meta {
+			metaKeys = append(metaKeys, key)
+		}
+		slices.Sort(metaKeys)
+		meta := make([]string, 0, len(metaKeys))
+		for _, key := range metaKeys {
+			meta = append(meta, key+"="+m.Meta[key])
+		}
+
+		if err := w.Write([]string{
+			strconv.FormatInt(m.ID, 10),
+			m.Name,
+			m.Author,
+			m.Format,
+			strconv.FormatInt(m.Duration, 10),
+			joinList(albums),
+			joinList(m.Playlists),
+			joinList(m.Podcasts),
+			joinList(m.Genres),
+			joinList(m.Languages),
+			joinList(m.Moods),
+			joinList(meta),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeM3U returns extended M3U playlist.
+func encodeM3U(media []models.MediaConfig, mediaURL string) []byte {
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n")
+	for _, conf := range media {
+		b.WriteString(fmt.Sprintf("#EXTINF:%d,%s - %s\n", int64(conf.Duration.Seconds()), conf.Author, conf.Name))
+		b.WriteString(m3uLocation(mediaURL, conf.ID) + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+// m3uLocation returns link with "{id}"
+// replaced by media id, or file name
+// "<id>.mp3" if link is not set.
+func m3uLocation(mediaURL string, id int64) string {
+	if mediaURL == "" {
+		return fmt.Sprintf("%d.mp3", id)
+	}
+	return strings.ReplaceAll(mediaURL, "{id}", strconv.FormatInt(id, 10))
+}
+
+// importRow is CSV row with tags
+// by type, types missing in file
+// are not changed by import.
+type importRow struct {
+	ID   int64
+	Tags map[string][]string
+}
+
+// parseCSV reads tags from CSV in export format.
+// Columns are found by header, so file can have
+// only id and some of tag columns. Semicolon
+// delimiter used by spreadsheets is recognized.
+func parseCSV(r io.Reader) ([]importRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.Contains(header, []byte(",")) && bytes.Contains(header, []byte(";")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", service.ErrInvalidImport, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no header", service.ErrInvalidImport)
+	}
+
+	idCol := -1
+	tagCols := make(map[int]string)
+	for i, col := range records[0] {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if col == colId {
+			idCol = i
+		}
+		if tagType, ok := tagColumns[col]; ok {
+			tagCols[i] = tagType
+		}
+	}
+	if idCol == -1 || len(tagCols) == 0 {
+		return nil, fmt.Errorf("%w: no id or tag columns", service.ErrInvalidImport)
+	}
+
+	rows := make([]importRow, 0, len(records)-1)
+	seen := make(map[int64]bool)
+	for line, record := range records[1:] {
+		id, err := strconv.ParseInt(strings.TrimSpace(record[idCol]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid id", service.ErrInvalidImport, line+2)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: line %d: duplicate id %d", service.ErrInvalidImport, line+2, id)
+		}
+		seen[id] = true
+
+		row := importRow{
+			ID:   id,
+			Tags: make(map[string][]string, len(tagCols)),
+		}
+		for i, tagType := range tagCols {
+			row.Tags[tagType] = splitList(record[i])
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// diff returns tag changes turning
+// library media into imported rows.
+func diff(rows []importRow, media []models.MediaConfig) models.ImportPlan {
+	byId := make(map[int64]models.MediaConfig, len(media))
+	for _, conf := range media {
+		byId[conf.ID] = conf
+	}
+
+	plan := models.ImportPlan{
+		Rows:    len(rows),
+		Changes: make([]models.ImportChange, 0),
+		Unknown: make([]int64, 0),
+	}
+
+	for _, row := range rows {
+		conf, ok := byId[row.ID]
+		if !ok {
+			plan.Unknown = append(plan.Unknown, row.ID)
+			continue
+		}
+
+		change := models.ImportChange{Conf: conf}
+		for _, tagType := range []string{"playlist", "podcast", "genre", "language", "mood"} {
+			names, ok := row.Tags[tagType]
+			if !ok {
+				continue
+			}
+			cur := tagNames(conf, tagType)
+
+			for _, name := range names {
+				if !slices.Contains(cur, name) {
+					change.Added = append(change.Added, models.Tag{Name: name, Type: models.TagTypesAvail[tagType]})
+				}
+			}
+			for _, name := range cur {
+				if !slices.Contains(names, name) {
+					change.Removed = append(change.Removed, models.Tag{Name: name, Type: models.TagTypesAvail[tagType]})
+				}
+			}
+		}
+
+		if len(change.Added)+len(change.Removed) > 0 {
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	return plan
+}
+
+func tagNames(conf models.MediaConfig, tagType string) []string {
+	switch tagType {
+	case "playlist":
+		return conf.Playlists
+	case "podcast":
+		return conf.Podcasts
+	case "genre":
+		return conf.Genres.Names()
+	case "language":
+		return conf.Languages.Names()
+	case "mood":
+		return conf.Moods.Names()
+	default:
+		return nil
+	}
+}
+
+func joinList(vals []string) string {
+	return strings.Join(vals, listSep+" ")
+}
+
+// splitList splits cell into values,
+// empty values are skipped.
+func splitList(cell string) []string {
+	vals := make([]string, 0)
+	for _, v := range strings.Split(cell, listSep) {
+		if v = strings.TrimSpace(v); v != "" && !slices.Contains(vals, v) {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+func sortById(media []models.MediaConfig) []models.MediaConfig {
+	res := slices.Clone(media)
+	slices.SortFunc(res, func(a, b models.MediaConfig) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return res
+}
+
+func nonNil(vals []string) []string {
+	if vals == nil {
+		return []string{}
+	}
+	return vals
+}
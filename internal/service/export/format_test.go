@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+func library() []models.MediaConfig {
+	return []models.MediaConfig{
+		{
+			ID:        2,
+			Name:      "Кукла колдуна",
+			Author:    "Король и Шут",
+			Duration:  205 * time.Second,
+			Playlists: []string{"Утро"},
+			Genres:    models.NewTagSet("Рок"),
+			Languages: models.NewTagSet("русский"),
+			Albums:    []models.Album{{Name: "Акустический альбом", Author: "Король и Шут", Track: 3, Disc: 1}},
+		},
+		{
+			ID:       1,
+			Name:     "Chill, Vol. 1",
+			Author:   "Lo-fi Girl",
+			Duration: 90 * time.Second,
+			Genres:   models.NewTagSet("Lo-fi"),
+		},
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	data, err := encodeCSV(library())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id,name,author,format,duration,albums,playlists,podcasts,genres,languages,moods,meta", lines[0])
+	// Sorted by id, values with separators are quoted.
+	assert.Equal(t, `1,"Chill, Vol. 1",Lo-fi Girl,song,90,,,,Lo-fi,,,`, lines[1])
+	assert.Equal(t, "2,Кукла колдуна,Король и Шут,song,205,Акустический альбом,Утро,,Рок,русский,,", lines[2])
+
+	// Unchanged export has no changes.
+	rows, err := parseCSV(bytes.NewReader(data))
+	require.NoError(t, err)
+	plan := diff(rows, library())
+	assert.Equal(t, 2, plan.Rows)
+	assert.Empty(t, plan.Changes)
+
+	edited := strings.Replace(string(data), ",Утро,,Рок,", ",Утро; Вечер,,,", 1)
+	rows, err = parseCSV(strings.NewReader(edited))
+	require.NoError(t, err)
+	plan = diff(rows, library())
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, int64(2), plan.Changes[0].Conf.ID)
+	assert.Equal(t, models.TagList{{Name: "Вечер", Type: models.TagTypesAvail["playlist"]}}, plan.Changes[0].Added)
+	assert.Equal(t, models.TagList{{Name: "Рок", Type: models.TagTypesAvail["genre"]}}, plan.Changes[0].Removed)
+}
+
+func TestParseCSVPartial(t *testing.T) {
+	// Spreadsheet export with semicolons,
+	// only genres are changed.
+	file := "\ufeffID;Genres\n1;Джаз\n3;Поп\n"
+
+	rows, err := parseCSV(strings.NewReader(file))
+	require.NoError(t, err)
+
+	plan := diff(rows, library())
+	assert.Equal(t, []int64{3}, plan.Unknown)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, models.TagList{{Name: "Джаз", Type: models.TagTypesAvail["genre"]}}, plan.Changes[0].Added)
+	assert.Equal(t, models.TagList{{Name: "Lo-fi", Type: models.TagTypesAvail["genre"]}}, plan.Changes[0].Removed)
+
+	_, err = parseCSV(strings.NewReader("id,name\n1,a\n"))
+	assert.ErrorIs(t, err, service.ErrInvalidImport)
+
+	_, err = parseCSV(strings.NewReader("id,genres\n1,Поп\n1,Рок\n"))
+	assert.ErrorIs(t, err, service.ErrInvalidImport)
+}
+
+func TestEncodeJSON(t *testing.T) {
+	data, err := encodeJSON(library())
+	require.NoError(t, err)
+
+	var res []exportMedia
+	require.NoError(t, json.Unmarshal(data, &res))
+	require.Len(t, res, 2)
+	assert.Equal(t, int64(1), res[0].ID)
+	assert.Equal(t, []string{}, res[0].Playlists)
+	assert.Equal(t, int64(205), res[1].Duration)
+	assert.Equal(t, 3, res[1].Albums[0].Track)
+}
+
+func TestEncodeM3U(t *testing.T) {
+	assert.Equal(t,
+		"#EXTM3U\n#EXTINF:205,Король и Шут - Кукла колдуна\n2.mp3\n#EXTINF:90,Lo-fi Girl - Chill, Vol. 1\n1.mp3\n",
+		string(encodeM3U(library(), "")),
+	)
+	assert.Equal(t, "https://radio.example/media/7", m3uLocation("https://radio.example/media/{id}", 7))
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/random"
 	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
 	"github.com/brianvoe/gofakeit/v6"
 )
 
@@ -39,6 +41,12 @@ func (f *Filler) Search(_ context.Context, _ int64, filter models.MediaFilter) (
 	return res, nil
 }
 
+func (f *Filler) Media(_ context.Context, _ int64, mediaId int64) (models.MediaConfig, error) {
+	conf := random.Media().ToConfig()
+	conf.ID = mediaId
+	return conf, nil
+}
+
 func (f *Filler) NewMedia(_ context.Context, _ int64, _ models.MediaConfig) (int64, error) {
 	return 0, nil
 }
@@ -51,6 +59,106 @@ func (f *Filler) DeleteMedia(_ context.Context, _ int64, _ models.MediaConfig) e
 	return nil
 }
 
+func (f *Filler) ReplaceSource(_ context.Context, _ int64, conf models.MediaConfig, _ string) (models.MediaConfig, error) {
+	return conf, nil
+}
+
+// Source always fails, since
+// filler media have no audio.
+func (f *Filler) Source(_ context.Context, _ int64, _ models.MediaConfig) (string, bool, error) {
+	return "", false, service.ErrMediaNotFound
+}
+
+// Taxonomy returns tags set by automatic
+// tagging and a few moods.
+func (f *Filler) Taxonomy(_ context.Context, _ int64) (models.Taxonomy, error) {
+	var tags models.TagList
+
+	add := func(tagType string, names ...string) {
+		for _, name := range names {
+			tags = append(tags, models.Tag{
+				ID:   int64(len(tags) + 1),
+				Name: name,
+				Type: models.TagTypesAvail[tagType],
+			})
+		}
+	}
+
+	add("genre",
+		models.Pop.Name, models.HipHop.Name, models.Rock.Name, models.Jazz.Name,
+		models.Electro.Name, models.Instrumental.Name, models.Rap.Name, models.LoFi.Name,
+	)
+	add("mood", "Веселое", "Грустное", "Спокойное", "Энергичное")
+	add("language",
+		models.Russian.Name, models.Korean.Name, models.Japanese.Name, models.Chinese.Name,
+	)
+
+	return models.NewTaxonomy(tags), nil
+}
+
+// TagUsage returns tags of taxonomy,
+// other types have no tags.
+func (f *Filler) TagUsage(ctx context.Context, id int64, tagType string) ([]models.TagUsage, error) {
+	tax, _ := f.Taxonomy(ctx, id)
+
+	res := make([]models.TagUsage, 0)
+	for _, list := range []models.TagList{tax.Genres, tax.Moods, tax.Languages} {
+		for _, tag := range list {
+			if tag.Type.Name == tagType {
+				res = append(res, models.TagUsage{Tag: tag, Count: rand.Intn(10)})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (f *Filler) Tag(ctx context.Context, id int64, tagId int64) (models.TagUsage, error) {
+	tax, _ := f.Taxonomy(ctx, id)
+
+	tag, found := tax.Find(tagId)
+	if !found {
+		return models.TagUsage{}, service.ErrTagNotFound
+	}
+
+	return models.TagUsage{Tag: tag}, nil
+}
+
+func (f *Filler) RenameTag(ctx context.Context, id int64, tagId int64, _ string) (models.Tag, error) {
+	u, err := f.Tag(ctx, id, tagId)
+	return u.Tag, err
+}
+
+func (f *Filler) SetTagMeta(ctx context.Context, id int64, tagId int64, _, _ string) (models.Tag, error) {
+	u, err := f.Tag(ctx, id, tagId)
+	return u.Tag, err
+}
+
+func (f *Filler) MergeTags(_ context.Context, _ int64, _, _ int64) (int, error) {
+	return 0, nil
+}
+
+func (f *Filler) DeleteTag(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (f *Filler) UnusedTags(_ context.Context, _ int64) (models.TagList, error) {
+	return models.TagList{}, nil
+}
+
+func (f *Filler) DeleteUnusedTags(_ context.Context, _ int64, tagIds []int64) ([]int64, error) {
+	return tagIds, nil
+}
+
+func (f *Filler) HasSource(_ context.Context, _ int64, _ models.MediaConfig) (bool, error) {
+	return rand.Intn(10) > 0, nil
+}
+
+func (f *Filler) ReprobeDuration(_ context.Context, _ int64, conf models.MediaConfig) (models.MediaConfig, error) {
+	conf.Duration = time.Duration(rand.Intn(300)+1) * time.Second
+	return conf, nil
+}
+
 func (f *Filler) LinkDownload(_ context.Context, _ int64, _ string) (models.LinkDownloadResult, error) {
 	const maxRespLen = 10
 
@@ -71,10 +179,42 @@ func (f *Filler) LinkDownload(_ context.Context, _ int64, _ string) (models.Link
 	}, nil
 }
 
-func (f *Filler) LinkUpload(_ context.Context, _ int64, _ models.LinkDownloadResult) error {
+func (f *Filler) LinkProviders() []string {
+	return []string{"Яндекс Музыка", "Spotify"}
+}
+
+func (f *Filler) CatalogSearch(_ context.Context, _ int64, _ string) ([]models.CatalogItem, error) {
+	const maxRespLen = 10
+
+	res := make([]models.CatalogItem, rand.Intn(maxRespLen))
+
+	for i := range res {
+		m := random.Media()
+		res[i] = models.CatalogItem{
+			Type:      models.ResSong,
+			Name:      m.Name,
+			Author:    m.Author,
+			Duration:  m.Duration,
+			Link:      "https://music.yandex.ru/album/1/track/1",
+			InLibrary: rand.Intn(2) == 0,
+		}
+	}
+
+	return res, nil
+}
+
+func (f *Filler) Duplicates(_ context.Context, _ int64, _ models.MediaConfig) ([]models.MediaConfig, error) {
+	return []models.MediaConfig{}, nil
+}
+
+func (f *Filler) PrepareUpload(_ context.Context, _ int64, _ []models.MediaConfig) error {
 	return nil
 }
 
+func (f *Filler) UploadItem(_ context.Context, _ int64, _ models.JobItem) (int64, models.JobItemStatus, error) {
+	return 0, models.ItemDone, nil
+}
+
 func (f *Filler) Schedule(_ context.Context, _ int64) ([]models.Segment, error) {
 	const maxScheduleSize = 50
 
@@ -120,3 +260,5 @@ func (f *Filler) StartAutoDJ(_ context.Context, _ int64) error {
 func (f *Filler) StopAutoDJ(_ context.Context, _ int64) error {
 	return nil
 }
+
+func (f *Filler) Stop() {}
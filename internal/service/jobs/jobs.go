@@ -0,0 +1,526 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// Minimal interval between
+	// progress notifications.
+	notifyInterval = 2 * time.Second
+	// Number of finished jobs
+	// stored for every user.
+	maxFinishedJobs = 10
+)
+
+// Handler processes one item of job.
+type Handler func(ctx context.Context, userId int64, item models.JobItem) (models.JobItemStatus, error)
+
+// UploadHandler processes one item of job
+// and returns id of created media.
+type UploadHandler func(ctx context.Context, userId int64, item models.JobItem) (int64, models.JobItemStatus, error)
+
+type jobs struct {
+	log      *slog.Logger
+	file     string
+	pins     Pins
+	handlers map[models.JobKind]UploadHandler
+	// Bounds number of simultaneously running jobs.
+	workers chan struct{}
+
+	mutex    sync.Mutex
+	jobs     map[int64]*models.Job
+	lastId   int64
+	cancels  map[int64]context.CancelFunc
+	notifies map[int64]models.JobNotify
+	notified map[int64]time.Time
+
+	// Guards file writes.
+	fileMutex sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Pins keeps source files of job items
+// from being evicted from download cache.
+type Pins interface {
+	Pin(path string)
+	Unpin(path string)
+}
+
+func New(
+	log *slog.Logger,
+	file string,
+	workers int,
+	pins Pins,
+) *jobs {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	j := &jobs{
+		log:      log,
+		file:     file,
+		pins:     pins,
+		handlers: make(map[models.JobKind]UploadHandler),
+		workers:  make(chan struct{}, workers),
+		jobs:     make(map[int64]*models.Job),
+		cancels:  make(map[int64]context.CancelFunc),
+		notifies: make(map[int64]models.JobNotify),
+		notified: make(map[int64]time.Time),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	if err := j.recover(); err != nil {
+		log.Error(
+			"failed to recover jobs",
+			slog.String("op", "jobs.New"),
+			sl.Err(err),
+		)
+	}
+
+	return j
+}
+
+// Register sets handler for given job kind.
+func (j *jobs) Register(kind models.JobKind, handler Handler) {
+	j.handlers[kind] = func(ctx context.Context, userId int64, item models.JobItem) (int64, models.JobItemStatus, error) {
+		status, err := handler(ctx, userId, item)
+		return 0, status, err
+	}
+}
+
+// RegisterUpload sets handler for given job kind,
+// id of created media is kept in job item.
+func (j *jobs) RegisterUpload(kind models.JobKind, handler UploadHandler) {
+	j.handlers[kind] = handler
+}
+
+// Submit creates job and runs it in background.
+func (j *jobs) Submit(ctx context.Context, userId int64, kind models.JobKind, title string, items []models.JobItem, notify models.JobNotify) (models.Job, error) {
+	const op = "jobs.Submit"
+
+	log := j.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+	)
+
+	if _, ok := j.handlers[kind]; !ok {
+		log.Error("unknown job kind", slog.String("kind", string(kind)))
+		return models.Job{}, fmt.Errorf("%s: unknown job kind \"%s\"", op, kind)
+	}
+
+	j.mutex.Lock()
+	j.lastId++
+	job := &models.Job{
+		ID:        j.lastId,
+		UserId:    userId,
+		Kind:      kind,
+		Title:     title,
+		Status:    models.JobPending,
+		Items:     slices.Clone(items),
+		CreatedAt: time.Now(),
+	}
+	for i := range job.Items {
+		job.Items[i].Status = models.ItemPending
+		job.Items[i].Err = ""
+		j.pin(job.Items[i])
+	}
+	j.jobs[job.ID] = job
+	j.pruneLocked(userId)
+	res := j.startLocked(job.ID, notify)
+	j.mutex.Unlock()
+
+	if err := j.dump(); err != nil {
+		log.Error("failed to dump jobs", sl.Err(err))
+	}
+
+	log.Info("job submitted", slog.Int64("jobId", res.ID), slog.Int("items", len(items)))
+
+	return res, nil
+}
+
+// Cancel stops active job.
+// Items that are not processed yet are marked as canceled.
+func (j *jobs) Cancel(ctx context.Context, userId int64, jobId int64) error {
+	const op = "jobs.Cancel"
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	job, ok := j.jobs[jobId]
+	if !ok || job.UserId != userId {
+		return fmt.Errorf("%s: %w", op, service.ErrJobNotFound)
+	}
+	if !job.Status.Active() {
+		return fmt.Errorf("%s: %w", op, service.ErrJobNotActive)
+	}
+
+	j.cancels[jobId]()
+
+	return nil
+}
+
+// Retry runs again failed, canceled
+// and not processed items of finished job.
+func (j *jobs) Retry(ctx context.Context, userId int64, jobId int64, notify models.JobNotify) (models.Job, error) {
+	const op = "jobs.Retry"
+
+	log := j.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+		slog.Int64("jobId", jobId),
+	)
+
+	j.mutex.Lock()
+
+	job, ok := j.jobs[jobId]
+	if !ok || job.UserId != userId {
+		j.mutex.Unlock()
+		return models.Job{}, fmt.Errorf("%s: %w", op, service.ErrJobNotFound)
+	}
+	if job.Status.Active() {
+		j.mutex.Unlock()
+		return models.Job{}, fmt.Errorf("%s: %w", op, service.ErrJobActive)
+	}
+
+	for i, item := range job.Items {
+		switch item.Status {
+		case models.ItemFailed, models.ItemCanceled:
+			job.Items[i].Status = models.ItemPending
+			job.Items[i].Err = ""
+		}
+	}
+	job.Status = models.JobPending
+	job.FinishedAt = time.Time{}
+
+	res := j.startLocked(jobId, notify)
+	j.mutex.Unlock()
+
+	if err := j.dump(); err != nil {
+		log.Error("failed to dump jobs", sl.Err(err))
+	}
+
+	return res, nil
+}
+
+// Job returns job by its id.
+func (j *jobs) Job(ctx context.Context, userId int64, jobId int64) (models.Job, error) {
+	const op = "jobs.Job"
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	job, ok := j.jobs[jobId]
+	if !ok || job.UserId != userId {
+		return models.Job{}, fmt.Errorf("%s: %w", op, service.ErrJobNotFound)
+	}
+
+	return copyJob(job), nil
+}
+
+// List returns user's jobs, newest first.
+func (j *jobs) List(ctx context.Context, userId int64) ([]models.Job, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	res := make([]models.Job, 0)
+	for _, job := range j.jobs {
+		if job.UserId == userId {
+			res = append(res, copyJob(job))
+		}
+	}
+
+	slices.SortFunc(res, func(a, b models.Job) int {
+		return int(b.ID - a.ID)
+	})
+
+	return res, nil
+}
+
+// Stop cancels all running jobs.
+func (j *jobs) Stop() {
+	j.cancel()
+}
+
+// startLocked runs job in background.
+// Must be called with locked mutex.
+func (j *jobs) startLocked(jobId int64, notify models.JobNotify) models.Job {
+	ctx, cancel := context.WithCancel(j.ctx)
+
+	j.cancels[jobId] = cancel
+	if notify != nil {
+		j.notifies[jobId] = notify
+	} else {
+		delete(j.notifies, jobId)
+	}
+
+	go j.run(ctx, jobId)
+
+	return copyJob(j.jobs[jobId])
+}
+
+// run processes job items one by one.
+func (j *jobs) run(ctx context.Context, jobId int64) {
+	const op = "jobs.run"
+
+	log := j.log.With(
+		slog.String("op", op),
+		slog.Int64("jobId", jobId),
+	)
+
+	// Wait for free worker.
+	select {
+	case j.workers <- struct{}{}:
+		defer func() { <-j.workers }()
+	case <-ctx.Done():
+		j.finish(ctx, jobId)
+		return
+	}
+
+	j.mutex.Lock()
+	job := j.jobs[jobId]
+	job.Status = models.JobRunning
+	userId := job.UserId
+	handler := j.handlers[job.Kind]
+	items := len(job.Items)
+	j.mutex.Unlock()
+
+	j.notify(jobId, true)
+
+	for i := 0; i < items; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		j.mutex.Lock()
+		item := job.Items[i]
+		j.mutex.Unlock()
+
+		if item.Status != models.ItemPending {
+			continue
+		}
+
+		mediaId, status, err := handler(ctx, userId, item)
+		if err != nil && ctx.Err() != nil {
+			// Item was interrupted by cancel,
+			// it stays pending to be marked as canceled.
+			break
+		}
+
+		j.mutex.Lock()
+		job.Items[i].Status = status
+		job.Items[i].MediaId = mediaId
+		if err != nil {
+			job.Items[i].Status = models.ItemFailed
+			job.Items[i].Err = err.Error()
+		}
+		// Failed item may be retried.
+		if !retriable(job.Items[i].Status) {
+			j.unpin(item)
+		}
+		j.mutex.Unlock()
+
+		if err := j.dump(); err != nil {
+			log.Error("failed to dump jobs", sl.Err(err))
+		}
+
+		j.notify(jobId, false)
+	}
+
+	j.finish(ctx, jobId)
+}
+
+// finish sets final job status
+// and sends last notification.
+func (j *jobs) finish(ctx context.Context, jobId int64) {
+	const op = "jobs.finish"
+
+	j.mutex.Lock()
+	job := j.jobs[jobId]
+	job.Status = models.JobDone
+	if ctx.Err() != nil {
+		job.Status = models.JobCanceled
+		for i, item := range job.Items {
+			if item.Status == models.ItemPending {
+				job.Items[i].Status = models.ItemCanceled
+			}
+		}
+	}
+	job.FinishedAt = time.Now()
+	j.cancels[jobId]()
+	delete(j.cancels, jobId)
+	j.mutex.Unlock()
+
+	// Bot is stopping, job will be
+	// marked as interrupted after restart.
+	if j.ctx.Err() != nil {
+		return
+	}
+
+	if err := j.dump(); err != nil {
+		j.log.Error("failed to dump jobs", slog.String("op", op), sl.Err(err))
+	}
+
+	j.notify(jobId, true)
+
+	j.mutex.Lock()
+	delete(j.notifies, jobId)
+	delete(j.notified, jobId)
+	j.mutex.Unlock()
+}
+
+// notify calls job notification.
+// Notifications are throttled unless forced.
+func (j *jobs) notify(jobId int64, force bool) {
+	j.mutex.Lock()
+	notify, ok := j.notifies[jobId]
+	job := copyJob(j.jobs[jobId])
+	if ok && !force && time.Since(j.notified[jobId]) < notifyInterval {
+		ok = false
+	}
+	if ok {
+		j.notified[jobId] = time.Now()
+	}
+	j.mutex.Unlock()
+
+	if ok {
+		notify(job)
+	}
+}
+
+// pruneLocked removes old finished jobs of user.
+// Must be called with locked mutex.
+func (j *jobs) pruneLocked(userId int64) {
+	finished := make([]int64, 0)
+	for id, job := range j.jobs {
+		if job.UserId == userId && !job.Status.Active() {
+			finished = append(finished, id)
+		}
+	}
+
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	slices.Sort(finished)
+	for _, id := range finished[:len(finished)-maxFinishedJobs] {
+		for _, item := range j.jobs[id].Items {
+			if retriable(item.Status) {
+				j.unpin(item)
+			}
+		}
+		delete(j.jobs, id)
+	}
+}
+
+// pin protects source file of item,
+// so it is available until item is processed.
+func (j *jobs) pin(item models.JobItem) {
+	if j.pins != nil && item.Conf.SourcePath != "" {
+		j.pins.Pin(item.Conf.SourcePath)
+	}
+}
+
+func (j *jobs) unpin(item models.JobItem) {
+	if j.pins != nil && item.Conf.SourcePath != "" {
+		j.pins.Unpin(item.Conf.SourcePath)
+	}
+}
+
+// retriable reports if item will be
+// processed again on retry.
+func retriable(s models.JobItemStatus) bool {
+	switch s {
+	case models.ItemPending, models.ItemFailed, models.ItemCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// recover loads jobs from file.
+// Jobs that were active are marked as interrupted.
+func (j *jobs) recover() error {
+	const op = "jobs.recover"
+
+	data, err := os.ReadFile(j.file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var jobs []*models.Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, job := range jobs {
+		if job.Status.Active() {
+			job.Status = models.JobInterrupted
+		}
+		for _, item := range job.Items {
+			if retriable(item.Status) {
+				j.pin(item)
+			}
+		}
+		j.jobs[job.ID] = job
+		j.lastId = max(j.lastId, job.ID)
+	}
+
+	return nil
+}
+
+// dump saves jobs to file.
+func (j *jobs) dump() error {
+	const op = "jobs.dump"
+
+	j.mutex.Lock()
+	jobs := make([]models.Job, 0, len(j.jobs))
+	for _, job := range j.jobs {
+		jobs = append(jobs, copyJob(job))
+	}
+	j.mutex.Unlock()
+
+	slices.SortFunc(jobs, func(a, b models.Job) int {
+		return int(a.ID - b.ID)
+	})
+
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	j.fileMutex.Lock()
+	defer j.fileMutex.Unlock()
+
+	if err := os.WriteFile(j.file, data, 0644); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func copyJob(job *models.Job) models.Job {
+	res := *job
+	res.Items = slices.Clone(job.Items)
+	return res
+}
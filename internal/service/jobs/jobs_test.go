@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	userId  = 1
+	jobKind = models.JobKind("test")
+)
+
+// fakePins counts pins of files.
+type fakePins struct {
+	mutex sync.Mutex
+	pins  map[string]int
+}
+
+func (p *fakePins) Pin(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.pins == nil {
+		p.pins = make(map[string]int)
+	}
+	p.pins[path]++
+}
+
+func (p *fakePins) Unpin(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.pins[path]--
+}
+
+func (p *fakePins) count(path string) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.pins[path]
+}
+
+func newJobs(t *testing.T, file string, pins Pins) *jobs {
+	j := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		file,
+		1,
+		pins,
+	)
+	t.Cleanup(j.Stop)
+	return j
+}
+
+func items(names ...string) []models.JobItem {
+	res := make([]models.JobItem, 0, len(names))
+	for _, name := range names {
+		res = append(res, models.JobItem{
+			Conf: models.MediaConfig{Name: name, SourcePath: name + ".mp3"},
+		})
+	}
+	return res
+}
+
+// finished returns notification receiving
+// job when it is finished.
+func finished() (models.JobNotify, <-chan models.Job) {
+	ch := make(chan models.Job, 1)
+	return func(job models.Job) {
+		if !job.Status.Active() {
+			ch <- job
+		}
+	}, ch
+}
+
+func wait(t *testing.T, ch <-chan models.Job) models.Job {
+	t.Helper()
+
+	select {
+	case job := <-ch:
+		return job
+	case <-time.After(time.Second):
+		t.Fatal("job is not finished")
+		return models.Job{}
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	pins := &fakePins{}
+	j := newJobs(t, filepath.Join(t.TempDir(), "jobs.json"), pins)
+
+	var mediaId int64
+	j.RegisterUpload(jobKind, func(_ context.Context, _ int64, item models.JobItem) (int64, models.JobItemStatus, error) {
+		if item.Conf.Name == "broken" {
+			return 0, models.ItemFailed, errors.New("broken file")
+		}
+		mediaId++
+		return mediaId, models.ItemDone, nil
+	})
+
+	_, err := j.Submit(context.Background(), userId, "unknown", "", items("first"), nil)
+	assert.Error(t, err)
+
+	notify, done := finished()
+	submitted, err := j.Submit(context.Background(), userId, jobKind, "upload", items("first", "broken", "second"), notify)
+	require.NoError(t, err)
+	assert.True(t, submitted.Status.Active())
+
+	job := wait(t, done)
+	assert.Equal(t, models.JobDone, job.Status)
+	assert.Equal(t, 2, job.Count(models.ItemDone))
+	assert.Equal(t, 1, job.Count(models.ItemFailed))
+	assert.Equal(t, "broken file", job.Items[1].Err)
+	// Ids of uploaded media are kept.
+	assert.Equal(t, int64(1), job.Items[0].MediaId)
+	assert.Equal(t, int64(2), job.Items[2].MediaId)
+
+	// Source of failed item is kept for retry.
+	assert.Equal(t, 0, pins.count("first.mp3"))
+	assert.Equal(t, 1, pins.count("broken.mp3"))
+
+	got, err := j.Job(context.Background(), userId, submitted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	_, err = j.Job(context.Background(), userId+1, submitted.ID)
+	assert.ErrorIs(t, err, service.ErrJobNotFound)
+}
+
+func TestCancel(t *testing.T) {
+	j := newJobs(t, filepath.Join(t.TempDir(), "jobs.json"), nil)
+
+	started := make(chan struct{})
+	j.Register(jobKind, func(ctx context.Context, _ int64, item models.JobItem) (models.JobItemStatus, error) {
+		if item.Conf.Name == "first" {
+			return models.ItemDone, nil
+		}
+		close(started)
+		<-ctx.Done()
+		return models.ItemFailed, ctx.Err()
+	})
+
+	notify, done := finished()
+	submitted, err := j.Submit(context.Background(), userId, jobKind, "upload", items("first", "second", "third"), notify)
+	require.NoError(t, err)
+
+	<-started
+	assert.ErrorIs(t, j.Cancel(context.Background(), userId+1, submitted.ID), service.ErrJobNotFound)
+	require.NoError(t, j.Cancel(context.Background(), userId, submitted.ID))
+
+	// Interrupted item is canceled, not failed.
+	job := wait(t, done)
+	assert.Equal(t, models.JobCanceled, job.Status)
+	assert.Equal(t, models.ItemDone, job.Items[0].Status)
+	assert.Equal(t, models.ItemCanceled, job.Items[1].Status)
+	assert.Equal(t, models.ItemCanceled, job.Items[2].Status)
+	assert.Empty(t, job.Items[1].Err)
+
+	assert.ErrorIs(t, j.Cancel(context.Background(), userId, submitted.ID), service.ErrJobNotActive)
+}
+
+func TestRetry(t *testing.T) {
+	j := newJobs(t, filepath.Join(t.TempDir(), "jobs.json"), nil)
+
+	var (
+		mutex     sync.Mutex
+		processed []string
+		fixed     bool
+	)
+	j.Register(jobKind, func(_ context.Context, _ int64, item models.JobItem) (models.JobItemStatus, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		processed = append(processed, item.Conf.Name)
+		if item.Conf.Name == "broken" && !fixed {
+			return models.ItemFailed, errors.New("broken file")
+		}
+		return models.ItemDone, nil
+	})
+
+	notify, done := finished()
+	submitted, err := j.Submit(context.Background(), userId, jobKind, "upload", items("first", "broken"), notify)
+	require.NoError(t, err)
+	wait(t, done)
+
+	mutex.Lock()
+	fixed = true
+	mutex.Unlock()
+
+	notify, done = finished()
+	_, err = j.Retry(context.Background(), userId, submitted.ID, notify)
+	require.NoError(t, err)
+
+	// Only failed item is processed again.
+	job := wait(t, done)
+	assert.Equal(t, models.JobDone, job.Status)
+	assert.Equal(t, 2, job.Count(models.ItemDone))
+	assert.Empty(t, job.Items[1].Err)
+
+	mutex.Lock()
+	assert.Equal(t, []string{"first", "broken", "broken"}, processed)
+	mutex.Unlock()
+}
+
+func TestRecover(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	j := newJobs(t, file, nil)
+
+	started := make(chan struct{})
+	j.Register(jobKind, func(ctx context.Context, _ int64, item models.JobItem) (models.JobItemStatus, error) {
+		if item.Conf.Name == "first" {
+			return models.ItemDone, nil
+		}
+		close(started)
+		<-ctx.Done()
+		return models.ItemFailed, ctx.Err()
+	})
+
+	submitted, err := j.Submit(context.Background(), userId, jobKind, "upload", items("first", "second"), nil)
+	require.NoError(t, err)
+
+	// Bot is stopped in the middle of job.
+	<-started
+	j.Stop()
+
+	pins := &fakePins{}
+	restored := newJobs(t, file, pins)
+	restored.Register(jobKind, func(_ context.Context, _ int64, _ models.JobItem) (models.JobItemStatus, error) {
+		return models.ItemDone, nil
+	})
+
+	job, err := restored.Job(context.Background(), userId, submitted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobInterrupted, job.Status)
+	assert.Equal(t, models.ItemDone, job.Items[0].Status)
+	assert.Equal(t, models.ItemPending, job.Items[1].Status)
+	// Source of not processed item is kept.
+	assert.Equal(t, 0, pins.count("first.mp3"))
+	assert.Equal(t, 1, pins.count("second.mp3"))
+
+	// Interrupted job is continued by retry.
+	notify, done := finished()
+	_, err = restored.Retry(context.Background(), userId, submitted.ID, notify)
+	require.NoError(t, err)
+
+	job = wait(t, done)
+	assert.Equal(t, models.JobDone, job.Status)
+	assert.Equal(t, 2, job.Count(models.ItemDone))
+
+	// Ids are not reused.
+	next, err := restored.Submit(context.Background(), userId, jobKind, "upload", items("third"), nil)
+	require.NoError(t, err)
+	assert.Greater(t, next.ID, submitted.ID)
+}
+
+func TestPrune(t *testing.T) {
+	j := newJobs(t, filepath.Join(t.TempDir(), "jobs.json"), nil)
+	j.Register(jobKind, func(_ context.Context, _ int64, _ models.JobItem) (models.JobItemStatus, error) {
+		return models.ItemDone, nil
+	})
+
+	ids := make([]int64, 0)
+	for i := 0; i < maxFinishedJobs+2; i++ {
+		notify, done := finished()
+		job, err := j.Submit(context.Background(), userId, jobKind, "upload", items("first"), notify)
+		require.NoError(t, err)
+		wait(t, done)
+		ids = append(ids, job.ID)
+	}
+
+	// Jobs of other users are kept.
+	notify, done := finished()
+	other, err := j.Submit(context.Background(), userId+1, jobKind, "upload", items("first"), notify)
+	require.NoError(t, err)
+	wait(t, done)
+
+	list, err := j.List(context.Background(), userId)
+	require.NoError(t, err)
+	// Old jobs are pruned on submit,
+	// the last one is not counted then.
+	assert.Len(t, list, maxFinishedJobs+1)
+	// Newest first.
+	assert.Equal(t, ids[len(ids)-1], list[0].ID)
+
+	_, err = j.Job(context.Background(), userId, ids[0])
+	assert.ErrorIs(t, err, service.ErrJobNotFound)
+	_, err = j.Job(context.Background(), userId, ids[1])
+	assert.NoError(t, err)
+	_, err = j.Job(context.Background(), userId+1, other.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,323 @@
+package libcheck
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+type libcheck struct {
+	log     *slog.Logger
+	library Library
+	jobs    Jobs
+
+	mutex sync.Mutex
+	// Last check of each user.
+	reports map[int64]*models.LibCheckReport
+}
+
+type Library interface {
+	Search(ctx context.Context, id int64, filter models.MediaFilter) ([]models.MediaConfig, error)
+	UnusedTags(ctx context.Context, id int64) (models.TagList, error)
+	HasSource(ctx context.Context, id int64, mediaConf models.MediaConfig) (bool, error)
+	ReprobeDuration(ctx context.Context, id int64, mediaConf models.MediaConfig) (models.MediaConfig, error)
+	DeleteUnusedTags(ctx context.Context, id int64, tagIds []int64) ([]int64, error)
+}
+
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind models.JobKind, title string, items []models.JobItem, notify models.JobNotify) (models.Job, error)
+}
+
+// New returns service checking
+// library for broken media.
+func New(
+	log *slog.Logger,
+	library Library,
+	jobs Jobs,
+) *libcheck {
+	return &libcheck{
+		log:     log,
+		library: library,
+		jobs:    jobs,
+		reports: make(map[int64]*models.LibCheckReport),
+	}
+}
+
+// Start checks whole library. Media and tags
+// are checked at once, audio files are checked
+// by job, Missing of report is filled as job runs.
+func (c *libcheck) Start(ctx context.Context, id int64, notify models.JobNotify) (models.Job, error) {
+	const op = "libcheck.Start"
+
+	log := c.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	c.mutex.Lock()
+	if r, ok := c.reports[id]; ok && r.Running {
+		c.mutex.Unlock()
+		return models.Job{}, service.ErrLibCheckInProgress
+	}
+	c.mutex.Unlock()
+
+	// Empty filter matches whole library.
+	media, err := c.library.Search(ctx, id, models.MediaFilter{})
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(media) == 0 {
+		return models.Job{}, service.ErrLibraryEmpty
+	}
+
+	unused, err := c.library.UnusedTags(ctx, id)
+	if err != nil {
+		log.Error("failed to get unused tags", sl.Err(err))
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := newReport(media, unused)
+
+	items := make([]models.JobItem, 0, len(media))
+	for _, conf := range media {
+		items = append(items, models.JobItem{Conf: conf})
+	}
+
+	c.mutex.Lock()
+	if r, ok := c.reports[id]; ok && r.Running {
+		c.mutex.Unlock()
+		return models.Job{}, service.ErrLibCheckInProgress
+	}
+	c.reports[id] = report
+	c.mutex.Unlock()
+
+	job, err := c.jobs.Submit(ctx, id, models.JobLibCheck, "Проверка библиотеки", items, c.finishNotify(report, notify))
+	if err != nil {
+		c.mutex.Lock()
+		delete(c.reports, id)
+		c.mutex.Unlock()
+
+		log.Error("failed to submit job", sl.Err(err))
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info(
+		"library check started",
+		slog.Int("media", report.Total),
+		slog.Int64("jobId", job.ID),
+	)
+
+	return job, nil
+}
+
+// Report returns last check of user.
+func (c *libcheck) Report(_ context.Context, id int64) (models.LibCheckReport, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	r, ok := c.reports[id]
+	if !ok {
+		return models.LibCheckReport{}, service.ErrLibCheckNotFound
+	}
+
+	return copyReport(r), nil
+}
+
+// Item checks audio file of media,
+// handler of library check jobs.
+func (c *libcheck) Item(ctx context.Context, userId int64, item models.JobItem) (models.JobItemStatus, error) {
+	const op = "libcheck.Item"
+
+	ok, err := c.library.HasSource(ctx, userId, item.Conf)
+	if err != nil {
+		c.log.Error(
+			"failed to check source",
+			slog.String("op", op),
+			slog.Int64("userId", userId),
+			slog.Int64("mediaId", item.Conf.ID),
+			sl.Err(err),
+		)
+		return models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !ok {
+		c.mutex.Lock()
+		// Report is lost if bot was restarted,
+		// retried jobs do not change finished one.
+		if r, found := c.reports[userId]; found && r.Running {
+			r.Missing = append(r.Missing, item.Conf)
+		}
+		c.mutex.Unlock()
+	}
+
+	return models.ItemDone, nil
+}
+
+// Reprobe detects again duration of media
+// with zero duration found by last check.
+func (c *libcheck) Reprobe(ctx context.Context, id int64, notify models.JobNotify) (models.Job, error) {
+	const op = "libcheck.Reprobe"
+
+	c.mutex.Lock()
+	r, ok := c.reports[id]
+	var media []models.MediaConfig
+	if ok {
+		media = slices.Clone(r.ZeroDuration)
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		return models.Job{}, service.ErrLibCheckNotFound
+	}
+	if len(media) == 0 {
+		return models.Job{}, service.ErrNothingToFix
+	}
+
+	items := make([]models.JobItem, 0, len(media))
+	for _, conf := range media {
+		items = append(items, models.JobItem{Conf: conf})
+	}
+
+	job, err := c.jobs.Submit(ctx, id, models.JobReprobe, fmt.Sprintf("Длительность (%d)", len(items)), items, notify)
+	if err != nil {
+		c.log.Error(
+			"failed to submit job",
+			slog.String("op", op),
+			slog.Int64("userId", id),
+			sl.Err(err),
+		)
+		return models.Job{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// ReprobeItem detects duration of media,
+// handler of reprobe jobs. Fixed media
+// is removed from report.
+func (c *libcheck) ReprobeItem(ctx context.Context, userId int64, item models.JobItem) (models.JobItemStatus, error) {
+	const op = "libcheck.ReprobeItem"
+
+	if _, err := c.library.ReprobeDuration(ctx, userId, item.Conf); err != nil {
+		return models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mutex.Lock()
+	if r, ok := c.reports[userId]; ok {
+		r.ZeroDuration = slices.DeleteFunc(r.ZeroDuration, func(conf models.MediaConfig) bool {
+			return conf.ID == item.Conf.ID
+		})
+	}
+	c.mutex.Unlock()
+
+	return models.ItemDone, nil
+}
+
+// DeleteUnusedTags deletes unused tags found
+// by last check. Tags used since check are
+// kept. Returns number of deleted tags.
+func (c *libcheck) DeleteUnusedTags(ctx context.Context, id int64) (int, error) {
+	const op = "libcheck.DeleteUnusedTags"
+
+	log := c.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	c.mutex.Lock()
+	r, ok := c.reports[id]
+	var tags models.TagList
+	if ok {
+		tags = slices.Clone(r.UnusedTags)
+	}
+	c.mutex.Unlock()
+
+	if !ok {
+		return 0, service.ErrLibCheckNotFound
+	}
+	if len(tags) == 0 {
+		return 0, service.ErrNothingToFix
+	}
+
+	tagIds := make([]int64, 0, len(tags))
+	for _, tag := range tags {
+		tagIds = append(tagIds, tag.ID)
+	}
+
+	deleted, err := c.library.DeleteUnusedTags(ctx, id, tagIds)
+	c.dropTags(id, deleted)
+	if err != nil {
+		log.Error("failed to delete tags", sl.Err(err))
+		return len(deleted), fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("unused tags deleted", slog.Int("count", len(deleted)))
+
+	return len(deleted), nil
+}
+
+// dropTags removes deleted tags from report.
+func (c *libcheck) dropTags(id int64, deleted []int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if r, ok := c.reports[id]; ok {
+		r.UnusedTags = slices.DeleteFunc(r.UnusedTags, func(t models.Tag) bool {
+			return slices.Contains(deleted, t.ID)
+		})
+	}
+}
+
+// finishNotify marks report as complete
+// when job is finished and passes
+// job to notify.
+func (c *libcheck) finishNotify(report *models.LibCheckReport, notify models.JobNotify) models.JobNotify {
+	return func(job models.Job) {
+		if !job.Status.Active() {
+			c.mutex.Lock()
+			report.Running = false
+			c.mutex.Unlock()
+		}
+		notify(job)
+	}
+}
+
+// newReport finds media with zero duration
+// and songs without genre or language.
+func newReport(media []models.MediaConfig, unused models.TagList) *models.LibCheckReport {
+	r := &models.LibCheckReport{
+		Total:        len(media),
+		ZeroDuration: make([]models.MediaConfig, 0),
+		Untagged:     make([]models.MediaConfig, 0),
+		Missing:      make([]models.MediaConfig, 0),
+		UnusedTags:   unused,
+		Running:      true,
+	}
+
+	for _, conf := range media {
+		if conf.Duration == 0 {
+			r.ZeroDuration = append(r.ZeroDuration, conf)
+		}
+		if conf.Format == models.Song && (len(conf.Genres) == 0 || len(conf.Languages) == 0) {
+			r.Untagged = append(r.Untagged, conf)
+		}
+	}
+
+	return r
+}
+
+func copyReport(r *models.LibCheckReport) models.LibCheckReport {
+	res := *r
+	res.ZeroDuration = slices.Clone(r.ZeroDuration)
+	res.Untagged = slices.Clone(r.Untagged)
+	res.Missing = slices.Clone(r.Missing)
+	res.UnusedTags = slices.Clone(r.UnusedTags)
+	return res
+}
@@ -0,0 +1,135 @@
+package libcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const userId = 1
+
+var errDelete = errors.New("delete failed")
+
+// fakeLibrary deletes tags not in use,
+// deletion of broken tag fails.
+type fakeLibrary struct {
+	Library
+
+	inUse  []int64
+	broken int64
+	calls  int
+}
+
+func (l *fakeLibrary) DeleteUnusedTags(_ context.Context, _ int64, tagIds []int64) ([]int64, error) {
+	l.calls++
+
+	deleted := make([]int64, 0)
+	for _, tagId := range tagIds {
+		if tagId == l.broken {
+			return deleted, errDelete
+		}
+		if !slices.Contains(l.inUse, tagId) {
+			deleted = append(deleted, tagId)
+		}
+	}
+	return deleted, nil
+}
+
+func TestNewReport(t *testing.T) {
+	tagged := models.MediaConfig{
+		ID:        1,
+		Duration:  time.Minute,
+		Format:    models.Song,
+		Genres:    models.NewTagSet("Рок"),
+		Languages: models.NewTagSet("русский"),
+	}
+	zero := models.MediaConfig{
+		ID:        2,
+		Format:    models.Song,
+		Genres:    models.NewTagSet("Поп"),
+		Languages: models.NewTagSet("английский"),
+	}
+	noLang := models.MediaConfig{
+		ID:       3,
+		Duration: time.Minute,
+		Format:   models.Song,
+		Genres:   models.NewTagSet("Поп"),
+	}
+	// Jingles are not tagged by genre.
+	jingle := models.MediaConfig{
+		ID:       4,
+		Duration: 5 * time.Second,
+		Format:   models.Jingle,
+	}
+	unused := models.TagList{{ID: 1, Name: "Джаз", Type: models.TagTypesAvail["genre"]}}
+
+	r := newReport([]models.MediaConfig{tagged, zero, noLang, jingle}, unused)
+
+	assert.Equal(t, 4, r.Total)
+	assert.Equal(t, []models.MediaConfig{zero}, r.ZeroDuration)
+	assert.Equal(t, []models.MediaConfig{noLang}, r.Untagged)
+	assert.Empty(t, r.Missing)
+	assert.Equal(t, unused, r.UnusedTags)
+	assert.True(t, r.Running)
+	assert.Equal(t, 1, r.Count(models.CheckUnusedTags))
+}
+
+func TestDeleteUnusedTags(t *testing.T) {
+	genre := models.TagTypesAvail["genre"]
+	unused := models.TagList{
+		{ID: 1, Name: "Блюз", Type: genre},
+		{ID: 2, Name: "джаз", Type: genre},
+		{ID: 3, Name: "Рок", Type: genre},
+		{ID: 4, Name: "Фолк", Type: genre},
+	}
+
+	// Tag 2 is used since check.
+	lib := &fakeLibrary{inUse: []int64{2}, broken: 4}
+	c := New(slog.New(slog.NewTextHandler(io.Discard, nil)), lib, nil)
+	c.reports[userId] = newReport(nil, slices.Clone(unused))
+
+	ctx := context.Background()
+
+	n, err := c.DeleteUnusedTags(ctx, userId)
+	assert.ErrorIs(t, err, errDelete)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, lib.calls)
+
+	// Deleted tags are dropped from report
+	// even if deletion is not complete.
+	r, err := c.Report(ctx, userId)
+	require.NoError(t, err)
+	assert.Equal(t, models.TagList{unused[1], unused[3]}, r.UnusedTags)
+
+	lib.broken = 0
+	n, err = c.DeleteUnusedTags(ctx, userId)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	r, err = c.Report(ctx, userId)
+	require.NoError(t, err)
+	assert.Equal(t, models.TagList{unused[1]}, r.UnusedTags)
+
+	// Tag in use is kept, nothing else to delete.
+	n, err = c.DeleteUnusedTags(ctx, userId)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	lib.inUse = nil
+	n, err = c.DeleteUnusedTags(ctx, userId)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = c.DeleteUnusedTags(ctx, userId)
+	assert.ErrorIs(t, err, service.ErrNothingToFix)
+}
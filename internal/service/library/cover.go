@@ -0,0 +1,135 @@
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/id3"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const coverIndex = "index.json"
+
+// covers is a persistent storage
+// of cover art of uploaded media.
+// Value is either link to image
+// or path to image saved in dir.
+type covers struct {
+	dir   string
+	file  string
+	mutex sync.Mutex
+	vals  map[int64]string
+}
+
+func newCovers(dir string) (*covers, error) {
+	c := &covers{
+		dir:  dir,
+		file: filepath.Join(dir, coverIndex),
+		vals: make(map[int64]string),
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return c, err
+	}
+
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c.vals); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c *covers) Get(id int64) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cover, ok := c.vals[id]
+	return cover, ok
+}
+
+func (c *covers) Set(id int64, cover string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.vals[id] = cover
+
+	data, err := json.Marshal(c.vals)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.file, data, 0644)
+}
+
+// Save writes image to dir and returns its path.
+// Images are named by content hash, so
+// tracks of one album share the same file.
+func (c *covers) Save(pic id3.Picture) (string, error) {
+	sum := sha256.Sum256(pic.Data)
+
+	ext := ".jpg"
+	if pic.MIME == "image/png" {
+		ext = ".png"
+	}
+
+	path := filepath.Join(c.dir, hex.EncodeToString(sum[:])+ext)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.WriteFile(path, pic.Data, 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// saveCover stores cover art for uploaded media.
+// Link from config is preferred, otherwise
+// embedded picture is extracted from audio file.
+func (l *library) saveCover(mediaId int64, conf models.MediaConfig) {
+	const op = "library.saveCover"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("mediaId", mediaId),
+	)
+
+	cover := conf.Cover
+	if cover == "" && conf.SourcePath != "" {
+		tags, err := id3.ReadFile(conf.SourcePath)
+		if err != nil && !errors.Is(err, id3.ErrNoTags) {
+			log.Warn("failed to read tags", sl.Err(err))
+		}
+		if len(tags.Picture.Data) > 0 {
+			cover, err = l.covers.Save(tags.Picture)
+			if err != nil {
+				log.Error("failed to save picture", sl.Err(err))
+				return
+			}
+		}
+	}
+
+	if cover == "" {
+		return
+	}
+
+	if err := l.covers.Set(mediaId, cover); err != nil {
+		log.Error("failed to save cover", sl.Err(err))
+	}
+}
@@ -0,0 +1,365 @@
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/fingerprint"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/utils/fuzzy"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Minimal weighted name/author similarity
+	// to treat media as possible duplicate.
+	textThreshold = 0.85
+	// Minimal name/author similarity
+	// when audio fingerprints match.
+	textThresholdAcoustic = 0.5
+	// Minimal fingerprints similarity
+	// to treat records as the same.
+	acousticThreshold = 0.85
+
+	nameWeight   = 0.6
+	authorWeight = 0.4
+
+	candidatesRespLen = 20
+
+	// Max number of uploaded media
+	// waiting for fingerprint.
+	fingerprintQueueLen = 100
+	fingerprintTimeout  = time.Minute
+	// Owner of audio copies in temporary directory.
+	fingerprintOwner = "fingerprint"
+	fingerprintTTL   = time.Hour
+)
+
+// fingerprints is a persistent storage
+// of audio fingerprints of uploaded media.
+type fingerprints struct {
+	file  string
+	mutex sync.Mutex
+	vals  map[int64]fingerprint.Fingerprint
+	// Values not saved to file yet.
+	dirty bool
+}
+
+// fingerprintTask is audio of uploaded
+// media waiting for fingerprint.
+type fingerprintTask struct {
+	mediaId int64
+	path    string
+}
+
+func newFingerprints(file string) (*fingerprints, error) {
+	f := &fingerprints{
+		file: file,
+		vals: make(map[int64]fingerprint.Fingerprint),
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return f, err
+	}
+
+	if err := json.Unmarshal(data, &f.vals); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+func (f *fingerprints) Get(id int64) (fingerprint.Fingerprint, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	fp, ok := f.vals[id]
+	return fp, ok
+}
+
+// Set stores fingerprint in memory,
+// it is written to file by Save.
+func (f *fingerprints) Set(id int64, fp fingerprint.Fingerprint) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.vals[id] = fp
+	f.dirty = true
+}
+
+// Save writes fingerprints to file
+// if there are unsaved ones.
+func (f *fingerprints) Save() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(f.vals)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(f.file, data, 0644); err != nil {
+		return err
+	}
+	f.dirty = false
+
+	return nil
+}
+
+// Duplicates returns media from library
+// that are possibly the same as given one.
+// Names and authors are compared after normalization
+// and transliteration, then audio fingerprints
+// (if available) are used to confirm the match.
+func (l *library) Duplicates(ctx context.Context, id int64, conf models.MediaConfig) ([]models.MediaConfig, error) {
+	const op = "library.Duplicates"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("name", conf.Name),
+		slog.String("author", conf.Author),
+	)
+
+	candidates, err := l.duplicateCandidates(ctx, id, conf)
+	if err != nil {
+		log.Error("failed to get candidates", sl.Err(err))
+		return []models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(candidates) == 0 {
+		return []models.MediaConfig{}, nil
+	}
+
+	var fp fingerprint.Fingerprint
+	if conf.SourcePath != "" {
+		fp, err = fingerprint.Compute(ctx, conf.SourcePath)
+		if err != nil {
+			log.Warn("failed to compute fingerprint", sl.Err(err))
+		}
+	}
+
+	type scored struct {
+		conf  models.MediaConfig
+		score float64
+	}
+
+	res := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		score := textScore(conf, c)
+
+		if storedFp, ok := l.fingerprints.Get(c.ID); ok && len(fp) > 0 {
+			if fingerprint.Similarity(fp, storedFp) >= acousticThreshold && score >= textThresholdAcoustic {
+				res = append(res, scored{conf: c, score: 1 + score})
+				continue
+			}
+		}
+
+		if score >= textThreshold {
+			res = append(res, scored{conf: c, score: score})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b scored) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	dups := make([]models.MediaConfig, 0, len(res))
+	for _, r := range res {
+		dups = append(dups, r.conf)
+	}
+
+	return dups, nil
+}
+
+// duplicateCandidates searches local index
+// by base name and main author. Index keys are
+// normalized and transliterated, so other spellings
+// are found without extra requests to radio.
+func (l *library) duplicateCandidates(ctx context.Context, id int64, conf models.MediaConfig) ([]models.MediaConfig, error) {
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.ensureIndex(ctx, token); err != nil {
+		return nil, err
+	}
+
+	queries := uniqueNonEmpty(
+		fuzzy.BaseName(conf.Name),
+		fuzzy.MainArtist(conf.Author),
+	)
+
+	res := make([]models.MediaConfig, 0)
+	for _, q := range queries {
+		for _, m := range l.index.Search(models.MediaFilter{Query: q, MaxRespLen: candidatesRespLen}) {
+			if m.ID == conf.ID {
+				continue
+			}
+			if !slices.ContainsFunc(res, func(c models.MediaConfig) bool { return c.ID == m.ID }) {
+				res = append(res, m.ToConfig())
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// queueFingerprint queues computation of
+// fingerprint of uploaded media. Audio is
+// copied, so source may be removed at once.
+func (l *library) queueFingerprint(mediaId int64, path string) {
+	const op = "library.queueFingerprint"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("mediaId", mediaId),
+	)
+
+	copyPath, err := l.copyAudio(path)
+	if err != nil {
+		log.Warn("failed to copy audio", sl.Err(err))
+		return
+	}
+
+	select {
+	case l.fpQueue <- fingerprintTask{mediaId: mediaId, path: copyPath}:
+	default:
+		log.Warn("fingerprint queue is full")
+		l.tmpDir.Release(copyPath)
+	}
+}
+
+// copyAudio copies file to temporary directory.
+func (l *library) copyAudio(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	out, err := l.tmpDir.Create(fingerprintOwner, "fingerprint-*.mp3", fingerprintTTL)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(l.tmpDir.Limit(out), src); err != nil {
+		l.tmpDir.Release(out.Name())
+		return "", err
+	}
+
+	return out.Name(), nil
+}
+
+// computeFingerprints computes queued fingerprints
+// one by one until ctx is done. Storage is written
+// once queue is drained, not after every media.
+func (l *library) computeFingerprints(ctx context.Context) {
+	defer close(l.fpDone)
+	defer l.saveFingerprints()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.dropFingerprints()
+			return
+		case task := <-l.fpQueue:
+			l.saveFingerprint(ctx, task.mediaId, task.path)
+			l.tmpDir.Release(task.path)
+			if len(l.fpQueue) == 0 {
+				l.saveFingerprints()
+			}
+		}
+	}
+}
+
+// dropFingerprints removes audio
+// of not processed media.
+func (l *library) dropFingerprints() {
+	for {
+		select {
+		case task := <-l.fpQueue:
+			l.tmpDir.Release(task.path)
+		default:
+			return
+		}
+	}
+}
+
+// saveFingerprint computes fingerprint
+// of uploaded media.
+func (l *library) saveFingerprint(ctx context.Context, mediaId int64, path string) {
+	const op = "library.saveFingerprint"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("mediaId", mediaId),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, fingerprintTimeout)
+	defer cancel()
+
+	fp, err := fingerprint.Compute(ctx, path)
+	if err != nil {
+		log.Warn("failed to compute fingerprint", sl.Err(err))
+		return
+	}
+
+	l.fingerprints.Set(mediaId, fp)
+}
+
+func (l *library) saveFingerprints() {
+	if err := l.fingerprints.Save(); err != nil {
+		l.log.Error("failed to save fingerprints", slog.String("op", "library.saveFingerprints"), sl.Err(err))
+	}
+}
+
+// textScore returns weighted similarity
+// of names and main authors.
+func textScore(a, b models.MediaConfig) float64 {
+	name := fuzzy.Similarity(
+		fuzzy.Key(fuzzy.BaseName(a.Name)),
+		fuzzy.Key(fuzzy.BaseName(b.Name)),
+	)
+	author := fuzzy.Similarity(
+		fuzzy.Key(fuzzy.MainArtist(a.Author)),
+		fuzzy.Key(fuzzy.MainArtist(b.Author)),
+	)
+
+	return nameWeight*name + authorWeight*author
+}
+
+func uniqueNonEmpty(vals ...string) []string {
+	res := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if v != "" && !slices.Contains(res, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
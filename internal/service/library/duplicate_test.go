@@ -0,0 +1,60 @@
+package library
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/fingerprint"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
+)
+
+func TestFingerprintsSave(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fingerprints.json")
+
+	f, err := newFingerprints(file)
+	require.NoError(t, err)
+	f.Set(1, fingerprint.Fingerprint{1, 2, 3})
+	f.Set(2, fingerprint.Fingerprint{4, 5, 6})
+
+	// Values are written only by Save.
+	assert.NoFileExists(t, file)
+	require.NoError(t, f.Save())
+
+	f, err = newFingerprints(file)
+	require.NoError(t, err)
+	fp, ok := f.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, fingerprint.Fingerprint{4, 5, 6}, fp)
+}
+
+func TestQueueFingerprint(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+
+	tmp, err := tmpdir.New(log, filepath.Join(dir, "tmp"), 0)
+	require.NoError(t, err)
+
+	l := New(log, nil, nil, nil, nil, tmp,
+		filepath.Join(dir, "fingerprints.json"),
+		filepath.Join(dir, "covers"),
+		filepath.Join(dir, "media.json"),
+	)
+
+	src := filepath.Join(dir, "track.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("audio"), 0644))
+
+	// Source may be removed right after upload.
+	l.queueFingerprint(1, src)
+	require.NoError(t, os.Remove(src))
+
+	// Copies of audio are released
+	// whether they are processed or not.
+	l.Stop()
+	assert.Zero(t, tmp.Usage().Files)
+}
@@ -0,0 +1,202 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// errSourceFound stops download of
+// source once first bytes are received.
+var errSourceFound = errors.New("source found")
+
+// sourceProbe is a writer failing on first write,
+// so only existence of source is checked.
+type sourceProbe struct{}
+
+func (sourceProbe) Write([]byte) (int, error) {
+	return 0, errSourceFound
+}
+
+// UnusedTags returns tags without media,
+// sorted by type and name. Formats are
+// fixed by server and never reported.
+func (l *library) UnusedTags(ctx context.Context, id int64) (models.TagList, error) {
+	const op = "library.UnusedTags"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return unusedTags(tags, media), nil
+}
+
+// DeleteUnusedTags deletes given tags having
+// no media, tags in use are kept. Library is
+// fetched once for all tags. Returns ids of
+// deleted tags, including already missing ones.
+func (l *library) DeleteUnusedTags(ctx context.Context, id int64, tagIds []int64) ([]int64, error) {
+	const op = "library.DeleteUnusedTags"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer l.tags.Invalidate()
+	defer l.index.Invalidate()
+
+	counts := usageCounts(media)
+
+	deleted := make([]int64, 0, len(tagIds))
+	for _, tagId := range tagIds {
+		if counts[tagId] > 0 {
+			continue
+		}
+		err := l.libClient.DeleteTag(ctx, token, tagId)
+		if err != nil && !errors.Is(err, client.ErrTagNotFound) {
+			log.Error("failed to delete tag", slog.Int64("tagId", tagId), sl.Err(err))
+			return deleted, fmt.Errorf("%s: %w", op, err)
+		}
+		deleted = append(deleted, tagId)
+	}
+
+	return deleted, nil
+}
+
+// HasSource reports if radio has
+// audio file of media. File is
+// not downloaded completely.
+func (l *library) HasSource(ctx context.Context, id int64, mediaConf models.MediaConfig) (bool, error) {
+	const op = "library.HasSource"
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		l.log.Error(
+			"failed to get token",
+			slog.String("op", op),
+			slog.Int64("userId", id),
+			sl.Err(err),
+		)
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = l.libClient.Source(ctx, token, mediaConf.ID, sourceProbe{})
+	switch {
+	case err == nil, errors.Is(err, errSourceFound):
+		return true, nil
+	case errors.Is(err, client.ErrMediaNotFound):
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+// ReprobeDuration detects duration of media
+// again by its audio file. File is uploaded
+// back, so radio updates duration too.
+func (l *library) ReprobeDuration(ctx context.Context, id int64, mediaConf models.MediaConfig) (models.MediaConfig, error) {
+	const op = "library.ReprobeDuration"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("mediaId", mediaConf.ID),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out, err := l.tmpDir.Create(listenOwner, "reprobe-*.mp3", listenTTL)
+	if err != nil {
+		log.Error("failed to create file", sl.Err(err))
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer l.tmpDir.Release(out.Name())
+	defer out.Close()
+
+	if err := l.libClient.Source(ctx, token, mediaConf.ID, l.tmpDir.Limit(out)); err != nil {
+		if errors.Is(err, client.ErrMediaNotFound) {
+			return models.MediaConfig{}, service.ErrMediaNotFound
+		}
+		log.Error("failed to download source", sl.Err(err))
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := l.ReplaceSource(ctx, id, mediaConf, out.Name())
+	if err != nil {
+		return models.MediaConfig{}, err
+	}
+	if res.Duration == 0 {
+		return models.MediaConfig{}, service.ErrDurationNotDetected
+	}
+
+	log.Info("duration detected", slog.Duration("duration", res.Duration))
+
+	return res, nil
+}
+
+func unusedTags(tags models.TagList, media []models.Media) models.TagList {
+	counts := usageCounts(media)
+
+	res := make(models.TagList, 0)
+	for _, tag := range tags {
+		if tag.Type.Name != "format" && counts[tag.ID] == 0 {
+			res = append(res, tag)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b models.Tag) int {
+		if a.Type.Name != b.Type.Name {
+			return strings.Compare(a.Type.Name, b.Type.Name)
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return res
+}
@@ -0,0 +1,30 @@
+package library
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestUnusedTags(t *testing.T) {
+	song := models.Tag{ID: 1, Name: "song", Type: models.TagTypesAvail["format"]}
+	jingle := models.Tag{ID: 2, Name: "jingle", Type: models.TagTypesAvail["format"]}
+	rock := models.Tag{ID: 3, Name: "Рок", Type: models.TagTypesAvail["genre"]}
+	jazz := models.Tag{ID: 4, Name: "джаз", Type: models.TagTypesAvail["genre"]}
+	blues := models.Tag{ID: 5, Name: "Блюз", Type: models.TagTypesAvail["genre"]}
+	morning := models.Tag{ID: 6, Name: "Утро", Type: models.TagTypesAvail["playlist"]}
+
+	media := []models.Media{
+		{ID: 1, Tags: models.TagList{song, rock}},
+		{ID: 2, Tags: models.TagList{song}},
+	}
+
+	// Formats are skipped, result is
+	// sorted by type and name.
+	assert.Equal(t,
+		models.TagList{blues, jazz, morning},
+		unusedTags(models.TagList{song, jingle, morning, rock, jazz, blues}, media),
+	)
+}
@@ -0,0 +1,113 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+// mediaInfo is info about media
+// which radio does not store.
+type mediaInfo struct {
+	Meta      map[string]string `json:"meta,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// infos is a persistent storage
+// of info of uploaded media.
+type infos struct {
+	file  string
+	mutex sync.Mutex
+	vals  map[int64]mediaInfo
+}
+
+func newInfos(file string) (*infos, error) {
+	i := &infos{
+		file: file,
+		vals: make(map[int64]mediaInfo),
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return i, nil
+		}
+		return i, err
+	}
+
+	if err := json.Unmarshal(data, &i.vals); err != nil {
+		return i, err
+	}
+
+	return i, nil
+}
+
+func (i *infos) Get(id int64) (mediaInfo, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	info, ok := i.vals[id]
+	return info, ok
+}
+
+func (i *infos) Set(id int64, info mediaInfo) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.vals[id] = info
+
+	data, err := json.Marshal(i.vals)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(i.file, data, 0644)
+}
+
+// saveInfo stores info of uploaded media.
+func (l *library) saveInfo(mediaId int64, conf models.MediaConfig, createdAt time.Time) {
+	const op = "library.saveInfo"
+
+	info := mediaInfo{
+		Meta:      maps.Clone(conf.Meta),
+		CreatedAt: createdAt,
+	}
+	if err := l.infos.Set(mediaId, info); err != nil {
+		l.log.Error(
+			"failed to save media info",
+			slog.String("op", op),
+			slog.Int64("mediaId", mediaId),
+			sl.Err(err),
+		)
+	}
+}
+
+// withUploadTime sets stored upload time
+// of media radio does not report it for.
+func (l *library) withUploadTime(media []models.Media) {
+	for i := range media {
+		if !media[i].CreatedAt.IsZero() {
+			continue
+		}
+		if info, ok := l.infos.Get(media[i].ID); ok {
+			media[i].CreatedAt = info.CreatedAt
+		}
+	}
+}
+
+// withInfo returns config
+// with stored cover and info.
+func (l *library) withInfo(conf models.MediaConfig) models.MediaConfig {
+	conf.Cover, _ = l.covers.Get(conf.ID)
+	if info, ok := l.infos.Get(conf.ID); ok {
+		conf.Meta = maps.Clone(info.Meta)
+	}
+	return conf
+}
@@ -0,0 +1,53 @@
+package library
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestInfosRecover(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "media.json")
+
+	i, err := newInfos(file)
+	require.NoError(t, err)
+	require.NoError(t, i.Set(1, mediaInfo{Meta: map[string]string{"date": "2024-05-01"}}))
+
+	i, err = newInfos(file)
+	require.NoError(t, err)
+
+	info, ok := i.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-05-01", info.Meta["date"])
+
+	_, ok = i.Get(2)
+	assert.False(t, ok)
+}
+
+func TestWithUploadTime(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "media.json")
+	uploaded := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	reported := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	i, err := newInfos(file)
+	require.NoError(t, err)
+	require.NoError(t, i.Set(1, mediaInfo{CreatedAt: uploaded}))
+	require.NoError(t, i.Set(2, mediaInfo{CreatedAt: uploaded}))
+
+	// Upload time is known after restart.
+	i, err = newInfos(file)
+	require.NoError(t, err)
+	l := &library{infos: i}
+
+	media := []models.Media{{ID: 1}, {ID: 2, CreatedAt: reported}, {ID: 3}}
+	l.withUploadTime(media)
+
+	assert.True(t, uploaded.Equal(media[0].CreatedAt))
+	assert.True(t, reported.Equal(media[1].CreatedAt))
+	assert.True(t, media[2].CreatedAt.IsZero())
+}
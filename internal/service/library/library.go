@@ -4,38 +4,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"regexp"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
 	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
 	"github.com/GintGld/fizteh-radio-bot/internal/models"
-	yamodels "github.com/GintGld/fizteh-radio-bot/internal/models/yandex"
 	"github.com/GintGld/fizteh-radio-bot/internal/service"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// TODO: add support for spotify
-
-var (
-	yaSong     = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/album/(?P<album>\d+)/track/(?P<track>\d+)`)
-	yaAlbum    = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/album/(?P<album>\d+)`)
-	yaPlaylist = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/users/(?P<user>[^\/]+)/playlists/(?P<kind>\d+)`)
-)
-
 type library struct {
 	log       *slog.Logger
 	auth      Auth
 	libClient LibraryClient
-	yaClient  YaClient
+	providers []LinkProvider
+	catalog   Catalog
+	tmpDir    TmpDir
+
+	fingerprints *fingerprints
+	covers       *covers
+	infos        *infos
+	tags         *tagResolver
+	index        *searchIndex
+	tracks       *trackNumbers
+
+	// Audio of uploaded media is
+	// fingerprinted in background.
+	fpQueue  chan fingerprintTask
+	fpCancel context.CancelFunc
+	fpDone   chan struct{}
 }
 
 type Auth interface {
 	Token(ctx context.Context, id int64) (jwt.Token, error)
+	Users(ctx context.Context) []int64
 }
 
 type LibraryClient interface {
@@ -44,34 +53,128 @@ type LibraryClient interface {
 	NewMedia(ctx context.Context, token jwt.Token, media models.Media) (int64, error)
 	UpdateMedia(ctx context.Context, token jwt.Token, media models.Media) error
 	DeleteMedia(ctx context.Context, token jwt.Token, mediaId int64) error
+	ReplaceSource(ctx context.Context, token jwt.Token, mediaId int64, sourcePath string) error
+	Source(ctx context.Context, token jwt.Token, mediaId int64, w io.Writer) error
 	AllTags(ctx context.Context, token jwt.Token) (models.TagList, error)
 	NewTag(ctx context.Context, token jwt.Token, tag models.Tag) (int64, error)
+	UpdateTag(ctx context.Context, token jwt.Token, tag models.Tag) error
+	DeleteTag(ctx context.Context, token jwt.Token, tagId int64) error
+}
+
+// LinkProvider resolves media by link
+// to external service and fetches its audio.
+type LinkProvider interface {
+	// Name identifies provider
+	// in external refs of media.
+	Name() string
+	// Title is shown to user.
+	Title() string
+	Match(link string) bool
+	Resolve(ctx context.Context, link string) (models.LinkDownloadResult, error)
+	Fetch(ctx context.Context, conf models.MediaConfig) (string, error)
+}
+
+// PreviewProvider is link provider that
+// may fetch preview instead of full track.
+type PreviewProvider interface {
+	IsPreview(ctx context.Context, conf models.MediaConfig, path string) bool
+}
+
+// TmpDir creates temporary files with TTL.
+type TmpDir interface {
+	Create(owner, pattern string, ttl time.Duration) (*os.File, error)
+	Release(path string)
+	Limit(w io.Writer) io.Writer
 }
 
-type YaClient interface {
-	Album(ctx context.Context, id string) (yamodels.Album, error)
-	Playlist(ctx context.Context, user string, id string) (yamodels.Playlist, error)
-	DownloadInfo(ctx context.Context, id string) ([]yamodels.DownloadInfo, error)
-	DownloadTrack(ctx context.Context, url string) (string, error)
-	DirectLink(ctx context.Context, url string) (string, error)
+// Catalog searches media in external music catalog.
+type Catalog interface {
+	Search(ctx context.Context, query string) ([]models.CatalogItem, error)
 }
 
 func New(
 	log *slog.Logger,
 	auth Auth,
 	libClient LibraryClient,
-	yaClient YaClient,
+	providers []LinkProvider,
+	catalog Catalog,
+	tmpDir TmpDir,
+	fingerprintFile string,
+	coverDir string,
+	infoFile string,
 ) *library {
-	l := &library{
-		log:       log,
-		auth:      auth,
-		libClient: libClient,
-		yaClient:  yaClient,
+	fps, err := newFingerprints(fingerprintFile)
+	if err != nil {
+		log.Error(
+			"failed to recover fingerprints",
+			slog.String("op", "library.New"),
+			sl.Err(err),
+		)
+	}
+
+	covers, err := newCovers(coverDir)
+	if err != nil {
+		log.Error(
+			"failed to recover covers",
+			slog.String("op", "library.New"),
+			sl.Err(err),
+		)
+	}
+
+	infos, err := newInfos(infoFile)
+	if err != nil {
+		log.Error(
+			"failed to recover media info",
+			slog.String("op", "library.New"),
+			sl.Err(err),
+		)
 	}
 
+	l := &library{
+		log:          log,
+		auth:         auth,
+		libClient:    libClient,
+		providers:    providers,
+		catalog:      catalog,
+		tmpDir:       tmpDir,
+		fingerprints: fps,
+		covers:       covers,
+		infos:        infos,
+		tags:         newTagResolver(libClient),
+		index:        newSearchIndex(),
+		tracks:       newTrackNumbers(),
+		fpQueue:      make(chan fingerprintTask, fingerprintQueueLen),
+		fpDone:       make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.fpCancel = cancel
+	go l.computeFingerprints(ctx)
+
 	return l
 }
 
+// Stop saves pending track numbers
+// and computed fingerprints.
+func (l *library) Stop() {
+	if l.fpCancel != nil {
+		l.fpCancel()
+		<-l.fpDone
+	}
+
+	// Pending track numbers are saved.
+	l.tracks.mutex.Lock()
+	ids := make([]int64, 0, len(l.tracks.pending))
+	for id := range l.tracks.pending {
+		ids = append(ids, id)
+	}
+	l.tracks.mutex.Unlock()
+
+	for _, id := range ids {
+		l.saveTrackMeta(id)
+	}
+}
+
 func (l *library) Search(ctx context.Context, id int64, filter models.MediaFilter) ([]models.MediaConfig, error) {
 	const op = "library.Search"
 
@@ -89,7 +192,13 @@ func (l *library) Search(ctx context.Context, id int64, filter models.MediaFilte
 		return []models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	res, err := l.libClient.Search(ctx, token, filter)
+	var res []models.Media
+	if localFilter(filter) {
+		res, err = l.searchIndexed(ctx, token, filter)
+	} else {
+		res, err = l.libClient.Search(ctx, token, filter)
+		l.withUploadTime(res)
+	}
 	if err != nil {
 		log.Error(
 			"failed to get library",
@@ -100,12 +209,46 @@ func (l *library) Search(ctx context.Context, id int64, filter models.MediaFilte
 
 	configs := make([]models.MediaConfig, 0, len(res))
 	for _, m := range res {
-		configs = append(configs, m.ToConfig())
+		configs = append(configs, l.withInfo(m.ToConfig()))
 	}
 
 	return configs, nil
 }
 
+// Media returns media by id.
+func (l *library) Media(ctx context.Context, id int64, mediaId int64) (models.MediaConfig, error) {
+	const op = "library.Media"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("mediaId", mediaId),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := l.libClient.Media(ctx, token, mediaId)
+	if err != nil {
+		if errors.Is(err, client.ErrMediaNotFound) {
+			return models.MediaConfig{}, service.ErrMediaNotFound
+		}
+		log.Error(
+			"failed to get media",
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return l.withInfo(m.ToConfig()), nil
+}
+
 func (l *library) NewMedia(ctx context.Context, id int64, mediaConf models.MediaConfig) (int64, error) {
 	const op = "library.NewMedia"
 
@@ -160,6 +303,15 @@ func (l *library) NewMedia(ctx context.Context, id int64, mediaConf models.Media
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
+	l.queueFingerprint(mediaId, media.SourcePath)
+	l.saveCover(mediaId, mediaConf)
+
+	media.ID = mediaId
+	media.CreatedAt = time.Now()
+	l.saveInfo(mediaId, mediaConf, media.CreatedAt)
+	l.saveTrackNumbers(token, &media, mediaConf.Albums)
+	l.index.Put(media)
+
 	return mediaId, nil
 }
 
@@ -200,6 +352,8 @@ func (l *library) UpdateMedia(ctx context.Context, id int64, mediaConf models.Me
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	l.index.Put(media)
+
 	return nil
 }
 
@@ -229,408 +383,367 @@ func (l *library) DeleteMedia(ctx context.Context, id int64, mediaConf models.Me
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	l.index.Delete(mediaConf.ID)
+	l.dropTrackNumbers(ctx, token, mediaConf)
+
 	return nil
 }
 
-func (l *library) LinkDownload(ctx context.Context, id int64, link string) (models.LinkDownloadResult, error) {
-	const op = "library.LinkDownload"
+// ReplaceSource replaces audio of existing media
+// with file by path. Media keeps its ID, tags
+// and segments, duration is detected again.
+func (l *library) ReplaceSource(ctx context.Context, id int64, mediaConf models.MediaConfig, path string) (models.MediaConfig, error) {
+	const op = "library.ReplaceSource"
 
 	log := l.log.With(
 		slog.String("op", op),
 		slog.Int64("userId", id),
+		slog.Int64("mediaId", mediaConf.ID),
 	)
 
-	var res models.LinkDownloadResult
+	dur, err := audio.Duration(ctx, path)
+	if err != nil {
+		log.Warn("failed to detect duration", slog.String("path", path), sl.Err(err))
+		return models.MediaConfig{}, service.ErrInvalidAudio
+	}
 
-	if yaSong.MatchString(link) {
-		res.Type = models.ResSong
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		mediaConf, err := l.linkTrack(ctx, id, link)
-		if err != nil {
-			log.Error(
-				"failed to handle track",
-				sl.Err(err),
-			)
-			return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+	if err := l.libClient.ReplaceSource(ctx, token, mediaConf.ID, path); err != nil {
+		if errors.Is(err, client.ErrMediaNotFound) {
+			return models.MediaConfig{}, service.ErrMediaNotFound
 		}
+		log.Error(
+			"failed to replace source",
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		res.MediaConf = mediaConf
-	} else if yaAlbum.MatchString(link) {
-		res.Type = models.ResAlbum
+	l.queueFingerprint(mediaConf.ID, path)
+	// Keep old cover if new file has no picture.
+	l.saveCover(mediaConf.ID, models.MediaConfig{SourcePath: path})
+	mediaConf.Cover, _ = l.covers.Get(mediaConf.ID)
 
-		album, err := l.linkAlbum(ctx, id, link)
-		if err != nil {
-			log.Error(
-				"failed to handle album",
-				sl.Err(err),
-			)
-			return models.LinkDownloadResult{}, nil
-		}
+	mediaConf.Duration = dur
+	mediaConf.SourcePath = path
 
-		res.Album = album
-	} else if yaPlaylist.MatchString(link) {
-		res.Type = models.ResPlaylist
+	l.index.Put(mediaConf.ToMedia())
 
-		playlist, err := l.linkPlaylist(ctx, link)
-		if err != nil {
-			log.Error(
-				"failed to handle playlist",
-				sl.Err(err),
-			)
-			return models.LinkDownloadResult{}, nil
-		}
-
-		res.Playlist = playlist
-	} else {
-		log.Warn(
-			"unknown link",
-			slog.String("link", link),
-		)
-		return models.LinkDownloadResult{}, service.ErrInvalidLink
-	}
-
-	return res, nil
+	return mediaConf, nil
 }
 
-func (l *library) linkTrack(ctx context.Context, id int64, url string) (models.MediaConfig, error) {
-	const op = "library.linkTrack"
+func (l *library) LinkDownload(ctx context.Context, id int64, link string) (models.LinkDownloadResult, error) {
+	const op = "library.LinkDownload"
 
 	log := l.log.With(
 		slog.String("op", op),
 		slog.Int64("userId", id),
 	)
 
-	trackId, albumId := exctractTrackInfo(url)
-
-	album, err := l.yaClient.Album(ctx, albumId)
-	if err != nil {
-		// TODO handle errors
-		log.Error(
-			"failed to get album info",
-			slog.String("albumId", albumId),
-			sl.Err(err),
-		)
-		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
-	}
-	if album.Err != nil {
-		log.Error(
-			"failed to get album info",
-			slog.String("albumId", albumId),
-			slog.String("error", *album.Err),
+	index := slices.IndexFunc(l.providers, func(p LinkProvider) bool {
+		return p.Match(link)
+	})
+	if index == -1 {
+		log.Warn(
+			"unknown link",
+			slog.String("link", link),
 		)
-		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+		return models.LinkDownloadResult{}, service.ErrInvalidLink
 	}
+	provider := l.providers[index]
 
-	index := slices.IndexFunc(album.Tracks, func(t yamodels.Track) bool {
-		return t.Id == trackId
-	})
-	track := album.Tracks[index]
-
-	filePath, err := l.downloadLinkTrack(ctx, track.Id)
+	res, err := provider.Resolve(ctx, link)
 	if err != nil {
-		// TODO handler errors
 		log.Error(
-			"failed to download track from yandex",
-			slog.String("trackId", track.Id),
+			"failed to resolve link",
+			slog.String("provider", provider.Name()),
+			slog.String("link", link),
 			sl.Err(err),
 		)
-		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+		return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var tag models.MediaFormat
-
-	switch track.Format {
-	case yamodels.YaMusicFormat:
-		tag = models.Song
-	case yamodels.YaPodcastFormat:
-		tag = models.Podcast
+	// Genres and languages are set by name,
+	// station may lack some of them.
+	tax, err := l.Taxonomy(ctx, id)
+	if err != nil {
+		log.Error("failed to get taxonomy", sl.Err(err))
+		return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
 	}
+	res.KnownTags(tax)
 
-	author := ""
-	if len(track.Artists) > 0 {
-		author = track.Artists[0].Name
+	// Album and playlist tracks are
+	// fetched lazily by upload job.
+	if res.Type == models.ResSong && res.MediaConf.SourcePath == "" {
+		if err := l.fetchSource(ctx, &res.MediaConf); err != nil {
+			log.Error(
+				"failed to fetch track",
+				slog.String("provider", provider.Name()),
+				slog.String("link", link),
+				sl.Err(err),
+			)
+			return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	return models.MediaConfig{
-		Name:       track.Title,
-		Author:     author,
-		Duration:   track.Duration,
-		SourcePath: filePath,
-		Format:     tag,
-	}, nil
+	return res, nil
 }
 
-func (l *library) linkAlbum(ctx context.Context, id int64, url string) (models.AlbumDownloadRes, error) {
-	const op = "library.linkTrack"
+// CatalogSearch searches tracks and albums in
+// external catalog and marks ones already in library.
+func (l *library) CatalogSearch(ctx context.Context, id int64, query string) ([]models.CatalogItem, error) {
+	const op = "library.CatalogSearch"
 
 	log := l.log.With(
 		slog.String("op", op),
 		slog.Int64("userId", id),
 	)
 
-	albumId := extractAlbumInfo(url)
-
-	album, err := l.yaClient.Album(ctx, albumId)
+	token, err := l.auth.Token(ctx, id)
 	if err != nil {
-		// TODO handle errors
 		log.Error(
-			"failed to get album info",
-			slog.String("albumId", albumId),
+			"failed to get token",
 			sl.Err(err),
 		)
-		return models.AlbumDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+		return []models.CatalogItem{}, fmt.Errorf("%s: %w", op, err)
 	}
-	if album.Err != nil {
+
+	items, err := l.catalog.Search(ctx, query)
+	if err != nil {
 		log.Error(
-			"failed to get album info",
-			slog.String("albumId", albumId),
-			slog.String("error", *album.Err),
+			"failed to search catalog",
+			slog.String("query", query),
+			sl.Err(err),
 		)
-		return models.AlbumDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+		return []models.CatalogItem{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	values := make([]models.MediaConfig, 0, len(album.Tracks))
-	for _, track := range album.Tracks {
-		filePath, err := l.downloadLinkTrack(ctx, track.Id)
+	for i, item := range items {
+		inLibrary, err := l.inLibrary(ctx, token, item)
 		if err != nil {
-			log.Error(
-				"failed to download track",
-				slog.String("trackId", track.Id),
+			log.Warn(
+				"failed to check if item is in library",
+				slog.String("name", item.Name),
 				sl.Err(err),
 			)
-			return models.AlbumDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+			continue
 		}
+		items[i].InLibrary = inLibrary
+	}
+
+	return items, nil
+}
 
-		values = append(values, models.MediaConfig{
-			Name:       track.Title,
-			Author:     track.Artists[0].Name,
-			Duration:   track.Duration,
-			SourcePath: filePath,
-			Format:     models.Song,
-			Albums: []models.Album{
-				{
-					Name:   album.Title,
-					Author: album.Artists[0].Name,
-				},
+// inLibrary reports if track with the same name and author
+// or album with the same name and author exists.
+func (l *library) inLibrary(ctx context.Context, token jwt.Token, item models.CatalogItem) (bool, error) {
+	if item.Type == models.ResAlbum {
+		_, err := l.tags.Lookup(ctx, token, models.Tag{
+			Name: item.Name,
+			Type: models.TagTypesAvail["album"],
+			Meta: map[string]string{
+				"author": item.Author,
 			},
 		})
+		if errors.Is(err, service.ErrTagNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	res, err := l.libClient.Search(ctx, token, models.MediaFilter{
+		Name:       item.Name,
+		Author:     item.Author,
+		MaxRespLen: 5,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(res, func(m models.Media) bool {
+		return strings.EqualFold(m.Name, item.Name) && strings.EqualFold(m.Author, item.Author)
+	}), nil
+}
+
+// LinkProviders returns names
+// of supported link providers.
+func (l *library) LinkProviders() []string {
+	names := make([]string, 0, len(l.providers))
+	for _, p := range l.providers {
+		names = append(names, p.Title())
+	}
+	return names
+}
+
+// fetchSource downloads audio of media
+// resolved by link provider and sets
+// source of config. File is kept
+// in provider's cache.
+func (l *library) fetchSource(ctx context.Context, conf *models.MediaConfig) error {
+	const op = "library.fetchSource"
+
+	index := slices.IndexFunc(l.providers, func(p LinkProvider) bool {
+		return p.Name() == conf.External.Provider
+	})
+	if index == -1 {
+		return fmt.Errorf("%s: unknown provider %q", op, conf.External.Provider)
+	}
+	provider := l.providers[index]
+
+	filePath, err := provider.Fetch(ctx, *conf)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	conf.SourcePath = filePath
+	if p, ok := provider.(PreviewProvider); ok {
+		conf.Preview = p.IsPreview(ctx, *conf, filePath)
 	}
 
-	return models.AlbumDownloadRes{
-		Name:   album.Title,
-		Author: album.Artists[0].Name,
-		Values: values,
-	}, nil
+	return nil
 }
 
-func (l *library) linkPlaylist(ctx context.Context, url string) (models.Playlist, error) {
-	const op = "library.linkPlaylist"
+// PrepareUpload creates album, playlist
+// and podcast tags of media to be uploaded.
+// Must be called before uploading
+// media items in background.
+func (l *library) PrepareUpload(ctx context.Context, userId int64, values []models.MediaConfig) error {
+	const op = "library.PrepareUpload"
 
 	log := l.log.With(
 		slog.String("op", op),
+		slog.Int64("userId", userId),
 	)
 
-	kind, userName := exctractPlaylistInfo(url)
-
-	playlist, err := l.yaClient.Playlist(ctx, userName, kind)
+	token, err := l.auth.Token(ctx, userId)
 	if err != nil {
-		// TODO handler errors
 		log.Error(
-			"failed to download track from yandex",
-			slog.String("user", userName),
-			slog.String("kind", kind),
+			"failed to get token",
 			sl.Err(err),
 		)
-		return models.Playlist{}, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	values := make([]models.MediaConfig, 0, len(playlist.Tracks))
-	for _, track := range playlist.Tracks {
-		filePath, err := l.downloadLinkTrack(ctx, track.Id)
-		if err != nil {
-			log.Error(
-				"failed to download track",
-				slog.String("trackId", track.Id),
-				sl.Err(err),
-			)
-			return models.Playlist{}, fmt.Errorf("%s: %w", op, err)
+	for _, conf := range values {
+		for _, t := range conf.ToMedia().Tags {
+			if !isGroupTag(t) {
+				continue
+			}
+			if _, err := l.tags.Ensure(ctx, token, t); err != nil {
+				log.Error("failed to create tag", slog.String("name", t.Name), sl.Err(err))
+				return fmt.Errorf("%s: %w", op, err)
+			}
 		}
-
-		values = append(values, models.MediaConfig{
-			Name:       track.Title,
-			Author:     track.Artists[0].Name,
-			Duration:   track.Duration,
-			SourcePath: filePath,
-			Format:     models.Song,
-			Playlists:  []string{playlist.Title},
-		})
 	}
 
-	return models.Playlist{
-		Name:   playlist.Title,
-		Values: values,
-	}, nil
+	return nil
 }
 
-// downloadLinkTrack downloads
-// track file and returns path to the file.
-func (l *library) downloadLinkTrack(ctx context.Context, id string) (string, error) {
-	const op = "library.downloadLinkTrack"
+// UploadItem uploads media of job item
+// and returns its id. Used as job handler.
+func (l *library) UploadItem(ctx context.Context, userId int64, item models.JobItem) (int64, models.JobItemStatus, error) {
+	const op = "library.UploadItem"
 
 	log := l.log.With(
 		slog.String("op", op),
-		slog.String("tracakId", id),
+		slog.Int64("userId", userId),
 	)
 
-	downloadOptions, err := l.yaClient.DownloadInfo(ctx, id)
+	token, err := l.auth.Token(ctx, userId)
 	if err != nil {
 		log.Error(
-			"failed to get download options",
+			"failed to get token",
 			sl.Err(err),
 		)
-		return "", fmt.Errorf("%s: %w", op, err)
+		return 0, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
 	}
 
-	downloadOptions = slices.DeleteFunc(downloadOptions, func(di yamodels.DownloadInfo) bool {
-		return di.Codec != yamodels.CodecMP3
-	})
-
-	if len(downloadOptions) == 0 {
-		log.Warn(
-			"download info with mp3 codec not found",
-		)
-		return "", client.ErrTrackNotFound
+	// Tracks of albums and playlists
+	// are fetched only on upload.
+	if _, err := os.Stat(item.Conf.SourcePath); err != nil && item.Conf.External.Provider != "" {
+		if err := l.fetchSource(ctx, &item.Conf); err != nil {
+			log.Error("failed to fetch source", slog.String("provider", item.Conf.External.Provider), slog.String("id", item.Conf.External.ID), sl.Err(err))
+			return 0, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
-	preferred := slices.MaxFunc(downloadOptions, func(a, b yamodels.DownloadInfo) int {
-		return int(b.Bitrate - a.Bitrate)
-	})
-
-	directURL, err := l.yaClient.DirectLink(ctx, preferred.URL)
-	if err != nil {
-		log.Error(
-			"failed to get direct download link",
-			sl.Err(err),
-		)
-		return "", client.ErrTrackNotFound
+	if _, err := os.Stat(item.Conf.SourcePath); err != nil {
+		log.Error("source file is not available", slog.String("source", item.Conf.SourcePath), sl.Err(err))
+		return 0, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
 	}
 
-	filePath, err := l.yaClient.DownloadTrack(ctx, directURL)
+	mediaId, status, err := l.uploadOne(ctx, userId, token, item.Conf)
 	if err != nil {
-		log.Error(
-			"failed to download track",
-			sl.Err(err),
-		)
-		return "", client.ErrTrackNotFound
+		log.Error("failed to upload media", slog.String("name", item.Conf.Name), slog.String("source", item.Conf.SourcePath), sl.Err(err))
+		return 0, status, fmt.Errorf("%s: %w", op, err)
 	}
 
-	time.AfterFunc(time.Hour, func() {
-		if err := os.Remove(filePath); err != nil {
-			l.log.Error(
-				"failed to delete file",
-				slog.String("path", filePath),
-				sl.Err(err),
-			)
-		}
-	})
+	if item.Conf.Preview && status == models.ItemDone {
+		status = models.ItemPreview
+	}
 
-	return filePath, nil
+	return mediaId, status, nil
 }
 
-func (l *library) LinkUpload(ctx context.Context, userId int64, res models.LinkDownloadResult) error {
-	const op = "library.LinkUpload"
+// uploadOne uploads media or, if it already
+// exists, adds new tags to the existing one.
+func (l *library) uploadOne(ctx context.Context, userId int64, token jwt.Token, conf models.MediaConfig) (int64, models.JobItemStatus, error) {
+	const op = "library.uploadOne"
 
 	log := l.log.With(
 		slog.String("op", op),
 		slog.Int64("userId", userId),
 	)
 
-	token, err := l.auth.Token(ctx, userId)
-	if err != nil {
-		log.Error(
-			"failed to get token",
-			sl.Err(err),
-		)
-		return fmt.Errorf("%s: %w", op, err)
+	id, err := l.NewMedia(ctx, userId, conf)
+	if err == nil {
+		return id, models.ItemDone, nil
+	}
+	if !errors.Is(err, service.ErrMediaExists) {
+		return 0, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Create tag for group
-	// set values to upload.
-	var values []models.MediaConfig
-	switch res.Type {
-	case models.ResAlbum:
-		if _, err := l.libClient.NewTag(ctx, token, models.Tag{
-			Name: res.Album.Name,
-			Type: models.TagTypesAvail["album"],
-			Meta: map[string]string{
-				"author": res.Album.Author,
-			},
-		}); err != nil {
-			// TODO: handle "tag already exist".
-			log.Error(
-				"failed to create tag",
-				slog.String("name", res.Playlist.Name),
-				sl.Err(err),
-			)
-			return fmt.Errorf("%s: %w", op, err)
-		}
-
-		values = res.Album.Values
-	case models.ResPlaylist:
-		if _, err := l.libClient.NewTag(ctx, token, models.Tag{
-			Name: res.Playlist.Name,
-			Type: models.TagTypesAvail["playlist"],
-		}); err != nil {
-			// TODO: handle "tag already exist".
-			log.Error(
-				"failed to create tag",
-				slog.String("name", res.Playlist.Name),
-				sl.Err(err),
-			)
-			return fmt.Errorf("%s: %w", op, err)
+	log.Info("media already exists, add new tags to it", slog.String("name", conf.Name), slog.String("author", conf.Author))
+	m := conf.ToMedia()
+	// Get old version of media.
+	oldMedia, err := l.libClient.Media(ctx, token, id)
+	if err != nil {
+		log.Error("failed to get media", slog.Int64("id", id), sl.Err(err))
+		return id, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
+	// Add new tags to old media if needed.
+	newTags := slices.Clone(oldMedia.Tags)
+	for _, t := range m.Tags {
+		if !slices.ContainsFunc(oldMedia.Tags, func(tInner models.Tag) bool {
+			return tInner.Name == t.Name && tInner.Type == t.Type
+		}) {
+			newTags = append(newTags, t)
 		}
+	}
+	// Update tags.
+	oldMedia.Tags = newTags
+	if err := l.UpdateMedia(ctx, userId, oldMedia.ToConfig()); err != nil {
+		log.Error("failed to update media", slog.Int64("id", oldMedia.ID), sl.Err(err))
+		return id, models.ItemFailed, fmt.Errorf("%s: %w", op, err)
+	}
 
-		values = res.Playlist.Values
-	}
-
-upload_loop:
-	for _, m := range values {
-		if id, err := l.NewMedia(ctx, userId, m); err != nil {
-			// If media already exists, add new tags to it
-			if errors.Is(err, service.ErrMediaExists) {
-				log.Info("media already exists, add new tags to it", slog.String("name", m.Name), slog.String("author", m.Author))
-				m := m.ToMedia()
-				// Get old version of media.
-				oldMedia, err := l.libClient.Media(ctx, token, id)
-				if err != nil {
-					log.Error("failed to get media", slog.Int64("id", id), sl.Err(err))
-					continue upload_loop
-				}
-				// Add new tags to old media if needed.
-				newTags := slices.Clone(oldMedia.Tags)
-				for _, t := range m.Tags {
-					if !slices.ContainsFunc(oldMedia.Tags, func(tInner models.Tag) bool {
-						return tInner.Name == t.Name && tInner.Type == t.Type
-					}) {
-						newTags = append(newTags, t)
-					}
-				}
-				// Update tags.
-				oldMedia.Tags = newTags
-				if err := l.UpdateMedia(ctx, userId, oldMedia.ToConfig()); err != nil {
-					log.Error("failed to update media", slog.Int64("id", oldMedia.ID), sl.Err(err))
-					return fmt.Errorf("%s: %w", op, err)
-				}
-				continue upload_loop
-			}
-			log.Error("failed to upload media", slog.String("Name", m.Name), slog.String("source", m.SourcePath), sl.Err(err))
-			return fmt.Errorf("%s: %w", op, err)
-		}
+	if _, ok := l.covers.Get(id); !ok {
+		l.saveCover(id, conf)
 	}
 
-	return nil
+	return id, models.ItemMerged, nil
 }
 
+// recoverTagIds sets ids of media tags.
+// Missing album, playlist and podcast tags are created.
 func (l *library) recoverTagIds(ctx context.Context, token jwt.Token, m *models.Media) error {
 	const op = "library.recoverTagIds"
 
@@ -639,41 +752,37 @@ func (l *library) recoverTagIds(ctx context.Context, token jwt.Token, m *models.
 		slog.Int64("mediaId", m.ID),
 	)
 
-	tags, err := l.libClient.AllTags(ctx, token)
-	if err != nil {
-		log.Error("failed to get all tags")
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
 	for i, t := range m.Tags {
-		if j := slices.IndexFunc(tags, func(tInner models.Tag) bool {
-			return t.Name == tInner.Name && t.Type == tInner.Type
-		}); j != -1 {
-			m.Tags[i].ID = tags[j].ID
+		var (
+			tag models.Tag
+			err error
+		)
+		if isGroupTag(t) {
+			tag, err = l.tags.Ensure(ctx, token, t)
 		} else {
-			log.Error("media has not existing tag", slog.Any("tag", t))
-			return service.ErrTagNotFound
+			tag, err = l.tags.Lookup(ctx, token, t)
+		}
+		if err != nil {
+			if errors.Is(err, service.ErrTagNotFound) {
+				log.Error("media has not existing tag", slog.Any("tag", t))
+				return service.ErrTagNotFound
+			}
+			log.Error("failed to resolve tag", slog.Any("tag", t), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
 		}
+		m.Tags[i].ID = tag.ID
 	}
-	return nil
-}
 
-func exctractTrackInfo(url string) (trackId, albumId string) {
-	trackId = string(yaSong.ExpandString([]byte{}, "$track", url, yaSong.FindSubmatchIndex([]byte(url))))
-	albumId = string(yaSong.ExpandString([]byte{}, "$album", url, yaSong.FindSubmatchIndex([]byte(url))))
-
-	return
-}
-
-func extractAlbumInfo(url string) (albumId string) {
-	albumId = string(yaAlbum.ExpandString([]byte{}, "$album", url, yaAlbum.FindSubmatchIndex([]byte(url))))
-
-	return
+	return nil
 }
 
-func exctractPlaylistInfo(url string) (kind, user string) {
-	kind = string(yaPlaylist.ExpandString([]byte{}, "$kind", url, yaPlaylist.FindSubmatchIndex([]byte(url))))
-	user = string(yaPlaylist.ExpandString([]byte{}, "$user", url, yaPlaylist.FindSubmatchIndex([]byte(url))))
-
-	return
+// isGroupTag reports if tag groups media
+// and can be created on upload.
+func isGroupTag(t models.Tag) bool {
+	switch t.Type.Name {
+	case "album", "playlist", "podcast":
+		return true
+	default:
+		return false
+	}
 }
@@ -0,0 +1,97 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	listenOwner = "library"
+	listenTTL   = 10 * time.Minute
+
+	// Max size of file bot can send to telegram.
+	listenMaxSize = 50 << 20
+	previewLength = 30 * time.Second
+)
+
+// Source downloads audio of media from radio
+// to temporary file. If file is too large
+// to be sent to telegram, 30-second preview
+// is cut from it, and preview is set to true.
+func (l *library) Source(ctx context.Context, id int64, mediaConf models.MediaConfig) (path string, preview bool, err error) {
+	const op = "library.Source"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("mediaId", mediaConf.ID),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	out, err := l.tmpDir.Create(listenOwner, "listen-*.mp3", listenTTL)
+	if err != nil {
+		log.Error("failed to create file", sl.Err(err))
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer out.Close()
+
+	if err := l.libClient.Source(ctx, token, mediaConf.ID, l.tmpDir.Limit(out)); err != nil {
+		l.tmpDir.Release(out.Name())
+		if errors.Is(err, client.ErrMediaNotFound) {
+			return "", false, service.ErrMediaNotFound
+		}
+		log.Error("failed to download source", sl.Err(err))
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	info, err := out.Stat()
+	if err != nil {
+		l.tmpDir.Release(out.Name())
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+	if info.Size() <= listenMaxSize {
+		return out.Name(), false, nil
+	}
+	defer l.tmpDir.Release(out.Name())
+
+	clip, err := l.tmpDir.Create(listenOwner, "preview-*.mp3", listenTTL)
+	if err != nil {
+		log.Error("failed to create file", sl.Err(err))
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+	clip.Close()
+
+	if err := audio.Clip(ctx, out.Name(), clip.Name(), previewStart(mediaConf.Duration), previewLength); err != nil {
+		l.tmpDir.Release(clip.Name())
+		log.Error("failed to cut preview", sl.Err(err))
+		return "", false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return clip.Name(), true, nil
+}
+
+// previewStart skips intro, so preview
+// is more likely to contain chorus.
+func previewStart(dur time.Duration) time.Duration {
+	if dur <= 2*previewLength {
+		return 0
+	}
+	return dur / 3
+}
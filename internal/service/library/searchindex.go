@@ -0,0 +1,321 @@
+package library
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/index"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Index is rebuilt after this period,
+// since library may be changed
+// bypassing bot (e.g. by web admin).
+const searchIndexTTL = 10 * time.Minute
+
+// searchIndex is local full-text index over
+// the whole library. It is built from radio API
+// and updated on changes made through bot.
+type searchIndex struct {
+	idx *index.Index
+
+	mutex   sync.Mutex
+	media   map[int64]models.Media
+	updated time.Time
+
+	// Serializes rebuilds.
+	buildMutex sync.Mutex
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		idx:   index.New(),
+		media: make(map[int64]models.Media),
+	}
+}
+
+// Put adds or updates media.
+// Known upload time is kept.
+func (s *searchIndex) Put(m models.Media) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = s.media[m.ID].CreatedAt
+	}
+	s.media[m.ID] = m
+	s.idx.Add(m.ID, indexFields(m)...)
+}
+
+func (s *searchIndex) Delete(id int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.media, id)
+	s.idx.Remove(id)
+}
+
+// Invalidate makes index to be rebuilt
+// on next search, e.g. after tags are renamed.
+func (s *searchIndex) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.updated = time.Time{}
+}
+
+func (s *searchIndex) expired() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return time.Since(s.updated) > searchIndexTTL
+}
+
+// rebuild replaces indexed media.
+func (s *searchIndex) rebuild(media []models.Media) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	old := s.media
+
+	s.media = make(map[int64]models.Media, len(media))
+	for _, m := range media {
+		// Radio may not report upload time,
+		// the one set by bot is kept then.
+		if m.CreatedAt.IsZero() {
+			m.CreatedAt = old[m.ID].CreatedAt
+		}
+		s.media[m.ID] = m
+		s.idx.Add(m.ID, indexFields(m)...)
+	}
+
+	for id := range old {
+		if _, ok := s.media[id]; !ok {
+			s.idx.Remove(id)
+		}
+	}
+
+	s.updated = time.Now()
+}
+
+// Search returns media matching query and filter,
+// most relevant first unless other order requested.
+// Without query all media are matched in order of ids.
+func (s *searchIndex) Search(filter models.MediaFilter) []models.Media {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var ids []int64
+	if filter.Query != "" {
+		for _, r := range s.idx.Search(filter.Query, 0) {
+			ids = append(ids, r.ID)
+		}
+	} else {
+		ids = make([]int64, 0, len(s.media))
+		for id := range s.media {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+	}
+
+	res := make([]models.Media, 0)
+	for _, id := range ids {
+		m, ok := s.media[id]
+		if !ok || !matchFilter(m, filter) {
+			continue
+		}
+		res = append(res, m)
+		// Whole result is needed to sort it.
+		if filter.Sort == models.SortRelevance && filter.MaxRespLen > 0 && len(res) == filter.MaxRespLen {
+			break
+		}
+	}
+
+	sortMedia(res, filter.Sort)
+
+	if filter.MaxRespLen > 0 && len(res) > filter.MaxRespLen {
+		res = res[:filter.MaxRespLen]
+	}
+
+	return res
+}
+
+// ensureIndex rebuilds expired index.
+func (l *library) ensureIndex(ctx context.Context, token jwt.Token) error {
+	if !l.index.expired() {
+		return nil
+	}
+
+	l.index.buildMutex.Lock()
+	defer l.index.buildMutex.Unlock()
+
+	// Index may be built while waiting.
+	if !l.index.expired() {
+		return nil
+	}
+
+	// Empty filter matches whole library.
+	media, err := l.libClient.Search(ctx, token, models.MediaFilter{})
+	if err != nil {
+		return err
+	}
+
+	l.withUploadTime(media)
+	l.index.rebuild(media)
+
+	return nil
+}
+
+// searchIndexed searches media in local index.
+// Tags of filter are matched by name, other conditions
+// are applied the same way radio API does.
+func (l *library) searchIndexed(ctx context.Context, token jwt.Token, filter models.MediaFilter) ([]models.Media, error) {
+	if err := l.ensureIndex(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return l.index.Search(filter), nil
+}
+
+// LoadIndex builds search index at startup
+// with token of any known user,
+// so first search is not delayed.
+func (l *library) LoadIndex(ctx context.Context) {
+	const op = "library.LoadIndex"
+
+	log := l.log.With(
+		slog.String("op", op),
+	)
+
+	for _, id := range l.auth.Users(ctx) {
+		token, err := l.auth.Token(ctx, id)
+		if err != nil {
+			continue
+		}
+
+		if err := l.ensureIndex(ctx, token); err != nil {
+			log.Error("failed to build index", sl.Err(err))
+			return
+		}
+
+		log.Info("search index built", slog.Int("media", l.index.idx.Len()))
+		return
+	}
+
+	log.Warn("no user to build search index")
+}
+
+// indexFields returns searchable text of media:
+// name, author and names of albums.
+func indexFields(m models.Media) []string {
+	fields := []string{m.Name, m.Author}
+	for _, t := range m.Tags {
+		if t.Type.Name == "album" {
+			fields = append(fields, t.Name)
+		}
+	}
+	return fields
+}
+
+// localFilter reports if filter has conditions
+// radio API does not support, such filters
+// are applied by local index.
+func localFilter(filter models.MediaFilter) bool {
+	return filter.Query != "" ||
+		len(filter.AnyTags) > 0 ||
+		len(filter.ExcludeTags) > 0 ||
+		filter.MinDuration > 0 ||
+		filter.MaxDuration > 0 ||
+		!filter.AddedAfter.IsZero() ||
+		filter.Sort != models.SortRelevance
+}
+
+// matchFilter reports if media satisfies
+// filter conditions except text query.
+func matchFilter(m models.Media, filter models.MediaFilter) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	if filter.Name != "" && !contains(m.Name, filter.Name) {
+		return false
+	}
+	if filter.Author != "" && !contains(m.Author, filter.Author) {
+		return false
+	}
+
+	has := func(name string) bool {
+		return slices.ContainsFunc(m.Tags, func(t models.Tag) bool {
+			return t.Name == name
+		})
+	}
+
+	for _, name := range filter.Tags {
+		if !has(name) {
+			return false
+		}
+	}
+	if len(filter.AnyTags) > 0 && !slices.ContainsFunc(filter.AnyTags, has) {
+		return false
+	}
+	if slices.ContainsFunc(filter.ExcludeTags, has) {
+		return false
+	}
+
+	if filter.MinDuration > 0 && m.Duration < filter.MinDuration {
+		return false
+	}
+	if filter.MaxDuration > 0 && m.Duration > filter.MaxDuration {
+		return false
+	}
+
+	// Media with unknown upload time
+	// are not considered recent.
+	if !filter.AddedAfter.IsZero() && !m.CreatedAt.After(filter.AddedAfter) {
+		return false
+	}
+
+	return true
+}
+
+// sortMedia sorts media in given order.
+// Relevance order is kept as is.
+func sortMedia(media []models.Media, order models.MediaSort) {
+	var cmpFunc func(a, b models.Media) int
+
+	switch order {
+	case models.SortName:
+		cmpFunc = func(a, b models.Media) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	case models.SortAuthor:
+		cmpFunc = func(a, b models.Media) int {
+			return strings.Compare(strings.ToLower(a.Author), strings.ToLower(b.Author))
+		}
+	case models.SortDuration:
+		cmpFunc = func(a, b models.Media) int {
+			return cmp.Compare(a.Duration, b.Duration)
+		}
+	case models.SortRecent:
+		// Ids grow with uploads, so they
+		// order media with unknown upload time.
+		cmpFunc = func(a, b models.Media) int {
+			if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+				return c
+			}
+			return cmp.Compare(b.ID, a.ID)
+		}
+	default:
+		return
+	}
+
+	slices.SortStableFunc(media, cmpFunc)
+}
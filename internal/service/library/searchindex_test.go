@@ -0,0 +1,169 @@
+package library
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestMatchFilter(t *testing.T) {
+	now := time.Now()
+
+	m := models.Media{
+		Name:     "Yellow Submarine",
+		Author:   "The Beatles",
+		Duration: 3 * time.Minute,
+		Tags: models.TagList{
+			{Name: "song"},
+			{Name: "Поп"},
+			{Name: "Веселое"},
+		},
+		CreatedAt: now.Add(-time.Hour),
+	}
+
+	testCases := []struct {
+		desc   string
+		filter models.MediaFilter
+		want   bool
+	}{
+		{
+			desc:   "empty",
+			filter: models.MediaFilter{},
+			want:   true,
+		},
+		{
+			desc:   "name and author",
+			filter: models.MediaFilter{Name: "submarine", Author: "Beatles"},
+			want:   true,
+		},
+		{
+			desc:   "other name",
+			filter: models.MediaFilter{Name: "Help"},
+			want:   false,
+		},
+		{
+			desc:   "all tags",
+			filter: models.MediaFilter{Tags: []string{"song", "Поп"}},
+			want:   true,
+		},
+		{
+			desc:   "missing tag",
+			filter: models.MediaFilter{Tags: []string{"song", "Рок"}},
+			want:   false,
+		},
+		{
+			desc:   "any tag",
+			filter: models.MediaFilter{AnyTags: []string{"Рок", "Поп"}},
+			want:   true,
+		},
+		{
+			desc:   "no any tag",
+			filter: models.MediaFilter{AnyTags: []string{"Рок", "Джаз"}},
+			want:   false,
+		},
+		{
+			desc:   "excluded tag",
+			filter: models.MediaFilter{ExcludeTags: []string{"Веселое"}},
+			want:   false,
+		},
+		{
+			desc:   "duration in range",
+			filter: models.MediaFilter{MinDuration: 2 * time.Minute, MaxDuration: 4 * time.Minute},
+			want:   true,
+		},
+		{
+			desc:   "too long",
+			filter: models.MediaFilter{MaxDuration: 2 * time.Minute},
+			want:   false,
+		},
+		{
+			desc:   "too short",
+			filter: models.MediaFilter{MinDuration: 4 * time.Minute},
+			want:   false,
+		},
+		{
+			desc:   "recent",
+			filter: models.MediaFilter{AddedAfter: now.Add(-24 * time.Hour)},
+			want:   true,
+		},
+		{
+			desc:   "not recent",
+			filter: models.MediaFilter{AddedAfter: now.Add(-time.Minute)},
+			want:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchFilter(m, tc.filter))
+		})
+	}
+
+	// Upload time of media is unknown.
+	assert.False(t, matchFilter(models.Media{}, models.MediaFilter{AddedAfter: now}))
+}
+
+func TestSortMedia(t *testing.T) {
+	now := time.Now()
+
+	media := []models.Media{
+		{ID: 1, Name: "b", Author: "C", Duration: time.Minute, CreatedAt: now.Add(-time.Hour)},
+		{ID: 2, Name: "C", Author: "a", Duration: 3 * time.Minute},
+		{ID: 3, Name: "a", Author: "b", Duration: 2 * time.Minute, CreatedAt: now},
+		{ID: 4, Name: "d", Author: "d", Duration: 4 * time.Minute},
+	}
+
+	testCases := []struct {
+		order models.MediaSort
+		want  []int64
+	}{
+		{order: models.SortRelevance, want: []int64{1, 2, 3, 4}},
+		{order: models.SortName, want: []int64{3, 1, 2, 4}},
+		{order: models.SortAuthor, want: []int64{2, 3, 1, 4}},
+		{order: models.SortDuration, want: []int64{1, 3, 2, 4}},
+		// Unknown upload time goes last, newer id first.
+		{order: models.SortRecent, want: []int64{3, 1, 4, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.order), func(t *testing.T) {
+			res := append([]models.Media{}, media...)
+			sortMedia(res, tc.order)
+
+			ids := make([]int64, 0, len(res))
+			for _, m := range res {
+				ids = append(ids, m.ID)
+			}
+			assert.Equal(t, tc.want, ids)
+		})
+	}
+}
+
+func TestIndexCreatedAt(t *testing.T) {
+	uploaded := time.Now().Add(-time.Hour)
+
+	s := newSearchIndex()
+	s.rebuild([]models.Media{{ID: 1, Name: "first"}})
+	s.Put(models.Media{ID: 2, Name: "second", CreatedAt: uploaded})
+
+	// Updated media and rebuilt index
+	// keep upload time set by bot.
+	s.Put(models.Media{ID: 2, Name: "second (live)"})
+	s.rebuild([]models.Media{{ID: 1, Name: "first"}, {ID: 2, Name: "second (live)"}})
+
+	recent := s.Search(models.MediaFilter{AddedAfter: uploaded.Add(-time.Minute)})
+	if assert.Len(t, recent, 1) {
+		assert.Equal(t, "second (live)", recent[0].Name)
+		assert.True(t, uploaded.Equal(recent[0].CreatedAt))
+	}
+
+	// Without query all media are matched.
+	all := s.Search(models.MediaFilter{Sort: models.SortRecent})
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, int64(2), all[0].ID)
+		assert.Equal(t, int64(1), all[1].ID)
+	}
+}
@@ -0,0 +1,438 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TagUsage returns tags of given type with
+// number of media tagged with each of them.
+// Tags are sorted by name.
+func (l *library) TagUsage(ctx context.Context, id int64, tagType string) ([]models.TagUsage, error) {
+	const op = "library.TagUsage"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("type", tagType),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	counts := usageCounts(media)
+
+	res := make([]models.TagUsage, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Type.Name != tagType {
+			continue
+		}
+		res = append(res, models.TagUsage{
+			Tag:   tag,
+			Count: counts[tag.ID],
+		})
+	}
+
+	slices.SortFunc(res, func(a, b models.TagUsage) int {
+		return strings.Compare(strings.ToLower(a.Tag.Name), strings.ToLower(b.Tag.Name))
+	})
+
+	return res, nil
+}
+
+// Tag returns tag by id with its usage.
+func (l *library) Tag(ctx context.Context, id int64, tagId int64) (models.TagUsage, error) {
+	const op = "library.Tag"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("tagId", tagId),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.TagUsage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return models.TagUsage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	i := slices.IndexFunc(tags, func(t models.Tag) bool {
+		return t.ID == tagId
+	})
+	if i == -1 {
+		return models.TagUsage{}, service.ErrTagNotFound
+	}
+
+	return models.TagUsage{
+		Tag:   tags[i],
+		Count: usageCounts(media)[tagId],
+	}, nil
+}
+
+// RenameTag changes name of tag.
+// If tag with the same name and type exists,
+// returns service.ErrTagExists, such tags
+// should be merged instead.
+func (l *library) RenameTag(ctx context.Context, id int64, tagId int64, name string) (models.Tag, error) {
+	const op = "library.RenameTag"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("tagId", tagId),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, err := l.libClient.AllTags(ctx, token)
+	if err != nil {
+		log.Error("failed to get tags", sl.Err(err))
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, found := findTag(tags, tagId)
+	if !found {
+		return models.Tag{}, service.ErrTagNotFound
+	}
+
+	tag.Name = name
+
+	if tagExists(tags, tag) {
+		return models.Tag{}, service.ErrTagExists
+	}
+
+	if err := l.updateTag(ctx, token, tag); err != nil {
+		log.Error("failed to update tag", sl.Err(err))
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tag renamed", slog.String("name", name))
+
+	return tag, nil
+}
+
+// SetTagMeta sets meta value of tag.
+// Empty value removes key. If tag with
+// the same name, type and identity meta
+// exists, returns service.ErrTagExists.
+func (l *library) SetTagMeta(ctx context.Context, id int64, tagId int64, key, val string) (models.Tag, error) {
+	const op = "library.SetTagMeta"
+
+	if key == "year" && val != "" {
+		if _, err := strconv.Atoi(val); err != nil {
+			return models.Tag{}, service.ErrInvalidYear
+		}
+	}
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("tagId", tagId),
+		slog.String("key", key),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, err := l.libClient.AllTags(ctx, token)
+	if err != nil {
+		log.Error("failed to get tags", sl.Err(err))
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, found := findTag(tags, tagId)
+	if !found {
+		return models.Tag{}, service.ErrTagNotFound
+	}
+
+	meta := make(map[string]string, len(tag.Meta)+1)
+	for k, v := range tag.Meta {
+		meta[k] = v
+	}
+	if val == "" {
+		delete(meta, key)
+	} else {
+		meta[key] = val
+	}
+	tag.Meta = meta
+
+	if slices.Contains(identityMeta, key) && tagExists(tags, tag) {
+		return models.Tag{}, service.ErrTagExists
+	}
+
+	if err := l.updateTag(ctx, token, tag); err != nil {
+		log.Error("failed to update tag", sl.Err(err))
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag, nil
+}
+
+// MergeTags moves all media tagged with
+// tag "from" to tag "to" and deletes "from".
+// Returns number of retagged media.
+func (l *library) MergeTags(ctx context.Context, id int64, fromId, toId int64) (int, error) {
+	const op = "library.MergeTags"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("from", fromId),
+		slog.Int64("to", toId),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	from, foundFrom := findTag(tags, fromId)
+	to, foundTo := findTag(tags, toId)
+	if !foundFrom || !foundTo || fromId == toId {
+		return 0, service.ErrTagNotFound
+	}
+	if from.Type.ID != to.Type.ID {
+		return 0, service.ErrTagMismatch
+	}
+
+	// Tags are cached by resolver
+	// and stored with indexed media.
+	defer l.tags.Invalidate()
+	defer l.index.Invalidate()
+
+	retagged := 0
+	for _, m := range media {
+		if !retag(&m, from, to) {
+			continue
+		}
+		if err := l.libClient.UpdateMedia(ctx, token, m); err != nil {
+			// Already retagged media are left as is,
+			// merge can be repeated.
+			log.Error(
+				"failed to update media",
+				slog.Int64("mediaId", m.ID),
+				slog.Int("retagged", retagged),
+				sl.Err(err),
+			)
+			return retagged, fmt.Errorf("%s: %w", op, err)
+		}
+		retagged++
+	}
+
+	// Positions of retagged media
+	// in album are kept.
+	if positions := positionMeta(from.Meta); len(positions) > 0 {
+		meta := maps.Clone(to.Meta)
+		if meta == nil {
+			meta = make(map[string]string, len(positions))
+		}
+		for k, v := range positions {
+			if _, ok := meta[k]; !ok {
+				meta[k] = v
+			}
+		}
+		to.Meta = meta
+		if err := l.libClient.UpdateTag(ctx, token, to); err != nil {
+			log.Error("failed to update tag", sl.Err(err))
+			return retagged, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := l.libClient.DeleteTag(ctx, token, fromId); err != nil && !errors.Is(err, client.ErrTagNotFound) {
+		log.Error("failed to delete tag", sl.Err(err))
+		return retagged, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info(
+		"tags merged",
+		slog.String("from", from.Name),
+		slog.String("to", to.Name),
+		slog.Int("retagged", retagged),
+	)
+
+	return retagged, nil
+}
+
+// DeleteTag deletes unused tag.
+// If tag is in use, returns service.ErrTagInUse.
+func (l *library) DeleteTag(ctx context.Context, id int64, tagId int64) error {
+	const op = "library.DeleteTag"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("tagId", tagId),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, media, err := l.tagsWithMedia(ctx, token)
+	if err != nil {
+		log.Error("failed to get library", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if usageCounts(media)[tagId] > 0 {
+		return service.ErrTagInUse
+	}
+
+	defer l.tags.Invalidate()
+
+	if err := l.libClient.DeleteTag(ctx, token, tagId); err != nil {
+		if errors.Is(err, client.ErrTagNotFound) {
+			return service.ErrTagNotFound
+		}
+		log.Error("failed to delete tag", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tag deleted")
+
+	return nil
+}
+
+// tagsWithMedia returns all tags and all media.
+func (l *library) tagsWithMedia(ctx context.Context, token jwt.Token) (models.TagList, []models.Media, error) {
+	tags, err := l.libClient.AllTags(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Empty filter matches whole library.
+	media, err := l.libClient.Search(ctx, token, models.MediaFilter{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tags, media, nil
+}
+
+func (l *library) updateTag(ctx context.Context, token jwt.Token, tag models.Tag) error {
+	defer l.tags.Invalidate()
+	defer l.index.Invalidate()
+
+	if err := l.libClient.UpdateTag(ctx, token, tag); err != nil {
+		if errors.Is(err, client.ErrTagNotFound) {
+			return service.ErrTagNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// tagExists reports if other tag has the
+// same type, name and identity meta as tag.
+func tagExists(tags models.TagList, tag models.Tag) bool {
+	return slices.ContainsFunc(tags, func(t models.Tag) bool {
+		return t.ID != tag.ID && t.Type.Name == tag.Type.Name && t.Name == tag.Name && metaMatch(tag.Meta, t.Meta)
+	})
+}
+
+// usageCounts returns number of media by tag id.
+func usageCounts(media []models.Media) map[int64]int {
+	counts := make(map[int64]int)
+	for _, m := range media {
+		for _, t := range m.Tags {
+			counts[t.ID]++
+		}
+	}
+	return counts
+}
+
+// retag replaces tag "from" with "to" in media.
+// Reports if media had tag "from".
+func retag(m *models.Media, from, to models.Tag) bool {
+	i := slices.IndexFunc(m.Tags, func(t models.Tag) bool {
+		return t.ID == from.ID
+	})
+	if i == -1 {
+		return false
+	}
+
+	tags := slices.Clone(m.Tags)
+	if slices.ContainsFunc(tags, func(t models.Tag) bool {
+		return t.ID == to.ID
+	}) {
+		tags = slices.Delete(tags, i, i+1)
+	} else {
+		tags[i] = to
+	}
+	m.Tags = tags
+
+	return true
+}
+
+func findTag(tags models.TagList, id int64) (models.Tag, bool) {
+	i := slices.IndexFunc(tags, func(t models.Tag) bool {
+		return t.ID == id
+	})
+	if i == -1 {
+		return models.Tag{}, false
+	}
+	return tags[i], true
+}
@@ -0,0 +1,110 @@
+package library
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestRetag(t *testing.T) {
+	from := models.Tag{ID: 1, Name: "Chill", Type: models.TagTypesAvail["playlist"]}
+	to := models.Tag{ID: 2, Name: "chill", Type: models.TagTypesAvail["playlist"]}
+	other := models.Tag{ID: 3, Name: "Поп", Type: models.TagTypesAvail["genre"]}
+
+	testCases := []struct {
+		desc    string
+		tags    models.TagList
+		changed bool
+		want    models.TagList
+	}{
+		{
+			desc:    "no tag",
+			tags:    models.TagList{other},
+			changed: false,
+			want:    models.TagList{other},
+		},
+		{
+			desc:    "replaced",
+			tags:    models.TagList{other, from},
+			changed: true,
+			want:    models.TagList{other, to},
+		},
+		{
+			desc:    "both tags",
+			tags:    models.TagList{from, other, to},
+			changed: true,
+			want:    models.TagList{other, to},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			m := models.Media{Tags: tc.tags}
+			orig := append(models.TagList{}, tc.tags...)
+
+			assert.Equal(t, tc.changed, retag(&m, from, to))
+			assert.Equal(t, tc.want, m.Tags)
+			// Source list is not modified.
+			assert.Equal(t, orig, tc.tags)
+		})
+	}
+}
+
+func TestUsageCounts(t *testing.T) {
+	media := []models.Media{
+		{Tags: models.TagList{{ID: 1}, {ID: 2}}},
+		{Tags: models.TagList{{ID: 1}}},
+		{},
+	}
+
+	assert.Equal(t, map[int64]int{1: 2, 2: 1}, usageCounts(media))
+}
+
+func TestTagExists(t *testing.T) {
+	album := models.TagTypesAvail["album"]
+	tags := models.TagList{
+		{ID: 1, Name: "Greatest Hits", Type: album, Meta: map[string]string{"author": "Queen"}},
+		{ID: 2, Name: "Greatest Hits", Type: album, Meta: map[string]string{"author": "ABBA"}},
+		{ID: 3, Name: "Greatest Hits", Type: models.TagTypesAvail["playlist"]},
+	}
+
+	testCases := []struct {
+		desc   string
+		tag    models.Tag
+		exists bool
+	}{
+		{
+			desc:   "itself",
+			tag:    tags[0],
+			exists: false,
+		},
+		{
+			desc:   "author changed to other album",
+			tag:    models.Tag{ID: 1, Name: "Greatest Hits", Type: album, Meta: map[string]string{"author": "ABBA"}},
+			exists: true,
+		},
+		{
+			desc:   "year changed",
+			tag:    models.Tag{ID: 1, Name: "Greatest Hits", Type: album, Meta: map[string]string{"author": "Queen", "year": "1981"}},
+			exists: false,
+		},
+		{
+			desc:   "author removed",
+			tag:    models.Tag{ID: 1, Name: "Greatest Hits", Type: album, Meta: map[string]string{}},
+			exists: true,
+		},
+		{
+			desc:   "other type",
+			tag:    models.Tag{ID: 4, Name: "Greatest Hits", Type: models.TagTypesAvail["genre"]},
+			exists: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.exists, tagExists(tags, tc.tag))
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Cached tags are refetched after this period,
+	// since they may be changed by other users.
+	tagsTTL = 5 * time.Minute
+	// Minimal interval between refetching tags
+	// on cache miss.
+	tagsMissRefresh = 10 * time.Second
+)
+
+type TagClient interface {
+	AllTags(ctx context.Context, token jwt.Token) (models.TagList, error)
+	NewTag(ctx context.Context, token jwt.Token, tag models.Tag) (int64, error)
+}
+
+// tagResolver finds tag ids by
+// (name, type, meta) and creates missing tags.
+// Tags are cached between requests.
+type tagResolver struct {
+	client TagClient
+
+	mutex   sync.Mutex
+	tags    models.TagList
+	updated time.Time
+
+	// Serializes tag creation
+	// to avoid creating tag twice.
+	createMutex sync.Mutex
+}
+
+func newTagResolver(client TagClient) *tagResolver {
+	return &tagResolver{
+		client: client,
+	}
+}
+
+// Lookup returns existing tag with id.
+// If there is no such tag returns service.ErrTagNotFound.
+func (r *tagResolver) Lookup(ctx context.Context, token jwt.Token, tag models.Tag) (models.Tag, error) {
+	const op = "tagResolver.Lookup"
+
+	r.mutex.Lock()
+	expired := time.Since(r.updated) > tagsTTL
+	r.mutex.Unlock()
+
+	if expired {
+		if err := r.refresh(ctx, token); err != nil {
+			return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if t, ok := r.cached(tag); ok {
+		return t, nil
+	}
+
+	// Tag may be created after last refresh.
+	r.mutex.Lock()
+	stale := time.Since(r.updated) > tagsMissRefresh
+	r.mutex.Unlock()
+
+	if stale {
+		if err := r.refresh(ctx, token); err != nil {
+			return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if t, ok := r.cached(tag); ok {
+			return t, nil
+		}
+	}
+
+	return models.Tag{}, fmt.Errorf("%s: %w", op, service.ErrTagNotFound)
+}
+
+// Ensure returns existing tag or creates new one.
+// If creation fails since tag with the same identity
+// meta was created concurrently, the existing tag
+// is returned. Tag with the same name but other
+// identity meta (e.g. album of other author)
+// is never used instead, new tag is created
+// with identity meta added to name.
+func (r *tagResolver) Ensure(ctx context.Context, token jwt.Token, tag models.Tag) (models.Tag, error) {
+	const op = "tagResolver.Ensure"
+
+	t, err := r.Lookup(ctx, token, tag)
+	if err == nil {
+		return t, nil
+	}
+	if !errors.Is(err, service.ErrTagNotFound) {
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.createMutex.Lock()
+	defer r.createMutex.Unlock()
+
+	// Tag could be created while waiting for lock.
+	if t, ok := r.cached(tag); ok {
+		return t, nil
+	}
+
+	id, createErr := r.client.NewTag(ctx, token, tag)
+	if createErr == nil {
+		tag.ID = id
+
+		r.mutex.Lock()
+		r.tags = append(r.tags, tag)
+		r.mutex.Unlock()
+
+		return tag, nil
+	}
+
+	// Tag may be created by someone else.
+	if err := r.refresh(ctx, token); err != nil {
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if t, ok := r.cached(tag); ok {
+		return t, nil
+	}
+
+	// Name is taken by tag with other identity.
+	name, ok := distinctName(tag)
+	if !ok {
+		return models.Tag{}, fmt.Errorf("%s: %w", op, createErr)
+	}
+	tag.Name = name
+
+	id, err = r.client.NewTag(ctx, token, tag)
+	if err != nil {
+		return models.Tag{}, fmt.Errorf("%s: %w", op, err)
+	}
+	tag.ID = id
+
+	r.mutex.Lock()
+	r.tags = append(r.tags, tag)
+	r.mutex.Unlock()
+
+	return tag, nil
+}
+
+// All returns all tags,
+// refetched if cache is expired.
+func (r *tagResolver) All(ctx context.Context, token jwt.Token) (models.TagList, error) {
+	const op = "tagResolver.All"
+
+	r.mutex.Lock()
+	expired := time.Since(r.updated) > tagsTTL
+	r.mutex.Unlock()
+
+	if expired {
+		if err := r.refresh(ctx, token); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Clone(r.tags), nil
+}
+
+// Update replaces cached tag
+// with the same id.
+func (r *tagResolver) Update(tag models.Tag) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if i := slices.IndexFunc(r.tags, func(t models.Tag) bool {
+		return t.ID == tag.ID
+	}); i != -1 {
+		r.tags[i] = tag
+	}
+}
+
+// Invalidate drops cached tags.
+func (r *tagResolver) Invalidate() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.tags = nil
+	r.updated = time.Time{}
+}
+
+func (r *tagResolver) refresh(ctx context.Context, token jwt.Token) error {
+	tags, err := r.client.AllTags(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.tags = tags
+	r.updated = time.Now()
+
+	return nil
+}
+
+// cached finds tag with the same name (or name
+// made distinct), type and identity meta in cache.
+func (r *tagResolver) cached(tag models.Tag) (models.Tag, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	distinct, _ := distinctName(tag)
+
+	for _, t := range r.tags {
+		if (t.Name != tag.Name && t.Name != distinct) || t.Type.Name != tag.Type.Name {
+			continue
+		}
+		if !metaMatch(tag.Meta, t.Meta) {
+			continue
+		}
+		return t, true
+	}
+
+	return models.Tag{}, false
+}
+
+// Meta keys identifying tag.
+// Other meta (e.g. album year) is informational.
+var identityMeta = []string{"author"}
+
+// distinctName returns name of tag with identity
+// meta added, e.g. "Greatest Hits (ABBA)" for album.
+// It is used when name is taken by tag with other
+// identity. Reports false if tag has no identity meta.
+func distinctName(tag models.Tag) (string, bool) {
+	vals := make([]string, 0, len(identityMeta))
+	for _, k := range identityMeta {
+		if v := tag.Meta[k]; v != "" {
+			vals = append(vals, v)
+		}
+	}
+	if len(vals) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("%s (%s)", tag.Name, strings.Join(vals, ", ")), true
+}
+
+// metaMatch reports if all non empty wanted
+// identity meta values equal to existing ones.
+func metaMatch(want, have map[string]string) bool {
+	for _, k := range identityMeta {
+		if v := want[k]; v != "" && have[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// fakeTagClient stores tags in memory.
+// If hidden is set, created tags are not
+// returned by NewTag (emulates concurrent creation).
+type fakeTagClient struct {
+	tags    models.TagList
+	lastId  int64
+	hidden  bool
+	calls   int
+	creates int
+}
+
+func (c *fakeTagClient) AllTags(_ context.Context, _ jwt.Token) (models.TagList, error) {
+	c.calls++
+	return append(models.TagList{}, c.tags...), nil
+}
+
+func (c *fakeTagClient) NewTag(_ context.Context, _ jwt.Token, tag models.Tag) (int64, error) {
+	c.creates++
+	for _, t := range c.tags {
+		if t.Name == tag.Name && t.Type.Name == tag.Type.Name {
+			return 0, errors.New("tag already exists")
+		}
+	}
+	c.lastId++
+	tag.ID = c.lastId
+	c.tags = append(c.tags, tag)
+	if c.hidden {
+		return 0, errors.New("timeout")
+	}
+	return tag.ID, nil
+}
+
+func album(name, author string) models.Tag {
+	return models.Tag{
+		Name: name,
+		Type: models.TagTypesAvail["album"],
+		Meta: map[string]string{"author": author},
+	}
+}
+
+func TestTagResolverEnsure(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		existing     models.TagList
+		hidden       bool
+		tag          models.Tag
+		expectedId   int64
+		expectedName string
+		expectedNew  int
+	}{
+		{
+			desc:        "create missing",
+			tag:         album("Abbey Road", "The Beatles"),
+			expectedId:  1,
+			expectedNew: 1,
+		},
+		{
+			desc:        "existing tag",
+			existing:    models.TagList{withId(album("Abbey Road", "The Beatles"), 7)},
+			tag:         album("Abbey Road", "The Beatles"),
+			expectedId:  7,
+			expectedNew: 0,
+		},
+		{
+			desc:         "same name other author",
+			existing:     models.TagList{withId(album("Greatest Hits", "Queen"), 7)},
+			tag:          album("Greatest Hits", "ABBA"),
+			expectedId:   2,
+			expectedName: "Greatest Hits (ABBA)",
+			expectedNew:  2,
+		},
+		{
+			desc:        "created concurrently",
+			hidden:      true,
+			tag:         album("Abbey Road", "The Beatles"),
+			expectedId:  1,
+			expectedNew: 1,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			client := &fakeTagClient{
+				tags:   tC.existing,
+				lastId: int64(len(tC.existing)),
+				hidden: tC.hidden,
+			}
+			r := newTagResolver(client)
+
+			tag, err := r.Ensure(context.Background(), jwt.Token{}, tC.tag)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedId, tag.ID)
+			if tC.expectedName != "" {
+				// Album of other author is not reused.
+				assert.Equal(t, tC.expectedName, tag.Name)
+			}
+			assert.Equal(t, tC.expectedNew, client.creates)
+
+			// Second call is served from cache.
+			calls := client.calls
+			tag, err = r.Ensure(context.Background(), jwt.Token{}, tC.tag)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedId, tag.ID)
+			assert.Equal(t, tC.expectedNew, client.creates)
+			assert.Equal(t, calls, client.calls)
+		})
+	}
+}
+
+func TestTagResolverLookup(t *testing.T) {
+	client := &fakeTagClient{
+		tags: models.TagList{
+			withId(album("Abbey Road", "The Beatles"), 1),
+		},
+	}
+	r := newTagResolver(client)
+
+	tag, err := r.Lookup(context.Background(), jwt.Token{}, album("Abbey Road", "The Beatles"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tag.ID)
+
+	_, err = r.Lookup(context.Background(), jwt.Token{}, album("Let It Be", "The Beatles"))
+	assert.ErrorIs(t, err, service.ErrTagNotFound)
+	assert.Equal(t, 0, client.creates)
+}
+
+func withId(tag models.Tag, id int64) models.Tag {
+	tag.ID = id
+	return tag
+}
@@ -0,0 +1,69 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+// Taxonomy returns genres, moods and
+// languages available on server.
+func (l *library) Taxonomy(ctx context.Context, id int64) (models.Taxonomy, error) {
+	const op = "library.Taxonomy"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+	)
+
+	token, err := l.auth.Token(ctx, id)
+	if err != nil {
+		log.Error(
+			"failed to get token",
+			sl.Err(err),
+		)
+		return models.Taxonomy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tags, err := l.tags.All(ctx, token)
+	if err != nil {
+		log.Error(
+			"failed to get tags",
+			sl.Err(err),
+		)
+		return models.Taxonomy{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.NewTaxonomy(tags), nil
+}
+
+// LoadTaxonomy loads tags at startup
+// with token of any known user,
+// so first keyboards are shown at once.
+func (l *library) LoadTaxonomy(ctx context.Context) {
+	const op = "library.LoadTaxonomy"
+
+	log := l.log.With(
+		slog.String("op", op),
+	)
+
+	for _, id := range l.auth.Users(ctx) {
+		tax, err := l.Taxonomy(ctx, id)
+		if err != nil {
+			continue
+		}
+
+		log.Info(
+			"taxonomy loaded",
+			slog.Int("genres", len(tax.Genres)),
+			slog.Int("moods", len(tax.Moods)),
+			slog.Int("languages", len(tax.Languages)),
+		)
+		return
+	}
+
+	log.Warn("no user to load taxonomy")
+}
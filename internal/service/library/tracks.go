@@ -0,0 +1,193 @@
+package library
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Tracks of album are uploaded one by one,
+	// their positions are saved together
+	// after this delay.
+	trackSaveDelay = 5 * time.Second
+	// Timeout of saving positions of one album.
+	trackSaveTimeout = 30 * time.Second
+)
+
+// trackNumbers collects positions of media
+// in albums to store them in album tag meta,
+// one update per album.
+type trackNumbers struct {
+	mutex sync.Mutex
+	// Batches by album tag id.
+	pending map[int64]*trackBatch
+
+	// Serializes saving, so batches
+	// of the same album do not lose keys.
+	saveMutex sync.Mutex
+}
+
+type trackBatch struct {
+	token jwt.Token
+	// Meta changes, empty value removes key.
+	meta  map[string]string
+	timer *time.Timer
+}
+
+func newTrackNumbers() *trackNumbers {
+	return &trackNumbers{
+		pending: make(map[int64]*trackBatch),
+	}
+}
+
+// saveTrackNumbers queues position of media
+// in albums to be stored in album tags meta.
+// Meta of tags in media is updated at once.
+func (l *library) saveTrackNumbers(token jwt.Token, m *models.Media, albums []models.Album) {
+	for _, a := range albums {
+		if a.Track == 0 {
+			continue
+		}
+
+		i := slices.IndexFunc(m.Tags, func(t models.Tag) bool {
+			return t.Type.Name == "album" && t.Name == a.Name && t.Meta["author"] == a.Author
+		})
+		if i == -1 || m.Tags[i].ID == 0 {
+			continue
+		}
+
+		changes := map[string]string{
+			models.TrackMetaKey(m.ID): strconv.Itoa(a.Track),
+		}
+		if a.Disc != 0 {
+			changes[models.DiscMetaKey(m.ID)] = strconv.Itoa(a.Disc)
+		}
+		l.queueTrackMeta(token, m.Tags[i].ID, changes)
+
+		meta := maps.Clone(m.Tags[i].Meta)
+		if meta == nil {
+			meta = make(map[string]string, len(changes))
+		}
+		maps.Copy(meta, changes)
+		m.Tags[i].Meta = meta
+	}
+}
+
+// dropTrackNumbers queues removal of
+// position of deleted media from album tags.
+func (l *library) dropTrackNumbers(ctx context.Context, token jwt.Token, conf models.MediaConfig) {
+	for _, a := range conf.Albums {
+		if a.Track == 0 {
+			continue
+		}
+		tag, err := l.tags.Lookup(ctx, token, a.Tag())
+		if err != nil {
+			continue
+		}
+		l.queueTrackMeta(token, tag.ID, map[string]string{
+			models.TrackMetaKey(conf.ID): "",
+			models.DiscMetaKey(conf.ID):  "",
+		})
+	}
+}
+
+func (l *library) queueTrackMeta(token jwt.Token, tagId int64, changes map[string]string) {
+	l.tracks.mutex.Lock()
+	defer l.tracks.mutex.Unlock()
+
+	batch, ok := l.tracks.pending[tagId]
+	if !ok {
+		batch = &trackBatch{
+			meta: make(map[string]string, len(changes)),
+		}
+		batch.timer = time.AfterFunc(trackSaveDelay, func() {
+			l.saveTrackMeta(tagId)
+		})
+		l.tracks.pending[tagId] = batch
+	}
+	batch.token = token
+	maps.Copy(batch.meta, changes)
+}
+
+// saveTrackMeta applies pending changes
+// to meta of album tag. Errors are only
+// logged, since media is already uploaded.
+func (l *library) saveTrackMeta(tagId int64) {
+	const op = "library.saveTrackMeta"
+
+	log := l.log.With(
+		slog.String("op", op),
+		slog.Int64("tagId", tagId),
+	)
+
+	l.tracks.mutex.Lock()
+	batch, ok := l.tracks.pending[tagId]
+	delete(l.tracks.pending, tagId)
+	l.tracks.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+	batch.timer.Stop()
+
+	l.tracks.saveMutex.Lock()
+	defer l.tracks.saveMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), trackSaveTimeout)
+	defer cancel()
+
+	tags, err := l.tags.All(ctx, batch.token)
+	if err != nil {
+		log.Warn("failed to get tags", sl.Err(err))
+		return
+	}
+	tag, ok := findTag(tags, tagId)
+	if !ok {
+		log.Warn("album tag not found")
+		return
+	}
+
+	meta := maps.Clone(tag.Meta)
+	if meta == nil {
+		meta = make(map[string]string, len(batch.meta))
+	}
+	for k, v := range batch.meta {
+		if v == "" {
+			delete(meta, k)
+		} else {
+			meta[k] = v
+		}
+	}
+	if maps.Equal(meta, tag.Meta) {
+		return
+	}
+	tag.Meta = meta
+
+	if err := l.libClient.UpdateTag(ctx, batch.token, tag); err != nil {
+		log.Warn("failed to save track numbers", sl.Err(err))
+		return
+	}
+	l.tags.Update(tag)
+}
+
+// positionMeta returns meta keys
+// storing positions of media.
+func positionMeta(meta map[string]string) map[string]string {
+	res := make(map[string]string)
+	for k, v := range meta {
+		if models.IsPositionMetaKey(k) {
+			res[k] = v
+		}
+	}
+	return res
+}
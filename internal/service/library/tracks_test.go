@@ -0,0 +1,99 @@
+package library
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+// fakeTrackClient stores updated tags in tag client.
+type fakeTrackClient struct {
+	LibraryClient
+	tags    *fakeTagClient
+	updates int
+}
+
+func (c *fakeTrackClient) UpdateTag(_ context.Context, _ jwt.Token, tag models.Tag) error {
+	c.updates++
+	for i := range c.tags.tags {
+		if c.tags.tags[i].ID == tag.ID {
+			c.tags.tags[i] = tag
+		}
+	}
+	return nil
+}
+
+func TestTrackNumbers(t *testing.T) {
+	tagClient := &fakeTagClient{
+		tags: models.TagList{withId(album("Abbey Road", "The Beatles"), 1)},
+	}
+	client := &fakeTrackClient{tags: tagClient}
+	l := &library{
+		log:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		libClient: client,
+		tags:      newTagResolver(tagClient),
+		tracks:    newTrackNumbers(),
+	}
+
+	for i := 1; i <= 3; i++ {
+		m := models.Media{
+			ID:   int64(10 + i),
+			Tags: models.TagList{withId(album("Abbey Road", "The Beatles"), 1)},
+		}
+		l.saveTrackNumbers(jwt.Token{}, &m, []models.Album{{Name: "Abbey Road", Author: "The Beatles", Track: i, Disc: 1}})
+
+		// Media keeps position at once.
+		track, disc := m.Tags[0].TrackOf(m.ID)
+		assert.Equal(t, i, track)
+		assert.Equal(t, 1, disc)
+	}
+	assert.Equal(t, 0, client.updates)
+
+	l.Stop()
+
+	// Positions of album are saved
+	// in one update, cache is updated.
+	assert.Equal(t, 1, client.updates)
+	calls := tagClient.calls
+	tag, err := l.tags.Lookup(context.Background(), jwt.Token{}, album("Abbey Road", "The Beatles"))
+	assert.NoError(t, err)
+	assert.Equal(t, calls, tagClient.calls)
+	for i := 1; i <= 3; i++ {
+		track, _ := tag.TrackOf(int64(10 + i))
+		assert.Equal(t, i, track)
+	}
+
+	// Position of deleted media is removed.
+	l.dropTrackNumbers(context.Background(), jwt.Token{}, models.MediaConfig{
+		ID:     12,
+		Albums: []models.Album{{Name: "Abbey Road", Author: "The Beatles", Track: 2}},
+	})
+	l.Stop()
+
+	tag, err = l.tags.Lookup(context.Background(), jwt.Token{}, album("Abbey Road", "The Beatles"))
+	assert.NoError(t, err)
+	track, disc := tag.TrackOf(12)
+	assert.Zero(t, track)
+	assert.Zero(t, disc)
+	assert.Equal(t, "The Beatles", tag.Meta["author"])
+}
+
+func TestPositionMeta(t *testing.T) {
+	meta := map[string]string{
+		"author":               "Queen",
+		"year":                 "1975",
+		models.TrackMetaKey(7): "11",
+		models.DiscMetaKey(7):  "1",
+	}
+
+	assert.Equal(t, map[string]string{
+		models.TrackMetaKey(7): "11",
+		models.DiscMetaKey(7):  "1",
+	}, positionMeta(meta))
+}
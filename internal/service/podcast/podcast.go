@@ -0,0 +1,470 @@
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/registry"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// How often due feeds are looked for.
+	checkInterval = 5 * time.Minute
+)
+
+type podcast struct {
+	log      *slog.Logger
+	interval time.Duration
+	feeds    Feeds
+	library  Library
+	jobs     Jobs
+	schedule Schedule
+	notify   models.PodcastNotify
+
+	// Marked subscriptions are being checked.
+	subs *registry.Registry[models.PodcastSub]
+}
+
+type Feeds interface {
+	Episodes(ctx context.Context, url string) (models.AlbumDownloadRes, error)
+}
+
+type Library interface {
+	PrepareUpload(ctx context.Context, id int64, values []models.MediaConfig) error
+	Media(ctx context.Context, id int64, mediaId int64) (models.MediaConfig, error)
+}
+
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind models.JobKind, title string, items []models.JobItem, notify models.JobNotify) (models.Job, error)
+}
+
+type Schedule interface {
+	NewSegment(ctx context.Context, id int64, segm models.Segment) error
+}
+
+// New returns service checking
+// podcast feeds every interval.
+func New(
+	log *slog.Logger,
+	feeds Feeds,
+	library Library,
+	jobs Jobs,
+	schedule Schedule,
+	file string,
+	interval time.Duration,
+) *podcast {
+	subs, err := registry.New(file, func(sub *models.PodcastSub) int64 {
+		return sub.ID
+	}, copySub)
+	if err != nil {
+		log.Error(
+			"failed to load podcast subscriptions",
+			slog.String("op", "podcast.New"),
+			sl.Err(err),
+		)
+	}
+
+	return &podcast{
+		log:      log,
+		interval: interval,
+		feeds:    feeds,
+		library:  library,
+		jobs:     jobs,
+		schedule: schedule,
+		subs:     subs,
+	}
+}
+
+// SetNotify sets function receiving
+// summaries of episodes ingestion.
+func (p *podcast) SetNotify(notify models.PodcastNotify) {
+	p.notify = notify
+}
+
+// Start runs feed checks in background.
+func (p *podcast) Start() {
+	p.subs.Start(checkInterval, p.checkDue)
+}
+
+// Stop stops background checks.
+func (p *podcast) Stop() {
+	p.subs.Stop()
+}
+
+// List returns podcast subscriptions of user.
+func (p *podcast) List(ctx context.Context, id int64) ([]models.PodcastSub, error) {
+	p.subs.Lock()
+	defer p.subs.Unlock()
+
+	res := make([]models.PodcastSub, 0)
+	for _, sub := range p.subs.All() {
+		if sub.UserId == id {
+			res = append(res, copySub(sub))
+		}
+	}
+	slices.SortFunc(res, func(a, b models.PodcastSub) int {
+		return int(a.ID - b.ID)
+	})
+
+	return res, nil
+}
+
+// Subscribe subscribes podcast tag to feed.
+// Feed title is used if podcast is empty.
+// Only the newest episode is imported,
+// older ones are considered as seen.
+func (p *podcast) Subscribe(ctx context.Context, id int64, url string, podcastName string) (models.PodcastSub, error) {
+	const op = "podcast.Subscribe"
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("url", url),
+	)
+
+	p.subs.Lock()
+	exists := slices.ContainsFunc(p.userSubsLocked(id), func(sub *models.PodcastSub) bool {
+		return sub.URL == url
+	})
+	p.subs.Unlock()
+	if exists {
+		return models.PodcastSub{}, service.ErrAlreadySubscribed
+	}
+
+	feed, err := p.feeds.Episodes(ctx, url)
+	if err != nil {
+		log.Error("failed to get feed", sl.Err(err))
+		if errors.Is(err, client.ErrFeedNotFound) || errors.Is(err, client.ErrInvalidFeed) {
+			return models.PodcastSub{}, service.ErrInvalidLink
+		}
+		return models.PodcastSub{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	podcastName = strings.TrimSpace(podcastName)
+	if podcastName == "" {
+		podcastName = feed.Name
+	}
+
+	seen := make([]string, 0, len(feed.Values))
+	for i, conf := range feed.Values {
+		if i == len(feed.Values)-1 {
+			break
+		}
+		seen = append(seen, conf.External.ID)
+	}
+
+	p.subs.Lock()
+	sub := &models.PodcastSub{
+		ID:      p.subs.NextId(),
+		UserId:  id,
+		URL:     url,
+		Podcast: podcastName,
+		Seen:    seen,
+	}
+	p.subs.Put(sub)
+	p.subs.Mark(sub.ID)
+	res := copySub(sub)
+	p.subs.Unlock()
+
+	if err := p.subs.Dump(); err != nil {
+		log.Error("failed to dump podcast subscriptions", sl.Err(err))
+	}
+
+	log.Info("podcast is subscribed", slog.Int64("subId", sub.ID), slog.String("podcast", podcastName))
+
+	go p.ingest(res.ID, feed, true)
+
+	return res, nil
+}
+
+// Unsubscribe removes subscription.
+// Imported episodes are kept.
+func (p *podcast) Unsubscribe(ctx context.Context, id int64, subId int64) error {
+	const op = "podcast.Unsubscribe"
+
+	p.subs.Lock()
+	sub, ok := p.subs.Get(subId)
+	if !ok || sub.UserId != id {
+		p.subs.Unlock()
+		return service.ErrPodcastNotFound
+	}
+	p.subs.Delete(subId)
+	p.subs.Unlock()
+
+	if err := p.subs.Dump(); err != nil {
+		p.log.Error("failed to dump podcast subscriptions", slog.String("op", op), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetSlot sets weekly slot newest episode
+// is queued at. Nil slot disables queueing.
+func (p *podcast) SetSlot(ctx context.Context, id int64, subId int64, slot *models.WeeklySlot) (models.PodcastSub, error) {
+	const op = "podcast.SetSlot"
+
+	p.subs.Lock()
+	sub, ok := p.subs.Get(subId)
+	if !ok || sub.UserId != id {
+		p.subs.Unlock()
+		return models.PodcastSub{}, service.ErrPodcastNotFound
+	}
+	sub.Slot = slot
+	res := copySub(sub)
+	p.subs.Unlock()
+
+	if err := p.subs.Dump(); err != nil {
+		p.log.Error("failed to dump podcast subscriptions", slog.String("op", op), sl.Err(err))
+		return models.PodcastSub{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// Check starts feed check in
+// background. Summary is always sent.
+func (p *podcast) Check(ctx context.Context, id int64, subId int64) error {
+	p.subs.Lock()
+	sub, ok := p.subs.Get(subId)
+	if !ok || sub.UserId != id {
+		p.subs.Unlock()
+		return service.ErrPodcastNotFound
+	}
+	if !p.subs.Mark(subId) {
+		p.subs.Unlock()
+		return service.ErrCheckInProgress
+	}
+	p.subs.Unlock()
+
+	go p.check(subId, true)
+
+	return nil
+}
+
+// checkDue starts check of feeds
+// not checked during interval.
+func (p *podcast) checkDue() {
+	p.subs.Lock()
+	due := make([]int64, 0)
+	for id, sub := range p.subs.All() {
+		if time.Since(sub.LastCheck) >= p.interval && p.subs.Mark(id) {
+			due = append(due, id)
+		}
+	}
+	p.subs.Unlock()
+
+	for _, id := range due {
+		p.check(id, false)
+	}
+}
+
+// check requests feed and ingests new episodes.
+// Subscription must be marked as checking.
+func (p *podcast) check(subId int64, manual bool) {
+	const op = "podcast.check"
+
+	p.subs.Lock()
+	sub, ok := p.subs.Get(subId)
+	if !ok {
+		p.subs.Unmark(subId)
+		p.subs.Unlock()
+		return
+	}
+	url := sub.URL
+	p.subs.Unlock()
+
+	feed, err := p.feeds.Episodes(p.subs.Context(), url)
+	if err != nil {
+		p.log.Error(
+			"failed to get feed",
+			slog.String("op", op),
+			slog.Int64("subId", subId),
+			sl.Err(err),
+		)
+		p.finish(subId, nil, err, true)
+		return
+	}
+
+	p.ingest(subId, feed, manual)
+}
+
+// ingest uploads episodes not seen yet.
+// Episode is marked as seen only when it
+// is uploaded, so failed ones are retried
+// with the next check.
+func (p *podcast) ingest(subId int64, feed models.AlbumDownloadRes, manual bool) {
+	const op = "podcast.ingest"
+
+	p.subs.Lock()
+	sub, ok := p.subs.Get(subId)
+	if !ok {
+		p.subs.Unmark(subId)
+		p.subs.Unlock()
+		return
+	}
+	userId := sub.UserId
+	podcastName := sub.Podcast
+	seen := slices.Clone(sub.Seen)
+	p.subs.Unlock()
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+		slog.Int64("subId", subId),
+	)
+
+	newValues := make([]models.MediaConfig, 0)
+	for _, conf := range feed.Values {
+		if slices.Contains(seen, conf.External.ID) {
+			continue
+		}
+		conf.Podcasts = []string{podcastName}
+		newValues = append(newValues, conf)
+	}
+
+	if len(newValues) == 0 {
+		p.finish(subId, nil, nil, manual)
+		return
+	}
+
+	if err := p.library.PrepareUpload(p.subs.Context(), userId, newValues); err != nil {
+		log.Error("failed to prepare upload", sl.Err(err))
+		p.finish(subId, nil, err, true)
+		return
+	}
+
+	items := make([]models.JobItem, 0, len(newValues))
+	for _, conf := range newValues {
+		items = append(items, models.JobItem{Conf: conf})
+	}
+
+	title := fmt.Sprintf("Выпуски подкаста \"%s\"", podcastName)
+	if _, err := p.jobs.Submit(p.subs.Context(), userId, models.JobUpload, title, items, func(job models.Job) {
+		if job.Status.Active() {
+			return
+		}
+		p.finish(subId, &job, nil, true)
+	}); err != nil {
+		log.Error("failed to submit job", sl.Err(err))
+		p.finish(subId, nil, err, true)
+		return
+	}
+
+	log.Info("new episodes are submitted", slog.Int("count", len(items)))
+}
+
+// finish marks uploaded episodes as seen,
+// queues the newest one if slot is set,
+// unmarks subscription and sends summary.
+func (p *podcast) finish(subId int64, job *models.Job, checkErr error, notify bool) {
+	const op = "podcast.finish"
+
+	log := p.log.With(
+		slog.String("op", op),
+		slog.Int64("subId", subId),
+	)
+
+	p.subs.Lock()
+	p.subs.Unmark(subId)
+	sub, ok := p.subs.Get(subId)
+	if !ok {
+		p.subs.Unlock()
+		return
+	}
+
+	sub.LastCheck = time.Now()
+	sub.LastErr = ""
+	if checkErr != nil {
+		sub.LastErr = checkErr.Error()
+	}
+
+	var newest int64
+	if job != nil {
+		for _, item := range job.Items {
+			if !item.Status.Uploaded() {
+				continue
+			}
+			sub.Seen = append(sub.Seen, item.Conf.External.ID)
+			newest = item.MediaId
+		}
+	}
+
+	summary := models.PodcastSummary{
+		Sub: copySub(sub),
+		Job: job,
+		Err: checkErr,
+	}
+	p.subs.Unlock()
+
+	if err := p.subs.Dump(); err != nil {
+		log.Error("failed to dump podcast subscriptions", sl.Err(err))
+	}
+
+	if newest != 0 && summary.Sub.Slot != nil {
+		start, err := p.queue(p.subs.Context(), summary.Sub, newest)
+		if err != nil {
+			log.Error("failed to queue episode", sl.Err(err))
+		}
+		summary.Queued = start
+	}
+
+	if notify && p.notify != nil {
+		p.notify(summary)
+	}
+}
+
+// queue creates protected segment with
+// episode at the nearest slot start.
+func (p *podcast) queue(ctx context.Context, sub models.PodcastSub, mediaId int64) (time.Time, error) {
+	const op = "podcast.queue"
+
+	media, err := p.library.Media(ctx, sub.UserId, mediaId)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	start := sub.Slot.Next(time.Now())
+	if err := p.schedule.NewSegment(ctx, sub.UserId, models.Segment{
+		Media:     media.ToMedia(),
+		Start:     start,
+		BeginCut:  0,
+		StopCut:   media.Duration,
+		Protected: true,
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return start, nil
+}
+
+// userSubsLocked returns subscriptions of user.
+// Must be called with locked subs.
+func (p *podcast) userSubsLocked(id int64) []*models.PodcastSub {
+	res := make([]*models.PodcastSub, 0)
+	for _, sub := range p.subs.All() {
+		if sub.UserId == id {
+			res = append(res, sub)
+		}
+	}
+	return res
+}
+
+func copySub(sub *models.PodcastSub) models.PodcastSub {
+	res := *sub
+	res.Seen = slices.Clone(sub.Seen)
+	if sub.Slot != nil {
+		slot := *sub.Slot
+		res.Slot = &slot
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+package podcast
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	feedCl "github.com/GintGld/fizteh-radio-bot/internal/client/feed"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/provider"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/servicetest"
+)
+
+const (
+	userId  = 1
+	podName = "Физтех подкаст"
+)
+
+// feedServer serves RSS feed
+// with episodes set by test.
+type feedServer struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	episodes []string
+	// Changes links to audio files.
+	mirror string
+}
+
+func newFeedServer(t *testing.T, episodes ...string) *feedServer {
+	s := &feedServer{episodes: episodes}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *feedServer) serve(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel>`)
+	b.WriteString(`<title>Подкаст</title><itunes:author>Редакция</itunes:author>`)
+	// Newest episode goes first,
+	// all are published the same day.
+	for i := len(s.episodes) - 1; i >= 0; i-- {
+		date := time.Date(2024, 1, 1, 10+i, 0, 0, 0, time.UTC)
+		fmt.Fprintf(&b, `<item><guid>%[1]s</guid><title>%[1]s</title>`, s.episodes[i])
+		fmt.Fprintf(&b, `<description><![CDATA[<p>О выпуске %s</p>]]></description>`, s.episodes[i])
+		fmt.Fprintf(&b, `<pubDate>%s</pubDate><itunes:duration>12:30</itunes:duration>`, date.Format(time.RFC1123Z))
+		fmt.Fprintf(&b, `<enclosure url="%s%s/%s.mp3" type="audio/mpeg"/></item>`, s.URL, s.mirror, s.episodes[i])
+	}
+	b.WriteString(`</channel></rss>`)
+
+	w.Header().Set("Content-Type", "application/rss+xml")
+	io.WriteString(w, b.String())
+}
+
+func (s *feedServer) move(mirror string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.mirror = mirror
+}
+
+func (s *feedServer) publish(episode string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.episodes = append(s.episodes, episode)
+}
+
+type fakeSchedule struct {
+	segments []models.Segment
+}
+
+func (s *fakeSchedule) NewSegment(_ context.Context, _ int64, segm models.Segment) error {
+	s.segments = append(s.segments, segm)
+	return nil
+}
+
+func TestIngest(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	srv := newFeedServer(t, "first", "second")
+	lib := &servicetest.Library{}
+	jobs := &servicetest.Jobs{Library: lib}
+	sch := &fakeSchedule{}
+
+	p := New(
+		log,
+		provider.NewFeed(log, feedCl.New(), nil),
+		lib,
+		jobs,
+		sch,
+		filepath.Join(t.TempDir(), "podcasts.json"),
+		time.Hour,
+	)
+
+	summaries := make(chan models.PodcastSummary, 1)
+	p.SetNotify(func(summary models.PodcastSummary) {
+		summaries <- summary
+	})
+
+	sub, err := p.Subscribe(context.Background(), userId, srv.URL+"/feed.rss", podName)
+	require.NoError(t, err)
+
+	// Only the newest episode is imported.
+	summary := <-summaries
+	require.NotNil(t, summary.Job)
+	require.Len(t, jobs.Submitted[0], 1)
+
+	conf := jobs.Submitted[0][0].Conf
+	assert.Equal(t, "second", conf.Name)
+	assert.Equal(t, "Редакция", conf.Author)
+	assert.Equal(t, models.Podcast, conf.Format)
+	assert.Equal(t, []string{podName}, conf.Podcasts)
+	assert.Equal(t, "2024-01-01", conf.Meta["date"])
+	assert.Equal(t, "О выпуске second", conf.Meta["description"])
+	assert.Equal(t, 12*time.Minute+30*time.Second, conf.Duration)
+	assert.Equal(t, "second", conf.External.ID)
+	assert.Equal(t, srv.URL+"/second.mp3", conf.External.URL)
+	assert.Len(t, summary.Sub.Seen, 2)
+	assert.True(t, summary.Queued.IsZero())
+
+	slot := models.WeeklySlot{Weekday: time.Monday, Hour: 19}
+	_, err = p.SetSlot(context.Background(), userId, sub.ID, &slot)
+	require.NoError(t, err)
+
+	srv.publish("third")
+	srv.publish("fourth")
+
+	require.NoError(t, p.Check(context.Background(), userId, sub.ID))
+
+	summary = <-summaries
+	require.Len(t, jobs.Submitted, 2)
+	require.Len(t, jobs.Submitted[1], 2)
+	assert.Equal(t, "third", jobs.Submitted[1][0].Conf.Name)
+	assert.Equal(t, "fourth", jobs.Submitted[1][1].Conf.Name)
+
+	// Newest episode is queued at the slot.
+	require.Len(t, sch.segments, 1)
+	assert.Equal(t, "fourth", sch.segments[0].Media.Name)
+	assert.True(t, sch.segments[0].Protected)
+	assert.Equal(t, summary.Queued, sch.segments[0].Start)
+	assert.Equal(t, time.Monday, summary.Queued.Add(models.TimeZone).Weekday())
+
+	// Nothing new on the next check.
+	require.NoError(t, p.Check(context.Background(), userId, sub.ID))
+
+	summary = <-summaries
+	assert.Nil(t, summary.Job)
+	assert.Len(t, jobs.Submitted, 2)
+
+	// Episodes are not imported again
+	// when audio files are moved.
+	srv.move("/mirror")
+	require.NoError(t, p.Check(context.Background(), userId, sub.ID))
+
+	summary = <-summaries
+	assert.Nil(t, summary.Job)
+	assert.Len(t, jobs.Submitted, 2)
+
+	subs, err := p.List(context.Background(), userId)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Len(t, subs[0].Seen, 4)
+}
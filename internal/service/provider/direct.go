@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/id3"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	// Stable ASCII name used in
+	// external refs and cache keys.
+	directName  = "direct"
+	directTitle = "прямая ссылка на mp3"
+
+	// Max size of downloaded file.
+	directMaxSize = 100 << 20
+)
+
+var (
+	directLink = regexp.MustCompile(`^https?://[^\s]+\.mp3(\?[^\s]*)?$`)
+)
+
+var (
+	ErrFileTooLarge = errors.New("file is too large")
+)
+
+type direct struct {
+	log    *slog.Logger
+	client *http.Client
+	tmpDir TmpDir
+	cache  FileCache
+}
+
+// NewDirect returns provider downloading
+// mp3 file by direct HTTP(S) link.
+func NewDirect(
+	log *slog.Logger,
+	tmpDir TmpDir,
+	cache FileCache,
+) *direct {
+	return &direct{
+		log:    log,
+		client: http.DefaultClient,
+		tmpDir: tmpDir,
+		cache:  cache,
+	}
+}
+
+func (d *direct) Name() string {
+	return directName
+}
+
+func (d *direct) Title() string {
+	return directTitle
+}
+
+func (d *direct) Match(link string) bool {
+	return directLink.MatchString(link)
+}
+
+// Resolve downloads file and reads its metadata.
+// If file has no ID3 tags, name and author are
+// taken from file name formatted as "author - name.mp3".
+func (d *direct) Resolve(ctx context.Context, link string) (models.LinkDownloadResult, error) {
+	const op = "direct.Resolve"
+
+	log := d.log.With(
+		slog.String("op", op),
+		slog.String("link", link),
+	)
+
+	filePath, err := d.download(ctx, link)
+	if err != nil {
+		log.Error("failed to download file", sl.Err(err))
+		return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	conf := models.MediaConfig{
+		Format:     models.Song,
+		SourcePath: filePath,
+		External: models.ExternalRef{
+			Provider: directName,
+			ID:       link,
+		},
+	}
+
+	author, name, found := strings.Cut(strings.TrimSuffix(fileName(link), ".mp3"), " - ")
+	if found {
+		conf.Name = strings.TrimSpace(name)
+		conf.Author = strings.TrimSpace(author)
+	}
+
+	if dur, err := audio.Duration(ctx, filePath); err != nil {
+		log.Warn("failed to get duration", sl.Err(err))
+	} else {
+		conf.Duration = dur
+	}
+
+	tags, err := id3.ReadFile(filePath)
+	if err != nil && !errors.Is(err, id3.ErrNoTags) {
+		log.Warn("failed to read tags", sl.Err(err))
+	}
+	if err == nil {
+		if tags.Title != "" {
+			conf.Name = tags.Title
+		}
+		if tags.Artist != "" {
+			conf.Author = tags.Artist
+		}
+		if tags.Album != "" {
+			conf.Albums = []models.Album{{
+				Name:   tags.Album,
+				Author: conf.Author,
+			}}
+		}
+	}
+
+	return models.LinkDownloadResult{
+		Type:      models.ResSong,
+		MediaConf: conf,
+	}, nil
+}
+
+// Fetch returns cached file or downloads it again.
+func (d *direct) Fetch(ctx context.Context, conf models.MediaConfig) (string, error) {
+	const op = "direct.Fetch"
+
+	filePath, err := d.download(ctx, conf.External.ID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return filePath, nil
+}
+
+// download downloads file to cache
+// unless it is already there.
+func (d *direct) download(ctx context.Context, link string) (string, error) {
+	key := dlcache.Key(directName, link)
+	if filePath, ok := d.cache.Get(key); ok {
+		return filePath, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > directMaxSize {
+		return "", ErrFileTooLarge
+	}
+
+	out, err := d.tmpDir.Create(directName, "direct-*.mp3", tmpTTL)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	n, err := io.Copy(d.tmpDir.Limit(out), io.LimitReader(resp.Body, directMaxSize+1))
+	if err == nil && n > directMaxSize {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		d.tmpDir.Release(out.Name())
+		return "", err
+	}
+	defer d.tmpDir.Release(out.Name())
+
+	return d.cache.Put(key, out.Name())
+}
+
+// fileName returns unescaped
+// last element of link path.
+func fileName(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return path.Base(u.Path)
+}
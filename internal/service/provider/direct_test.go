@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/tmpdir"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+var log = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// storages returns temporary directory
+// with quota and download cache.
+func storages(t *testing.T, quota int64) (*tmpdir.Manager, *dlcache.Cache) {
+	tmp, err := tmpdir.New(log, filepath.Join(t.TempDir(), "tmp"), quota)
+	require.NoError(t, err)
+	cache, err := dlcache.New(filepath.Join(t.TempDir(), "cache"), 0)
+	require.NoError(t, err)
+	return tmp, cache
+}
+
+// serveFiles serves files by path
+// and counts requests.
+func serveFiles(t *testing.T, files map[string][]byte) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// id3Tag returns ID3v2.3 tag
+// with title and artist.
+func id3Tag(title, artist string) []byte {
+	frame := func(id, text string) []byte {
+		b := []byte(id)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(text)+1))
+		b = append(b, 0, 0, 3)
+		return append(b, text...)
+	}
+
+	body := append(frame("TIT2", title), frame("TPE1", artist)...)
+	n := len(body)
+	b := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+	return append(b, body...)
+}
+
+func TestDirectMatch(t *testing.T) {
+	d := NewDirect(log, nil, nil)
+
+	assert.True(t, d.Match("https://example.com/music/track.mp3"))
+	assert.True(t, d.Match("http://example.com/track.mp3?token=abc"))
+	assert.False(t, d.Match("https://example.com/track.ogg"))
+	assert.False(t, d.Match("ftp://example.com/track.mp3"))
+	assert.False(t, d.Match("https://example.com/my track.mp3"))
+}
+
+func TestDirectResolve(t *testing.T) {
+	srv, requests := serveFiles(t, map[string][]byte{
+		"/Кино - Звезда по имени Солнце.mp3": []byte("audio"),
+		"/tagged.mp3": append(id3Tag("Кукушка", "Кино"), "audio"...),
+	})
+
+	tmp, cache := storages(t, 0)
+	d := NewDirect(log, tmp, cache)
+
+	link := srv.URL + "/%D0%9A%D0%B8%D0%BD%D0%BE%20-%20%D0%97%D0%B2%D0%B5%D0%B7%D0%B4%D0%B0%20%D0%BF%D0%BE%20%D0%B8%D0%BC%D0%B5%D0%BD%D0%B8%20%D0%A1%D0%BE%D0%BB%D0%BD%D1%86%D0%B5.mp3"
+	res, err := d.Resolve(context.Background(), link)
+	require.NoError(t, err)
+	assert.Equal(t, models.ResSong, res.Type)
+	// Name is taken from file name.
+	assert.Equal(t, "Звезда по имени Солнце", res.MediaConf.Name)
+	assert.Equal(t, "Кино", res.MediaConf.Author)
+	assert.Equal(t, models.ExternalRef{Provider: "direct", ID: link}, res.MediaConf.External)
+	assert.FileExists(t, res.MediaConf.SourcePath)
+
+	// Downloaded file is cached by
+	// provider name and link.
+	filePath, err := d.Fetch(context.Background(), res.MediaConf)
+	require.NoError(t, err)
+	assert.Equal(t, res.MediaConf.SourcePath, filePath)
+	assert.Equal(t, int32(1), requests.Load())
+	_, ok := cache.Get(dlcache.Key("direct", link))
+	assert.True(t, ok)
+
+	// Tags are preferred to file name.
+	res, err = d.Resolve(context.Background(), srv.URL+"/tagged.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, "Кукушка", res.MediaConf.Name)
+	assert.Equal(t, "Кино", res.MediaConf.Author)
+
+	// Temporary files are released.
+	assert.Zero(t, tmp.Usage().Files)
+}
+
+func TestDirectFetchErrors(t *testing.T) {
+	srv, _ := serveFiles(t, map[string][]byte{
+		// Quota is checked once per megabyte.
+		"/large.mp3": make([]byte, 2<<20),
+	})
+
+	tmp, cache := storages(t, 0)
+	d := NewDirect(log, tmp, cache)
+
+	_, err := d.Fetch(context.Background(), models.MediaConfig{
+		External: models.ExternalRef{Provider: directName, ID: srv.URL + "/missing.mp3"},
+	})
+	assert.ErrorContains(t, err, "unexpected status 404")
+
+	_, err = d.Resolve(context.Background(), srv.URL+"/missing.mp3")
+	assert.Error(t, err)
+
+	// Download is stopped by quota
+	// of temporary directory.
+	tmp, cache = storages(t, 16)
+	d = NewDirect(log, tmp, cache)
+
+	_, err = d.Fetch(context.Background(), models.MediaConfig{
+		External: models.ExternalRef{Provider: directName, ID: srv.URL + "/large.mp3"},
+	})
+	assert.ErrorIs(t, err, tmpdir.ErrQuotaExceeded)
+	assert.Zero(t, tmp.Usage().Files)
+	_, ok := cache.Get(dlcache.Key(directName, srv.URL+"/large.mp3"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	yamodels "github.com/GintGld/fizteh-radio-bot/internal/models/yandex"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// Bitrate of transcoded files in kbps.
+	stationBitrate = 320
+	// Downloaded file shorter than this part
+	// of expected duration is considered a preview.
+	previewRatio = 0.5
+	// Lifetime of temporary files.
+	tmpTTL = time.Hour
+)
+
+// FileCache stores downloaded files
+// by provider and track id.
+type FileCache interface {
+	Get(key string) (string, bool)
+	Download(ctx context.Context, key string, req *http.Request) (string, error)
+	Put(key string, src string) (string, error)
+	Remove(key string) error
+}
+
+// TmpDir creates temporary files
+// removed after TTL.
+type TmpDir interface {
+	Create(owner, pattern string, ttl time.Duration) (*os.File, error)
+	Release(path string)
+	Limit(w io.Writer) io.Writer
+}
+
+// DownloadPolicy defines which
+// download option is preferred.
+type DownloadPolicy struct {
+	// Accepted codecs, most preferred first.
+	Codecs []string
+	// Max bitrate in kbps, 0 means no limit.
+	MaxBitrate float64
+	// If previews may be downloaded
+	// when full track is not available.
+	AllowPreview bool
+}
+
+var DefaultDownloadPolicy = DownloadPolicy{
+	Codecs:     []string{yamodels.CodecMP3, yamodels.CodecAAC},
+	MaxBitrate: 320,
+}
+
+// Choose returns the best download option.
+// Options are ordered by codec preference,
+// full tracks go before previews,
+// higher bitrate (below limit) goes first.
+func (p DownloadPolicy) Choose(options []yamodels.DownloadInfo) (yamodels.DownloadInfo, error) {
+	options = slices.DeleteFunc(slices.Clone(options), func(di yamodels.DownloadInfo) bool {
+		return !slices.Contains(p.Codecs, string(di.Codec)) ||
+			(p.MaxBitrate > 0 && di.Bitrate > p.MaxBitrate)
+	})
+	if len(options) == 0 {
+		return yamodels.DownloadInfo{}, service.ErrNoDownloadOption
+	}
+
+	full := slices.DeleteFunc(slices.Clone(options), func(di yamodels.DownloadInfo) bool {
+		return di.Preview
+	})
+	if len(full) == 0 {
+		if !p.AllowPreview {
+			return yamodels.DownloadInfo{}, service.ErrPreviewOnly
+		}
+		full = options
+	}
+
+	slices.SortStableFunc(full, func(a, b yamodels.DownloadInfo) int {
+		if c := cmp.Compare(
+			slices.Index(p.Codecs, string(a.Codec)),
+			slices.Index(p.Codecs, string(b.Codec)),
+		); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Bitrate, a.Bitrate)
+	})
+
+	return full[0], nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	yamodels "github.com/GintGld/fizteh-radio-bot/internal/models/yandex"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+func TestDownloadPolicyChoose(t *testing.T) {
+	var (
+		mp3Low     = yamodels.DownloadInfo{Codec: yamodels.CodecMP3, Bitrate: 128}
+		mp3High    = yamodels.DownloadInfo{Codec: yamodels.CodecMP3, Bitrate: 320}
+		aacHigh    = yamodels.DownloadInfo{Codec: yamodels.CodecAAC, Bitrate: 192}
+		aacLow     = yamodels.DownloadInfo{Codec: yamodels.CodecAAC, Bitrate: 64}
+		mp3Preview = yamodels.DownloadInfo{Codec: yamodels.CodecMP3, Bitrate: 128, Preview: true}
+	)
+
+	testCases := []struct {
+		desc        string
+		policy      DownloadPolicy
+		options     []yamodels.DownloadInfo
+		expected    yamodels.DownloadInfo
+		expectedErr error
+	}{
+		{
+			desc:     "max mp3 bitrate",
+			policy:   DefaultDownloadPolicy,
+			options:  []yamodels.DownloadInfo{aacHigh, mp3Low, mp3High},
+			expected: mp3High,
+		},
+		{
+			desc:     "bitrate limit",
+			policy:   DownloadPolicy{Codecs: []string{"mp3"}, MaxBitrate: 192},
+			options:  []yamodels.DownloadInfo{mp3Low, mp3High},
+			expected: mp3Low,
+		},
+		{
+			desc:     "aac only",
+			policy:   DefaultDownloadPolicy,
+			options:  []yamodels.DownloadInfo{aacLow, aacHigh},
+			expected: aacHigh,
+		},
+		{
+			desc:     "preferred aac",
+			policy:   DownloadPolicy{Codecs: []string{"aac", "mp3"}},
+			options:  []yamodels.DownloadInfo{mp3High, aacLow},
+			expected: aacLow,
+		},
+		{
+			desc:        "codec not accepted",
+			policy:      DownloadPolicy{Codecs: []string{"mp3"}},
+			options:     []yamodels.DownloadInfo{aacHigh},
+			expectedErr: service.ErrNoDownloadOption,
+		},
+		{
+			desc:     "full track before preview",
+			policy:   DownloadPolicy{Codecs: []string{"mp3", "aac"}, AllowPreview: true},
+			options:  []yamodels.DownloadInfo{mp3Preview, aacLow},
+			expected: aacLow,
+		},
+		{
+			desc:        "preview rejected",
+			policy:      DefaultDownloadPolicy,
+			options:     []yamodels.DownloadInfo{mp3Preview},
+			expectedErr: service.ErrPreviewOnly,
+		},
+		{
+			desc:     "preview allowed",
+			policy:   DownloadPolicy{Codecs: []string{"mp3"}, AllowPreview: true},
+			options:  []yamodels.DownloadInfo{mp3Preview},
+			expected: mp3Preview,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			res, err := tC.policy.Choose(tC.options)
+			if tC.expectedErr != nil {
+				assert.ErrorIs(t, err, tC.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, res)
+		})
+	}
+}
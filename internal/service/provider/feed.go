@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+const (
+	feedName = "RSS-лента подкаста"
+
+	// Max length of episode
+	// description stored in meta.
+	maxDescriptionLen = 1000
+)
+
+var (
+	feedLink = regexp.MustCompile(`^https?://[^\s]+(\.rss|\.xml|\.atom|/feed/?|/rss/?)(\?[^\s]*)?$`)
+)
+
+type feed struct {
+	log        *slog.Logger
+	feedClient FeedClient
+	cache      FileCache
+}
+
+type FeedClient interface {
+	Feed(ctx context.Context, url string) (models.Feed, error)
+}
+
+// NewFeed returns provider importing
+// episodes of podcast RSS or Atom feed.
+func NewFeed(
+	log *slog.Logger,
+	feedClient FeedClient,
+	cache FileCache,
+) *feed {
+	return &feed{
+		log:        log,
+		feedClient: feedClient,
+		cache:      cache,
+	}
+}
+
+func (f *feed) Name() string {
+	return feedName
+}
+
+func (f *feed) Title() string {
+	return feedName
+}
+
+func (f *feed) Match(link string) bool {
+	return feedLink.MatchString(link)
+}
+
+// Resolve returns all episodes of podcast.
+func (f *feed) Resolve(ctx context.Context, link string) (models.LinkDownloadResult, error) {
+	const op = "feed.Resolve"
+
+	album, err := f.Episodes(ctx, link)
+	if err != nil {
+		return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.LinkDownloadResult{
+		Type:  models.ResAlbum,
+		Album: album,
+	}, nil
+}
+
+// Episodes returns podcast episodes in
+// chronological order tagged with feed title.
+func (f *feed) Episodes(ctx context.Context, link string) (models.AlbumDownloadRes, error) {
+	const op = "feed.Episodes"
+
+	log := f.log.With(
+		slog.String("op", op),
+		slog.String("link", link),
+	)
+
+	podcast, err := f.feedClient.Feed(ctx, link)
+	if err != nil {
+		log.Error("failed to get feed", sl.Err(err))
+		return models.AlbumDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	author := podcast.Author
+	if author == "" {
+		author = podcast.Title
+	}
+
+	// Feeds list episodes newest first.
+	episodes := slices.Clone(podcast.Episodes)
+	slices.SortStableFunc(episodes, func(a, b models.FeedEpisode) int {
+		return a.Published.Compare(b.Published)
+	})
+
+	values := make([]models.MediaConfig, 0, len(episodes))
+	for _, ep := range episodes {
+		conf := models.MediaConfig{
+			Name:     ep.Title,
+			Author:   author,
+			Duration: ep.Duration,
+			Format:   models.Podcast,
+			Podcasts: []string{podcast.Title},
+			Meta:     make(map[string]string),
+			External: models.ExternalRef{
+				Provider: feedName,
+				ID:       ep.GUID,
+				URL:      ep.URL,
+			},
+		}
+		if !ep.Published.IsZero() {
+			conf.Meta["date"] = ep.Published.Format("2006-01-02")
+		}
+		if ep.Description != "" {
+			conf.Meta["description"] = truncate(ep.Description, maxDescriptionLen)
+		}
+		values = append(values, conf)
+	}
+
+	return models.AlbumDownloadRes{
+		Name:   podcast.Title,
+		Author: author,
+		Format: models.Podcast,
+		Values: values,
+	}, nil
+}
+
+// Fetch downloads episode audio.
+// Non-mp3 files are transcoded.
+func (f *feed) Fetch(ctx context.Context, conf models.MediaConfig) (string, error) {
+	const op = "feed.Fetch"
+
+	log := f.log.With(
+		slog.String("op", op),
+		slog.String("url", conf.External.URL),
+	)
+
+	key := dlcache.Key(feedName, conf.External.URL)
+	if filePath, ok := f.cache.Get(key); ok {
+		return filePath, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", conf.External.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if strings.EqualFold(path.Ext(req.URL.Path), ".mp3") {
+		filePath, err := f.cache.Download(ctx, key, req)
+		if err != nil {
+			log.Error("failed to download episode", sl.Err(err))
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		return filePath, nil
+	}
+
+	rawKey := dlcache.Key(feedName, conf.External.URL, "raw")
+	src, err := f.cache.Download(ctx, rawKey, req)
+	if err != nil {
+		log.Error("failed to download episode", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + "-transcoded.mp3"
+	if err := audio.Transcode(ctx, src, dst, stationBitrate); err != nil {
+		os.Remove(dst)
+		log.Error("failed to transcode episode", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	filePath, err := f.cache.Put(key, dst)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := f.cache.Remove(rawKey); err != nil {
+		log.Warn("failed to remove cached file", sl.Err(err))
+	}
+
+	return filePath, nil
+}
+
+// truncate cuts string to n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
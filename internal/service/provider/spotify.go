@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	spmodels "github.com/GintGld/fizteh-radio-bot/internal/models/spotify"
+)
+
+const (
+	spotifyName = "Spotify"
+
+	// Placeholders of audio command.
+	outputPlaceholder = "{output}"
+	queryPlaceholder  = "{query}"
+)
+
+var (
+	spLink = regexp.MustCompile(`^https://open\.spotify\.com/(intl-[a-z]+/)?(?P<type>track|album|playlist)/(?P<id>[A-Za-z0-9]+)`)
+)
+
+var (
+	ErrNoAudioCommand = errors.New("audio command is not set")
+)
+
+type spotify struct {
+	log      *slog.Logger
+	spClient SpotifyClient
+	audioCmd string
+	tmpDir   TmpDir
+	cache    FileCache
+}
+
+type SpotifyClient interface {
+	Track(ctx context.Context, id string) (spmodels.Track, error)
+	Album(ctx context.Context, id string) (spmodels.Album, error)
+	Playlist(ctx context.Context, id string) (spmodels.Playlist, error)
+}
+
+// NewSpotify returns provider resolving
+// metadata via Spotify API.
+// Spotify does not give audio, so it is
+// fetched by audioCmd, where {output} is
+// replaced with path to the result mp3 file
+// and {query} with "author - name".
+func NewSpotify(
+	log *slog.Logger,
+	spClient SpotifyClient,
+	audioCmd string,
+	tmpDir TmpDir,
+	cache FileCache,
+) *spotify {
+	return &spotify{
+		log:      log,
+		spClient: spClient,
+		audioCmd: audioCmd,
+		tmpDir:   tmpDir,
+		cache:    cache,
+	}
+}
+
+func (s *spotify) Name() string {
+	return spotifyName
+}
+
+func (s *spotify) Title() string {
+	return spotifyName
+}
+
+func (s *spotify) Match(link string) bool {
+	return spLink.MatchString(link)
+}
+
+// Resolve returns metadata of track, album or playlist.
+func (s *spotify) Resolve(ctx context.Context, link string) (models.LinkDownloadResult, error) {
+	const op = "spotify.Resolve"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	linkType, id := extractSpotifyInfo(link)
+
+	var res models.LinkDownloadResult
+
+	switch linkType {
+	case "track":
+		track, err := s.spClient.Track(ctx, id)
+		if err != nil {
+			log.Error("failed to get track info", slog.String("trackId", id), sl.Err(err))
+			return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res.Type = models.ResSong
+		res.MediaConf = s.mediaConf(track)
+	case "album":
+		album, err := s.spClient.Album(ctx, id)
+		if err != nil {
+			log.Error("failed to get album info", slog.String("albumId", id), sl.Err(err))
+			return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		values := make([]models.MediaConfig, 0, len(album.Tracks))
+		for _, track := range album.Tracks {
+			values = append(values, s.mediaConf(track))
+		}
+
+		res.Type = models.ResAlbum
+		res.Album = models.AlbumDownloadRes{
+			Name:   album.Name,
+			Author: spArtistName(album.Artists),
+			Values: values,
+		}
+	case "playlist":
+		playlist, err := s.spClient.Playlist(ctx, id)
+		if err != nil {
+			log.Error("failed to get playlist info", slog.String("playlistId", id), sl.Err(err))
+			return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		values := make([]models.MediaConfig, 0, len(playlist.Tracks))
+		for _, track := range playlist.Tracks {
+			conf := s.mediaConf(track)
+			conf.Playlists = []string{playlist.Name}
+			values = append(values, conf)
+		}
+
+		res.Type = models.ResPlaylist
+		res.Playlist = models.Playlist{
+			Name:   playlist.Name,
+			Values: values,
+		}
+	}
+
+	return res, nil
+}
+
+// Fetch runs audio command and
+// returns path to the result file.
+// Result is cached, so command
+// runs once per track.
+func (s *spotify) Fetch(ctx context.Context, conf models.MediaConfig) (string, error) {
+	const op = "spotify.Fetch"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("trackId", conf.External.ID),
+	)
+
+	key := dlcache.Key(spotifyName, conf.External.ID)
+	if filePath, ok := s.cache.Get(key); ok {
+		return filePath, nil
+	}
+
+	args := strings.Fields(s.audioCmd)
+	if len(args) == 0 {
+		return "", fmt.Errorf("%s: %w", op, ErrNoAudioCommand)
+	}
+
+	file, err := s.tmpDir.Create(spotifyName, "spotify-*.mp3", tmpTTL)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	file.Close()
+	// Command may refuse to overwrite existing file.
+	os.Remove(file.Name())
+
+	query := conf.Author + " - " + conf.Name
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, outputPlaceholder, file.Name())
+		arg = strings.ReplaceAll(arg, queryPlaceholder, query)
+		args[i] = arg
+	}
+
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		log.Error("audio command failed", slog.String("output", string(out)), sl.Err(err))
+		s.tmpDir.Release(file.Name())
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := os.Stat(file.Name()); err != nil {
+		log.Error("audio command produced no file", slog.String("output", string(out)))
+		s.tmpDir.Release(file.Name())
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer s.tmpDir.Release(file.Name())
+
+	filePath, err := s.cache.Put(key, file.Name())
+	if err != nil {
+		log.Error("failed to cache file", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return filePath, nil
+}
+
+func (s *spotify) mediaConf(track spmodels.Track) models.MediaConfig {
+	conf := models.MediaConfig{
+		Name:     track.Name,
+		Author:   spArtistName(track.Artists),
+		Duration: track.Duration(),
+		Format:   models.Song,
+		External: models.ExternalRef{
+			Provider: spotifyName,
+			ID:       track.Id,
+		},
+	}
+	if track.Album.Name != "" {
+		conf.Albums = []models.Album{{
+			Name:   track.Album.Name,
+			Author: spArtistName(track.Album.Artists),
+		}}
+	}
+	return conf
+}
+
+func spArtistName(artists []spmodels.Artist) string {
+	if len(artists) == 0 {
+		return ""
+	}
+	return artists[0].Name
+}
+
+func extractSpotifyInfo(url string) (linkType, id string) {
+	linkType = string(spLink.ExpandString([]byte{}, "$type", url, spLink.FindSubmatchIndex([]byte(url))))
+	id = string(spLink.ExpandString([]byte{}, "$id", url, spLink.FindSubmatchIndex([]byte(url))))
+
+	return
+}
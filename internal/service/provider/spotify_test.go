@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	spmodels "github.com/GintGld/fizteh-radio-bot/internal/models/spotify"
+)
+
+var errNotFound = errors.New("not found")
+
+type fakeSpotify struct {
+	tracks    map[string]spmodels.Track
+	albums    map[string]spmodels.Album
+	playlists map[string]spmodels.Playlist
+}
+
+func (f fakeSpotify) Track(_ context.Context, id string) (spmodels.Track, error) {
+	if t, ok := f.tracks[id]; ok {
+		return t, nil
+	}
+	return spmodels.Track{}, errNotFound
+}
+
+func (f fakeSpotify) Album(_ context.Context, id string) (spmodels.Album, error) {
+	if a, ok := f.albums[id]; ok {
+		return a, nil
+	}
+	return spmodels.Album{}, errNotFound
+}
+
+func (f fakeSpotify) Playlist(_ context.Context, id string) (spmodels.Playlist, error) {
+	if p, ok := f.playlists[id]; ok {
+		return p, nil
+	}
+	return spmodels.Playlist{}, errNotFound
+}
+
+func spTrack(id, name, artist, album string) spmodels.Track {
+	t := spmodels.Track{
+		Id:         id,
+		Name:       name,
+		DurationMs: 180000,
+		Artists:    []spmodels.Artist{{Name: artist}},
+	}
+	t.Album.Name = album
+	t.Album.Artists = []spmodels.Artist{{Name: artist}}
+	return t
+}
+
+func TestSpotifyMatch(t *testing.T) {
+	s := NewSpotify(log, nil, "", nil, nil)
+
+	assert.True(t, s.Match("https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC"))
+	assert.True(t, s.Match("https://open.spotify.com/intl-de/album/1ATL5GLyefJaxhQzSPVrLX?si=abc"))
+	assert.True(t, s.Match("https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M"))
+	assert.False(t, s.Match("https://open.spotify.com/artist/0OdUWJ0sBjDrqHygGUXeCF"))
+	assert.False(t, s.Match("https://music.yandex.ru/album/4867528"))
+
+	linkType, id := extractSpotifyInfo("https://open.spotify.com/intl-de/album/1ATL5GLyefJaxhQzSPVrLX?si=abc")
+	assert.Equal(t, "album", linkType)
+	assert.Equal(t, "1ATL5GLyefJaxhQzSPVrLX", id)
+}
+
+func TestSpotifyResolve(t *testing.T) {
+	first := spTrack("t1", "Кукушка", "Кино", "Чёрный альбом")
+	second := spTrack("t2", "Красно-жёлтые дни", "Кино", "Чёрный альбом")
+
+	s := NewSpotify(log, fakeSpotify{
+		tracks: map[string]spmodels.Track{"t1": first},
+		albums: map[string]spmodels.Album{"a1": {
+			Name:    "Чёрный альбом",
+			Artists: []spmodels.Artist{{Name: "Кино"}},
+			Tracks:  []spmodels.Track{first, second},
+		}},
+		playlists: map[string]spmodels.Playlist{"p1": {
+			Name:   "Рок",
+			Tracks: []spmodels.Track{second},
+		}},
+	}, "", nil, nil)
+
+	res, err := s.Resolve(context.Background(), "https://open.spotify.com/track/t1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ResSong, res.Type)
+	assert.Equal(t, models.MediaConfig{
+		Name:     "Кукушка",
+		Author:   "Кино",
+		Duration: first.Duration(),
+		Format:   models.Song,
+		Albums:   []models.Album{{Name: "Чёрный альбом", Author: "Кино"}},
+		External: models.ExternalRef{Provider: spotifyName, ID: "t1"},
+	}, res.MediaConf)
+
+	res, err = s.Resolve(context.Background(), "https://open.spotify.com/album/a1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ResAlbum, res.Type)
+	assert.Equal(t, "Чёрный альбом", res.Album.Name)
+	assert.Equal(t, "Кино", res.Album.Author)
+	require.Len(t, res.Album.Values, 2)
+	assert.Equal(t, "t2", res.Album.Values[1].External.ID)
+
+	res, err = s.Resolve(context.Background(), "https://open.spotify.com/playlist/p1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ResPlaylist, res.Type)
+	require.Len(t, res.Playlist.Values, 1)
+	assert.Equal(t, []string{"Рок"}, res.Playlist.Values[0].Playlists)
+
+	for _, link := range []string{
+		"https://open.spotify.com/track/missing",
+		"https://open.spotify.com/album/missing",
+		"https://open.spotify.com/playlist/missing",
+	} {
+		_, err := s.Resolve(context.Background(), link)
+		assert.ErrorIs(t, err, errNotFound, link)
+	}
+}
+
+func TestSpotifyFetch(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "source.mp3")
+	require.NoError(t, os.WriteFile(src, []byte("audio"), 0644))
+
+	conf := models.MediaConfig{
+		Name:     "Кукушка",
+		Author:   "Кино",
+		External: models.ExternalRef{Provider: spotifyName, ID: "t1"},
+	}
+
+	tmp, cache := storages(t, 0)
+	s := NewSpotify(log, fakeSpotify{}, "cp "+src+" {output}", tmp, cache)
+
+	filePath, err := s.Fetch(context.Background(), conf)
+	require.NoError(t, err)
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(data))
+	assert.Zero(t, tmp.Usage().Files)
+
+	// Command runs once per track.
+	s.audioCmd = "false"
+	cached, err := s.Fetch(context.Background(), conf)
+	require.NoError(t, err)
+	assert.Equal(t, filePath, cached)
+	_, ok := cache.Get(dlcache.Key(spotifyName, "t1"))
+	assert.True(t, ok)
+}
+
+func TestSpotifyFetchErrors(t *testing.T) {
+	conf := models.MediaConfig{
+		External: models.ExternalRef{Provider: spotifyName, ID: "t1"},
+	}
+
+	testCases := []struct {
+		desc     string
+		audioCmd string
+		err      error
+	}{
+		{
+			desc:     "no command",
+			audioCmd: " ",
+			err:      ErrNoAudioCommand,
+		},
+		{
+			desc:     "command failed",
+			audioCmd: "false {output}",
+		},
+		{
+			desc:     "no file produced",
+			audioCmd: "true {output} {query}",
+			err:      os.ErrNotExist,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tmp, cache := storages(t, 0)
+			s := NewSpotify(log, fakeSpotify{}, tC.audioCmd, tmp, cache)
+
+			_, err := s.Fetch(context.Background(), conf)
+			require.Error(t, err)
+			if tC.err != nil {
+				assert.ErrorIs(t, err, tC.err)
+			}
+			// Nothing is cached or left
+			// in temporary directory.
+			_, ok := cache.Get(dlcache.Key(spotifyName, "t1"))
+			assert.False(t, ok)
+			assert.Zero(t, tmp.Usage().Files)
+		})
+	}
+}
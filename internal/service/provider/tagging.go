@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+// GenreMap maps genres of external services
+// to names of station genres (genre tags on server).
+type GenreMap map[string]string
+
+// DefaultGenreMap contains Yandex Music genres.
+var DefaultGenreMap = GenreMap{
+	"pop":         models.Pop.Name,
+	"ruspop":      models.Pop.Name,
+	"foreignpop":  models.Pop.Name,
+	"kpop":        models.Pop.Name,
+	"jpop":        models.Pop.Name,
+	"estrada":     models.Pop.Name,
+	"disco":       models.Pop.Name,
+	"hiphop":      models.HipHop.Name,
+	"rnb":         models.HipHop.Name,
+	"rap":         models.Rap.Name,
+	"rusrap":      models.Rap.Name,
+	"foreignrap":  models.Rap.Name,
+	"rock":        models.Rock.Name,
+	"rusrock":     models.Rock.Name,
+	"alternative": models.Rock.Name,
+	"indie":       models.Rock.Name,
+	"metal":       models.Rock.Name,
+	"punk":        models.Rock.Name,
+	"postrock":    models.Rock.Name,
+	"jazz":        models.Jazz.Name,
+	"blues":       models.Jazz.Name,
+	"soul":        models.Jazz.Name,
+	"electronics": models.Electro.Name,
+	"dance":       models.Electro.Name,
+	"house":       models.Electro.Name,
+	"techno":      models.Electro.Name,
+	"dnb":         models.Electro.Name,
+	"dubstep":     models.Electro.Name,
+	"classical":   models.Instrumental.Name,
+	"soundtrack":  models.Instrumental.Name,
+	"ambient":     models.Instrumental.Name,
+	"lounge":      models.LoFi.Name,
+	"lofi":        models.LoFi.Name,
+}
+
+// Genres which imply language of lyrics.
+var genreLangs = map[string]models.Language{
+	"ruspop":  models.Russian,
+	"rusrap":  models.Russian,
+	"rusrock": models.Russian,
+	"estrada": models.Russian,
+	"bard":    models.Russian,
+	"kpop":    models.Korean,
+	"jpop":    models.Japanese,
+	"anime":   models.Japanese,
+}
+
+// With returns copy of map
+// overridden by other map.
+func (m GenreMap) With(other map[string]string) GenreMap {
+	res := make(GenreMap, len(m)+len(other))
+	for k, v := range m {
+		res[k] = v
+	}
+	for k, v := range other {
+		res[strings.ToLower(k)] = v
+	}
+	return res
+}
+
+// Genres returns station genres
+// corresponding to external ones.
+// Unknown genres are skipped, genres
+// missing on server are dropped later
+// (see models.LinkDownloadResult.KnownTags).
+func (m GenreMap) Genres(genres ...string) models.TagSet {
+	res := models.NewTagSet()
+
+	for _, g := range genres {
+		if name, ok := m[strings.ToLower(g)]; ok {
+			res.Add(name)
+		}
+	}
+
+	return res
+}
+
+// Languages infers language of lyrics by genre,
+// if it is not possible, by script of title.
+// Latin script is ambiguous, so it gives nothing.
+func Languages(genre, title string) models.TagSet {
+	res := models.NewTagSet()
+
+	lang, ok := genreLangs[strings.ToLower(genre)]
+	if !ok {
+		lang, ok = scriptLanguage(title)
+	}
+	if !ok {
+		return res
+	}
+
+	res.Add(lang.Name)
+
+	return res
+}
+
+// scriptLanguage returns language
+// of prevailing non-latin script.
+func scriptLanguage(text string) (models.Language, bool) {
+	var cyrillic, hangul, kana, han int
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		}
+	}
+
+	switch {
+	case cyrillic > 0 && cyrillic >= hangul+kana+han:
+		return models.Russian, true
+	case hangul > 0:
+		return models.Korean, true
+	// Japanese uses both kana and kanji.
+	case kana > 0:
+		return models.Japanese, true
+	case han > 0:
+		return models.Chinese, true
+	}
+
+	return models.Language{}, false
+}
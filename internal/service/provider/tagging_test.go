@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+)
+
+func TestGenres(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		genreMap GenreMap
+		genres   []string
+		expected []models.Genre
+	}{
+		{
+			desc:     "default",
+			genreMap: DefaultGenreMap,
+			genres:   []string{"rusrap"},
+			expected: []models.Genre{models.Rap},
+		},
+		{
+			desc:     "unknown",
+			genreMap: DefaultGenreMap,
+			genres:   []string{"folk"},
+		},
+		{
+			desc:     "overridden",
+			genreMap: DefaultGenreMap.With(map[string]string{"Folk": models.Rock.Name, "lounge": models.Jazz.Name}),
+			genres:   []string{"folk", "lounge"},
+			expected: []models.Genre{models.Rock, models.Jazz},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			expected := models.NewTagSet()
+			for _, g := range tC.expected {
+				expected.Add(g.Name)
+			}
+			assert.Equal(t, expected, tC.genreMap.Genres(tC.genres...))
+		})
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		genre    string
+		title    string
+		expected *models.Language
+	}{
+		{
+			desc:     "by genre",
+			genre:    "kpop",
+			title:    "Dynamite",
+			expected: &models.Korean,
+		},
+		{
+			desc:     "cyrillic",
+			genre:    "rock",
+			title:    "Группа крови",
+			expected: &models.Russian,
+		},
+		{
+			desc:     "japanese",
+			title:    "夜に駆ける",
+			expected: &models.Japanese,
+		},
+		{
+			desc:     "chinese",
+			title:    "月亮代表我的心",
+			expected: &models.Chinese,
+		},
+		{
+			desc:  "latin",
+			genre: "rock",
+			title: "Bohemian Rhapsody",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			expected := models.NewTagSet()
+			if tC.expected != nil {
+				expected.Add(tC.expected.Name)
+			}
+			assert.Equal(t, expected, Languages(tC.genre, tC.title))
+		})
+	}
+}
+
+func TestKnownTags(t *testing.T) {
+	tax := models.NewTaxonomy(models.TagList{
+		{ID: 1, Name: models.Rock.Name, Type: models.TagTypesAvail["genre"]},
+		{ID: 2, Name: models.Russian.Name, Type: models.TagTypesAvail["language"]},
+	})
+
+	rap := models.MediaConfig{
+		Genres:    DefaultGenreMap.Genres("rusrap"),
+		Languages: Languages("rusrap", "Рэп"),
+	}
+	rock := models.MediaConfig{
+		Genres:    DefaultGenreMap.Genres("rock"),
+		Languages: Languages("jpop", "夜に駆ける"),
+	}
+
+	res := models.LinkDownloadResult{
+		Type:  models.ResAlbum,
+		Album: models.AlbumDownloadRes{Values: []models.MediaConfig{rap, rock}},
+	}
+	res.KnownTags(tax)
+
+	// Rap and japanese are missing on server.
+	assert.Equal(t, models.NewTagSet(), res.Album.Values[0].Genres)
+	assert.Equal(t, models.NewTagSet(models.Russian.Name), res.Album.Values[0].Languages)
+	assert.Equal(t, models.NewTagSet(models.Rock.Name), res.Album.Values[1].Genres)
+	assert.Equal(t, models.NewTagSet(), res.Album.Values[1].Languages)
+}
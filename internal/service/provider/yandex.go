@@ -0,0 +1,665 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/client"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/audio"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/dlcache"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	yamodels "github.com/GintGld/fizteh-radio-bot/internal/models/yandex"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	yandexName = "Яндекс Музыка"
+
+	// Links used to import search results.
+	yaTrackLink  = "https://music.yandex.ru/album/%d/track/%s"
+	yaAlbumLink  = "https://music.yandex.ru/album/%d"
+	yaArtistLink = "https://music.yandex.ru/artist/%s"
+
+	// Artist import modes.
+	artistTracks = "tracks"
+	artistAlbums = "albums"
+
+	// Number of imported popular artist tracks.
+	yaArtistTopTracks = 20
+	// Max number of imported artist albums.
+	yaArtistMaxAlbums = 50
+
+	// Max number of search results of each type.
+	yaSearchTracks = 10
+	yaSearchAlbums = 5
+)
+
+var (
+	yaSong     = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/album/(?P<album>\d+)/track/(?P<track>\d+)`)
+	yaAlbum    = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/album/(?P<album>\d+)`)
+	yaPlaylist = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/users/(?P<user>[^\/]+)/playlists/(?P<kind>\d+)`)
+	yaArtist   = regexp.MustCompile(`^https://music\.yandex\.(ru|com)/artist/(?P<artist>\d+)(/(?P<mode>tracks|albums))?`)
+)
+
+type yandex struct {
+	log      *slog.Logger
+	yaClient YaClient
+	genres   GenreMap
+	policy   DownloadPolicy
+	cache    FileCache
+
+	// Tracks downloaded as marked previews.
+	mutex    sync.Mutex
+	previews map[string]bool
+}
+
+type YaClient interface {
+	Album(ctx context.Context, id string) (yamodels.Album, error)
+	Playlist(ctx context.Context, user string, id string) (yamodels.Playlist, error)
+	DownloadInfo(ctx context.Context, id string) ([]yamodels.DownloadInfo, error)
+	DownloadTrack(ctx context.Context, key string, url string) (string, error)
+	DirectLink(ctx context.Context, url string, codec yamodels.CodecType) (string, error)
+	Search(ctx context.Context, text string, page int) (yamodels.SearchResult, error)
+	ArtistInfo(ctx context.Context, id string) (yamodels.ArtistInfo, error)
+	ArtistTracks(ctx context.Context, id string, count int) ([]yamodels.Track, error)
+	ArtistAlbums(ctx context.Context, id string, count int) ([]yamodels.Album, error)
+}
+
+// NewYandex returns Yandex Music provider.
+// Genres of tracks are mapped to station genres
+// by genres map. Download option (codec, bitrate)
+// is chosen by policy. Downloaded tracks
+// are kept in cache shared with the client.
+func NewYandex(
+	log *slog.Logger,
+	yaClient YaClient,
+	genres GenreMap,
+	policy DownloadPolicy,
+	cache FileCache,
+) *yandex {
+	return &yandex{
+		log:      log,
+		yaClient: yaClient,
+		genres:   genres,
+		policy:   policy,
+		cache:    cache,
+		previews: make(map[string]bool),
+	}
+}
+
+func (y *yandex) Name() string {
+	return yandexName
+}
+
+func (y *yandex) Title() string {
+	return yandexName
+}
+
+func (y *yandex) Match(link string) bool {
+	return yaSong.MatchString(link) || yaAlbum.MatchString(link) || yaPlaylist.MatchString(link) || yaArtist.MatchString(link)
+}
+
+// Resolve returns metadata of track, album, playlist or artist.
+func (y *yandex) Resolve(ctx context.Context, link string) (models.LinkDownloadResult, error) {
+	const op = "yandex.Resolve"
+
+	var (
+		res models.LinkDownloadResult
+		err error
+	)
+
+	switch {
+	case yaSong.MatchString(link):
+		res.Type = models.ResSong
+		res.MediaConf, err = y.track(ctx, link)
+	case yaAlbum.MatchString(link):
+		res.Type = models.ResAlbum
+		res.Album, err = y.album(ctx, link)
+	case yaPlaylist.MatchString(link):
+		res.Type = models.ResPlaylist
+		res.Playlist, err = y.playlist(ctx, link)
+	case yaArtist.MatchString(link):
+		res.Type = models.ResArtist
+		res.Artist, err = y.artist(ctx, link)
+	}
+	if err != nil {
+		return models.LinkDownloadResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// Fetch downloads track file and returns path to the file.
+// Cached file is returned without requests.
+func (y *yandex) Fetch(ctx context.Context, conf models.MediaConfig) (string, error) {
+	const op = "yandex.Fetch"
+
+	log := y.log.With(
+		slog.String("op", op),
+		slog.String("trackId", conf.External.ID),
+	)
+
+	key := dlcache.Key(yandexName, conf.External.ID)
+	if filePath, ok := y.cache.Get(key); ok {
+		return filePath, nil
+	}
+
+	downloadOptions, err := y.yaClient.DownloadInfo(ctx, conf.External.ID)
+	if err != nil {
+		log.Error(
+			"failed to get download options",
+			sl.Err(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	preferred, err := y.policy.Choose(downloadOptions)
+	if err != nil {
+		log.Warn(
+			"no suitable download option",
+			slog.Any("options", downloadOptions),
+			sl.Err(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	directURL, err := y.yaClient.DirectLink(ctx, preferred.URL, preferred.Codec)
+	if err != nil {
+		log.Error(
+			"failed to get direct download link",
+			sl.Err(err),
+		)
+		return "", client.ErrTrackNotFound
+	}
+
+	// Files of other codecs are cached
+	// separately until transcoded.
+	downloadKey := key
+	if preferred.Codec != yamodels.CodecMP3 {
+		downloadKey = dlcache.Key(yandexName, conf.External.ID, string(preferred.Codec))
+	}
+
+	filePath, err := y.yaClient.DownloadTrack(ctx, downloadKey, directURL)
+	if err != nil {
+		log.Error(
+			"failed to download track",
+			sl.Err(err),
+		)
+		return "", client.ErrTrackNotFound
+	}
+
+	if preferred.Codec != yamodels.CodecMP3 {
+		filePath, err = y.transcode(ctx, downloadKey, key, filePath)
+		if err != nil {
+			log.Error(
+				"failed to transcode track",
+				slog.String("codec", string(preferred.Codec)),
+				sl.Err(err),
+			)
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Preview may be not marked in download info.
+	if err := y.checkPreview(ctx, conf.External.ID, filePath, conf.Duration, preferred.Preview); err != nil {
+		log.Warn(
+			"downloaded preview instead of track",
+			slog.String("name", conf.Name),
+			sl.Err(err),
+		)
+		if err := y.cache.Remove(key); err != nil {
+			log.Warn("failed to remove cached file", sl.Err(err))
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return filePath, nil
+}
+
+// Search searches tracks and albums in catalog.
+// Tracks are returned first.
+func (y *yandex) Search(ctx context.Context, query string) ([]models.CatalogItem, error) {
+	const op = "yandex.Search"
+
+	res, err := y.yaClient.Search(ctx, query, 0)
+	if err != nil {
+		return []models.CatalogItem{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items := make([]models.CatalogItem, 0, yaSearchTracks+yaSearchAlbums)
+
+	for _, track := range res.Tracks[:min(len(res.Tracks), yaSearchTracks)] {
+		// Track without album can not be imported by link.
+		if track.AlbumId == 0 {
+			continue
+		}
+		items = append(items, models.CatalogItem{
+			Type:     models.ResSong,
+			Name:     track.Title,
+			Author:   artistName(track.Artists),
+			Album:    track.AlbumTitle,
+			Duration: track.Duration,
+			Link:     fmt.Sprintf(yaTrackLink, track.AlbumId, track.Id),
+		})
+	}
+
+	for _, album := range res.Albums[:min(len(res.Albums), yaSearchAlbums)] {
+		items = append(items, models.CatalogItem{
+			Type:       models.ResAlbum,
+			Name:       album.Title,
+			Author:     artistName(album.Artists),
+			TrackCount: album.TrackCount,
+			Link:       fmt.Sprintf(yaAlbumLink, album.Id),
+		})
+	}
+
+	return items, nil
+}
+
+func (y *yandex) track(ctx context.Context, url string) (models.MediaConfig, error) {
+	const op = "yandex.track"
+
+	log := y.log.With(
+		slog.String("op", op),
+	)
+
+	trackId, albumId := exctractTrackInfo(url)
+
+	album, err := y.getAlbum(ctx, albumId)
+	if err != nil {
+		log.Error(
+			"failed to get album info",
+			slog.String("albumId", albumId),
+			sl.Err(err),
+		)
+		return models.MediaConfig{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	index := slices.IndexFunc(album.Tracks, func(t yamodels.Track) bool {
+		return t.Id == trackId
+	})
+	if index == -1 {
+		log.Warn(
+			"track not found in album",
+			slog.String("albumId", albumId),
+			slog.String("trackId", trackId),
+		)
+		return models.MediaConfig{}, client.ErrTrackNotFound
+	}
+	track := album.Tracks[index]
+
+	conf := models.MediaConfig{
+		Name:     track.Title,
+		Author:   artistName(track.Artists),
+		Duration: track.Duration,
+		Format:   models.Song,
+		External: y.ref(track.Id),
+	}
+
+	if track.Format == yamodels.YaPodcastFormat || album.MetaType == yamodels.Podcast {
+		conf.Format = models.Podcast
+		conf.Podcasts = []string{album.Title}
+		return conf, nil
+	}
+
+	conf.Albums = []models.Album{y.albumTag(album)}
+	y.fillMeta(&conf, track, album.Genre)
+
+	return conf, nil
+}
+
+func (y *yandex) album(ctx context.Context, url string) (models.AlbumDownloadRes, error) {
+	const op = "yandex.album"
+
+	log := y.log.With(
+		slog.String("op", op),
+	)
+
+	albumId := extractAlbumInfo(url)
+
+	album, err := y.getAlbum(ctx, albumId)
+	if err != nil {
+		log.Error(
+			"failed to get album info",
+			slog.String("albumId", albumId),
+			sl.Err(err),
+		)
+		return models.AlbumDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	format := models.Song
+	if album.MetaType == yamodels.Podcast {
+		format = models.Podcast
+	}
+
+	return models.AlbumDownloadRes{
+		Name:   album.Title,
+		Author: artistName(album.Artists),
+		Format: format,
+		Values: y.albumValues(album),
+	}, nil
+}
+
+// albumValues returns media of album.
+// Podcast episodes are returned as podcast media
+// from the oldest to the newest.
+func (y *yandex) albumValues(album yamodels.Album) []models.MediaConfig {
+	albumTag := y.albumTag(album)
+	podcast := album.MetaType == yamodels.Podcast
+
+	values := make([]models.MediaConfig, 0, len(album.Tracks))
+	for _, track := range album.Tracks {
+		conf := models.MediaConfig{
+			Name:     track.Title,
+			Author:   artistName(track.Artists),
+			Duration: track.Duration,
+			Format:   models.Song,
+			External: y.ref(track.Id),
+		}
+		if podcast {
+			conf.Format = models.Podcast
+			conf.Podcasts = []string{album.Title}
+		} else {
+			conf.Albums = []models.Album{albumTag}
+			y.fillMeta(&conf, track, album.Genre)
+		}
+		values = append(values, conf)
+	}
+
+	// Podcast episodes are listed newest first.
+	if podcast {
+		slices.Reverse(values)
+	}
+
+	return values
+}
+
+// artist returns artist info if link has no mode,
+// otherwise popular tracks or all albums of artist.
+func (y *yandex) artist(ctx context.Context, url string) (models.ArtistDownloadRes, error) {
+	const op = "yandex.artist"
+
+	log := y.log.With(
+		slog.String("op", op),
+	)
+
+	artistId, mode := extractArtistInfo(url)
+
+	info, err := y.yaClient.ArtistInfo(ctx, artistId)
+	if err != nil {
+		log.Error(
+			"failed to get artist info",
+			slog.String("artistId", artistId),
+			sl.Err(err),
+		)
+		return models.ArtistDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res := models.ArtistDownloadRes{
+		Name:       info.Artist.Name,
+		Link:       fmt.Sprintf(yaArtistLink, artistId),
+		TrackCount: info.TrackCount,
+		AlbumCount: info.AlbumCount,
+	}
+
+	switch mode {
+	case artistTracks:
+		tracks, err := y.yaClient.ArtistTracks(ctx, artistId, yaArtistTopTracks)
+		if err != nil {
+			log.Error(
+				"failed to get artist tracks",
+				slog.String("artistId", artistId),
+				sl.Err(err),
+			)
+			return models.ArtistDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		res.Values = make([]models.MediaConfig, 0, len(tracks))
+		for _, track := range tracks {
+			conf := models.MediaConfig{
+				Name:     track.Title,
+				Author:   artistName(track.Artists),
+				Duration: track.Duration,
+				Format:   models.Song,
+				External: y.ref(track.Id),
+			}
+			if track.AlbumTitle != "" {
+				conf.Albums = []models.Album{{
+					Name:   track.AlbumTitle,
+					Author: conf.Author,
+					Year:   track.AlbumYear,
+				}}
+			}
+			y.fillMeta(&conf, track, track.AlbumGenre)
+			res.Values = append(res.Values, conf)
+		}
+	case artistAlbums:
+		albums, err := y.yaClient.ArtistAlbums(ctx, artistId, yaArtistMaxAlbums)
+		if err != nil {
+			log.Error(
+				"failed to get artist albums",
+				slog.String("artistId", artistId),
+				sl.Err(err),
+			)
+			return models.ArtistDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, a := range albums {
+			album, err := y.getAlbum(ctx, strconv.Itoa(a.Id))
+			if err != nil {
+				log.Error(
+					"failed to get album info",
+					slog.Int("albumId", a.Id),
+					sl.Err(err),
+				)
+				return models.ArtistDownloadRes{}, fmt.Errorf("%s: %w", op, err)
+			}
+			res.Values = append(res.Values, y.albumValues(album)...)
+		}
+	}
+
+	return res, nil
+}
+
+func (y *yandex) playlist(ctx context.Context, url string) (models.Playlist, error) {
+	const op = "yandex.playlist"
+
+	log := y.log.With(
+		slog.String("op", op),
+	)
+
+	kind, userName := exctractPlaylistInfo(url)
+
+	playlist, err := y.yaClient.Playlist(ctx, userName, kind)
+	if err != nil {
+		// TODO handler errors
+		log.Error(
+			"failed to get playlist info",
+			slog.String("user", userName),
+			slog.String("kind", kind),
+			sl.Err(err),
+		)
+		return models.Playlist{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	values := make([]models.MediaConfig, 0, len(playlist.Tracks))
+	for _, track := range playlist.Tracks {
+		conf := models.MediaConfig{
+			Name:      track.Title,
+			Author:    artistName(track.Artists),
+			Duration:  track.Duration,
+			Format:    models.Song,
+			Playlists: []string{playlist.Title},
+			External:  y.ref(track.Id),
+		}
+		y.fillMeta(&conf, track, track.AlbumGenre)
+		values = append(values, conf)
+	}
+
+	return models.Playlist{
+		Name:   playlist.Title,
+		Values: values,
+	}, nil
+}
+
+// transcode converts cached file to mp3,
+// caches it by dstKey and removes the source.
+func (y *yandex) transcode(ctx context.Context, srcKey, dstKey, src string) (string, error) {
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + "-transcoded.mp3"
+
+	if err := audio.Transcode(ctx, src, dst, stationBitrate); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	filePath, err := y.cache.Put(dstKey, dst)
+	if err != nil {
+		return "", err
+	}
+
+	if err := y.cache.Remove(srcKey); err != nil {
+		y.log.Warn("failed to remove cached file", slog.String("path", src), sl.Err(err))
+	}
+
+	return filePath, nil
+}
+
+// checkPreview returns service.ErrPreviewOnly if
+// file is preview and previews are not allowed.
+// Allowed marked previews are remembered.
+func (y *yandex) checkPreview(ctx context.Context, trackId, path string, expected time.Duration, marked bool) error {
+	if !marked && !y.shorter(ctx, path, expected) {
+		return nil
+	}
+	if !y.policy.AllowPreview {
+		return service.ErrPreviewOnly
+	}
+
+	y.log.Warn("preview is downloaded", slog.String("path", path))
+	if marked {
+		y.mutex.Lock()
+		y.previews[trackId] = true
+		y.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// IsPreview reports if fetched file
+// is preview instead of full track.
+func (y *yandex) IsPreview(ctx context.Context, conf models.MediaConfig, path string) bool {
+	y.mutex.Lock()
+	marked := y.previews[conf.External.ID]
+	y.mutex.Unlock()
+
+	return marked || y.shorter(ctx, path, conf.Duration)
+}
+
+// shorter reports if file is much
+// shorter than track is expected to be.
+func (y *yandex) shorter(ctx context.Context, path string, expected time.Duration) bool {
+	if expected <= 0 {
+		return false
+	}
+
+	dur, err := audio.Duration(ctx, path)
+	if err != nil {
+		y.log.Warn("failed to get duration", slog.String("path", path), sl.Err(err))
+		return false
+	}
+
+	return float64(dur) < previewRatio*float64(expected)
+}
+
+// getAlbum returns album info
+// checking error in response.
+func (y *yandex) getAlbum(ctx context.Context, albumId string) (yamodels.Album, error) {
+	album, err := y.yaClient.Album(ctx, albumId)
+	if err != nil {
+		return yamodels.Album{}, err
+	}
+	if album.Err != nil {
+		return yamodels.Album{}, fmt.Errorf("yandex error: %s", *album.Err)
+	}
+	return album, nil
+}
+
+// albumTag returns album with
+// year and label stored in tag meta.
+func (y *yandex) albumTag(album yamodels.Album) models.Album {
+	res := models.Album{
+		Name:   album.Title,
+		Author: artistName(album.Artists),
+		Year:   album.Year,
+	}
+	if len(album.Labels) > 0 {
+		res.Label = album.Labels[0]
+	}
+	return res
+}
+
+// fillMeta sets genres and languages inferred
+// from genre and title, cover art
+// and position of track in album.
+// Position is stored in album tag meta.
+func (y *yandex) fillMeta(conf *models.MediaConfig, track yamodels.Track, genre string) {
+	conf.Genres = y.genres.Genres(genre)
+	conf.Languages = Languages(genre, track.Title)
+	conf.Cover = yamodels.CoverURL(track.CoverUri)
+
+	if len(conf.Albums) > 0 {
+		conf.Albums[0].Track = track.Number
+		conf.Albums[0].Disc = track.Disc
+	}
+}
+
+func (y *yandex) ref(trackId string) models.ExternalRef {
+	return models.ExternalRef{
+		Provider: yandexName,
+		ID:       trackId,
+	}
+}
+
+func artistName(artists []yamodels.Artist) string {
+	if len(artists) == 0 {
+		return ""
+	}
+	return artists[0].Name
+}
+
+func exctractTrackInfo(url string) (trackId, albumId string) {
+	trackId = string(yaSong.ExpandString([]byte{}, "$track", url, yaSong.FindSubmatchIndex([]byte(url))))
+	albumId = string(yaSong.ExpandString([]byte{}, "$album", url, yaSong.FindSubmatchIndex([]byte(url))))
+
+	return
+}
+
+func extractAlbumInfo(url string) (albumId string) {
+	albumId = string(yaAlbum.ExpandString([]byte{}, "$album", url, yaAlbum.FindSubmatchIndex([]byte(url))))
+
+	return
+}
+
+func extractArtistInfo(url string) (artistId, mode string) {
+	artistId = string(yaArtist.ExpandString([]byte{}, "$artist", url, yaArtist.FindSubmatchIndex([]byte(url))))
+	mode = string(yaArtist.ExpandString([]byte{}, "$mode", url, yaArtist.FindSubmatchIndex([]byte(url))))
+
+	return
+}
+
+func exctractPlaylistInfo(url string) (kind, user string) {
+	kind = string(yaPlaylist.ExpandString([]byte{}, "$kind", url, yaPlaylist.FindSubmatchIndex([]byte(url))))
+	user = string(yaPlaylist.ExpandString([]byte{}, "$user", url, yaPlaylist.FindSubmatchIndex([]byte(url))))
+
+	return
+}
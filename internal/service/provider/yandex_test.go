@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+func TestTrackRegExp(t *testing.T) {
+	type expected struct {
+		trackId string
+		albumId string
+	}
+
+	testCases := []struct {
+		desc     string
+		url      string
+		expected expected
+	}{
+		{
+			desc: "",
+			url:  "https://music.yandex.ru/album/4867528/track/38184060",
+			expected: expected{
+				trackId: "38184060",
+				albumId: "4867528",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			trackId, albumId := exctractTrackInfo(tC.url)
+			assert.Equal(t, tC.expected.trackId, trackId)
+			assert.Equal(t, tC.expected.albumId, albumId)
+		})
+	}
+}
+
+func TestAlbumRegExp(t *testing.T) {
+	type expected struct {
+		albumId string
+	}
+
+	testCases := []struct {
+		desc     string
+		url      string
+		expected expected
+	}{
+		{
+			desc: "",
+			url:  "https://music.yandex.ru/album/6569250",
+			expected: expected{
+				albumId: "6569250",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			albumId := extractAlbumInfo(tC.url)
+			assert.Equal(t, tC.expected.albumId, albumId)
+		})
+	}
+}
+
+func TestPlaylistRegExp(t *testing.T) {
+	type expected struct {
+		kind string
+		user string
+	}
+
+	testCases := []struct {
+		desc     string
+		url      string
+		expected expected
+	}{
+		{
+			desc: "",
+			url:  "https://music.yandex.com/users/remizova.av@phystech.edu/playlists/1007",
+			expected: expected{
+				kind: "1007",
+				user: "remizova.av@phystech.edu",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			kind, user := exctractPlaylistInfo(tC.url)
+			assert.Equal(t, tC.expected.user, user)
+			assert.Equal(t, tC.expected.kind, kind)
+		})
+	}
+}
+
+func TestArtistRegExp(t *testing.T) {
+	type expected struct {
+		artistId string
+		mode     string
+	}
+
+	testCases := []struct {
+		desc     string
+		url      string
+		expected expected
+	}{
+		{
+			desc: "artist",
+			url:  "https://music.yandex.ru/artist/36800",
+			expected: expected{
+				artistId: "36800",
+			},
+		},
+		{
+			desc: "popular tracks",
+			url:  "https://music.yandex.ru/artist/36800/tracks",
+			expected: expected{
+				artistId: "36800",
+				mode:     "tracks",
+			},
+		},
+		{
+			desc: "albums",
+			url:  "https://music.yandex.com/artist/36800/albums",
+			expected: expected{
+				artistId: "36800",
+				mode:     "albums",
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			artistId, mode := extractArtistInfo(tC.url)
+			assert.Equal(t, tC.expected.artistId, artistId)
+			assert.Equal(t, tC.expected.mode, mode)
+		})
+	}
+}
+
+func TestCheckPreview(t *testing.T) {
+	strict := NewYandex(log, nil, nil, DownloadPolicy{}, nil)
+	assert.ErrorIs(t, strict.checkPreview(context.Background(), "1", "track.mp3", 0, true), service.ErrPreviewOnly)
+	assert.NoError(t, strict.checkPreview(context.Background(), "2", "track.mp3", 0, false))
+
+	// Allowed previews are marked.
+	y := NewYandex(log, nil, nil, DownloadPolicy{AllowPreview: true}, nil)
+	assert.NoError(t, y.checkPreview(context.Background(), "1", "track.mp3", 0, true))
+	assert.NoError(t, y.checkPreview(context.Background(), "2", "track.mp3", 0, false))
+
+	preview := models.MediaConfig{External: models.ExternalRef{Provider: yandexName, ID: "1"}}
+	assert.True(t, y.IsPreview(context.Background(), preview, "track.mp3"))
+	track := models.MediaConfig{External: models.ExternalRef{Provider: yandexName, ID: "2"}}
+	assert.False(t, y.IsPreview(context.Background(), track, "track.mp3"))
+}
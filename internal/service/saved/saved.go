@@ -0,0 +1,534 @@
+package saved
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/registry"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// How often due smart playlists are looked for.
+	checkInterval = 5 * time.Minute
+	// Max number of media in smart playlist.
+	smartRespLen = 1000
+)
+
+type saved struct {
+	log      *slog.Logger
+	interval time.Duration
+	library  Library
+
+	// Marked smart playlists are being updated.
+	searches *registry.Registry[models.SavedSearch]
+}
+
+type Library interface {
+	Search(ctx context.Context, id int64, filter models.MediaFilter) ([]models.MediaConfig, error)
+	UpdateMedia(ctx context.Context, id int64, mediaConf models.MediaConfig) error
+}
+
+// New returns service storing saved searches
+// and updating smart playlists every interval.
+func New(
+	log *slog.Logger,
+	library Library,
+	file string,
+	interval time.Duration,
+) *saved {
+	searches, err := registry.New(file, func(search *models.SavedSearch) int64 {
+		return search.ID
+	}, copySearch)
+	if err != nil {
+		log.Error(
+			"failed to load saved searches",
+			slog.String("op", "saved.New"),
+			sl.Err(err),
+		)
+	}
+
+	return &saved{
+		log:      log,
+		interval: interval,
+		library:  library,
+		searches: searches,
+	}
+}
+
+// Start runs smart playlists updates in background.
+func (s *saved) Start() {
+	s.searches.Start(checkInterval, s.syncDue)
+}
+
+// Stop stops background updates.
+func (s *saved) Stop() {
+	s.searches.Stop()
+}
+
+// List returns searches of user and shared ones.
+func (s *saved) List(ctx context.Context, id int64) ([]models.SavedSearch, error) {
+	s.searches.Lock()
+	defer s.searches.Unlock()
+
+	res := make([]models.SavedSearch, 0)
+	for _, search := range s.searches.All() {
+		if visible(search, id) {
+			res = append(res, copySearch(search))
+		}
+	}
+	slices.SortFunc(res, func(a, b models.SavedSearch) int {
+		return int(a.ID - b.ID)
+	})
+
+	return res, nil
+}
+
+// Get returns search visible to user.
+func (s *saved) Get(ctx context.Context, id int64, searchId int64) (models.SavedSearch, error) {
+	s.searches.Lock()
+	defer s.searches.Unlock()
+
+	search, ok := s.searches.Get(searchId)
+	if !ok || !visible(search, id) {
+		return models.SavedSearch{}, service.ErrSearchNotFound
+	}
+
+	return copySearch(search), nil
+}
+
+// Save saves personal search. Period is applied
+// as recency bound on each run instead of
+// AddedAfter of filter.
+func (s *saved) Save(ctx context.Context, id int64, name string, filter models.MediaFilter, period time.Duration) (models.SavedSearch, error) {
+	const op = "saved.Save"
+
+	s.searches.Lock()
+	if s.nameTakenLocked(name, 0, func(search *models.SavedSearch) bool {
+		return visible(search, id)
+	}) {
+		s.searches.Unlock()
+		return models.SavedSearch{}, service.ErrSearchExists
+	}
+
+	filter.AddedAfter = time.Time{}
+
+	search := &models.SavedSearch{
+		ID:     s.searches.NextId(),
+		UserId: id,
+		Name:   name,
+		Filter: filter,
+		Period: period,
+	}
+	s.searches.Put(search)
+	res := copySearch(search)
+	s.searches.Unlock()
+
+	if err := s.searches.Dump(); err != nil {
+		s.log.Error("failed to dump saved searches", slog.String("op", op), sl.Err(err))
+		return models.SavedSearch{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info(
+		"search saved",
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("searchId", res.ID),
+		slog.String("name", name),
+	)
+
+	return res, nil
+}
+
+// Run searches media with saved filter.
+func (s *saved) Run(ctx context.Context, id int64, searchId int64) ([]models.MediaConfig, error) {
+	const op = "saved.Run"
+
+	search, err := s.Get(ctx, id, searchId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.library.Search(ctx, id, search.MediaFilter())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// SetShared makes search visible to all users
+// or only to its owner.
+func (s *saved) SetShared(ctx context.Context, id int64, searchId int64, shared bool) (models.SavedSearch, error) {
+	const op = "saved.SetShared"
+
+	s.searches.Lock()
+	search, err := s.ownLocked(id, searchId)
+	if err != nil {
+		s.searches.Unlock()
+		return models.SavedSearch{}, err
+	}
+	if shared && s.nameTakenLocked(search.Name, searchId, func(other *models.SavedSearch) bool {
+		return other.Shared
+	}) {
+		s.searches.Unlock()
+		return models.SavedSearch{}, service.ErrSearchExists
+	}
+	search.Shared = shared
+	res := copySearch(search)
+	s.searches.Unlock()
+
+	if err := s.searches.Dump(); err != nil {
+		s.log.Error("failed to dump saved searches", slog.String("op", op), sl.Err(err))
+		return models.SavedSearch{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// SetSmart promotes search to smart playlist
+// and starts its first update in background,
+// or demotes it taking playlist tag off media.
+// Playlist is named as search, so it must not
+// clash with existing playlists.
+func (s *saved) SetSmart(ctx context.Context, id int64, searchId int64, smart bool) (models.SavedSearch, error) {
+	const op = "saved.SetSmart"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.Int64("searchId", searchId),
+	)
+
+	s.searches.Lock()
+	search, err := s.ownLocked(id, searchId)
+	if err != nil {
+		s.searches.Unlock()
+		return models.SavedSearch{}, err
+	}
+	if search.Smart == smart {
+		res := copySearch(search)
+		s.searches.Unlock()
+		return res, nil
+	}
+	if s.searches.Marked(searchId) {
+		s.searches.Unlock()
+		return models.SavedSearch{}, service.ErrSyncInProgress
+	}
+	if smart && s.nameTakenLocked(search.Name, searchId, func(other *models.SavedSearch) bool {
+		return other.Smart
+	}) {
+		s.searches.Unlock()
+		return models.SavedSearch{}, service.ErrSearchExists
+	}
+	name := search.Name
+	s.searches.Mark(searchId)
+	s.searches.Unlock()
+
+	if smart {
+		tagged, err := s.library.Search(ctx, id, models.MediaFilter{
+			Tags:       []string{name},
+			MaxRespLen: 1,
+		})
+		if err == nil && len(tagged) > 0 {
+			err = service.ErrPlaylistExists
+		}
+		if err != nil {
+			s.unmark(searchId)
+			if !errors.Is(err, service.ErrPlaylistExists) {
+				log.Error("failed to check playlist", sl.Err(err))
+			}
+			return models.SavedSearch{}, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if _, err := s.apply(ctx, id, name, nil, false); err != nil {
+			s.unmark(searchId)
+			log.Error("failed to clear playlist", sl.Err(err))
+			return models.SavedSearch{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	s.searches.Lock()
+	search, ok := s.searches.Get(searchId)
+	if !ok {
+		s.searches.Unmark(searchId)
+		s.searches.Unlock()
+		return models.SavedSearch{}, service.ErrSearchNotFound
+	}
+	search.Smart = smart
+	search.Size = 0
+	search.LastSync = time.Time{}
+	search.LastErr = ""
+	res := copySearch(search)
+	if !smart {
+		s.searches.Unmark(searchId)
+	}
+	s.searches.Unlock()
+
+	if err := s.searches.Dump(); err != nil {
+		log.Error("failed to dump saved searches", sl.Err(err))
+	}
+
+	if smart {
+		go s.sync(searchId)
+	}
+
+	log.Info("smart playlist toggled", slog.Bool("smart", smart))
+
+	return res, nil
+}
+
+// Sync updates smart playlist right now.
+func (s *saved) Sync(ctx context.Context, id int64, searchId int64) (models.SmartSyncResult, error) {
+	s.searches.Lock()
+	search, ok := s.searches.Get(searchId)
+	if !ok || !visible(search, id) {
+		s.searches.Unlock()
+		return models.SmartSyncResult{}, service.ErrSearchNotFound
+	}
+	if !search.Smart {
+		s.searches.Unlock()
+		return models.SmartSyncResult{}, service.ErrNotSmart
+	}
+	if !s.searches.Mark(searchId) {
+		s.searches.Unlock()
+		return models.SmartSyncResult{}, service.ErrSyncInProgress
+	}
+	s.searches.Unlock()
+
+	return s.sync(searchId)
+}
+
+// Delete deletes search. Playlist tag
+// of smart search is taken off media.
+func (s *saved) Delete(ctx context.Context, id int64, searchId int64) error {
+	const op = "saved.Delete"
+
+	search, err := s.SetSmart(ctx, id, searchId, false)
+	if err != nil {
+		return err
+	}
+
+	s.searches.Lock()
+	s.searches.Delete(searchId)
+	s.searches.Unlock()
+
+	if err := s.searches.Dump(); err != nil {
+		s.log.Error("failed to dump saved searches", slog.String("op", op), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info(
+		"saved search deleted",
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("name", search.Name),
+	)
+
+	return nil
+}
+
+// syncDue updates smart playlists
+// not updated during interval.
+func (s *saved) syncDue() {
+	s.searches.Lock()
+	due := make([]int64, 0)
+	for id, search := range s.searches.All() {
+		if search.Smart && time.Since(search.LastSync) >= s.interval && s.searches.Mark(id) {
+			due = append(due, id)
+		}
+	}
+	s.searches.Unlock()
+
+	for _, id := range due {
+		s.sync(id)
+	}
+}
+
+// sync tags media matching search with playlist
+// and takes the tag off media not matching anymore.
+// Search must be marked as syncing.
+func (s *saved) sync(searchId int64) (models.SmartSyncResult, error) {
+	const op = "saved.sync"
+
+	s.searches.Lock()
+	search, ok := s.searches.Get(searchId)
+	if !ok {
+		s.searches.Unmark(searchId)
+		s.searches.Unlock()
+		return models.SmartSyncResult{}, service.ErrSearchNotFound
+	}
+	userId, name := search.UserId, search.Name
+	filter := search.MediaFilter()
+	recency := search.Period > 0
+	s.searches.Unlock()
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+		slog.Int64("searchId", searchId),
+	)
+
+	filter.MaxRespLen = smartRespLen
+
+	matched, err := s.library.Search(s.searches.Context(), userId, filter)
+	var res models.SmartSyncResult
+	if err == nil {
+		res, err = s.apply(s.searches.Context(), userId, name, matched, recency)
+	}
+	if err != nil {
+		log.Error("failed to update smart playlist", sl.Err(err))
+	}
+
+	s.searches.Lock()
+	s.searches.Unmark(searchId)
+	search, ok = s.searches.Get(searchId)
+	if ok {
+		search.LastSync = time.Now()
+		search.LastErr = ""
+		if err != nil {
+			search.LastErr = err.Error()
+		} else {
+			search.Size = len(matched)
+		}
+	}
+	s.searches.Unlock()
+
+	if err := s.searches.Dump(); err != nil {
+		log.Error("failed to dump saved searches", sl.Err(err))
+	}
+
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if res.Added > 0 || res.Removed > 0 {
+		log.Info(
+			"smart playlist updated",
+			slog.Int("added", res.Added),
+			slog.Int("removed", res.Removed),
+		)
+	}
+
+	return res, nil
+}
+
+// apply makes matched media to be
+// the only media tagged with playlist.
+// If search is bound by recency, media
+// with unknown upload time are kept.
+func (s *saved) apply(ctx context.Context, userId int64, playlist string, matched []models.MediaConfig, recency bool) (models.SmartSyncResult, error) {
+	tagged, err := s.library.Search(ctx, userId, models.MediaFilter{
+		Tags:       []string{playlist},
+		MaxRespLen: smartRespLen,
+	})
+	if err != nil {
+		return models.SmartSyncResult{}, err
+	}
+
+	add, remove := diff(matched, tagged, playlist, recency)
+
+	var res models.SmartSyncResult
+	for _, conf := range add {
+		conf.Playlists = append(slices.Clone(conf.Playlists), playlist)
+		if err := s.library.UpdateMedia(ctx, userId, conf); err != nil {
+			return res, err
+		}
+		res.Added++
+	}
+	for _, conf := range remove {
+		conf.Playlists = slices.DeleteFunc(slices.Clone(conf.Playlists), func(p string) bool {
+			return p == playlist
+		})
+		if err := s.library.UpdateMedia(ctx, userId, conf); err != nil {
+			return res, err
+		}
+		res.Removed++
+	}
+
+	return res, nil
+}
+
+// diff returns matched media without playlist tag
+// and tagged media not matched anymore.
+// If recency is set, media with unknown
+// upload time are never removed, since
+// they are not matched by recency bound.
+func diff(matched, tagged []models.MediaConfig, playlist string, recency bool) (add, remove []models.MediaConfig) {
+	inPlaylist := func(conf models.MediaConfig) bool {
+		return slices.Contains(conf.Playlists, playlist)
+	}
+
+	for _, conf := range matched {
+		if !inPlaylist(conf) {
+			add = append(add, conf)
+		}
+	}
+	for _, conf := range tagged {
+		// Tag names are not unique across types.
+		if !inPlaylist(conf) {
+			continue
+		}
+		if recency && conf.CreatedAt.IsZero() {
+			continue
+		}
+		if !slices.ContainsFunc(matched, func(m models.MediaConfig) bool {
+			return m.ID == conf.ID
+		}) {
+			remove = append(remove, conf)
+		}
+	}
+
+	return add, remove
+}
+
+func (s *saved) unmark(searchId int64) {
+	s.searches.Lock()
+	defer s.searches.Unlock()
+
+	s.searches.Unmark(searchId)
+}
+
+// ownLocked returns search owned by user.
+// Must be called with locked searches.
+func (s *saved) ownLocked(id int64, searchId int64) (*models.SavedSearch, error) {
+	search, ok := s.searches.Get(searchId)
+	if !ok || !visible(search, id) {
+		return nil, service.ErrSearchNotFound
+	}
+	if search.UserId != id {
+		return nil, service.ErrNotSearchOwner
+	}
+	return search, nil
+}
+
+// nameTakenLocked reports if other search
+// selected by filter has the same name.
+// Must be called with locked searches.
+func (s *saved) nameTakenLocked(name string, exceptId int64, filter func(*models.SavedSearch) bool) bool {
+	for id, search := range s.searches.All() {
+		if id != exceptId && filter(search) && strings.EqualFold(search.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func visible(search *models.SavedSearch, id int64) bool {
+	return search.Shared || search.UserId == id
+}
+
+func copySearch(search *models.SavedSearch) models.SavedSearch {
+	res := *search
+	res.Filter.Tags = slices.Clone(search.Filter.Tags)
+	res.Filter.AnyTags = slices.Clone(search.Filter.AnyTags)
+	res.Filter.ExcludeTags = slices.Clone(search.Filter.ExcludeTags)
+	return res
+}
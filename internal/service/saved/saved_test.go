@@ -0,0 +1,136 @@
+package saved
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/servicetest"
+)
+
+const (
+	userId  = 1
+	otherId = 2
+)
+
+func media(id int64, genre string, playlists ...string) models.MediaConfig {
+	return models.MediaConfig{
+		ID:        id,
+		Genres:    models.NewTagSet(genre),
+		Playlists: playlists,
+	}
+}
+
+func TestDiff(t *testing.T) {
+	matched := []models.MediaConfig{
+		media(1, "Рок", "Драйв"),
+		media(2, "Рок"),
+	}
+	tagged := []models.MediaConfig{
+		media(1, "Рок", "Драйв"),
+		media(3, "Поп", "Драйв"),
+		// Genre with the same name.
+		media(4, "Драйв"),
+	}
+
+	add, remove := diff(matched, tagged, "Драйв", false)
+
+	require.Len(t, add, 1)
+	assert.Equal(t, int64(2), add[0].ID)
+	require.Len(t, remove, 1)
+	assert.Equal(t, int64(3), remove[0].ID)
+
+	// Upload time of media 3 is unknown,
+	// media 5 is uploaded before period.
+	old := media(5, "Поп", "Драйв")
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	tagged = append(tagged, old)
+
+	_, remove = diff(matched, tagged, "Драйв", true)
+
+	require.Len(t, remove, 1)
+	assert.Equal(t, int64(5), remove[0].ID)
+}
+
+func TestSmartPlaylist(t *testing.T) {
+	lib := &servicetest.Library{
+		Stored: []models.MediaConfig{
+			media(1, "Рок"),
+			media(2, "Рок", "Утро"),
+			media(3, "Поп"),
+		},
+	}
+
+	file := filepath.Join(t.TempDir(), "saved.json")
+	s := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		lib,
+		file,
+		time.Hour,
+	)
+	ctx := context.Background()
+
+	search, err := s.Save(ctx, userId, "Драйв", models.MediaFilter{Tags: []string{"Рок"}}, 0)
+	require.NoError(t, err)
+
+	_, err = s.Save(ctx, userId, "драйв", models.MediaFilter{}, 0)
+	assert.ErrorIs(t, err, service.ErrSearchExists)
+
+	// Personal search is hidden from others.
+	_, err = s.Get(ctx, otherId, search.ID)
+	assert.ErrorIs(t, err, service.ErrSearchNotFound)
+
+	_, err = s.SetShared(ctx, userId, search.ID, true)
+	require.NoError(t, err)
+	_, err = s.SetSmart(ctx, otherId, search.ID, true)
+	assert.ErrorIs(t, err, service.ErrNotSearchOwner)
+
+	// Playlist with the same name exists.
+	clash, err := s.Save(ctx, userId, "Утро", models.MediaFilter{}, 0)
+	require.NoError(t, err)
+	_, err = s.SetSmart(ctx, userId, clash.ID, true)
+	assert.ErrorIs(t, err, service.ErrPlaylistExists)
+
+	_, err = s.SetSmart(ctx, userId, search.ID, true)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		search, err := s.Get(ctx, userId, search.ID)
+		return err == nil && !search.LastSync.IsZero()
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []int64{1, 2}, lib.Playlist("Драйв"))
+
+	// Media stops matching search.
+	require.NoError(t, lib.UpdateMedia(ctx, userId, media(1, "Джаз", "Драйв")))
+
+	res, err := s.Sync(ctx, otherId, search.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SmartSyncResult{Removed: 1}, res)
+	assert.Equal(t, []int64{2}, lib.Playlist("Драйв"))
+
+	// Searches are restored from file.
+	restored := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		lib,
+		file,
+		time.Hour,
+	)
+	list, err := restored.List(ctx, otherId)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.True(t, list[0].Smart)
+	assert.Equal(t, 1, list[0].Size)
+
+	require.NoError(t, s.Delete(ctx, userId, search.ID))
+	assert.Empty(t, lib.Playlist("Драйв"))
+	assert.Equal(t, []int64{2}, lib.Playlist("Утро"))
+}
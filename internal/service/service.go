@@ -6,10 +6,55 @@ var (
 	// Auth
 	ErrUserNotFound = errors.New("user not found")
 
-	ErrMediaExists = errors.New("media exists")
+	ErrMediaExists   = errors.New("media exists")
+	ErrMediaNotFound = errors.New("media not found")
+	ErrInvalidAudio  = errors.New("invalid audio file")
 
 	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag exists")
+	ErrTagInUse    = errors.New("tag is in use")
+	ErrTagMismatch = errors.New("tags have different types")
+	ErrInvalidYear = errors.New("year is not a number")
 
 	// Links
 	ErrInvalidLink = errors.New("invalid link")
+
+	// Downloads
+	ErrPreviewOnly      = errors.New("only preview is available")
+	ErrNoDownloadOption = errors.New("no suitable download option")
+
+	// Jobs
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobActive    = errors.New("job is active")
+	ErrJobNotActive = errors.New("job is not active")
+
+	// Watched playlists
+	ErrNotPlaylist    = errors.New("link is not a playlist")
+	ErrAlreadyWatched = errors.New("playlist is already watched")
+	ErrWatchNotFound  = errors.New("watched playlist not found")
+	ErrSyncInProgress = errors.New("playlist synchronization is in progress")
+
+	// Podcast subscriptions
+	ErrAlreadySubscribed = errors.New("podcast feed is already subscribed")
+	ErrPodcastNotFound   = errors.New("podcast subscription not found")
+	ErrCheckInProgress   = errors.New("podcast feed check is in progress")
+
+	// Saved searches
+	ErrSearchExists   = errors.New("saved search with this name exists")
+	ErrSearchNotFound = errors.New("saved search not found")
+	ErrNotSearchOwner = errors.New("saved search belongs to other user")
+	ErrNotSmart       = errors.New("saved search is not smart playlist")
+	ErrPlaylistExists = errors.New("playlist with this name exists")
+
+	// Export and import
+	ErrNothingToExport = errors.New("nothing to export")
+	ErrInvalidImport   = errors.New("invalid import file")
+	ErrImportNotFound  = errors.New("import not found")
+
+	// Library check
+	ErrLibraryEmpty        = errors.New("library is empty")
+	ErrLibCheckInProgress  = errors.New("library check is in progress")
+	ErrLibCheckNotFound    = errors.New("library check not found")
+	ErrNothingToFix        = errors.New("nothing to fix")
+	ErrDurationNotDetected = errors.New("duration is not detected")
 )
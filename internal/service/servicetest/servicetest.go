@@ -0,0 +1,136 @@
+// Package servicetest contains fakes
+// of services shared by tests.
+package servicetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+// Library keeps stored media in memory. Search matches
+// name and tags by genres, playlists and podcasts.
+type Library struct {
+	mutex sync.Mutex
+
+	Stored []models.MediaConfig
+	// Returned by LinkDownload.
+	Link models.LinkDownloadResult
+	// Values passed to PrepareUpload.
+	Prepared []models.MediaConfig
+	// Values passed to UpdateMedia.
+	Updated []models.MediaConfig
+}
+
+func (l *Library) LinkDownload(_ context.Context, _ int64, _ string) (models.LinkDownloadResult, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.Link, nil
+}
+
+func (l *Library) PrepareUpload(_ context.Context, _ int64, values []models.MediaConfig) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.Prepared = append(l.Prepared, values...)
+	return nil
+}
+
+func (l *Library) Search(_ context.Context, _ int64, filter models.MediaFilter) ([]models.MediaConfig, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	res := make([]models.MediaConfig, 0)
+	for _, conf := range l.Stored {
+		if filter.Name != "" && conf.Name != filter.Name {
+			continue
+		}
+		if slices.ContainsFunc(filter.Tags, func(tag string) bool {
+			return !conf.Genres.Has(tag) && !slices.Contains(conf.Playlists, tag) && !slices.Contains(conf.Podcasts, tag)
+		}) {
+			continue
+		}
+		res = append(res, conf)
+	}
+	return res, nil
+}
+
+func (l *Library) Media(_ context.Context, _ int64, mediaId int64) (models.MediaConfig, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, conf := range l.Stored {
+		if conf.ID == mediaId {
+			return conf, nil
+		}
+	}
+	return models.MediaConfig{}, service.ErrMediaNotFound
+}
+
+func (l *Library) UpdateMedia(_ context.Context, _ int64, conf models.MediaConfig) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.Updated = append(l.Updated, conf)
+	for i := range l.Stored {
+		if l.Stored[i].ID == conf.ID {
+			l.Stored[i] = conf
+		}
+	}
+	return nil
+}
+
+// Playlist returns ids of media in playlist.
+func (l *Library) Playlist(name string) []int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	ids := make([]int64, 0)
+	for _, conf := range l.Stored {
+		if slices.Contains(conf.Playlists, name) {
+			ids = append(ids, conf.ID)
+		}
+	}
+	return ids
+}
+
+// add stores media with new id.
+func (l *Library) add(conf models.MediaConfig) int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	conf.ID = int64(len(l.Stored) + 1)
+	l.Stored = append(l.Stored, conf)
+	return conf.ID
+}
+
+// Jobs finishes jobs at once. Items named
+// "broken" fail, others are uploaded
+// to Library if it is set.
+type Jobs struct {
+	Library   *Library
+	Submitted [][]models.JobItem
+}
+
+func (j *Jobs) Submit(_ context.Context, _ int64, _ models.JobKind, _ string, items []models.JobItem, notify models.JobNotify) (models.Job, error) {
+	j.Submitted = append(j.Submitted, items)
+
+	job := models.Job{Status: models.JobDone, Items: items}
+	for i := range job.Items {
+		job.Items[i].Status = models.ItemDone
+		if job.Items[i].Conf.Name == "broken" {
+			job.Items[i].Status = models.ItemFailed
+			continue
+		}
+		if j.Library != nil {
+			job.Items[i].MediaId = j.Library.add(job.Items[i].Conf)
+		}
+	}
+	notify(job)
+
+	return job, nil
+}
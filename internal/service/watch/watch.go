@@ -0,0 +1,466 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/logger/sl"
+	"github.com/GintGld/fizteh-radio-bot/internal/lib/registry"
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service"
+)
+
+const (
+	// How often due playlists are looked for.
+	checkInterval = 5 * time.Minute
+	// Max number of media with playlist tag
+	// requested to remove missing tracks.
+	playlistRespLen = 1000
+)
+
+type watch struct {
+	log      *slog.Logger
+	interval time.Duration
+	library  Library
+	jobs     Jobs
+	notify   models.WatchNotify
+
+	// Marked playlists are being synchronized.
+	playlists *registry.Registry[models.WatchedPlaylist]
+}
+
+type Library interface {
+	LinkDownload(ctx context.Context, id int64, link string) (models.LinkDownloadResult, error)
+	PrepareUpload(ctx context.Context, id int64, values []models.MediaConfig) error
+	Search(ctx context.Context, id int64, filter models.MediaFilter) ([]models.MediaConfig, error)
+	UpdateMedia(ctx context.Context, id int64, mediaConf models.MediaConfig) error
+}
+
+type Jobs interface {
+	Submit(ctx context.Context, id int64, kind models.JobKind, title string, items []models.JobItem, notify models.JobNotify) (models.Job, error)
+}
+
+// New returns service synchronizing
+// watched playlists every interval.
+func New(
+	log *slog.Logger,
+	library Library,
+	jobs Jobs,
+	file string,
+	interval time.Duration,
+) *watch {
+	playlists, err := registry.New(file, func(p *models.WatchedPlaylist) int64 {
+		return p.ID
+	}, copyPlaylist)
+	if err != nil {
+		log.Error(
+			"failed to load watched playlists",
+			slog.String("op", "watch.New"),
+			sl.Err(err),
+		)
+	}
+
+	return &watch{
+		log:       log,
+		interval:  interval,
+		library:   library,
+		jobs:      jobs,
+		playlists: playlists,
+	}
+}
+
+// SetNotify sets function receiving
+// summaries of synchronizations.
+func (w *watch) SetNotify(notify models.WatchNotify) {
+	w.notify = notify
+}
+
+// Start runs synchronization in background.
+func (w *watch) Start() {
+	w.playlists.Start(checkInterval, w.syncDue)
+}
+
+// Stop stops background synchronization.
+func (w *watch) Stop() {
+	w.playlists.Stop()
+}
+
+// List returns playlists watched by user.
+func (w *watch) List(ctx context.Context, id int64) ([]models.WatchedPlaylist, error) {
+	w.playlists.Lock()
+	defer w.playlists.Unlock()
+
+	res := make([]models.WatchedPlaylist, 0)
+	for _, p := range w.playlists.All() {
+		if p.UserId == id {
+			res = append(res, *p)
+		}
+	}
+	slices.SortFunc(res, func(a, b models.WatchedPlaylist) int {
+		return int(a.ID - b.ID)
+	})
+
+	return res, nil
+}
+
+// Add starts watching playlist by link
+// and runs its first synchronization.
+func (w *watch) Add(ctx context.Context, id int64, link string) (models.WatchedPlaylist, error) {
+	const op = "watch.Add"
+
+	log := w.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", id),
+		slog.String("link", link),
+	)
+
+	w.playlists.Lock()
+	exists := slices.ContainsFunc(w.userPlaylistsLocked(id), func(p *models.WatchedPlaylist) bool {
+		return p.Link == link
+	})
+	w.playlists.Unlock()
+	if exists {
+		return models.WatchedPlaylist{}, service.ErrAlreadyWatched
+	}
+
+	res, err := w.library.LinkDownload(ctx, id, link)
+	if err != nil {
+		log.Error("failed to get playlist", sl.Err(err))
+		return models.WatchedPlaylist{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if res.Type != models.ResPlaylist {
+		return models.WatchedPlaylist{}, service.ErrNotPlaylist
+	}
+
+	w.playlists.Lock()
+	p := &models.WatchedPlaylist{
+		ID:     w.playlists.NextId(),
+		UserId: id,
+		Link:   link,
+		Name:   res.Playlist.Name,
+	}
+	w.playlists.Put(p)
+	w.playlists.Mark(p.ID)
+	copied := copyPlaylist(p)
+	w.playlists.Unlock()
+
+	if err := w.playlists.Dump(); err != nil {
+		log.Error("failed to dump watched playlists", sl.Err(err))
+	}
+
+	log.Info("playlist is watched", slog.Int64("playlistId", p.ID), slog.String("name", p.Name))
+
+	go w.sync(copied.ID, res.Playlist, true)
+
+	return copied, nil
+}
+
+// Remove stops watching playlist.
+// Imported tracks are kept.
+func (w *watch) Remove(ctx context.Context, id int64, playlistId int64) error {
+	const op = "watch.Remove"
+
+	w.playlists.Lock()
+	p, ok := w.playlists.Get(playlistId)
+	if !ok || p.UserId != id {
+		w.playlists.Unlock()
+		return service.ErrWatchNotFound
+	}
+	w.playlists.Delete(playlistId)
+	w.playlists.Unlock()
+
+	if err := w.playlists.Dump(); err != nil {
+		w.log.Error("failed to dump watched playlists", slog.String("op", op), sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetRemoveMissing sets if playlist tag is removed
+// from tracks taken out of the source playlist.
+func (w *watch) SetRemoveMissing(ctx context.Context, id int64, playlistId int64, remove bool) (models.WatchedPlaylist, error) {
+	const op = "watch.SetRemoveMissing"
+
+	w.playlists.Lock()
+	p, ok := w.playlists.Get(playlistId)
+	if !ok || p.UserId != id {
+		w.playlists.Unlock()
+		return models.WatchedPlaylist{}, service.ErrWatchNotFound
+	}
+	p.RemoveMissing = remove
+	res := copyPlaylist(p)
+	w.playlists.Unlock()
+
+	if err := w.playlists.Dump(); err != nil {
+		w.log.Error("failed to dump watched playlists", slog.String("op", op), sl.Err(err))
+		return models.WatchedPlaylist{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// Sync starts synchronization of playlist
+// in background. Summary is always sent.
+func (w *watch) Sync(ctx context.Context, id int64, playlistId int64) error {
+	w.playlists.Lock()
+	p, ok := w.playlists.Get(playlistId)
+	if !ok || p.UserId != id {
+		w.playlists.Unlock()
+		return service.ErrWatchNotFound
+	}
+	if !w.playlists.Mark(playlistId) {
+		w.playlists.Unlock()
+		return service.ErrSyncInProgress
+	}
+	w.playlists.Unlock()
+
+	go w.syncLink(playlistId, true)
+
+	return nil
+}
+
+// syncDue starts synchronization of playlists
+// not synchronized during interval.
+func (w *watch) syncDue() {
+	w.playlists.Lock()
+	due := make([]int64, 0)
+	for id, p := range w.playlists.All() {
+		if time.Since(p.LastSync) >= w.interval && w.playlists.Mark(id) {
+			due = append(due, id)
+		}
+	}
+	w.playlists.Unlock()
+
+	for _, id := range due {
+		w.syncLink(id, false)
+	}
+}
+
+// syncLink requests playlist and synchronizes it.
+// Playlist must be marked as syncing.
+func (w *watch) syncLink(playlistId int64, manual bool) {
+	const op = "watch.syncLink"
+
+	w.playlists.Lock()
+	p, ok := w.playlists.Get(playlistId)
+	if !ok {
+		w.playlists.Unmark(playlistId)
+		w.playlists.Unlock()
+		return
+	}
+	userId, link := p.UserId, p.Link
+	w.playlists.Unlock()
+
+	res, err := w.library.LinkDownload(w.playlists.Context(), userId, link)
+	if err == nil && res.Type != models.ResPlaylist {
+		err = service.ErrNotPlaylist
+	}
+	if err != nil {
+		w.log.Error(
+			"failed to get playlist",
+			slog.String("op", op),
+			slog.Int64("playlistId", playlistId),
+			sl.Err(err),
+		)
+		w.finish(playlistId, nil, err, nil, true)
+		return
+	}
+
+	w.sync(playlistId, res.Playlist, manual)
+}
+
+// sync imports new tracks of playlist and
+// handles tracks taken out of the source.
+// Track is marked as imported only when
+// it is uploaded, so failed ones are
+// retried with the next synchronization.
+func (w *watch) sync(playlistId int64, playlist models.Playlist, manual bool) {
+	const op = "watch.sync"
+
+	w.playlists.Lock()
+	p, ok := w.playlists.Get(playlistId)
+	if !ok {
+		w.playlists.Unmark(playlistId)
+		w.playlists.Unlock()
+		return
+	}
+	userId := p.UserId
+	// Source playlist may be renamed,
+	// station tag is kept.
+	name := p.Name
+	known := slices.Clone(p.Tracks)
+	removeMissing := p.RemoveMissing
+	w.playlists.Unlock()
+
+	log := w.log.With(
+		slog.String("op", op),
+		slog.Int64("userId", userId),
+		slog.Int64("playlistId", playlistId),
+	)
+
+	newValues := make([]models.MediaConfig, 0)
+	for _, conf := range playlist.Values {
+		if !slices.ContainsFunc(known, func(t models.WatchedTrack) bool {
+			return t.ID == conf.External.ID
+		}) {
+			conf.Playlists = slices.Clone(conf.Playlists)
+			if i := slices.Index(conf.Playlists, playlist.Name); i != -1 {
+				conf.Playlists[i] = name
+			}
+			newValues = append(newValues, conf)
+		}
+	}
+
+	removed := make([]models.WatchedTrack, 0)
+	for _, t := range known {
+		if !slices.ContainsFunc(playlist.Values, func(conf models.MediaConfig) bool {
+			return conf.External.ID == t.ID
+		}) {
+			removed = append(removed, t)
+		}
+	}
+
+	if len(removed) > 0 && removeMissing {
+		if err := w.removeTag(w.playlists.Context(), userId, name, removed); err != nil {
+			log.Error("failed to remove playlist tag", sl.Err(err))
+		}
+	}
+
+	if len(newValues) == 0 {
+		w.finish(playlistId, removed, nil, nil, manual || len(removed) > 0)
+		return
+	}
+
+	if err := w.library.PrepareUpload(w.playlists.Context(), userId, newValues); err != nil {
+		log.Error("failed to prepare upload", sl.Err(err))
+		w.finish(playlistId, removed, err, nil, true)
+		return
+	}
+
+	items := make([]models.JobItem, 0, len(newValues))
+	for _, conf := range newValues {
+		items = append(items, models.JobItem{Conf: conf})
+	}
+
+	title := fmt.Sprintf("Синхронизация \"%s\"", name)
+	if _, err := w.jobs.Submit(w.playlists.Context(), userId, models.JobUpload, title, items, func(job models.Job) {
+		if job.Status.Active() {
+			return
+		}
+		w.finish(playlistId, removed, nil, &job, true)
+	}); err != nil {
+		log.Error("failed to submit job", sl.Err(err))
+		w.finish(playlistId, removed, err, nil, true)
+		return
+	}
+
+	log.Info("new tracks are submitted", slog.Int("count", len(items)))
+}
+
+// finish updates list of imported tracks,
+// unmarks playlist as syncing and sends summary.
+func (w *watch) finish(playlistId int64, removed []models.WatchedTrack, syncErr error, job *models.Job, notify bool) {
+	const op = "watch.finish"
+
+	w.playlists.Lock()
+	w.playlists.Unmark(playlistId)
+	p, ok := w.playlists.Get(playlistId)
+	if !ok {
+		w.playlists.Unlock()
+		return
+	}
+
+	p.LastSync = time.Now()
+	p.LastErr = ""
+	if syncErr != nil {
+		p.LastErr = syncErr.Error()
+	}
+
+	p.Tracks = slices.DeleteFunc(p.Tracks, func(t models.WatchedTrack) bool {
+		return slices.ContainsFunc(removed, func(r models.WatchedTrack) bool {
+			return r.ID == t.ID
+		})
+	})
+	if job != nil {
+		for _, item := range job.Items {
+			if !item.Status.Uploaded() {
+				continue
+			}
+			p.Tracks = append(p.Tracks, models.WatchedTrack{
+				ID:     item.Conf.External.ID,
+				Name:   item.Conf.Name,
+				Author: item.Conf.Author,
+			})
+		}
+	}
+
+	summary := models.WatchSummary{
+		Playlist: copyPlaylist(p),
+		Job:      job,
+		Removed:  removed,
+		Err:      syncErr,
+	}
+	w.playlists.Unlock()
+
+	if err := w.playlists.Dump(); err != nil {
+		w.log.Error("failed to dump watched playlists", slog.String("op", op), sl.Err(err))
+	}
+
+	if notify && w.notify != nil {
+		w.notify(summary)
+	}
+}
+
+// removeTag removes playlist tag from media
+// matching tracks by name and author.
+func (w *watch) removeTag(ctx context.Context, userId int64, playlist string, tracks []models.WatchedTrack) error {
+	const op = "watch.removeTag"
+
+	media, err := w.library.Search(ctx, userId, models.MediaFilter{
+		Tags:       []string{playlist},
+		MaxRespLen: playlistRespLen,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, conf := range media {
+		if !slices.ContainsFunc(tracks, func(t models.WatchedTrack) bool {
+			return strings.EqualFold(t.Name, conf.Name) && strings.EqualFold(t.Author, conf.Author)
+		}) {
+			continue
+		}
+
+		conf.Playlists = slices.DeleteFunc(conf.Playlists, func(p string) bool {
+			return p == playlist
+		})
+		if err := w.library.UpdateMedia(ctx, userId, conf); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// userPlaylistsLocked returns playlists of user.
+// Must be called with locked playlists.
+func (w *watch) userPlaylistsLocked(id int64) []*models.WatchedPlaylist {
+	res := make([]*models.WatchedPlaylist, 0)
+	for _, p := range w.playlists.All() {
+		if p.UserId == id {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func copyPlaylist(p *models.WatchedPlaylist) models.WatchedPlaylist {
+	res := *p
+	res.Tracks = slices.Clone(p.Tracks)
+	return res
+}
@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/GintGld/fizteh-radio-bot/internal/models"
+	"github.com/GintGld/fizteh-radio-bot/internal/service/servicetest"
+)
+
+const (
+	userId   = 1
+	link     = "https://music.yandex.ru/users/editor/playlists/3"
+	playlist = "Утро"
+)
+
+func track(id, name string) models.MediaConfig {
+	return models.MediaConfig{
+		Name:      name,
+		Author:    "author",
+		Playlists: []string{playlist},
+		External:  models.ExternalRef{ID: id},
+	}
+}
+
+// stored returns track uploaded to library.
+func stored(id int64, name string) models.MediaConfig {
+	conf := track(strconv.FormatInt(id, 10), name)
+	conf.ID = id
+	return conf
+}
+
+func playlistRes(tracks ...models.MediaConfig) models.LinkDownloadResult {
+	return models.LinkDownloadResult{
+		Type:     models.ResPlaylist,
+		Playlist: models.Playlist{Name: playlist, Values: tracks},
+	}
+}
+
+// renamed returns result of playlist
+// renamed in source service.
+func renamed(res models.LinkDownloadResult, name string) models.LinkDownloadResult {
+	res.Playlist.Name = name
+	for i := range res.Playlist.Values {
+		res.Playlist.Values[i].Playlists = []string{name}
+	}
+	return res
+}
+
+func TestSync(t *testing.T) {
+	lib := &servicetest.Library{
+		Link: playlistRes(track("1", "first"), track("2", "second"), track("3", "broken")),
+	}
+	jobs := &servicetest.Jobs{}
+
+	w := New(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		lib,
+		jobs,
+		filepath.Join(t.TempDir(), "watched.json"),
+		time.Hour,
+	)
+
+	summaries := make(chan models.WatchSummary, 1)
+	w.SetNotify(func(summary models.WatchSummary) {
+		summaries <- summary
+	})
+
+	p, err := w.Add(context.Background(), userId, link)
+	require.NoError(t, err)
+
+	summary := <-summaries
+	require.NotNil(t, summary.Job)
+	assert.Len(t, jobs.Submitted[0], 3)
+	// Failed track is not marked as imported.
+	assert.Len(t, summary.Playlist.Tracks, 2)
+
+	_, err = w.SetRemoveMissing(context.Background(), userId, p.ID, true)
+	require.NoError(t, err)
+
+	// Second track is taken out of playlist,
+	// playlist is renamed.
+	lib.Link = renamed(playlistRes(track("1", "first"), track("3", "broken"), track("4", "fourth")), "Вечер")
+	lib.Stored = []models.MediaConfig{stored(1, "first"), stored(2, "second")}
+
+	require.NoError(t, w.Sync(context.Background(), userId, p.ID))
+
+	summary = <-summaries
+	require.Len(t, jobs.Submitted, 2)
+	assert.Equal(t, "broken", jobs.Submitted[1][0].Conf.Name)
+	assert.Equal(t, "fourth", jobs.Submitted[1][1].Conf.Name)
+	assert.Equal(t, []string{playlist}, jobs.Submitted[1][1].Conf.Playlists)
+
+	require.Len(t, summary.Removed, 1)
+	assert.Equal(t, "second", summary.Removed[0].Name)
+
+	require.Len(t, lib.Updated, 1)
+	assert.Equal(t, "second", lib.Updated[0].Name)
+	assert.Empty(t, lib.Updated[0].Playlists)
+
+	playlists, err := w.List(context.Background(), userId)
+	require.NoError(t, err)
+	require.Len(t, playlists, 1)
+	assert.Len(t, playlists[0].Tracks, 2)
+	assert.Equal(t, playlist, playlists[0].Name)
+}